@@ -0,0 +1,22 @@
+-- Migration: create sessions table for refresh tokens (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- sessions テーブル (リフレッシュトークン1件ごとの行。family_id がセッションID)
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    family_id UUID NOT NULL, -- ローテーションで引き継がれるセッションID
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token_hash VARCHAR(64) UNIQUE NOT NULL, -- リフレッシュトークンのSHA-256
+    device_name VARCHAR(255),
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    last_used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    rotated_at TIMESTAMP, -- 使用済みになった日時（再利用検知に使用）
+    revoked_at TIMESTAMP, -- 無効化された日時
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- sessions 検索用インデックス
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions(family_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
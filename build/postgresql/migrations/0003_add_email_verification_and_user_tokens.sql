@@ -0,0 +1,27 @@
+-- Migration: add email verification flag and single-use user tokens (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'users' AND column_name = 'email_verified'
+  ) THEN
+    ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT false;
+  END IF;
+END
+$$;
+
+-- user_tokens テーブル (パスワード再設定・メールアドレス確認用の使い捨てトークン)
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(50) NOT NULL, -- 'password_reset' or 'email_verification'
+    token_hash VARCHAR(64) UNIQUE NOT NULL, -- トークンのSHA-256
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP, -- 使用済みになった日時
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- user_tokens 検索用インデックス
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens(user_id, purpose);
@@ -0,0 +1,27 @@
+-- Migration: create TOTP two-factor authentication tables (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- user_mfa テーブル (TOTPによる二要素認証の設定)
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret_encrypted TEXT NOT NULL, -- AES-GCMで暗号化したシークレット
+    enabled BOOLEAN NOT NULL DEFAULT false, -- 確認コードの入力が完了するまでは false
+    confirmed_at TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0, -- 最後に使われたTOTPのステップ (リプレイ防止)
+    failed_attempts INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- mfa_recovery_codes テーブル (二要素認証のリカバリーコード)
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL, -- コードのSHA-256
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- mfa_recovery_codes 検索用インデックス
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
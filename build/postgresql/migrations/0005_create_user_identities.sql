@@ -0,0 +1,17 @@
+-- Migration: link external identity provider subjects to local users (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- user_identities テーブル (Auth0などの外部認証のユーザーとローカルユーザーの対応)
+CREATE TABLE IF NOT EXISTS user_identities (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL, -- 'auth0'
+    subject VARCHAR(255) NOT NULL, -- 外部認証の sub (例: auth0|abc)
+    email VARCHAR(255), -- 連携時のメールアドレス
+    last_login_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+
+-- user_identities 検索用インデックス
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
@@ -0,0 +1,21 @@
+-- Migration: project membership for /api routes (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- project_members テーブル (プロジェクトに参加しているユーザーとロール)
+CREATE TABLE IF NOT EXISTS project_members (
+    project_id INT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(50) NOT NULL, -- 'owner', 'admin', 'editor', 'viewer'
+    invited_by UUID, -- 招待したユーザー
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_id, user_id)
+);
+
+-- project_members 検索用インデックス
+CREATE INDEX IF NOT EXISTS idx_project_members_user_id ON project_members(user_id);
+
+-- これまで /api はすべてのユーザーがデフォルトプロジェクトを操作できたため、既存ユーザーを admin として登録する
+INSERT INTO project_members (project_id, user_id, role)
+SELECT 1, id, 'admin' FROM users
+ON CONFLICT DO NOTHING;
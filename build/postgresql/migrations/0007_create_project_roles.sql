@@ -0,0 +1,14 @@
+-- Migration: custom roles per project for RBAC (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- project_roles テーブル (プロジェクトごとのカスタムロール)
+CREATE TABLE IF NOT EXISTS project_roles (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL, -- ロール名 (組み込みロールと同じ名前は使えない)
+    description TEXT,
+    permissions JSONB NOT NULL DEFAULT '[]', -- 権限の配列 (例: ["entries:read", "entries:write"])
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (project_id, name)
+);
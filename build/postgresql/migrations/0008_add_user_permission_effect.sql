@@ -0,0 +1,24 @@
+-- Migration: deny rules and hierarchical resources for user_permissions (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- effect: allow (付与) または deny (禁止)。deny は allow やロールより優先される
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'user_permissions' AND column_name = 'effect'
+  ) THEN
+    ALTER TABLE user_permissions
+      ADD COLUMN effect VARCHAR(10) NOT NULL DEFAULT 'allow' CHECK (effect IN ('allow', 'deny'));
+  END IF;
+END
+$$;
+
+-- resource_type, resource_id には階層ごとの種類とIDを / で連結して保存する
+-- (例: project:1/collection:5/entry:* は resource_type = 'project/collection/entry', resource_id = '1/5/*')
+-- 既存の1階層の値 (resource_type = 'collection', resource_id = '5') はそのまま collection:5 として扱われる
+COMMENT ON COLUMN user_permissions.resource_type IS '種類を / で連結したもの (例: project/collection/entry)';
+COMMENT ON COLUMN user_permissions.resource_id IS 'IDを / で連結したもの (例: 1/5/*)';
+
+-- 権限チェックでは user_id と permission_type で候補を取得してからリソースを照合する
+CREATE INDEX IF NOT EXISTS idx_user_permissions_user_permission ON user_permissions(user_id, permission_type);
@@ -0,0 +1,39 @@
+-- Migration: project-scoped and expiring user_permissions (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- project_id: 権限を付与したプロジェクト。既存の権限はプロジェクト1のものとして扱う
+-- expires_at: NULL は無期限。期限切れの権限は権限チェックで無視され、定期的に削除される
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'user_permissions' AND column_name = 'project_id'
+  ) THEN
+    ALTER TABLE user_permissions
+      ADD COLUMN project_id INT NOT NULL DEFAULT 1 REFERENCES projects(id) ON DELETE CASCADE;
+  END IF;
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'user_permissions' AND column_name = 'expires_at'
+  ) THEN
+    ALTER TABLE user_permissions ADD COLUMN expires_at TIMESTAMP;
+  END IF;
+END
+$$;
+
+-- ユニーク制約にプロジェクトを含める
+DROP INDEX IF EXISTS uniq_user_permissions_global;
+DROP INDEX IF EXISTS uniq_user_permissions_scoped;
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_user_permissions_project_global
+  ON user_permissions(project_id, user_id, permission_type)
+  WHERE resource_type IS NULL AND resource_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_user_permissions_project_scoped
+  ON user_permissions(project_id, user_id, permission_type, resource_type, resource_id)
+  WHERE resource_type IS NOT NULL AND resource_id IS NOT NULL;
+
+-- 権限チェックでは project_id, user_id, permission_type で候補を取得する
+DROP INDEX IF EXISTS idx_user_permissions_user_permission;
+CREATE INDEX IF NOT EXISTS idx_user_permissions_project_user_permission ON user_permissions(project_id, user_id, permission_type);
+
+-- 期限切れの権限の削除用
+CREATE INDEX IF NOT EXISTS idx_user_permissions_expires_at ON user_permissions(expires_at) WHERE expires_at IS NOT NULL;
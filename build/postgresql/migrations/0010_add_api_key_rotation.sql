@@ -0,0 +1,32 @@
+-- Migration: key prefix and rotation grace period for api_keys (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- key_prefix: 一覧・詳細で表示するキーの先頭部分。キーそのものは作成・ローテーション時にのみ返す
+-- previous_key, previous_key_expire_at: ローテーション前のキーは previous_key_expire_at まで引き続き使える
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_keys' AND column_name = 'key_prefix'
+  ) THEN
+    ALTER TABLE api_keys ADD COLUMN key_prefix VARCHAR(20) NOT NULL DEFAULT '';
+    UPDATE api_keys SET key_prefix = LEFT(key, 8);
+  END IF;
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_keys' AND column_name = 'previous_key'
+  ) THEN
+    ALTER TABLE api_keys ADD COLUMN previous_key VARCHAR(255);
+  END IF;
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_keys' AND column_name = 'previous_key_expire_at'
+  ) THEN
+    ALTER TABLE api_keys ADD COLUMN previous_key_expire_at TIMESTAMP;
+  END IF;
+END
+$$;
+
+-- プロジェクトごとの一覧と、ローテーション前のキーでの認証用
+CREATE INDEX IF NOT EXISTS idx_api_keys_project_id ON api_keys(project_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_previous_key ON api_keys(previous_key) WHERE previous_key IS NOT NULL;
@@ -0,0 +1,52 @@
+-- Migration: store api_keys as prefix + SHA-256 of the secret (idempotent, PostgreSQL 11+)
+-- Run this against the Postgres DB for existing deployments
+
+-- 新しいキーの形式は w3st_<prefix>_<secret>。key_prefix で候補を取得し、secret の SHA-256 を定数時間で照合する
+-- 形式を変更する前のキー（16進数）は先頭8文字を prefix、キー全体を secret として扱うため、発行済みのキーはそのまま使える
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_keys' AND column_name = 'key_hash'
+  ) THEN
+    ALTER TABLE api_keys ADD COLUMN key_hash VARCHAR(64);
+  END IF;
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_keys' AND column_name = 'previous_key_prefix'
+  ) THEN
+    ALTER TABLE api_keys ADD COLUMN previous_key_prefix VARCHAR(20);
+    ALTER TABLE api_keys ADD COLUMN previous_key_hash VARCHAR(64);
+  END IF;
+
+  -- 平文のキーをハッシュに置き換えてから削除する
+  IF EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_keys' AND column_name = 'key'
+  ) THEN
+    UPDATE api_keys
+      SET key_prefix = LEFT(key, 8),
+          key_hash = encode(sha256(convert_to(key, 'UTF8')), 'hex')
+      WHERE key_hash IS NULL;
+    ALTER TABLE api_keys DROP COLUMN key;
+  END IF;
+  IF EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_keys' AND column_name = 'previous_key'
+  ) THEN
+    UPDATE api_keys
+      SET previous_key_prefix = LEFT(previous_key, 8),
+          previous_key_hash = encode(sha256(convert_to(previous_key, 'UTF8')), 'hex')
+      WHERE previous_key IS NOT NULL;
+    ALTER TABLE api_keys DROP COLUMN previous_key;
+  END IF;
+
+  ALTER TABLE api_keys ALTER COLUMN key_prefix DROP DEFAULT;
+  ALTER TABLE api_keys ALTER COLUMN key_hash SET NOT NULL;
+END
+$$;
+
+-- 形式を変更する前のキーは prefix が重複することがあるため、ユニーク制約は付けない
+DROP INDEX IF EXISTS idx_api_keys_previous_key;
+CREATE INDEX IF NOT EXISTS idx_api_keys_key_prefix ON api_keys(key_prefix);
+CREATE INDEX IF NOT EXISTS idx_api_keys_previous_key_prefix ON api_keys(previous_key_prefix) WHERE previous_key_prefix IS NOT NULL;
@@ -0,0 +1,21 @@
+-- Migration: scopes for api_keys (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- scopes: キーで利用できるSDKの操作。既存のキーはこれまでと同じ読み取りのみ（collections:read, entries:read）
+-- api_key_collections.scopes: コレクションごとに api_keys.scopes を上書きする（NULLなら上書きしない）
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_keys' AND column_name = 'scopes'
+  ) THEN
+    ALTER TABLE api_keys ADD COLUMN scopes JSONB NOT NULL DEFAULT '["collections:read", "entries:read"]';
+  END IF;
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_key_collections' AND column_name = 'scopes'
+  ) THEN
+    ALTER TABLE api_key_collections ADD COLUMN scopes JSONB;
+  END IF;
+END
+$$;
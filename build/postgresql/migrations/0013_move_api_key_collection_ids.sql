@@ -0,0 +1,24 @@
+-- Migration: api_keys.collection_ids -> api_key_collections (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- キーで利用できるコレクションを api_key_collections（外部キー付き）で管理する。
+-- 別のプロジェクトのコレクションや削除済みのコレクションのIDは移さない。
+-- 移した後は collection_ids を削除する（コレクションを削除すると ON DELETE CASCADE で利用できなくなる）
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_keys' AND column_name = 'collection_ids'
+  ) THEN
+    INSERT INTO api_key_collections (api_key_id, collection_id)
+    SELECT k.id, c.id
+    FROM api_keys k
+    JOIN api_collections c ON c.id = ANY(k.collection_ids) AND c.project_id = k.project_id
+    ON CONFLICT DO NOTHING;
+
+    ALTER TABLE api_keys DROP COLUMN collection_ids;
+  END IF;
+END
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_api_key_collections_collection_id ON api_key_collections(collection_id);
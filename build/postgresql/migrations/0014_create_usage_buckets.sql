@@ -0,0 +1,20 @@
+-- Migration: usage metering and monthly quotas (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- プロジェクトの月間のリクエスト上限（NULL は上限なし）
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS monthly_request_quota INT CHECK (monthly_request_quota > 0);
+
+-- usage_buckets テーブル (プロジェクト・APIキー・コレクションごとの1時間あたりのSDKの利用量)
+CREATE TABLE IF NOT EXISTS usage_buckets (
+    project_id INT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    api_key_id INT NOT NULL DEFAULT 0, -- APIキーID (0: APIキー以外。キーを削除しても利用量は残す)
+    collection_id INT NOT NULL DEFAULT 0, -- コレクションID (0: コレクション一覧など)
+    bucket_start TIMESTAMP WITH TIME ZONE NOT NULL, -- 1時間の始まり
+    requests BIGINT NOT NULL DEFAULT 0,
+    bytes_served BIGINT NOT NULL DEFAULT 0,
+    entries_created BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (project_id, api_key_id, collection_id, bucket_start)
+);
+
+-- usage_buckets 集計用インデックス（今月・直近1時間のプロジェクトの利用量を合計する）
+CREATE INDEX IF NOT EXISTS idx_usage_buckets_project_bucket_start ON usage_buckets(project_id, bucket_start);
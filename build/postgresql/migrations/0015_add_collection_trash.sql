@@ -0,0 +1,17 @@
+-- Migration: add api_collections.deleted_at (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- コレクションを削除するとゴミ箱に移し（deleted_at を設定する）、30日間は復元できる。
+-- 期限を過ぎたコレクションはサーバーが関連するデータごと削除する
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_collections' AND column_name = 'deleted_at'
+  ) THEN
+    ALTER TABLE api_collections ADD COLUMN deleted_at TIMESTAMP;
+  END IF;
+END
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_api_collections_deleted_at ON api_collections(deleted_at) WHERE deleted_at IS NOT NULL;
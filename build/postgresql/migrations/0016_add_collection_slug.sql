@@ -0,0 +1,20 @@
+-- Migration: add api_collections.slug (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- SDK のパスでコレクションのIDの代わりに slug を使えるようにする（/collections/products/entries）。
+-- 既存のコレクションの slug は collection-<id> にする（PATCH /api/collections/:collectionId で変更できる）
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_collections' AND column_name = 'slug'
+  ) THEN
+    ALTER TABLE api_collections ADD COLUMN slug VARCHAR(100);
+    UPDATE api_collections SET slug = 'collection-' || id;
+    ALTER TABLE api_collections ALTER COLUMN slug SET NOT NULL;
+  END IF;
+END
+$$;
+
+-- ゴミ箱にあるコレクションの slug は新しいコレクションで使える（復元するときに重複していれば復元できない）
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_collections_project_slug ON api_collections(project_id, slug) WHERE deleted_at IS NULL;
@@ -0,0 +1,15 @@
+-- Migration: add api_collections.reject_unknown_fields (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- true のコレクションでは、フィールドとして定義されていないキーを含むエントリを 422 で拒否する。
+-- 既存のコレクションはこれまでどおり受け付ける（false）
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_collections' AND column_name = 'reject_unknown_fields'
+  ) THEN
+    ALTER TABLE api_collections ADD COLUMN reject_unknown_fields BOOLEAN NOT NULL DEFAULT false;
+  END IF;
+END
+$$;
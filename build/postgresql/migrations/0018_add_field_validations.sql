@@ -0,0 +1,23 @@
+-- Migration: add validations to api_fields and field_data (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- フィールドの値の検証ルール（min_length, max_length, min, max, pattern, pattern_message, enum, min_items, max_items, unique）。
+-- 既存のフィールドはルールなし（'{}'）で、これまでどおり型だけを確認する。
+-- unique はエントリの保存時にアドバイザリーロックを取ってから確認するため、インデックスは作らない
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_fields' AND column_name = 'validations'
+  ) THEN
+    ALTER TABLE api_fields ADD COLUMN validations JSONB NOT NULL DEFAULT '{}';
+  END IF;
+
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'field_data' AND column_name = 'validations'
+  ) THEN
+    ALTER TABLE field_data ADD COLUMN validations JSONB NOT NULL DEFAULT '{}';
+  END IF;
+END
+$$;
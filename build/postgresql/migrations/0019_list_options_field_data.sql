@@ -0,0 +1,50 @@
+-- Migration: make list_options the options of field_data (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- select・multiselect フィールドの選択肢。value はエントリに保存する値、label は表示名、position は並び順。
+-- 既存の選択肢は label に value を、position に id を入れて、これまでの順序を保つ
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'list_options' AND column_name = 'label'
+  ) THEN
+    ALTER TABLE list_options ADD COLUMN label VARCHAR(255) NOT NULL DEFAULT '';
+    UPDATE list_options SET label = value;
+  END IF;
+
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'list_options' AND column_name = 'position'
+  ) THEN
+    ALTER TABLE list_options ADD COLUMN position INT NOT NULL DEFAULT 0;
+    UPDATE list_options SET position = id;
+  END IF;
+
+  -- フィールドの定義は field_data にあるため、外部キーを付け替える（既存の行は検証しない）
+  IF EXISTS (
+    SELECT 1 FROM pg_constraint
+    WHERE conname = 'list_options_field_id_fkey' AND confrelid = 'api_fields'::regclass
+  ) THEN
+    ALTER TABLE list_options DROP CONSTRAINT list_options_field_id_fkey;
+    ALTER TABLE list_options ADD CONSTRAINT list_options_field_id_fkey
+      FOREIGN KEY (field_id) REFERENCES field_data(id) ON DELETE CASCADE NOT VALID;
+  END IF;
+END
+$$;
+
+-- 選択肢の値はフィールド内で一意
+CREATE UNIQUE INDEX IF NOT EXISTS idx_list_options_field_value ON list_options(field_id, value);
+
+CREATE OR REPLACE FUNCTION validate_list_options()
+RETURNS TRIGGER AS $$
+DECLARE
+    f_type VARCHAR(50);
+BEGIN
+    SELECT field_type INTO f_type FROM field_data WHERE id = NEW.field_id;
+    IF f_type NOT IN ('select', 'multiselect', 'dropdown') THEN
+        RAISE EXCEPTION 'list_options can only be added to select or multiselect fields';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
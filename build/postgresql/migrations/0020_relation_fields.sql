@@ -0,0 +1,28 @@
+-- Migration: tie api_kind_relation to relation fields (idempotent)
+-- Run this against the Postgres DB for existing deployments
+
+-- field_id は参照先のエントリのIDを保存する relation 型のフィールド（field_data.id）。
+-- 既存のリレーションはフィールドに結び付いていないため NULL のままにし、エントリの検証には使わない。
+-- on_delete は参照先のエントリを削除したときの扱い（restrict, cascade, set-null）。既存のリレーションは restrict
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_kind_relation' AND column_name = 'field_id'
+  ) THEN
+    ALTER TABLE api_kind_relation ADD COLUMN field_id INT REFERENCES field_data(id) ON DELETE CASCADE;
+  END IF;
+
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'api_kind_relation' AND column_name = 'on_delete'
+  ) THEN
+    ALTER TABLE api_kind_relation ADD COLUMN on_delete VARCHAR(20) NOT NULL DEFAULT 'restrict';
+  END IF;
+END
+$$;
+
+-- 1つのフィールドに1つのリレーション
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_kind_relation_field ON api_kind_relation(field_id);
+-- エントリの削除時に、参照先のコレクションからリレーションを探す
+CREATE INDEX IF NOT EXISTS idx_api_kind_relation_related ON api_kind_relation(related_collection_id);
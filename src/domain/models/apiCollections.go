@@ -1,17 +1,73 @@
 package models
 
 import (
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type ApiCollection struct {
-	ID          int       `gorm:"type:serial;primary_key" json:"id"`
-	UserID      uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
-	ProjectID   int       `gorm:"not null" json:"project_id"`
-	Name        string    `gorm:"type:varchar(100);not null" json:"name"`
-	Description string    `gorm:"not null" json:"description"`
-	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
-	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	ID        int       `gorm:"type:serial;primary_key" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	ProjectID int       `gorm:"not null" json:"project_id"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	// Slug SDK のパスでIDの代わりに使える識別子（/collections/products/entries）。プロジェクト内で一意
+	Slug        string `gorm:"type:varchar(100);not null" json:"slug"`
+	Description string `gorm:"not null" json:"description"`
+	// RejectUnknownFields フィールドとして定義されていないキーを含むエントリを受け付けない
+	RejectUnknownFields bool      `gorm:"not null;default:false" json:"reject_unknown_fields"`
+	CreatedAt           time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt           time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	// DeletedAt ゴミ箱に移した日時。nil でなければ一覧・取得の対象にしない
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// CollectionUpdate コレクションの変更内容。nil の項目は変更しない
+type CollectionUpdate struct {
+	Name        *string
+	Description *string
+	Slug        *string
+	// RejectUnknownFields 未定義のキーを含むエントリを受け付けるかどうか
+	RejectUnknownFields *bool
+	// UpdatedAt 変更前に取得したコレクションの updated_at。その後に他の更新があった場合は変更しない
+	UpdatedAt time.Time
+}
+
+// CollectionSlugMaxLength slug の最大の長さ
+const CollectionSlugMaxLength = 100
+
+// collectionSlugPattern 英小文字で始まり、英小文字・数字・-・_ だけを含む（数字だけの slug はIDと区別できないため使えない）
+var collectionSlugPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// ValidCollectionSlug slug として使える文字列かどうか
+func ValidCollectionSlug(slug string) bool {
+	return len(slug) <= CollectionSlugMaxLength && collectionSlugPattern.MatchString(slug)
+}
+
+// CollectionSlugFromName name から slug を作る。英数字以外は - にし、使える文字がない場合は "collection" にする
+func CollectionSlugFromName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteByte('-')
+		}
+	}
+	slug := strings.TrimRight(b.String(), "-")
+	// 数字で始まる場合は英字で始まるようにする
+	if slug != "" && slug[0] >= '0' && slug[0] <= '9' {
+		slug = "c-" + slug
+	}
+	if slug == "" {
+		slug = "collection"
+	}
+	// 重複したときに -2 などを付けられるように短くしておく
+	if len(slug) > CollectionSlugMaxLength-4 {
+		slug = strings.TrimRight(slug[:CollectionSlugMaxLength-4], "-")
+	}
+	return slug
 }
@@ -1,6 +1,32 @@
 package models
 
+import (
+	"database/sql/driver"
+
+	"gorm.io/datatypes"
+)
+
+// ApiKeyCollections APIキーで利用できるコレクション。Scopes を指定した場合はキーの Scopes の代わりに使う
 type ApiKeyCollections struct {
-	ApiKeyID     int `gorm:"type:int;primary_key" json:"api_key_Id"`
-	CollectionID int `gorm:"type:int;primary_key" json:"collection_id"`
+	ApiKeyID     int              `gorm:"type:int;primary_key" json:"api_key_Id"`
+	CollectionID int              `gorm:"type:int;primary_key" json:"collection_id"`
+	Scopes       CollectionScopes `gorm:"type:jsonb" json:"scopes"`
+}
+
+// CollectionScopes コレクションごとのスコープ。nil は NULL（キーの Scopes を使う）として保存する
+type CollectionScopes []string
+
+func (s CollectionScopes) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return datatypes.JSONSlice[string](s).Value()
+}
+
+func (s *CollectionScopes) Scan(value any) error {
+	if value == nil {
+		*s = nil
+		return nil
+	}
+	return (*datatypes.JSONSlice[string])(s).Scan(value)
 }
@@ -1,21 +1,322 @@
 package models
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"net/netip"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 )
 
+// APIキーの形式は w3st_<prefix>_<secret>。
+// secret の末尾 ApiKeyChecksumLength 文字は、それより前の部分の CRC32 を base62 にしたチェックサムで、
+// 流出したキーをシークレットスキャンで w3st_[0-9A-Za-z]{8}_[0-9A-Za-z]{38} として検出し、誤検出を除外できるようにしている
+const (
+	ApiKeyScheme         = "w3st"
+	ApiKeyPrefixLength   = 8
+	ApiKeySecretLength   = 32
+	ApiKeyChecksumLength = 6
+)
+
+// legacyApiKeyPrefixLength 形式を変更する前の16進数のキーは、先頭8文字を prefix、キー全体を secret として扱う
+const legacyApiKeyPrefixLength = 8
+
+// ApiKeyAlphabet キーの prefix, secret, チェックサムに使う文字（base62）
+const ApiKeyAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// APIキーのスコープ。キーで呼び出せるSDKの操作を表す
+const (
+	ApiKeyScopeCollectionsRead = "collections:read"
+	ApiKeyScopeEntriesRead     = "entries:read"
+	ApiKeyScopeEntriesWrite    = "entries:write"
+	ApiKeyScopeMediaWrite      = "media:write"
+)
+
+// ApiKeyScopes キーに付与できるスコープ
+var ApiKeyScopes = []string{ApiKeyScopeCollectionsRead, ApiKeyScopeEntriesRead, ApiKeyScopeEntriesWrite, ApiKeyScopeMediaWrite}
+
+// DefaultApiKeyScopes スコープを指定せずに作成したキーのスコープ（読み取りのみ）
+var DefaultApiKeyScopes = []string{ApiKeyScopeCollectionsRead, ApiKeyScopeEntriesRead}
+
+// ErrInvalidApiKeyScope ApiKeyScopes にないスコープが指定された
+var ErrInvalidApiKeyScope = errors.New("invalid api key scope")
+
+// NormalizeApiKeyScopes スコープを検証し、重複を除いて ApiKeyScopes の順に並べる
+func NormalizeApiKeyScopes(scopes []string) ([]string, error) {
+	for _, scope := range scopes {
+		if !slices.Contains(ApiKeyScopes, scope) {
+			return nil, ErrInvalidApiKeyScope
+		}
+	}
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range ApiKeyScopes {
+		if slices.Contains(scopes, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
+
+// ApiKeys プロジェクトのSDK用APIキー。キーそのものは保存せず、検索用の KeyPrefix と secret のハッシュだけを保存する。
+// ローテーション後は PreviousKeyExpireAt までローテーション前のキーでも認証できる
 type ApiKeys struct {
-	Id            int       `gorm:"type:serial;primary_key" json:"id"`
-	UserID        uuid.UUID `gorm:"type:uuid;not null" json:"userId"`
-	ProjectID     int       `gorm:"not null" json:"projectId"`
-	Name          string    `gorm:"size:100;not null" json:"name"`
-	Key           string    `gorm:"size:100;not null;unique" json:"key"`
-	CollectionIds []int     `gorm:"type:int[];not null;default:'{}'" json:"collection_ids"`
-	IpWhiteList   []string  `gorm:"type:text" json:"ip_whitelist"`
-	ExpireAt      time.Time `gorm:"not null;default:0" json:"expire_at"`
-	Revoked       bool      `gorm:"not null;default:false" json:"revoked"`
-	RateLimit     int       `gorm:"not null;default:0" json:"rate_limit_per_hour"`
-	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	Id                  int        `gorm:"type:serial;primary_key" json:"id"`
+	UserID              uuid.UUID  `gorm:"type:uuid;not null" json:"userId"`
+	ProjectID           int        `gorm:"not null" json:"projectId"`
+	Name                string     `gorm:"size:100;not null" json:"name"`
+	KeyPrefix           string     `gorm:"size:20;not null" json:"key_prefix"`
+	KeyHash             string     `gorm:"size:64;not null" json:"-"`
+	PreviousKeyPrefix   *string    `gorm:"size:20" json:"-"`
+	PreviousKeyHash     *string    `gorm:"size:64" json:"-"`
+	PreviousKeyExpireAt *time.Time `json:"previous_key_expire_at"`
+	IpWhiteList         TextArray  `gorm:"column:ip_whitelist;type:text[]" json:"ip_whitelist"`
+	ExpireAt            time.Time  `gorm:"not null;default:0" json:"expire_at"`
+	Revoked             bool       `gorm:"not null;default:false" json:"revoked"`
+	RateLimit           int        `gorm:"column:rate_limit_per_hour;not null;default:0" json:"rate_limit_per_hour"`
+	// Scopes コレクションごとに上書きしていないコレクションに対するスコープ
+	Scopes datatypes.JSONSlice[string] `gorm:"type:jsonb;not null" json:"scopes"`
+	// Collections キーで利用できるコレクション（api_key_collections）。コレクションを削除すると行も削除される
+	Collections []ApiKeyCollections `gorm:"foreignKey:ApiKeyID" json:"collections"`
+	CreatedAt   time.Time           `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// CollectionIDs キーで利用できるコレクションのID
+func (k *ApiKeys) CollectionIDs() []int {
+	ids := make([]int, len(k.Collections))
+	for i, c := range k.Collections {
+		ids[i] = c.CollectionID
+	}
+	return ids
+}
+
+// CollectionScopeOverrides コレクションごとに上書きしたスコープ
+func (k *ApiKeys) CollectionScopeOverrides() map[int][]string {
+	scopes := make(map[int][]string)
+	for _, c := range k.Collections {
+		if c.Scopes != nil {
+			scopes[c.CollectionID] = append([]string{}, c.Scopes...)
+		}
+	}
+	return scopes
+}
+
+// InGracePeriod ローテーション前のキーがまだ使えるかどうか
+func (k *ApiKeys) InGracePeriod(now time.Time) bool {
+	return k.PreviousKeyHash != nil && k.PreviousKeyExpireAt != nil && now.Before(*k.PreviousKeyExpireAt)
+}
+
+// IsExpired 有効期限を過ぎているかどうか
+func (k *ApiKeys) IsExpired(now time.Time) bool {
+	return !k.ExpireAt.IsZero() && !now.Before(k.ExpireAt)
+}
+
+// AllowsIP IpWhiteList（IPアドレスまたはCIDR）に ip が含まれるか。IpWhiteList が空なら制限しない
+func (k *ApiKeys) AllowsIP(ip string) bool {
+	return IPAllowed(k.IpWhiteList, ip)
+}
+
+// SetKey 発行したキーの prefix と secret のハッシュを設定する
+func (k *ApiKeys) SetKey(prefix, secret string) {
+	k.KeyPrefix = prefix
+	k.KeyHash = HashApiKeySecret(secret)
+}
+
+// Matches prefix と secret が現在のキー、または猶予期間中のローテーション前のキーと一致するか。ハッシュは定数時間で比較する
+func (k *ApiKeys) Matches(prefix, secret string, now time.Time) bool {
+	hash := HashApiKeySecret(secret)
+	if prefix == k.KeyPrefix && subtle.ConstantTimeCompare([]byte(hash), []byte(k.KeyHash)) == 1 {
+		return true
+	}
+	if k.PreviousKeyPrefix == nil || prefix != *k.PreviousKeyPrefix || !k.InGracePeriod(now) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(*k.PreviousKeyHash)) == 1
+}
+
+// IssuedApiKey 作成・ローテーションしたAPIキー。Key はこのときにしか取得できない
+type IssuedApiKey struct {
+	ApiKey *ApiKeys
+	Key    string
+}
+
+// ApiKeyPrincipal 認証したAPIキーの設定。SDKのリクエストはこの権限で処理する
+type ApiKeyPrincipal struct {
+	ApiKeyID      int
+	UserID        uuid.UUID
+	ProjectID     int
+	CollectionIds []int
+	IpWhiteList   []string
+	RateLimit     int
+	ExpireAt      time.Time
+	Scopes        []string
+	// CollectionScopes コレクションIDごとのスコープ。含まれないコレクションは Scopes を使う
+	CollectionScopes map[int][]string
+}
+
+// HasScope コレクションに対して scope の操作ができるか。キーで利用できないコレクションは常に false
+func (p *ApiKeyPrincipal) HasScope(collectionID int, scope string) bool {
+	if !slices.Contains(p.CollectionIds, collectionID) {
+		return false
+	}
+	if scopes, ok := p.CollectionScopes[collectionID]; ok {
+		return slices.Contains(scopes, scope)
+	}
+	return slices.Contains(p.Scopes, scope)
+}
+
+// CollectionIdsWithScope scope の操作ができるコレクションのID
+func (p *ApiKeyPrincipal) CollectionIdsWithScope(scope string) []int {
+	ids := make([]int, 0, len(p.CollectionIds))
+	for _, id := range p.CollectionIds {
+		if p.HasScope(id, scope) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Principal キーの設定を ApiKeyPrincipal にする。スライスはコピーするため、キャッシュしても元のモデルの変更の影響を受けない
+func (k *ApiKeys) Principal() *ApiKeyPrincipal {
+	return &ApiKeyPrincipal{
+		ApiKeyID:         k.Id,
+		UserID:           k.UserID,
+		ProjectID:        k.ProjectID,
+		CollectionIds:    k.CollectionIDs(),
+		IpWhiteList:      append([]string(nil), k.IpWhiteList...),
+		RateLimit:        k.RateLimit,
+		ExpireAt:         k.ExpireAt,
+		Scopes:           append([]string(nil), k.Scopes...),
+		CollectionScopes: k.CollectionScopeOverrides(),
+	}
+}
+
+// AllowsIP IpWhiteList に ip が含まれるか。IpWhiteList が空なら制限しない
+func (p *ApiKeyPrincipal) AllowsIP(ip string) bool {
+	return IPAllowed(p.IpWhiteList, ip)
+}
+
+// ApiKeyCreate 作成するAPIキーの設定
+type ApiKeyCreate struct {
+	UserID        uuid.UUID
+	ProjectID     int
+	Name          string
+	CollectionIds []int
+	// IpWhiteList 接続元として許可するIPアドレスまたはCIDR。空なら制限しない
+	IpWhiteList []string
+	// ExpireAt nil の場合は作成から1年
+	ExpireAt *time.Time
+	// RateLimit 1時間あたりの最大リクエスト数。0 の場合は既定値（プロジェクトの上限を超える場合はプロジェクトの上限）
+	RateLimit int
+	// Scopes nil の場合は DefaultApiKeyScopes
+	Scopes []string
+	// CollectionScopes コレクションIDごとに Scopes を上書きする。CollectionIds に含まれるコレクションだけ指定できる
+	CollectionScopes map[int][]string
+}
+
+// ApiKeyUpdate APIキーの変更内容。nil の項目は変更しない
+type ApiKeyUpdate struct {
+	Name          *string
+	CollectionIds []int
+	Revoked       *bool
+	Scopes        []string
+	// CollectionScopes nil でない場合はコレクションごとのスコープをすべて置き換える
+	CollectionScopes map[int][]string
+}
+
+// FormatApiKey prefix と secret（チェックサムを含まない）からキーを組み立て、チェックサムを付けた secret とともに返す
+func FormatApiKey(prefix, secret string) (key string, checksummedSecret string) {
+	body := ApiKeyScheme + "_" + prefix + "_" + secret
+	checksum := apiKeyChecksum(body)
+	return body + checksum, secret + checksum
+}
+
+// ParseApiKey キーを prefix と secret に分ける。w3st_ 形式はチェックサムも確認する。
+// 形式を変更する前の16進数のキーもそのまま使えるように、w3st_ で始まらないキーは先頭8文字を prefix として扱う
+func ParseApiKey(key string) (prefix string, secret string, ok bool) {
+	rest, found := strings.CutPrefix(key, ApiKeyScheme+"_")
+	if !found {
+		if len(key) <= legacyApiKeyPrefixLength {
+			return "", "", false
+		}
+		return key[:legacyApiKeyPrefixLength], key, true
+	}
+
+	prefix, secret, found = strings.Cut(rest, "_")
+	if !found || len(prefix) != ApiKeyPrefixLength || len(secret) != ApiKeySecretLength+ApiKeyChecksumLength {
+		return "", "", false
+	}
+	body, checksum := key[:len(key)-ApiKeyChecksumLength], key[len(key)-ApiKeyChecksumLength:]
+	if subtle.ConstantTimeCompare([]byte(apiKeyChecksum(body)), []byte(checksum)) != 1 {
+		return "", "", false
+	}
+	return prefix, secret, true
+}
+
+// HashApiKeySecret secret の SHA-256 を16進数で返す。secret は十分な長さの乱数なので salt は使わない
+func HashApiKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyChecksum CRC32 を ApiKeyChecksumLength 文字の base62 にする
+func apiKeyChecksum(body string) string {
+	n := crc32.ChecksumIEEE([]byte(body))
+	out := make([]byte, ApiKeyChecksumLength)
+	for i := ApiKeyChecksumLength - 1; i >= 0; i-- {
+		out[i] = ApiKeyAlphabet[n%62]
+		n /= 62
+	}
+	return string(out)
+}
+
+// ErrInvalidIPWhiteList IpWhiteList に IPアドレスでもCIDRでもない値が含まれている
+var ErrInvalidIPWhiteList = errors.New("invalid ip whitelist entry")
+
+// NormalizeIPWhiteList IPアドレスとCIDRを検証し、正規化した表記（例: 10.0.0.0/8, 2001:db8::1）で返す
+func NormalizeIPWhiteList(entries []string) ([]string, error) {
+	normalized := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			normalized = append(normalized, prefix.Masked().String())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			normalized = append(normalized, addr.Unmap().String())
+			continue
+		}
+		return nil, ErrInvalidIPWhiteList
+	}
+	return normalized, nil
+}
+
+// IPAllowed ip が allowList のいずれかのIPアドレスまたはCIDRに含まれるか。allowList が空なら常に true
+func IPAllowed(allowList []string, ip string) bool {
+	if len(allowList) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, entry := range allowList {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			if prefix.Contains(addr) {
+				return true
+			}
+			continue
+		}
+		if allowed, err := netip.ParseAddr(entry); err == nil && allowed.Unmap() == addr {
+			return true
+		}
+	}
+	return false
 }
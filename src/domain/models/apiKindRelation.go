@@ -1,10 +1,52 @@
 package models
 
+import "time"
+
+// ApiKindRelation relation 型のフィールドが参照するコレクション。フィールドの値は参照先のコレクションのエントリのID
 type ApiKindRelation struct {
-	ID           int    `gorm:"type:serial;primary_key" json:"id"`
-	ApiSchemaID  int    `gorm:"type:int;not null" json:"apiSchemaId"`
-	RelatedID    int    `gorm:"type:int;not null" json:"relatedId"`
-	RelationType string `gorm:"type:varchar(50);not null" json:"relationType"`
-	CreateAt     string `gorm:"type:timestamp;not null" json:"createAt"`
-	UpdateAt     string `gorm:"type:timestamp;not null" json:"updateAt"`
+	ID           int `gorm:"type:serial;primary_key" json:"id"`
+	CollectionID int `gorm:"type:int;not null" json:"collection_id"`
+	// FieldID relation 型のフィールド（field_data.id）。1つのフィールドに1つのリレーション
+	FieldID int `gorm:"type:int" json:"field_id"`
+	// FieldKey フィールドのキー（field_data.field_id）。読み込み時だけ設定する
+	FieldKey            string `gorm:"->;column:field_key" json:"field_key,omitempty"`
+	RelatedCollectionID int    `gorm:"type:int;not null" json:"related_collection_id"`
+	RelationType        string `gorm:"type:varchar(50);not null" json:"relation_type"`
+	// OnDelete 参照先のエントリを削除したときの、参照しているエントリの扱い
+	OnDelete  string    `gorm:"type:varchar(20);not null;default:restrict" json:"on_delete"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (ApiKindRelation) TableName() string {
+	return "api_kind_relation"
+}
+
+// リレーションの種類
+const (
+	RelationTypeOneToMany  = "one-to-many"  // フィールドの値は参照先のエントリのID 1つ
+	RelationTypeManyToMany = "many-to-many" // フィールドの値は参照先のエントリのIDの配列
+)
+
+// 参照先のエントリを削除したときの扱い
+const (
+	RelationOnDeleteRestrict = "restrict" // 参照しているエントリがあれば削除しない
+	RelationOnDeleteCascade  = "cascade"  // 参照しているエントリも削除する
+	RelationOnDeleteSetNull  = "set-null" // 参照を外す（ID は null に、配列からは取り除く）
+)
+
+// ValidRelationType リレーションの種類として使えるかどうか
+func ValidRelationType(relationType string) bool {
+	return relationType == RelationTypeOneToMany || relationType == RelationTypeManyToMany
+}
+
+// ValidRelationOnDelete 削除時の扱いとして使えるかどうか
+func ValidRelationOnDelete(onDelete string) bool {
+	return onDelete == RelationOnDeleteRestrict || onDelete == RelationOnDeleteCascade || onDelete == RelationOnDeleteSetNull
+}
+
+// EntryReferences リレーションごとの、エントリを参照しているエントリ
+type EntryReferences struct {
+	Relation ApiKindRelation `json:"relation"`
+	Entries  []Entry         `json:"entries"`
 }
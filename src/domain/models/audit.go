@@ -11,3 +11,12 @@ type AuditLog struct {
 	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
 	Details   string    `gorm:"type:text" json:"details"`
 }
+
+// APIキーの変更を記録する監査ログのアクション
+const (
+	AuditActionApiKeyCreate = "api_key.create"
+	AuditActionApiKeyUpdate = "api_key.update"
+	AuditActionApiKeyRevoke = "api_key.revoke"
+	AuditActionApiKeyRotate = "api_key.rotate"
+	AuditActionApiKeyDelete = "api_key.delete"
+)
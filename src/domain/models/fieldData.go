@@ -1,16 +1,77 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 type FieldData struct {
-	ID           int       `gorm:"type:serial;primary_key" json:"id"`
-	ProjectID    int       `gorm:"type:int;not null" json:"project_id"`
-	CollectionID int       `gorm:"type:int;not null" json:"collection_id"`
-	FieldID      string    `gorm:"type:varchar(100);not null" json:"field_id"`
-	ViewName     string    `gorm:"type:varchar(100);not null" json:"view_name"`
-	FieldType    string    `gorm:"type:varchar(50);not null" json:"field_type"`
-	IsRequired   bool      `gorm:"not null;default:false" json:"is_required"`
-	DefaultValue string    `gorm:"type:jsonb" json:"default_value"`
-	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP"`
-	UpdatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	ID           int    `gorm:"type:serial;primary_key" json:"id"`
+	ProjectID    int    `gorm:"type:int;not null" json:"project_id"`
+	CollectionID int    `gorm:"type:int;not null" json:"collection_id"`
+	FieldID      string `gorm:"type:varchar(100);not null" json:"field_id"`
+	ViewName     string `gorm:"type:varchar(100);not null" json:"view_name"`
+	FieldType    string `gorm:"type:varchar(50);not null" json:"field_type"`
+	IsRequired   bool   `gorm:"not null;default:false" json:"is_required"`
+	DefaultValue string `gorm:"type:jsonb" json:"default_value"`
+	// Validations 値の検証ルール（型の確認に加えて行う）
+	Validations FieldValidations `gorm:"type:jsonb;serializer:json" json:"validations"`
+	// Options select・multiselect の選択肢（並び順）。それ以外の型では空
+	Options []ListOption `gorm:"foreignKey:FieldID" json:"options,omitempty"`
+	// Relation relation 型のフィールドが参照するコレクション。リレーションを設定していない場合は nil
+	Relation  *ApiKindRelation `gorm:"foreignKey:FieldID" json:"relation,omitempty"`
+	CreatedAt time.Time        `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time        `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// FieldValidations フィールドの値の検証ルール。設定していないルールは確認しない
+type FieldValidations struct {
+	// MinLength, MaxLength 文字列の長さ（文字数）
+	MinLength *int `json:"min_length,omitempty"`
+	MaxLength *int `json:"max_length,omitempty"`
+	// Min, Max 数値の範囲
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	// Pattern 文字列が一致する正規表現。PatternMessage は一致しない場合に返すメッセージ
+	Pattern        string `json:"pattern,omitempty"`
+	PatternMessage string `json:"pattern_message,omitempty"`
+	// Enum 使える値。配列の場合はすべての要素がこの中の値であること
+	Enum []interface{} `json:"enum,omitempty"`
+	// MinItems, MaxItems 配列の要素数
+	MinItems *int `json:"min_items,omitempty"`
+	MaxItems *int `json:"max_items,omitempty"`
+	// Unique コレクション内の他のエントリと同じ値を使えない
+	Unique bool `json:"unique,omitempty"`
+}
+
+// フィールドの型。エントリの値はこの型で検証する
+const (
+	FieldTypeText        = "text"
+	FieldTypeNumber      = "number"
+	FieldTypeInteger     = "integer"
+	FieldTypeBoolean     = "boolean"
+	FieldTypeDate        = "date"     // YYYY-MM-DD
+	FieldTypeDatetime    = "datetime" // RFC 3339
+	FieldTypeEmail       = "email"
+	FieldTypeURL         = "url"
+	FieldTypeJSON        = "json"        // 任意の JSON の値
+	FieldTypeSelect      = "select"      // 選択肢の値（list_options）
+	FieldTypeMultiSelect = "multiselect" // 選択肢の値の配列
+	FieldTypeRelation    = "relation"    // 他のエントリのID（複数の場合は配列）
+	FieldTypeMedia       = "media"       // メディアのID（UUID）
+)
+
+var fieldTypes = []string{
+	FieldTypeText, FieldTypeNumber, FieldTypeInteger, FieldTypeBoolean, FieldTypeDate, FieldTypeDatetime,
+	FieldTypeEmail, FieldTypeURL, FieldTypeJSON, FieldTypeSelect, FieldTypeMultiSelect, FieldTypeRelation, FieldTypeMedia,
+}
+
+// HasOptions 選択肢（list_options）を持つ型かどうか
+func HasOptions(fieldType string) bool {
+	return fieldType == FieldTypeSelect || fieldType == FieldTypeMultiSelect
+}
+
+// ValidFieldType フィールドの型として使えるかどうか
+func ValidFieldType(fieldType string) bool {
+	return slices.Contains(fieldTypes, fieldType)
 }
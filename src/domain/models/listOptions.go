@@ -1,9 +1,26 @@
 package models
 
-type ListOptions struct {
-	ID          int    `gorm:"type:serial;primary_key" json:"id"`
-	ApiSchemaID int    `gorm:"type:int;not null" json:"apiSchemaId"`
-	Value       string `gorm:"type:varchar(50);not null" json:"value"`
-	CreateAt    string `gorm:"type:timestamp;not null" json:"createAt"`
-	UpdateAt    string `gorm:"type:timestamp;not null" json:"updateAt"`
+import "time"
+
+// ListOption select・multiselect のフィールドの選択肢
+type ListOption struct {
+	ID int `gorm:"type:serial;primary_key" json:"id"`
+	// FieldID 選択肢を持つフィールド（field_data.id）
+	FieldID int `gorm:"type:int;not null" json:"field_id"`
+	// Value エントリに保存する値。Label は画面に表示する名前
+	Value string `gorm:"type:varchar(255);not null" json:"value"`
+	Label string `gorm:"type:varchar(255);not null" json:"label"`
+	// Position 並び順（小さいほど先）
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// ListOptionUpdate 選択肢の変更内容。nil の項目は変更しない
+type ListOptionUpdate struct {
+	Value *string
+	Label *string
 }
+
+// ListOptionValueMaxLength 選択肢の値・表示名の最大の長さ
+const ListOptionValueMaxLength = 255
@@ -0,0 +1,8 @@
+package models
+
+// Mail 送信するメール（本文はプレーンテキスト）
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserMFA ユーザーごとのTOTP設定。シークレットは暗号化して保存する
+type UserMFA struct {
+	UserID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"user_id"`
+	SecretEncrypted string     `gorm:"type:text;not null" json:"-"`
+	Enabled         bool       `gorm:"not null;default:false" json:"enabled"`
+	ConfirmedAt     *time.Time `json:"confirmed_at,omitempty"`
+	LastUsedStep    int64      `gorm:"not null;default:0" json:"-"`
+	FailedAttempts  int        `gorm:"not null;default:0" json:"-"`
+	LockedUntil     *time.Time `json:"-"`
+	CreatedAt       time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (UserMFA) TableName() string {
+	return "user_mfa"
+}
+
+// MFARecoveryCode 認証アプリを使えない場合の使い捨てコード。ハッシュのみを保存する
+type MFARecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:varchar(64);not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// MFAEnrollment 登録開始時にクライアントへ返す情報
+type MFAEnrollment struct {
+	Secret     string
+	OTPAuthURI string
+}
@@ -2,12 +2,73 @@ package models
 
 import "time"
 
+const (
+	PermissionEffectAllow = "allow"
+	PermissionEffectDeny  = "deny"
+)
+
+// UserPermission ユーザーにプロジェクト内で個別に付与（deny の場合は禁止）した権限。
+// Resource は resource_type, resource_id の2つのカラムに分けて保存する（Resource.Columns を参照）。
+// ExpiresAt を過ぎた権限は権限チェックで無視され、定期的に削除される（nil は無期限）
 type UserPermission struct {
-	ID         UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	UserID     UUID      `gorm:"type:uuid;not null" json:"user_id"`
-	ProjectID  int       `gorm:"not null" json:"project_id"`
-	Permission string    `gorm:"type:varchar(50);not null" json:"permission"`
-	Resource   string    `gorm:"type:varchar(255);not null" json:"resource"`
-	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	ID           int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       UUID       `gorm:"type:uuid;not null" json:"user_id"`
+	ProjectID    int        `gorm:"not null" json:"project_id"`
+	Permission   string     `gorm:"column:permission_type;type:varchar(100);not null" json:"permission"`
+	Effect       string     `gorm:"type:varchar(10);not null;default:allow" json:"effect"`
+	ResourceType *string    `gorm:"type:varchar(100)" json:"-"`
+	ResourceID   *string    `gorm:"type:varchar(255)" json:"-"`
+	Resource     string     `gorm:"-" json:"resource"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	CreatedAt    time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// SetResource Resource と保存用のカラムを同時に設定する
+func (p *UserPermission) SetResource(resource Resource) {
+	p.Resource = resource.String()
+	p.ResourceType, p.ResourceID = resource.Columns()
+}
+
+// ParsedResource 保存されているカラムからリソースを読み取る
+func (p *UserPermission) ParsedResource() (Resource, error) {
+	return ResourceFromColumns(p.ResourceType, p.ResourceID)
+}
+
+// IsDeny 許可よりも優先される禁止ルールかどうか
+func (p *UserPermission) IsDeny() bool {
+	return p.Effect == PermissionEffectDeny
+}
+
+// PermissionGrant 複数のユーザーにまとめて登録する権限。UserIDs と Permissions のすべての組み合わせを登録する
+type PermissionGrant struct {
+	ProjectID   int
+	UserIDs     []UUID
+	Permissions []string
+	Resource    string
+	Effect      string
+	ExpiresAt   *time.Time
+	// ActorRole 付与するユーザーのプロジェクトでのロール。このロールが持っていない権限は付与できない
+	ActorRole string
+}
+
+// PermissionBatchResult まとめて操作した権限の件数
+type PermissionBatchResult struct {
+	// Affected 登録・削除・コピーした件数
+	Affected int
+	// Skipped 登録済み、またはコピーできないためにスキップした件数
+	Skipped int
+}
+
+// PermissionExplanation 権限チェックの結果と、その根拠になった権限
+type PermissionExplanation struct {
+	Permission string
+	Resource   string
+	// Effect 一致した権限のうち採用したものの effect。一致する権限がなければ空
+	Effect  string
+	Allowed bool
+	// Grant 判定の根拠になった権限（禁止ルールがあればそのうち最も限定的なもの）
+	Grant *UserPermission
+	// Matches permission と resource に一致したすべての権限
+	Matches []*UserPermission
 }
@@ -5,10 +5,12 @@ import (
 )
 
 type Project struct {
-	ID               int       `json:"id" gorm:"primaryKey"`
-	Name             string    `json:"name" gorm:"not null"`
-	Description      string    `json:"description"`
-	RateLimitPerHour int       `json:"rate_limit_per_hour" gorm:"default:1000"`
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	ID               int    `json:"id" gorm:"primaryKey"`
+	Name             string `json:"name" gorm:"not null"`
+	Description      string `json:"description"`
+	RateLimitPerHour int    `json:"rate_limit_per_hour" gorm:"default:1000"`
+	// MonthlyRequestQuota 1か月（UTC）あたりのSDKのリクエスト上限。nil の場合は上限なし
+	MonthlyRequestQuota *int      `json:"monthly_request_quota"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProjectMember プロジェクトに参加しているユーザーとそのロール
+type ProjectMember struct {
+	ProjectID int       `gorm:"primaryKey;autoIncrement:false" json:"project_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	// Role 組み込みロール、またはプロジェクトのカスタムロールの名前
+	Role      string    `gorm:"type:varchar(50);not null" json:"role"`
+	InvitedBy uuid.UUID `gorm:"type:uuid" json:"invited_by"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// ProjectMemberDetail メンバー一覧で返すユーザー情報付きのメンバー
+type ProjectMemberDetail struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
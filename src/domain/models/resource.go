@@ -0,0 +1,132 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// ResourceWildcard すべてのリソース、または種類ごとのすべてのIDを表す
+const ResourceWildcard = "*"
+
+// ErrInvalidResource リソースの書式が正しくない
+var ErrInvalidResource = errors.New("invalid resource")
+
+// ResourceSegment リソースの階層の1つ（"collection:5" など）
+type ResourceSegment struct {
+	Type string
+	ID   string
+}
+
+// Resource "project:1/collection:5/entry:*" のような階層構造のリソース。
+// 要素が空の場合はすべてのリソースを表す
+type Resource []ResourceSegment
+
+// ParseResource "種類:ID" を / で区切った文字列を読み取る。
+// ID の "*" はその種類のすべてのリソースを表し、末尾の "/*" は省略した場合と同じ意味になる
+func ParseResource(s string) (Resource, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == ResourceWildcard {
+		return Resource{}, nil
+	}
+
+	parts := strings.Split(s, "/")
+	if parts[len(parts)-1] == ResourceWildcard {
+		parts = parts[:len(parts)-1]
+	}
+
+	resource := make(Resource, 0, len(parts))
+	for _, part := range parts {
+		kind, id, ok := strings.Cut(part, ":")
+		if !ok || !validResourceType(kind) || id == "" {
+			return nil, ErrInvalidResource
+		}
+		resource = append(resource, ResourceSegment{Type: kind, ID: id})
+	}
+	return resource, nil
+}
+
+func validResourceType(kind string) bool {
+	if kind == "" {
+		return false
+	}
+	for _, r := range kind {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Resource) String() string {
+	if len(r) == 0 {
+		return ResourceWildcard
+	}
+	parts := make([]string, len(r))
+	for i, segment := range r {
+		parts[i] = segment.Type + ":" + segment.ID
+	}
+	return strings.Join(parts, "/")
+}
+
+// Covers target が r と同じか r の配下のリソースであれば true を返す。
+// 例えば "project:1/collection:5" は "project:1/collection:5/entry:9" を含む
+func (r Resource) Covers(target Resource) bool {
+	if len(r) > len(target) {
+		return false
+	}
+	for i, segment := range r {
+		if segment.Type != target[i].Type {
+			return false
+		}
+		if segment.ID != ResourceWildcard && segment.ID != target[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+// Specificity どれだけ限定されたリソースか。階層が深く、ワイルドカードが少ないほど大きい
+func (r Resource) Specificity() int {
+	score := 0
+	for _, segment := range r {
+		score += 2
+		if segment.ID != ResourceWildcard {
+			score++
+		}
+	}
+	return score
+}
+
+// Columns user_permissions の resource_type, resource_id に保存する値を返す。
+// 種類とIDをそれぞれ / で連結し（"project/collection", "1/5"）、すべてのリソースの場合は両方 nil になる
+func (r Resource) Columns() (*string, *string) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	types := make([]string, len(r))
+	ids := make([]string, len(r))
+	for i, segment := range r {
+		types[i] = segment.Type
+		ids[i] = segment.ID
+	}
+	resourceType := strings.Join(types, "/")
+	resourceID := strings.Join(ids, "/")
+	return &resourceType, &resourceID
+}
+
+// ResourceFromColumns Columns で保存した値からリソースを組み立てる
+func ResourceFromColumns(resourceType, resourceID *string) (Resource, error) {
+	if resourceType == nil || resourceID == nil {
+		return Resource{}, nil
+	}
+	types := strings.Split(*resourceType, "/")
+	ids := strings.Split(*resourceID, "/")
+	if len(types) != len(ids) {
+		return nil, ErrInvalidResource
+	}
+	parts := make([]string, len(types))
+	for i := range types {
+		parts[i] = types[i] + ":" + ids[i]
+	}
+	return ParseResource(strings.Join(parts, "/"))
+}
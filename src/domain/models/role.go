@@ -0,0 +1,170 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ユーザー全体のロール（users.role）
+const (
+	// UserRoleAdmin システム管理者。すべてのプロジェクトのすべての操作ができる
+	UserRoleAdmin = "admin"
+	UserRoleUser  = "user"
+)
+
+// プロジェクトの組み込みロール
+const (
+	ProjectRoleOwner  = "owner"
+	ProjectRoleAdmin  = "admin"
+	ProjectRoleEditor = "editor"
+	ProjectRoleAuthor = "author"
+	ProjectRoleViewer = "viewer"
+)
+
+// 権限（<リソース>:<操作>）
+const (
+	PermissionProjectsRead    = "projects:read"
+	PermissionProjectsWrite   = "projects:write"
+	PermissionMembersRead     = "members:read"
+	PermissionMembersWrite    = "members:write"
+	PermissionRolesRead       = "roles:read"
+	PermissionRolesWrite      = "roles:write"
+	PermissionCollectionsRead = "collections:read"
+	// PermissionCollectionsWrite コレクションとフィールドの作成・変更・削除
+	PermissionCollectionsWrite = "collections:write"
+	PermissionEntriesRead      = "entries:read"
+	// PermissionEntriesCreate エントリの作成のみ（author 向け）
+	PermissionEntriesCreate = "entries:create"
+	// PermissionEntriesWrite エントリの作成・変更・削除
+	PermissionEntriesWrite     = "entries:write"
+	PermissionMediaRead        = "media:read"
+	PermissionMediaWrite       = "media:write"
+	PermissionVersionsRead     = "versions:read"
+	PermissionVersionsWrite    = "versions:write"
+	PermissionApiKeysRead      = "api_keys:read"
+	PermissionApiKeysWrite     = "api_keys:write"
+	PermissionPermissionsRead  = "permissions:read"
+	PermissionPermissionsWrite = "permissions:write"
+	PermissionAuditRead        = "audit:read"
+	PermissionAuditWrite       = "audit:write"
+	PermissionAlertsRead       = "alerts:read"
+	PermissionAlertsWrite      = "alerts:write"
+
+	// プロジェクトを指定せずに行う操作
+	PermissionProjectsCreate = "projects:create"
+	PermissionProjectsList   = "projects:list"
+	// PermissionAuditReadOwn 自分の操作の監査ログの閲覧
+	PermissionAuditReadOwn = "audit:read_own"
+
+	// システム管理者のみの操作
+	PermissionUsersManage  = "users:manage"
+	PermissionAuditReadAll = "audit:read_all"
+)
+
+// ProjectPermissions プロジェクトのロールに割り当てられる権限
+var ProjectPermissions = []string{
+	PermissionProjectsRead, PermissionProjectsWrite,
+	PermissionMembersRead, PermissionMembersWrite,
+	PermissionRolesRead, PermissionRolesWrite,
+	PermissionCollectionsRead, PermissionCollectionsWrite,
+	PermissionEntriesRead, PermissionEntriesCreate, PermissionEntriesWrite,
+	PermissionMediaRead, PermissionMediaWrite,
+	PermissionVersionsRead, PermissionVersionsWrite,
+	PermissionApiKeysRead, PermissionApiKeysWrite,
+	PermissionPermissionsRead, PermissionPermissionsWrite,
+	PermissionAuditRead, PermissionAuditWrite,
+	PermissionAlertsRead, PermissionAlertsWrite,
+}
+
+// BaseUserPermissions ログインしているユーザーであれば誰でも持っている権限
+var BaseUserPermissions = []string{
+	PermissionProjectsCreate,
+	PermissionProjectsList,
+	PermissionAuditReadOwn,
+}
+
+var viewerPermissions = []string{
+	PermissionProjectsRead,
+	PermissionMembersRead,
+	PermissionRolesRead,
+	PermissionCollectionsRead,
+	PermissionEntriesRead,
+	PermissionMediaRead,
+	PermissionVersionsRead,
+	PermissionAuditWrite,
+	PermissionAlertsRead,
+}
+
+var authorPermissions = append(append([]string{}, viewerPermissions...),
+	PermissionEntriesCreate,
+	PermissionMediaWrite,
+)
+
+var editorPermissions = append(append([]string{}, authorPermissions...),
+	PermissionEntriesWrite,
+	PermissionVersionsWrite,
+)
+
+var adminPermissions = append(append([]string{}, editorPermissions...),
+	PermissionMembersWrite,
+	PermissionRolesWrite,
+	PermissionCollectionsWrite,
+	PermissionApiKeysRead,
+	PermissionApiKeysWrite,
+	PermissionPermissionsRead,
+	PermissionPermissionsWrite,
+	PermissionAuditRead,
+	PermissionAlertsWrite,
+)
+
+// builtinRoles 組み込みロールの権限。owner はプロジェクトのすべての権限を持つ
+var builtinRoles = map[string][]string{
+	ProjectRoleOwner:  ProjectPermissions,
+	ProjectRoleAdmin:  adminPermissions,
+	ProjectRoleEditor: editorPermissions,
+	ProjectRoleAuthor: authorPermissions,
+	ProjectRoleViewer: viewerPermissions,
+}
+
+// BuiltinRoleNames 組み込みロールの名前（権限の多い順）
+var BuiltinRoleNames = []string{ProjectRoleOwner, ProjectRoleAdmin, ProjectRoleEditor, ProjectRoleAuthor, ProjectRoleViewer}
+
+// BuiltinRolePermissions 組み込みロールでなければ false を返す
+func BuiltinRolePermissions(role string) ([]string, bool) {
+	permissions, ok := builtinRoles[role]
+	return permissions, ok
+}
+
+func IsBuiltinRole(role string) bool {
+	_, ok := builtinRoles[role]
+	return ok
+}
+
+// IsProjectPermission カスタムロールに割り当てられる権限か確認する
+func IsProjectPermission(permission string) bool {
+	return slices.Contains(ProjectPermissions, permission)
+}
+
+func IsBasePermission(permission string) bool {
+	return slices.Contains(BaseUserPermissions, permission)
+}
+
+// ProjectRole プロジェクトごとに定義できるカスタムロール
+type ProjectRole struct {
+	ID          int                         `gorm:"primaryKey" json:"id"`
+	ProjectID   int                         `gorm:"not null" json:"project_id"`
+	Name        string                      `gorm:"type:varchar(50);not null" json:"name"`
+	Description string                      `gorm:"type:text" json:"description"`
+	Permissions datatypes.JSONSlice[string] `gorm:"type:jsonb;not null;default:'[]'" json:"permissions"`
+	// BuiltIn 一覧で組み込みロールを返す場合に true
+	BuiltIn   bool      `gorm:"-" json:"built_in"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// HasPermission ロールの権限に permission が含まれるか確認する
+func (r *ProjectRole) HasPermission(permission string) bool {
+	return slices.Contains(r.Permissions, permission)
+}
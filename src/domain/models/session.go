@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session リフレッシュトークン1件ごとの行。
+// ローテーションで発行されたトークンは同じ FamilyID を引き継ぎ、FamilyID がセッションIDとして扱われる
+type Session struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	FamilyID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"family_id"`
+	UserID           uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	RefreshTokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	DeviceName       string     `gorm:"type:varchar(255)" json:"device_name"`
+	IPAddress        string     `gorm:"type:varchar(45)" json:"ip_address"`
+	UserAgent        string     `gorm:"type:text" json:"user_agent"`
+	LastUsedAt       time.Time  `gorm:"not null" json:"last_used_at"`
+	ExpiresAt        time.Time  `gorm:"not null" json:"expires_at"`
+	RotatedAt        *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt        time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// SessionMetadata セッション作成・更新時に記録するクライアント情報
+type SessionMetadata struct {
+	DeviceName string
+	IPAddress  string
+	UserAgent  string
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// TextArray PostgreSQL の text[]。nil は NULL として保存する
+type TextArray []string
+
+func (a TextArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	quoted := make([]string, len(a))
+	for i, s := range a {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		s = strings.ReplaceAll(s, `"`, `\"`)
+		quoted[i] = `"` + s + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}", nil
+}
+
+// Scan 1次元の配列リテラル（{a,"b c",NULL}）を読み込む。NULL の要素は空文字にする
+func (a *TextArray) Scan(value any) error {
+	var literal string
+	switch v := value.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		literal = v
+	case []byte:
+		literal = string(v)
+	default:
+		return fmt.Errorf("TextArray: unsupported type %T", value)
+	}
+
+	if len(literal) < 2 || literal[0] != '{' || literal[len(literal)-1] != '}' {
+		return fmt.Errorf("TextArray: invalid array literal %q", literal)
+	}
+	body := literal[1 : len(literal)-1]
+	elements := TextArray{}
+	if body == "" {
+		*a = elements
+		return nil
+	}
+
+	var current strings.Builder
+	quoted, inQuotes, escaped := false, false, false
+	for _, r := range body {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+			quoted = true
+		case r == ',' && !inQuotes:
+			elements = append(elements, arrayElement(current.String(), quoted))
+			current.Reset()
+			quoted = false
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if inQuotes || escaped {
+		return fmt.Errorf("TextArray: invalid array literal %q", literal)
+	}
+	*a = append(elements, arrayElement(current.String(), quoted))
+	return nil
+}
+
+// arrayElement 引用符で囲まれていない NULL は空文字にする
+func arrayElement(s string, quoted bool) string {
+	if !quoted && s == "NULL" {
+		return ""
+	}
+	return s
+}
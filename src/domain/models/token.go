@@ -1,3 +1,13 @@
 package models
 
+import "github.com/google/uuid"
+
 type Token string
+
+// TokenPair ログインやリフレッシュ時にクライアントへ返すトークンの組
+type TokenPair struct {
+	AccessToken  Token
+	RefreshToken string
+	ExpiresIn    int64
+	SessionID    uuid.UUID
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+)
+
+// 利用量を集計する単位
+const (
+	UsageGranularityHour  = "hour"
+	UsageGranularityDay   = "day"
+	UsageGranularityMonth = "month"
+)
+
+// UsageBucket プロジェクト・APIキー・コレクションごとの1時間あたりの利用量。
+// APIキー・コレクションを特定できないリクエストは ApiKeyID・CollectionID を0として数える
+type UsageBucket struct {
+	ProjectID    int `gorm:"primaryKey" json:"project_id"`
+	ApiKeyID     int `gorm:"primaryKey" json:"api_key_id"`
+	CollectionID int `gorm:"primaryKey" json:"collection_id"`
+	// BucketStart 1時間の始まり（UTC）
+	BucketStart    time.Time `gorm:"primaryKey" json:"bucket_start"`
+	Requests       int64     `json:"requests"`
+	BytesServed    int64     `json:"bytes_served"`
+	EntriesCreated int64     `json:"entries_created"`
+}
+
+// UsageQuery 利用量の集計条件。ApiKeyID・CollectionID が0の場合は絞り込まない
+type UsageQuery struct {
+	ProjectID    int
+	From         time.Time
+	To           time.Time
+	Granularity  string
+	ApiKeyID     int
+	CollectionID int
+}
+
+// UsageTotals 期間内の利用量の合計
+type UsageTotals struct {
+	Requests       int64 `json:"requests"`
+	BytesServed    int64 `json:"bytes_served"`
+	EntriesCreated int64 `json:"entries_created"`
+}
+
+// UsagePoint granularity ごとの利用量。PeriodStart は UTC の期間の始まり
+type UsagePoint struct {
+	PeriodStart time.Time `json:"period_start"`
+	UsageTotals
+}
+
+// MonthlyQuota プロジェクトの今月（UTC）のリクエスト数と月間上限。Limit が nil の場合は上限なし
+type MonthlyQuota struct {
+	Limit *int  `json:"limit"`
+	Used  int64 `json:"used"`
+	// Reset 来月の始まり
+	Reset time.Time `json:"reset"`
+}
+
+// Exceeded 月間上限に達しているか
+func (q MonthlyQuota) Exceeded() bool {
+	return q.Limit != nil && q.Used >= int64(*q.Limit)
+}
+
+// Remaining 月間上限までの残りのリクエスト数。上限がない場合は -1
+func (q MonthlyQuota) Remaining() int64 {
+	if q.Limit == nil {
+		return -1
+	}
+	return max(int64(*q.Limit)-q.Used, 0)
+}
+
+// UsageReport GET /api/projects/:projectId/usage のレスポンス
+type UsageReport struct {
+	From        time.Time    `json:"from"`
+	To          time.Time    `json:"to"`
+	Granularity string       `json:"granularity"`
+	Usage       []UsagePoint `json:"usage"`
+	Totals      UsageTotals  `json:"totals"`
+	Quota       MonthlyQuota `json:"quota"`
+}
@@ -9,11 +9,12 @@ import (
 type UUID = uuid.UUID
 
 type Users struct {
-	ID        UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
-	Email     string    `gorm:"type:varchar(255);not null;unique" json:"email"`
-	Password  string    `gorm:"type:varchar(100);not null" json:"password"`
-	Role      string    `gorm:"type:varchar(50);default:'user'" json:"role"`
-	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
-	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	ID            UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name          string    `gorm:"type:varchar(100);not null" json:"name"`
+	Email         string    `gorm:"type:varchar(255);not null;unique" json:"email"`
+	Password      string    `gorm:"type:varchar(100);not null" json:"-"`
+	Role          string    `gorm:"type:varchar(50);default:'user'" json:"role"`
+	EmailVerified bool      `gorm:"not null;default:false" json:"email_verified"`
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 }
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdentityProviderAuth0 Auth0で認証されたユーザーの provider
+const IdentityProviderAuth0 = "auth0"
+
+// UserIdentity 外部の認証基盤のユーザー（provider + subject）とローカルユーザーの対応
+type UserIdentity struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider    string    `gorm:"type:varchar(50);not null" json:"provider"`
+	Subject     string    `gorm:"type:varchar(255);not null" json:"subject"`
+	Email       string    `gorm:"type:varchar(255)" json:"email"`
+	LastLoginAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"last_login_at"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// ExternalIdentity 外部の認証基盤から受け取ったユーザー情報
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// UserTokenPurposePasswordReset パスワード再設定用
+	UserTokenPurposePasswordReset = "password_reset"
+	// UserTokenPurposeEmailVerification メールアドレス確認用
+	UserTokenPurposeEmailVerification = "email_verification"
+)
+
+// UserToken メールで送付する使い捨てトークン。DBにはハッシュのみを保存する
+type UserToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Purpose   string     `gorm:"type:varchar(50);not null" json:"purpose"`
+	TokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
@@ -9,8 +9,21 @@ import (
 
 type ApiKeyRepository interface {
 	Create(ctx context.Context, apiKey *models.ApiKeys) *errors.DomainError
-	FindByKey(ctx context.Context, key string) (*models.ApiKeys, *errors.DomainError)
+	// FindByPrefix APIキーを現在のキーまたは猶予期間中のローテーション前のキーの prefix で検索する。
+	// 形式を変更する前のキーは prefix が重複していることがあるため、secret のハッシュと取り消し・有効期限は呼び出し側で確認する
+	FindByPrefix(ctx context.Context, prefix string) ([]*models.ApiKeys, *errors.DomainError)
+	FindByID(ctx context.Context, projectID int, id int) (*models.ApiKeys, *errors.DomainError)
+	FindByProjectID(ctx context.Context, projectID int) ([]*models.ApiKeys, *errors.DomainError)
 	FindByUserID(ctx context.Context, userID string) ([]*models.ApiKeys, *errors.DomainError)
-	Update(ctx context.Context, apiKey *models.ApiKeys) *errors.DomainError
-	Delete(ctx context.Context, id int) *errors.DomainError
+	// Update columns のカラムだけを更新する（同時に他のカラムが変更されても上書きしない）。
+	// collections が nil でない場合は、利用できるコレクション（api_key_collections）を collections で置き換える
+	Update(ctx context.Context, id int, columns map[string]interface{}, collections []models.ApiKeyCollections) *errors.DomainError
+	// Rotate 現在のキーが currentKeyPrefix のままで取り消されていない場合だけ、apiKey の新しいキーとローテーション前のキーを保存する。
+	// 他の操作で変更されていた場合は AlreadyExist
+	Rotate(ctx context.Context, apiKey *models.ApiKeys, currentKeyPrefix string) *errors.DomainError
+	// AttachCollection キーで利用できるコレクションを追加する。追加済みの場合はスコープを置き換える
+	AttachCollection(ctx context.Context, collection *models.ApiKeyCollections) *errors.DomainError
+	// DetachCollection キーからコレクションを外す。外すコレクションがない場合は QueryDataNotFoundError
+	DetachCollection(ctx context.Context, apiKeyID int, collectionID int) *errors.DomainError
+	Delete(ctx context.Context, projectID int, id int) *errors.DomainError
 }
@@ -1,11 +1,37 @@
 package repositories
 
 import (
+	"context"
+	"time"
+
 	"w3st/domain/models"
 )
 
+// CollectionsRepository コレクションを扱う。ゴミ箱にあるコレクション（deleted_at が NULL でないもの）は
+// ゴミ箱を扱うメソッド以外では返さない
 type CollectionsRepository interface {
 	CreateCollection(newCollection *models.ApiCollection) error
 	GetCollectionByProjectId(projectId int) ([]models.ApiCollection, error)
 	GetCollectionsByCollectionId(collectionId int, projectId int) (*models.ApiCollection, error)
+	// GetCollectionsByIds プロジェクトのコレクションのうち collectionIds に含まれるものを返す
+	GetCollectionsByIds(projectId int, collectionIds []int) ([]models.ApiCollection, error)
+
+	// GetCollectionBySlug プロジェクトのコレクションを slug で取得する
+	GetCollectionBySlug(ctx context.Context, projectId int, slug string) (*models.ApiCollection, error)
+	// UpdateCollection update の nil でない項目を変更し、変更後のコレクションを返す。slug が重複する場合は AlreadyExist
+	UpdateCollection(ctx context.Context, collectionId int, update models.CollectionUpdate) (*models.ApiCollection, error)
+	// LockCollection ゴミ箱にあるものも含めてコレクションを取得し、トランザクションの終わりまで行をロックする
+	LockCollection(ctx context.Context, collectionId int, projectId int) (*models.ApiCollection, error)
+	// GetReferencingCollectionIds collectionId をリレーションで参照している他のコレクション（ゴミ箱にあるものを除く）のIDを返す
+	GetReferencingCollectionIds(ctx context.Context, collectionId int) ([]int, error)
+	// SoftDeleteCollection コレクションをゴミ箱に移す
+	SoftDeleteCollection(ctx context.Context, collectionId int, deletedAt time.Time) error
+	// RestoreCollection ゴミ箱にあるコレクションを戻す。slug が重複する場合は AlreadyExist
+	RestoreCollection(ctx context.Context, collectionId int) error
+	// DeleteCollection コレクションと、フィールド・エントリ・バージョン・リレーション・APIキーへの付与を削除する
+	DeleteCollection(ctx context.Context, collectionId int) error
+	// GetDeletedCollections プロジェクトのゴミ箱にあるコレクションを、ゴミ箱に移した日時の新しい順に返す
+	GetDeletedCollections(ctx context.Context, projectId int) ([]models.ApiCollection, error)
+	// GetDeletedCollectionsBefore すべてのプロジェクトから before より前にゴミ箱に移したコレクションを返す
+	GetDeletedCollectionsBefore(ctx context.Context, before time.Time) ([]models.ApiCollection, error)
 }
@@ -1,13 +1,35 @@
 package repositories
 
 import (
+	"context"
+
 	"w3st/domain/models"
 )
 
 type EntriesRepository interface {
-	CreateEntry(newEntry *models.Entry) error
+	CreateEntry(ctx context.Context, newEntry *models.Entry) error
 	GetEntriesByCollectionIdAndProjectId(collectionId int, projectId int) ([]models.Entry, error)
 	GetEntryByIdAndProjectId(entryId int, projectId int) (*models.Entry, error)
-	UpdateEntry(entry *models.Entry) error
-	DeleteEntry(entryId int, projectId int) error
+	// GetEntriesByIds entryIds のうちコレクションにあるエントリ（populate で参照先をまとめて読み込む）
+	GetEntriesByIds(collectionId int, projectId int, entryIds []int) ([]models.Entry, error)
+	UpdateEntry(ctx context.Context, entry *models.Entry) error
+	DeleteEntry(ctx context.Context, entryId int, projectId int) error
+	// LockEntry エントリを取得し、トランザクションの終わりまで他の変更と参照の追加をロックする
+	LockEntry(ctx context.Context, entryId int, projectId int) (*models.Entry, error)
+	// LockFieldValues コレクションのフィールドの値の重複確認をトランザクションの終わりまで他のリクエストと排他にする
+	LockFieldValues(ctx context.Context, collectionId int, fieldId string) error
+	// ExistsFieldValue コレクション内に fieldId の値が value（JSON）のエントリがあるか。excludeEntryId のエントリは除く
+	ExistsFieldValue(ctx context.Context, collectionId int, fieldId string, value string, excludeEntryId int) (bool, error)
+	// CountOptionValue fieldId の値が value（select）か、value を含む（multiselect）エントリの数
+	CountOptionValue(ctx context.Context, collectionId int, fieldId string, value string) (int64, error)
+	// ReplaceOptionValue fieldId の値の oldValue を newValue に書き換え、書き換えたエントリの数を返す
+	ReplaceOptionValue(ctx context.Context, collectionId int, fieldId string, oldValue string, newValue string) (int64, error)
+	// LockReferencedEntries entryIds のうちコレクションにあるエントリのIDを返し、トランザクションの終わりまで削除されないようにする
+	LockReferencedEntries(ctx context.Context, collectionId int, entryIds []int) ([]int, error)
+	// GetReferencingEntries fieldId の値が entryId（relation）か、entryId を含む配列のエントリ
+	GetReferencingEntries(ctx context.Context, collectionId int, fieldId string, entryId int) ([]models.Entry, error)
+	// LockReferencingEntryIds GetReferencingEntries のエントリのIDを返し、トランザクションの終わりまでロックする
+	LockReferencingEntryIds(ctx context.Context, collectionId int, fieldId string, entryId int) ([]int, error)
+	// RemoveReference fieldId の entryId への参照を外し（値は null に、配列からは取り除く）、書き換えたエントリの数を返す
+	RemoveReference(ctx context.Context, collectionId int, fieldId string, entryId int) (int64, error)
 }
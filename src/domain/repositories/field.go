@@ -10,5 +10,7 @@ type FieldRepository interface {
 	CreateField(newField *models.FieldData) error
 	UpdateField(newField *models.FieldData) error
 	DeleteFieldById(projectId int, fieldId uuid.UUID) error
+	// GetFieldsByCollectionId, GetField 選択肢（Options）も並び順で読み込み、リレーション（Relation）も読み込む
 	GetFieldsByCollectionId(collectionId int, projectId int) ([]models.FieldData, error)
+	GetField(collectionId int, projectId int, fieldId int) (*models.FieldData, error)
 }
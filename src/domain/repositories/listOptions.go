@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"w3st/domain/models"
+)
+
+type ListOptionRepository interface {
+	GetOptionsByFieldId(ctx context.Context, fieldId int) ([]models.ListOption, error)
+	// LockOption 選択肢を取得し、トランザクションの終わりまで他の変更をロックする
+	LockOption(ctx context.Context, fieldId int, optionId int) (*models.ListOption, error)
+	CreateOption(ctx context.Context, option *models.ListOption) error
+	UpdateOption(ctx context.Context, option *models.ListOption) error
+	DeleteOption(ctx context.Context, fieldId int, optionId int) error
+	// UpdateOptionPositions optionIds の順に position を 0, 1, 2... にする
+	UpdateOptionPositions(ctx context.Context, fieldId int, optionIds []int) error
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/errors"
+
+	"github.com/google/uuid"
+)
+
+type MFARepository interface {
+	FindByUserID(ctx context.Context, userID uuid.UUID) (*models.UserMFA, *errors.DomainError)
+	Save(ctx context.Context, mfa *models.UserMFA) *errors.DomainError
+	// UpdateLastUsedStep 前回より新しいステップの場合のみ更新する。同じコードの再利用時は false を返す
+	UpdateLastUsedStep(ctx context.Context, userID uuid.UUID, step int64) (bool, *errors.DomainError)
+	// UpdateLockout 連続失敗回数とロック期限のみを更新する
+	UpdateLockout(ctx context.Context, userID uuid.UUID, failedAttempts int, lockedUntil *time.Time) *errors.DomainError
+	// Delete TOTP設定とリカバリーコードを削除する
+	Delete(ctx context.Context, userID uuid.UUID) *errors.DomainError
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) *errors.DomainError
+	// UseRecoveryCode 未使用のコードであれば使用済みにして true を返す
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, *errors.DomainError)
+}
@@ -2,15 +2,23 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"w3st/domain/models"
 	"w3st/errors"
 )
 
+// PermissionRepository 個別の権限はプロジェクトごとに管理し、すべての操作で projectID を指定する。
+// 検索では期限切れの権限を返さない
 type PermissionRepository interface {
-	Create(ctx context.Context, permission *models.UserPermission) *errors.DomainError
-	FindByID(ctx context.Context, id string) (*models.UserPermission, *errors.DomainError)
-	FindByUserID(ctx context.Context, userID string) ([]*models.UserPermission, *errors.DomainError)
-	FindByUserIDAndResource(ctx context.Context, userID, resource string) ([]*models.UserPermission, *errors.DomainError)
-	Delete(ctx context.Context, id string) *errors.DomainError
+	// CreateBatch 同じユーザー・権限・リソースの組み合わせが登録済みのものは無視し、登録した件数を返す
+	CreateBatch(ctx context.Context, permissions []*models.UserPermission) (int, *errors.DomainError)
+	FindByID(ctx context.Context, projectID int, id string) (*models.UserPermission, *errors.DomainError)
+	FindByUserID(ctx context.Context, projectID int, userID string) ([]*models.UserPermission, *errors.DomainError)
+	// FindByUserIDAndPermission リソースに関係なく permission の権限（allow, deny の両方）を返す
+	FindByUserIDAndPermission(ctx context.Context, projectID int, userID, permission string) ([]*models.UserPermission, *errors.DomainError)
+	// DeleteByResource userIDs の permissions のうち resource と完全に一致するものを削除し、削除した件数を返す
+	DeleteByResource(ctx context.Context, projectID int, userIDs []string, permissions []string, resource models.Resource) (int, *errors.DomainError)
+	// DeleteExpired now までに期限が切れた権限をすべてのプロジェクトから削除し、削除した件数を返す
+	DeleteExpired(ctx context.Context, now time.Time) (int, *errors.DomainError)
 }
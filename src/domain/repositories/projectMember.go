@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"w3st/domain/models"
+	"w3st/errors"
+
+	"github.com/google/uuid"
+)
+
+type ProjectMemberRepository interface {
+	FindByProjectAndUser(ctx context.Context, projectID int, userID uuid.UUID) (*models.ProjectMember, *errors.DomainError)
+	FindByProjectID(ctx context.Context, projectID int) ([]models.ProjectMemberDetail, *errors.DomainError)
+	// FindProjectsByUserID ユーザーが参加しているプロジェクトを返す
+	FindProjectsByUserID(ctx context.Context, userID uuid.UUID) ([]models.Project, *errors.DomainError)
+	// Create すでにメンバーの場合は AlreadyExist を返す
+	Create(ctx context.Context, member *models.ProjectMember) *errors.DomainError
+	// CreateProjectWithOwner プロジェクトと作成者のメンバー情報を同じトランザクションで作成する
+	CreateProjectWithOwner(ctx context.Context, project *models.Project, owner *models.ProjectMember) *errors.DomainError
+	CountByRole(ctx context.Context, projectID int, role string) (int64, *errors.DomainError)
+	Delete(ctx context.Context, projectID int, userID uuid.UUID) *errors.DomainError
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"w3st/domain/models"
+)
+
+// RelationRepository relation 型のフィールドのリレーション（api_kind_relation）。読み込んだリレーションには FieldKey を設定する
+type RelationRepository interface {
+	// GetRelationsByCollectionId コレクションのフィールドから他のコレクションへのリレーション
+	GetRelationsByCollectionId(ctx context.Context, collectionId int) ([]models.ApiKindRelation, error)
+	// GetRelationsByRelatedCollectionId コレクションのエントリを参照しているリレーション
+	GetRelationsByRelatedCollectionId(ctx context.Context, relatedCollectionId int) ([]models.ApiKindRelation, error)
+	GetRelation(ctx context.Context, collectionId int, relationId int) (*models.ApiKindRelation, error)
+	CreateRelation(ctx context.Context, relation *models.ApiKindRelation) error
+	UpdateRelationOnDelete(ctx context.Context, relation *models.ApiKindRelation) error
+	DeleteRelation(ctx context.Context, collectionId int, relationId int) error
+}
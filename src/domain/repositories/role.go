@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+
+	"w3st/domain/models"
+	"w3st/errors"
+)
+
+type RoleRepository interface {
+	FindByProjectID(ctx context.Context, projectID int) ([]models.ProjectRole, *errors.DomainError)
+	FindByName(ctx context.Context, projectID int, name string) (*models.ProjectRole, *errors.DomainError)
+	FindByID(ctx context.Context, projectID int, id int) (*models.ProjectRole, *errors.DomainError)
+	// Create 同じプロジェクトに同じ名前のロールがある場合は AlreadyExist を返す
+	Create(ctx context.Context, role *models.ProjectRole) *errors.DomainError
+	Delete(ctx context.Context, projectID int, id int) *errors.DomainError
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"w3st/domain/models"
+	"w3st/errors"
+
+	"github.com/google/uuid"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) *errors.DomainError
+	FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, *errors.DomainError)
+	FindActiveByUserID(ctx context.Context, userID uuid.UUID) ([]models.Session, *errors.DomainError)
+	// IsFamilyActive 失効・期限切れでない最新のトークンが存在するかを返す
+	IsFamilyActive(ctx context.Context, familyID uuid.UUID) (bool, *errors.DomainError)
+	// MarkRotated 未使用のトークンのみを使用済みにする。すでに使用済みの場合は false を返す
+	MarkRotated(ctx context.Context, id uuid.UUID) (bool, *errors.DomainError)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) *errors.DomainError
+	RevokeFamilyByUser(ctx context.Context, userID uuid.UUID, familyID uuid.UUID) *errors.DomainError
+	RevokeAllByUser(ctx context.Context, userID uuid.UUID) *errors.DomainError
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/errors"
+)
+
+type UsageRepository interface {
+	// AddBuckets buckets の利用量を同じバケットの利用量に加える（なければ作成する）
+	AddBuckets(ctx context.Context, buckets []models.UsageBucket) *errors.DomainError
+	// Aggregate query.Granularity ごとに利用量を合計する。利用のない期間は返さない
+	Aggregate(ctx context.Context, query models.UsageQuery) ([]models.UsagePoint, *errors.DomainError)
+	// SumRequests プロジェクトごとの from 以降のリクエスト数。利用のないプロジェクトは含まない
+	SumRequests(ctx context.Context, projectIDs []int, from time.Time) (map[int]int64, *errors.DomainError)
+}
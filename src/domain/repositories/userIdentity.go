@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"w3st/domain/models"
+	"w3st/errors"
+
+	"github.com/google/uuid"
+)
+
+type UserIdentityRepository interface {
+	FindBySubject(ctx context.Context, provider string, subject string) (*models.UserIdentity, *errors.DomainError)
+	// Create 同じ provider + subject がすでに連携されている場合は AlreadyExist を返す
+	Create(ctx context.Context, identity *models.UserIdentity) *errors.DomainError
+	// CreateWithUser ユーザーと連携情報を同じトランザクションで作成する
+	CreateWithUser(ctx context.Context, user *models.Users, identity *models.UserIdentity) *errors.DomainError
+	UpdateLastLogin(ctx context.Context, id uuid.UUID) *errors.DomainError
+}
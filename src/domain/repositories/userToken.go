@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"w3st/domain/models"
+	"w3st/errors"
+
+	"github.com/google/uuid"
+)
+
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *models.UserToken) *errors.DomainError
+	FindByHash(ctx context.Context, purpose string, tokenHash string) (*models.UserToken, *errors.DomainError)
+	// MarkUsed 未使用のトークンのみを使用済みにする。すでに使用済みの場合は false を返す
+	MarkUsed(ctx context.Context, id uuid.UUID) (bool, *errors.DomainError)
+	// InvalidateByUser 同じ用途の未使用トークンをすべて使用済みにする
+	InvalidateByUser(ctx context.Context, userID uuid.UUID, purpose string) *errors.DomainError
+}
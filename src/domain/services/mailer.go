@@ -0,0 +1,11 @@
+package services
+
+import (
+	"context"
+
+	"w3st/domain/models"
+)
+
+type Mailer interface {
+	Send(ctx context.Context, mail *models.Mail) error
+}
@@ -1,6 +1,63 @@
 package dto
 
+import "time"
+
+// CreateApiKeyRequest ip_whitelist にはIPアドレスまたはCIDRを指定する（省略時は制限なし）。
+// expire_at を省略した場合は1年後、rate_limit_per_hour を省略した場合は既定値（プロジェクトのレート制限以下）、
+// scopes を省略した場合は読み取りのみ（collections:read, entries:read）になる。
+// collection_scopes はコレクションIDごとに scopes を上書きする
 type CreateApiKeyRequest struct {
-	Name          string `json:"name" binding:"required"`
-	CollectionIds []int  `json:"collection_ids" binding:"required"`
+	Name             string           `json:"name" binding:"required"`
+	CollectionIds    []int            `json:"collection_ids" binding:"required"`
+	IpWhiteList      []string         `json:"ip_whitelist" binding:"omitempty,max=100,dive,required"`
+	ExpireAt         *time.Time       `json:"expire_at"`
+	RateLimit        int              `json:"rate_limit_per_hour" binding:"omitempty,min=1"`
+	Scopes           []string         `json:"scopes"`
+	CollectionScopes map[int][]string `json:"collection_scopes"`
+}
+
+// UpdateApiKeyRequest 省略した項目は変更しない。revoked に true を指定するとキーを取り消す。
+// collection_scopes を指定した場合はコレクションごとのスコープをすべて置き換える（{} ですべて解除）
+type UpdateApiKeyRequest struct {
+	Name             *string          `json:"name" binding:"omitempty,min=1,max=100"`
+	CollectionIds    []int            `json:"collection_ids"`
+	Revoked          *bool            `json:"revoked"`
+	Scopes           []string         `json:"scopes"`
+	CollectionScopes map[int][]string `json:"collection_scopes"`
+}
+
+// AttachApiKeyCollectionRequest scopes を省略した場合はキーの scopes を使う
+type AttachApiKeyCollectionRequest struct {
+	CollectionID int      `json:"collection_id" binding:"required,min=1"`
+	Scopes       []string `json:"scopes"`
+}
+
+// RotateApiKeyRequest grace_period_seconds を省略した場合は24時間。0 を指定するとローテーション前のキーはすぐに使えなくなる
+type RotateApiKeyRequest struct {
+	GracePeriodSeconds *int `json:"grace_period_seconds" binding:"omitempty,min=0,max=604800"`
+}
+
+// ApiKeyResponse キーそのものは含めず、先頭部分（key_prefix）のみを返す
+type ApiKeyResponse struct {
+	ID            int      `json:"id"`
+	Name          string   `json:"name"`
+	KeyPrefix     string   `json:"key_prefix"`
+	UserID        string   `json:"user_id"`
+	ProjectID     int      `json:"project_id"`
+	CollectionIds []int    `json:"collection_ids"`
+	IpWhiteList   []string `json:"ip_whitelist"`
+	ExpireAt      string   `json:"expire_at"`
+	Revoked       bool     `json:"revoked"`
+	RateLimit     int      `json:"rate_limit_per_hour"`
+	Scopes        []string `json:"scopes"`
+	// CollectionScopes コレクションIDごとのスコープ（上書きしていないコレクションは含まない）
+	CollectionScopes    map[int][]string `json:"collection_scopes"`
+	PreviousKeyExpireAt *string          `json:"previous_key_expire_at"`
+	CreatedAt           string           `json:"created_at"`
+}
+
+// IssuedApiKeyResponse 作成・ローテーション時のレスポンス。api_key はこのレスポンスでしか取得できない
+type IssuedApiKeyResponse struct {
+	ApiKey string `json:"api_key"`
+	*ApiKeyResponse
 }
@@ -1,6 +1,29 @@
 package dto
 
+import "time"
+
+// MakeCollection slug を省略した場合は name から作る
 type MakeCollection struct {
 	Name        string `json:"name" binding:"required,min=1"`
+	Slug        string `json:"slug" binding:"omitempty,max=100"`
 	Description string `json:"description" binding:"required,min=1"`
+	// RejectUnknownFields フィールドとして定義されていないキーを含むエントリを受け付けない
+	RejectUnknownFields bool `json:"reject_unknown_fields"`
+}
+
+// UpdateCollection 省略した項目は変更しない。updated_at には取得したコレクションの updated_at を指定する
+type UpdateCollection struct {
+	Name                *string   `json:"name" binding:"omitempty,min=1,max=100"`
+	Slug                *string   `json:"slug" binding:"omitempty,max=100"`
+	Description         *string   `json:"description"`
+	RejectUnknownFields *bool     `json:"reject_unknown_fields"`
+	UpdatedAt           time.Time `json:"updated_at" binding:"required"`
+}
+
+// DeleteCollectionQuery DELETE /collections/:collectionId のクエリ
+type DeleteCollectionQuery struct {
+	// Force 他のコレクションからリレーションで参照されていても削除する
+	Force bool `form:"force"`
+	// Permanent ゴミ箱に移さずに削除する
+	Permanent bool `form:"permanent"`
 }
@@ -6,6 +6,8 @@ type CreateField struct {
 	FieldType    string `json:"field_type"`
 	IsRequired   bool   `json:"is_required"`
 	DefaultValue string `json:"default_value"`
+	// Validations 値の検証ルール（省略した場合は型だけを確認する）
+	Validations FieldValidations `json:"validations"`
 }
 
 type UpdateField struct {
@@ -14,4 +16,20 @@ type UpdateField struct {
 	FieldType    string `json:"field_type"`
 	IsRequired   bool   `json:"is_required"`
 	DefaultValue string `json:"default_value"`
+	// Validations 値の検証ルール（省略した場合は型だけを確認する）
+	Validations FieldValidations `json:"validations"`
+}
+
+// FieldValidations フィールドの値の検証ルール。省略したルールは確認しない
+type FieldValidations struct {
+	MinLength      *int          `json:"min_length"`
+	MaxLength      *int          `json:"max_length"`
+	Min            *float64      `json:"min"`
+	Max            *float64      `json:"max"`
+	Pattern        string        `json:"pattern"`
+	PatternMessage string        `json:"pattern_message"`
+	Enum           []interface{} `json:"enum"`
+	MinItems       *int          `json:"min_items"`
+	MaxItems       *int          `json:"max_items"`
+	Unique         bool          `json:"unique"`
 }
@@ -0,0 +1,18 @@
+package dto
+
+// CreateListOption label を省略した場合は value を表示名にする
+type CreateListOption struct {
+	Value string `json:"value" binding:"required,max=255"`
+	Label string `json:"label" binding:"max=255"`
+}
+
+// UpdateListOption 省略した項目は変更しない。value を変更するとエントリに保存されている値も書き換える
+type UpdateListOption struct {
+	Value *string `json:"value" binding:"omitempty,max=255"`
+	Label *string `json:"label" binding:"omitempty,max=255"`
+}
+
+// ReorderListOptions フィールドのすべての選択肢のIDを並べたい順に指定する
+type ReorderListOptions struct {
+	OptionIDs []int `json:"option_ids" binding:"required"`
+}
@@ -1,9 +1,30 @@
 package dto
 
+import "time"
+
 type CreatePermission struct {
 	UserID     string `json:"user_id" binding:"required,uuid"`
 	Permission string `json:"permission" binding:"required,min=1"`
 	Resource   string `json:"resource" binding:"required,min=1"`
+	// Effect 省略時は allow。deny は許可やロールよりも優先される
+	Effect string `json:"effect" binding:"omitempty,oneof=allow deny"`
+	// ExpiresAt 省略時は無期限
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// BulkPermission user_ids と permissions のすべての組み合わせを付与・削除する
+type BulkPermission struct {
+	UserIDs     []string   `json:"user_ids" binding:"required,min=1,max=100,dive,uuid"`
+	Permissions []string   `json:"permissions" binding:"required,min=1,max=50,dive,min=1"`
+	Resource    string     `json:"resource" binding:"required,min=1"`
+	Effect      string     `json:"effect" binding:"omitempty,oneof=allow deny"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// CopyPermissions from_project_id のプロジェクトでのユーザーの権限を操作中のプロジェクトにコピーする
+type CopyPermissions struct {
+	UserID        string `json:"user_id" binding:"required,uuid"`
+	FromProjectID int    `json:"from_project_id" binding:"required,min=1"`
 }
 
 type UpdatePermission struct {
@@ -15,8 +36,27 @@ type UpdatePermission struct {
 type PermissionResponse struct {
 	ID         string `json:"id"`
 	UserID     string `json:"user_id"`
+	Permission string `json:"permission"`
+	Effect     string `json:"effect"`
+	Resource   string `json:"resource"`
+	// ExpiresAt 無期限の場合は null
+	ExpiresAt *string `json:"expires_at"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+type PermissionBatchResponse struct {
+	Message  string `json:"message"`
+	Affected int    `json:"affected"`
+	Skipped  int    `json:"skipped"`
+}
+
+type PermissionExplainResponse struct {
 	Permission string `json:"permission"`
 	Resource   string `json:"resource"`
-	CreatedAt  string `json:"created_at"`
-	UpdatedAt  string `json:"updated_at"`
+	Allowed    bool   `json:"allowed"`
+	// Effect 判定に使った権限の effect。一致する権限がなければ空
+	Effect  string                `json:"effect,omitempty"`
+	Grant   *PermissionResponse   `json:"grant"`
+	Matches []*PermissionResponse `json:"matches"`
 }
@@ -0,0 +1,13 @@
+package dto
+
+type InviteProjectMember struct {
+	Email string `json:"email" binding:"required,email"`
+	// 組み込みロールまたはカスタムロールの名前。省略した場合は editor
+	Role string `json:"role" binding:"omitempty,max=50"`
+}
+
+type CreateProjectRole struct {
+	Name        string   `json:"name" binding:"required,max=50"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required,min=1"`
+}
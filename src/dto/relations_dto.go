@@ -0,0 +1,14 @@
+package dto
+
+// CreateRelation field_id は relation 型のフィールドのID。on_delete を省略した場合は restrict
+type CreateRelation struct {
+	FieldID             int    `json:"field_id" binding:"required"`
+	RelatedCollectionID int    `json:"related_collection_id" binding:"required"`
+	RelationType        string `json:"relation_type" binding:"required"`
+	OnDelete            string `json:"on_delete"`
+}
+
+// UpdateRelation 参照先のエントリを削除したときの扱いだけを変更できる
+type UpdateRelation struct {
+	OnDelete string `json:"on_delete" binding:"required"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+// GetUsageQuery from・to は RFC3339。省略した場合は今月の始まりから現在まで
+type GetUsageQuery struct {
+	From         time.Time `form:"from"`
+	To           time.Time `form:"to"`
+	Granularity  string    `form:"granularity" binding:"omitempty,oneof=hour day month"`
+	ApiKeyID     int       `form:"api_key_id" binding:"omitempty,min=1"`
+	CollectionID int       `form:"collection_id" binding:"omitempty,min=1"`
+}
+
+// SetMonthlyQuotaRequest monthly_request_quota が null の場合は上限をなくす
+type SetMonthlyQuotaRequest struct {
+	MonthlyRequestQuota *int `json:"monthly_request_quota"`
+}
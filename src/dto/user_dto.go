@@ -1,17 +1,79 @@
 package dto
 
 type SignupData struct {
-	Name     string `json:"name" binding:"required,min=1"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=1"`
+	Name       string `json:"name" binding:"required,min=1"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required,min=1"`
+	DeviceName string `json:"device_name"`
 }
 
 type LoginData struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=1"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required,min=1"`
+	DeviceName string `json:"device_name"`
 }
 
 type UpdateUserData struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 }
+
+type RefreshTokenData struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+	DeviceName   string `json:"device_name"`
+}
+
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	SessionID    string `json:"session_id"`
+}
+
+type SessionResponse struct {
+	ID         string `json:"id"`
+	DeviceName string `json:"device_name"`
+	IPAddress  string `json:"ip_address"`
+	UserAgent  string `json:"user_agent"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"`
+}
+
+type ForgotPasswordData struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordData struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=1"`
+}
+
+type VerifyEmailData struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type MFALoginData struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+	DeviceName     string `json:"device_name"`
+}
+
+type MFACodeData struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFAChallengeResponse struct {
+	MFARequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresIn      int64  `json:"expires_in"`
+}
+
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -22,6 +22,9 @@ const (
 	QueryDataNotFoundError
 	ErrorUnknown
 	TransactionError
+	Unauthenticated
+	// ValidationFailed 入力がスキーマの定義を満たさない（項目ごとの内容は ValidationError に入れる）
+	ValidationFailed
 )
 
 func (e *DomainError) Error() string {
@@ -0,0 +1,40 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError 1項目の検証エラー
+type FieldError struct {
+	// Path エラーになった値の位置 ex) "title", "tags[1]"
+	Path string `json:"path"`
+	// Code エラーの種類 ex) "required", "invalid_type"
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError 検証エラーの一覧。すべての項目のエラーをまとめて返すために使う
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	if e == nil {
+		return ""
+	}
+	paths := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		paths = append(paths, fmt.Sprintf("%s(%s)", fieldErr.Path, fieldErr.Code))
+	}
+	return "validation failed: " + strings.Join(paths, ", ")
+}
+
+// NewValidationError 検証エラーの一覧を ValidationFailed の DomainError として返す
+func NewValidationError(fieldErrors []FieldError) *DomainError {
+	return &DomainError{
+		ErrType: ValidationFailed,
+		Message: "入力内容に誤りがあります",
+		Err:     &ValidationError{Errors: fieldErrors},
+	}
+}
@@ -1,6 +1,8 @@
 package factory
 
 import (
+	"w3st/domain/services"
+	"w3st/infra/mailer"
 	infrastructure "w3st/infra/repository"
 	"w3st/interfaces/controllers"
 	"w3st/presenter"
@@ -12,67 +14,104 @@ import (
 type Factory interface {
 	InitUserController() *controllers.UserController
 	InitAuthUsecase() usecase.JwtUsecase
+	InitSessionUsecase() usecase.SessionUsecase
+	InitIdentityUsecase() usecase.IdentityUsecase
 	InitApiKeyUsecase() usecase.ApiKeyUsecase
 	InitApiKeyController() *controllers.ApiKeyController
+	InitCollectionsUsecase() usecase.CollectionsUsecase
 	InitSDKCollectionsController() *controllers.SDKCollectionsController
 	InitGUICollectionsController() *controllers.GUICollectionsController
 	InitSDKEntriesController() *controllers.SDKEntriesController
 	InitGUIEntriesController() *controllers.GUIEntriesController
 	InitFieldController() *controllers.FieldController
+	InitListOptionsController() *controllers.ListOptionsController
+	InitRelationsController() *controllers.RelationsController
 	InitMediaController() *controllers.MediaController
 	InitAuditController() *controllers.AuditController
 	InitSystemAlertController() *controllers.SystemAlertController
 	InitSystemAlertUsecase() usecase.SystemAlertUsecase
 	InitProjectUsecase() usecase.ProjectUsecase
 	InitProjectController() *controllers.ProjectController
+	InitRoleUsecase() usecase.RoleUsecase
+	InitPermissionUsecase() usecase.PermissionUsecase
 	InitPermissionController() *controllers.PermissionController
 	InitVersionController() *controllers.VersionController
+	InitUsageUsecase() usecase.UsageUsecase
+	InitUsageController(usageUsecase usecase.UsageUsecase) *controllers.UsageController
 }
 
 type factory struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Mailer services.Mailer
 }
 
 func NewFactory(db *gorm.DB) Factory {
-	return &factory{DB: db}
+	m, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		panic(err.Error())
+	}
+	return &factory{DB: db, Mailer: m}
 }
 
 func (f factory) InitUserController() *controllers.UserController {
 	userRepo := infrastructure.NewUserRepositoryImpl(f.DB)
-	userUsecase := usecase.NewUserUsecase(userRepo)
-	jwtAuthUsecase := usecase.NewjwtAuthUsecase()
+	credentialUsecase := usecase.NewCredentialUsecase()
+	userUsecase := usecase.NewUserUsecase(userRepo, credentialUsecase)
+	sessionUsecase := f.InitSessionUsecase()
+	userTokenRepo := infrastructure.NewUserTokenRepositoryImpl(f.DB)
+	sessionRepo := infrastructure.NewSessionRepositoryImpl(f.DB)
+	accountUsecase := usecase.NewAccountUsecase(userRepo, userTokenRepo, sessionRepo, credentialUsecase, f.Mailer)
+	mfaRepo := infrastructure.NewMFARepositoryImpl(f.DB)
+	mfaUsecase := usecase.NewMFAUsecase(mfaRepo, userRepo, f.InitAuthUsecase())
 	userPresenter := presenter.NewUserPresenter()
+	sessionPresenter := presenter.NewSessionPresenter()
 
-	return controllers.NewUserController(userUsecase, jwtAuthUsecase, userPresenter)
+	return controllers.NewUserController(userUsecase, sessionUsecase, accountUsecase, mfaUsecase, userPresenter, sessionPresenter)
 }
 
 func (f factory) InitAuthUsecase() usecase.JwtUsecase {
 	return usecase.NewjwtAuthUsecase()
 }
 
+func (f factory) InitSessionUsecase() usecase.SessionUsecase {
+	sessionRepo := infrastructure.NewSessionRepositoryImpl(f.DB)
+	return usecase.NewSessionUsecase(sessionRepo, f.InitAuthUsecase())
+}
+
+func (f factory) InitIdentityUsecase() usecase.IdentityUsecase {
+	identityRepo := infrastructure.NewUserIdentityRepositoryImpl(f.DB)
+	userRepo := infrastructure.NewUserRepositoryImpl(f.DB)
+	return usecase.NewIdentityUsecase(identityRepo, userRepo, usecase.NewCredentialUsecase())
+}
+
 func (f factory) InitApiKeyUsecase() usecase.ApiKeyUsecase {
 	apiKeyRepo := infrastructure.NewApiKeyRepositoryImpl(f.DB)
-	return usecase.NewApiKeyUsecase(apiKeyRepo)
+	projectRepo := infrastructure.NewProjectRepository(f.DB)
+	collectionRepo := infrastructure.NewCollectionsRepository(f.DB)
+	auditRepo := infrastructure.NewAuditRepositoryImpl(f.DB)
+	return usecase.NewApiKeyUsecase(apiKeyRepo, projectRepo, collectionRepo, usecase.NewAuditUsecase(auditRepo))
 }
 
 func (f factory) InitApiKeyController() *controllers.ApiKeyController {
-	apiKeyRepo := infrastructure.NewApiKeyRepositoryImpl(f.DB)
-	apiKeyUsecase := usecase.NewApiKeyUsecase(apiKeyRepo)
+	apiKeyPresenter := presenter.NewApiKeyPresenter()
 
-	return controllers.NewApiKeyController(apiKeyUsecase)
+	return controllers.NewApiKeyController(f.InitApiKeyUsecase(), apiKeyPresenter)
 }
 
-func (f factory) InitSDKCollectionsController() *controllers.SDKCollectionsController {
+func (f factory) InitCollectionsUsecase() usecase.CollectionsUsecase {
 	collectionRepo := infrastructure.NewCollectionsRepository(f.DB)
-	collectionUsecase := usecase.NewCollectionsUsecase(collectionRepo)
+	transactionRepo := infrastructure.NewTransactionRepositoryImpl(f.DB)
+	return usecase.NewCollectionsUsecase(collectionRepo, transactionRepo)
+}
 
-	return controllers.NewSDKCollectionsController(collectionUsecase)
+func (f factory) InitSDKCollectionsController() *controllers.SDKCollectionsController {
+	return controllers.NewSDKCollectionsController(f.InitCollectionsUsecase())
 }
 
 func (f factory) InitGUICollectionsController() *controllers.GUICollectionsController {
 	collectionRepo := infrastructure.NewCollectionsRepository(f.DB)
 	fieldRepo := infrastructure.NewFieldRepository(f.DB)
-	collectionUsecase := usecase.NewCollectionsUsecase(collectionRepo)
+	collectionUsecase := f.InitCollectionsUsecase()
 	fieldUsecase := usecase.NewFieldUsecase(fieldRepo, collectionRepo)
 
 	return controllers.NewGUICollectionsController(collectionUsecase, fieldUsecase)
@@ -80,18 +119,20 @@ func (f factory) InitGUICollectionsController() *controllers.GUICollectionsContr
 
 func (f factory) InitSDKEntriesController() *controllers.SDKEntriesController {
 	entriesRepo := infrastructure.NewEntriesRepository(f.DB)
-	collectionRepo := infrastructure.NewCollectionsRepository(f.DB)
-	collectionUsecase := usecase.NewCollectionsUsecase(collectionRepo)
-	entriesUsecase := usecase.NewEntriesUsecase(entriesRepo, collectionUsecase)
+	fieldRepo := infrastructure.NewFieldRepository(f.DB)
+	relationRepo := infrastructure.NewRelationRepository(f.DB)
+	transactionRepo := infrastructure.NewTransactionRepositoryImpl(f.DB)
+	entriesUsecase := usecase.NewEntriesUsecase(entriesRepo, fieldRepo, relationRepo, f.InitCollectionsUsecase(), transactionRepo)
 
 	return controllers.NewSDKEntriesController(entriesUsecase)
 }
 
 func (f factory) InitGUIEntriesController() *controllers.GUIEntriesController {
 	entriesRepo := infrastructure.NewEntriesRepository(f.DB)
-	collectionRepo := infrastructure.NewCollectionsRepository(f.DB)
-	collectionUsecase := usecase.NewCollectionsUsecase(collectionRepo)
-	entriesUsecase := usecase.NewEntriesUsecase(entriesRepo, collectionUsecase)
+	fieldRepo := infrastructure.NewFieldRepository(f.DB)
+	relationRepo := infrastructure.NewRelationRepository(f.DB)
+	transactionRepo := infrastructure.NewTransactionRepositoryImpl(f.DB)
+	entriesUsecase := usecase.NewEntriesUsecase(entriesRepo, fieldRepo, relationRepo, f.InitCollectionsUsecase(), transactionRepo)
 
 	return controllers.NewGUIEntriesController(entriesUsecase)
 }
@@ -104,6 +145,27 @@ func (f factory) InitFieldController() *controllers.FieldController {
 	return controllers.NewFieldController(fieldUsecase)
 }
 
+func (f factory) InitListOptionsController() *controllers.ListOptionsController {
+	optionsRepo := infrastructure.NewListOptionRepository(f.DB)
+	fieldRepo := infrastructure.NewFieldRepository(f.DB)
+	collectionRepo := infrastructure.NewCollectionsRepository(f.DB)
+	entriesRepo := infrastructure.NewEntriesRepository(f.DB)
+	transactionRepo := infrastructure.NewTransactionRepositoryImpl(f.DB)
+	listOptionsUsecase := usecase.NewListOptionsUsecase(optionsRepo, fieldRepo, collectionRepo, entriesRepo, transactionRepo)
+
+	return controllers.NewListOptionsController(listOptionsUsecase)
+}
+
+func (f factory) InitRelationsController() *controllers.RelationsController {
+	relationRepo := infrastructure.NewRelationRepository(f.DB)
+	fieldRepo := infrastructure.NewFieldRepository(f.DB)
+	collectionRepo := infrastructure.NewCollectionsRepository(f.DB)
+	entriesRepo := infrastructure.NewEntriesRepository(f.DB)
+	relationsUsecase := usecase.NewRelationsUsecase(relationRepo, fieldRepo, collectionRepo, entriesRepo)
+
+	return controllers.NewRelationsController(relationsUsecase)
+}
+
 func (f factory) InitMediaController() *controllers.MediaController {
 	mediaRepo := infrastructure.NewMediaRepositoryImpl(f.DB)
 	mediaUsecase := usecase.NewMediaUsecase(mediaRepo)
@@ -132,19 +194,30 @@ func (f factory) InitSystemAlertUsecase() usecase.SystemAlertUsecase {
 
 func (f factory) InitProjectUsecase() usecase.ProjectUsecase {
 	projectRepo := infrastructure.NewProjectRepository(f.DB)
-	return usecase.NewProjectUsecase(projectRepo)
+	memberRepo := infrastructure.NewProjectMemberRepositoryImpl(f.DB)
+	userRepo := infrastructure.NewUserRepositoryImpl(f.DB)
+	return usecase.NewProjectUsecase(projectRepo, memberRepo, f.InitRoleUsecase(), userRepo)
 }
 
 func (f factory) InitProjectController() *controllers.ProjectController {
 	projectUsecase := f.InitProjectUsecase()
-	return controllers.NewProjectController(projectUsecase)
+	return controllers.NewProjectController(projectUsecase, f.InitRoleUsecase())
 }
 
-func (f factory) InitPermissionController() *controllers.PermissionController {
+func (f factory) InitRoleUsecase() usecase.RoleUsecase {
+	roleRepo := infrastructure.NewRoleRepositoryImpl(f.DB)
+	memberRepo := infrastructure.NewProjectMemberRepositoryImpl(f.DB)
+	return usecase.NewRoleUsecase(roleRepo, memberRepo)
+}
+
+func (f factory) InitPermissionUsecase() usecase.PermissionUsecase {
 	permissionRepo := infrastructure.NewPermissionRepositoryImpl(f.DB)
-	permissionUsecase := usecase.NewPermissionUsecase(permissionRepo)
+	memberRepo := infrastructure.NewProjectMemberRepositoryImpl(f.DB)
+	return usecase.NewPermissionUsecase(permissionRepo, memberRepo, f.InitRoleUsecase())
+}
 
-	return controllers.NewPermissionController(permissionUsecase)
+func (f factory) InitPermissionController() *controllers.PermissionController {
+	return controllers.NewPermissionController(f.InitPermissionUsecase(), presenter.NewPermissionPresenter())
 }
 
 func (f factory) InitVersionController() *controllers.VersionController {
@@ -153,3 +226,14 @@ func (f factory) InitVersionController() *controllers.VersionController {
 
 	return controllers.NewVersionController(versionUsecase)
 }
+
+// InitUsageUsecase 利用量はメモリに数えてからまとめて書き込むため、ミドルウェアとコントローラーで同じものを使う
+func (f factory) InitUsageUsecase() usecase.UsageUsecase {
+	usageRepo := infrastructure.NewUsageRepositoryImpl(f.DB)
+	projectRepo := infrastructure.NewProjectRepository(f.DB)
+	return usecase.NewUsageUsecase(usageRepo, projectRepo, f.InitSystemAlertUsecase())
+}
+
+func (f factory) InitUsageController(usageUsecase usecase.UsageUsecase) *controllers.UsageController {
+	return controllers.NewUsageController(usageUsecase)
+}
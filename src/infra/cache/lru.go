@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU 件数の上限と有効期間を持つキャッシュ。上限を超えると最も長く使われていない値から捨てる。
+// 複数の goroutine から同時に使える
+type LRU[K comparable, V any] struct {
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	items map[K]*list.Element
+	// 先頭ほど最近使われた値
+	order *list.List
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+type options struct {
+	now func() time.Time
+}
+
+type Option func(*options)
+
+// WithClock テスト用に現在時刻を差し替える
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// NewLRU capacity 件まで、追加してから ttl の間だけ値を保持するキャッシュを作る。
+// capacity または ttl が0以下の場合は何も保持しない
+func NewLRU[K comparable, V any](capacity int, ttl time.Duration, opts ...Option) *LRU[K, V] {
+	o := options{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &LRU[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      o.now,
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get 有効期間内の値を返す。期限切れの値はこのときに捨てる
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	e := elem.Value.(*entry[K, V])
+	if !c.now().Before(e.expiresAt) {
+		c.removeElement(elem)
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	return e.value, true
+}
+
+// Add 値を ttl の間保持する
+func (c *LRU[K, V]) Add(key K, value V) {
+	c.AddUntil(key, value, time.Time{})
+}
+
+// AddUntil 値を until まで保持する。until が ttl より先、またはゼロ値の場合は ttl の間だけ保持する
+func (c *LRU[K, V]) AddUntil(key K, value V, until time.Time) {
+	if c.capacity <= 0 || c.ttl <= 0 {
+		return
+	}
+	expiresAt := c.now().Add(c.ttl)
+	if !until.IsZero() && until.Before(expiresAt) {
+		expiresAt = until
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry[K, V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Remove 値を捨てる
+func (c *LRU[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// RemoveFunc match が true を返す値をすべて捨て、捨てた件数を返す
+func (c *LRU[K, V]) RemoveFunc(match func(key K, value V) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		e := elem.Value.(*entry[K, V])
+		if match(e.key, e.value) {
+			c.removeElement(elem)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+// Len 保持している件数（期限切れでまだ捨てていない値を含む）
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU[K, V]) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock テスト用の進められる時計
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+	c := NewLRU[string, int](2, time.Minute)
+
+	c.Add("a", 1)
+	c.Add("b", 2)
+	// a を使うと、上限を超えたときに捨てられるのは b になる
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	c.Add("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("expected a=1, got %d (%v)", v, ok)
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Fatalf("expected c=3, got %d (%v)", v, ok)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", c.Len())
+	}
+}
+
+func TestLRU_Expiry(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := NewLRU[string, int](10, time.Minute, WithClock(clock.Now))
+
+	c.Add("ttl", 1)
+	// ttl より前の期限を指定した値はその期限で捨てる
+	c.AddUntil("until", 2, clock.Now().Add(10*time.Second))
+	// ttl より後の期限は ttl に切り詰める
+	c.AddUntil("capped", 3, clock.Now().Add(time.Hour))
+
+	clock.Advance(10 * time.Second)
+	if _, ok := c.Get("until"); ok {
+		t.Fatal("expected until to expire at its own deadline")
+	}
+	if _, ok := c.Get("ttl"); !ok {
+		t.Fatal("expected ttl to be cached")
+	}
+
+	clock.Advance(time.Minute)
+	if _, ok := c.Get("ttl"); ok {
+		t.Fatal("expected ttl to expire")
+	}
+	if _, ok := c.Get("capped"); ok {
+		t.Fatal("expected capped to expire after ttl")
+	}
+	if c.Len() != 0 {
+		t.Fatalf("expected expired entries to be removed, got %d", c.Len())
+	}
+}
+
+func TestLRU_RemoveFunc(t *testing.T) {
+	t.Parallel()
+	c := NewLRU[string, int](10, time.Minute)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Add("c", 1)
+
+	if removed := c.RemoveFunc(func(_ string, v int) bool { return v == 1 }); removed != 2 {
+		t.Fatalf("expected 2 removed, got %d", removed)
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Fatal("expected b to be kept")
+	}
+	c.Remove("b")
+	if c.Len() != 0 {
+		t.Fatalf("expected empty cache, got %d", c.Len())
+	}
+}
+
+func TestLRU_Disabled(t *testing.T) {
+	t.Parallel()
+	c := NewLRU[string, int](10, 0)
+	c.Add("a", 1)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected nothing to be cached when ttl is 0")
+	}
+}
+
+func TestLRU_Concurrent(t *testing.T) {
+	t.Parallel()
+	c := NewLRU[int, int](50, time.Minute)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				c.Add(i%100, g)
+				c.Get(i % 100)
+				if i%97 == 0 {
+					c.RemoveFunc(func(_ int, v int) bool { return v == g })
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if c.Len() > 50 {
+		t.Fatalf("expected at most 50 entries, got %d", c.Len())
+	}
+}
@@ -29,7 +29,8 @@ func SetupDB() *gorm.DB {
 		sslmode,
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// 一意制約の違反などを gorm.ErrDuplicatedKey などのエラーにする
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -56,9 +57,12 @@ func SetupDB() *gorm.DB {
 		id SERIAL PRIMARY KEY,
 		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
 		name VARCHAR(100) NOT NULL, -- コレクション名 ex) 'ユーザー', '商品'
+		slug VARCHAR(100) NOT NULL, -- SDK のパスで使う識別子 ex) 'users', 'products'（プロジェクト内で一意）
 		description TEXT, -- 説明 ex) 'ユーザー情報を管理するコレクション'
+		reject_unknown_fields BOOLEAN NOT NULL DEFAULT false, -- フィールドとして定義されていないキーを含むエントリを拒否するか
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		deleted_at TIMESTAMP -- ゴミ箱に移した日時（NULL なら削除されていない）
 	);
 
 	-- api_fields: 各スキーマのフィールド定義
@@ -70,6 +74,7 @@ func SetupDB() *gorm.DB {
 		field_type VARCHAR(50) NOT NULL, -- フィールドの型 ('text', 'number', 'boolean', etc.)
 		is_required BOOLEAN DEFAULT false, -- 必須かどうか
 		default_value JSONB, -- デフォルト値 (JSON形式で保存)
+		validations JSONB NOT NULL DEFAULT '{}', -- 値の検証ルール ex) {"max_length": 100, "unique": true}
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
@@ -84,6 +89,7 @@ func SetupDB() *gorm.DB {
 		field_type VARCHAR(50) NOT NULL, -- フィールドの型 ('text', 'number', 'boolean', etc.)
 		is_required BOOLEAN DEFAULT false, -- 必須かどうか
 		default_value JSONB, -- デフォルト値 (JSON形式で保存)
+		validations JSONB NOT NULL DEFAULT '{}', -- 値の検証ルール ex) {"max_length": 100, "unique": true}
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
@@ -100,8 +106,10 @@ func SetupDB() *gorm.DB {
 	-- list_options テーブル (選択肢)
 	CREATE TABLE IF NOT EXISTS list_options (
 		id SERIAL PRIMARY KEY,
-		field_id INT NOT NULL REFERENCES api_fields(id) ON DELETE CASCADE,
-		value VARCHAR(255) NOT NULL, -- 選択肢の値 ex) 'オプション1', 'オプション2'
+		field_id INT NOT NULL REFERENCES field_data(id) ON DELETE CASCADE, -- 選択肢を持つフィールド（select・multiselect）
+		value VARCHAR(255) NOT NULL, -- エントリに保存する値 ex) 'small', 'large'
+		label VARCHAR(255) NOT NULL DEFAULT '', -- 表示名 ex) 'Sサイズ', 'Lサイズ'
+		position INT NOT NULL DEFAULT 0, -- 並び順（小さいほど先）
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
@@ -119,8 +127,10 @@ func SetupDB() *gorm.DB {
 	CREATE TABLE IF NOT EXISTS api_kind_relation (
 		id SERIAL PRIMARY KEY,
 		collection_id INT NOT NULL REFERENCES api_collections(id) ON DELETE CASCADE,
+		field_id INT REFERENCES field_data(id) ON DELETE CASCADE, -- 参照先のエントリのIDを保存する relation 型のフィールド
 		related_collection_id INT NOT NULL REFERENCES api_collections(id) ON DELETE CASCADE,
 		relation_type VARCHAR(50) NOT NULL, -- リレーションの種類 ex) 'one-to-many', 'many-to-many'
+		on_delete VARCHAR(20) NOT NULL DEFAULT 'restrict', -- 参照先のエントリを削除したとき ex) 'restrict', 'cascade', 'set-null'
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
@@ -129,17 +139,23 @@ func SetupDB() *gorm.DB {
 		id SERIAL PRIMARY KEY,
 		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
 		name VARCHAR(100),                              -- 任意の名前（管理用）
-		key VARCHAR(255) UNIQUE NOT NULL,               -- 発行されたAPIキー文字列
+		key_prefix VARCHAR(20) NOT NULL,                -- 検索・表示用のキーの prefix
+		key_hash VARCHAR(64) NOT NULL,                  -- secret の SHA-256（キーそのものは保存しない）
+		previous_key_prefix VARCHAR(20),                -- ローテーション前のキーの prefix（猶予期間中のみ有効）
+		previous_key_hash VARCHAR(64),                  -- ローテーション前のキーの secret の SHA-256
+		previous_key_expire_at TIMESTAMP,               -- ローテーション前のキーの有効期限
 		ip_whitelist TEXT[],                            -- 許可されたIPアドレス（空配列は無制限）
 		expire_at TIMESTAMP,                            -- 有効期限（NULLなら無期限）
 		revoked BOOLEAN DEFAULT FALSE,                  -- 無効化フラグ
 		rate_limit_per_hour INT DEFAULT 1000,           -- 1時間あたりの最大リクエスト数
+		scopes JSONB NOT NULL DEFAULT '["collections:read", "entries:read"]', -- SDKで利用できる操作（collections:read, entries:read, entries:write, media:write）
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
 
 	CREATE TABLE IF NOT EXISTS api_key_collections (
 		api_key_id INT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
 		collection_id INT NOT NULL REFERENCES api_collections(id) ON DELETE CASCADE,
+		scopes JSONB,                                   -- コレクションごとのスコープ（NULLならキーの scopes を使う）
 		PRIMARY KEY (api_key_id, collection_id)
 	);
 
@@ -172,9 +188,12 @@ func SetupDB() *gorm.DB {
 	CREATE TABLE IF NOT EXISTS user_permissions (
 		id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
 		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		project_id INT NOT NULL DEFAULT 1, -- 権限を付与したプロジェクト
 		permission_type VARCHAR(100) NOT NULL,
-		resource_type VARCHAR(100),
-		resource_id VARCHAR(255),
+		effect VARCHAR(10) NOT NULL DEFAULT 'allow' CHECK (effect IN ('allow', 'deny')), -- deny は allow やロールより優先される
+		resource_type VARCHAR(100), -- 種類を / で連結したもの (例: project/collection/entry)
+		resource_id VARCHAR(255), -- IDを / で連結したもの (例: 1/5/*)
+		expires_at TIMESTAMP, -- NULL は無期限。期限切れの権限は定期的に削除される
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
@@ -189,6 +208,102 @@ func SetupDB() *gorm.DB {
 		details JSONB,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
+
+	-- sessions テーブル (リフレッシュトークン1件ごとの行。family_id がセッションID)
+	CREATE TABLE IF NOT EXISTS sessions (
+		id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+		family_id UUID NOT NULL, -- ローテーションで引き継がれるセッションID
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		refresh_token_hash VARCHAR(64) UNIQUE NOT NULL, -- リフレッシュトークンのSHA-256
+		device_name VARCHAR(255),
+		ip_address VARCHAR(45),
+		user_agent TEXT,
+		last_used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		expires_at TIMESTAMP NOT NULL,
+		rotated_at TIMESTAMP, -- 使用済みになった日時（再利用検知に使用）
+		revoked_at TIMESTAMP, -- 無効化された日時
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- user_tokens テーブル (パスワード再設定・メールアドレス確認用の使い捨てトークン)
+	CREATE TABLE IF NOT EXISTS user_tokens (
+		id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		purpose VARCHAR(50) NOT NULL, -- 'password_reset' or 'email_verification'
+		token_hash VARCHAR(64) UNIQUE NOT NULL, -- トークンのSHA-256
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP, -- 使用済みになった日時
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- user_mfa テーブル (TOTPによる二要素認証の設定)
+	CREATE TABLE IF NOT EXISTS user_mfa (
+		user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		secret_encrypted TEXT NOT NULL, -- AES-GCMで暗号化したシークレット
+		enabled BOOLEAN NOT NULL DEFAULT false, -- 確認コードの入力が完了するまでは false
+		confirmed_at TIMESTAMP,
+		last_used_step BIGINT NOT NULL DEFAULT 0, -- 最後に使われたTOTPのステップ (リプレイ防止)
+		failed_attempts INT NOT NULL DEFAULT 0,
+		locked_until TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- mfa_recovery_codes テーブル (二要素認証のリカバリーコード)
+	CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+		id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		code_hash VARCHAR(64) NOT NULL, -- コードのSHA-256
+		used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- user_identities テーブル (Auth0などの外部認証のユーザーとローカルユーザーの対応)
+	CREATE TABLE IF NOT EXISTS user_identities (
+		id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		provider VARCHAR(50) NOT NULL, -- 'auth0'
+		subject VARCHAR(255) NOT NULL, -- 外部認証の sub (例: auth0|abc)
+		email VARCHAR(255), -- 連携時のメールアドレス
+		last_login_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (provider, subject)
+	);
+
+	-- project_members テーブル (プロジェクトに参加しているユーザーとロール)
+	CREATE TABLE IF NOT EXISTS project_members (
+		project_id INT NOT NULL, -- プロジェクトID
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		role VARCHAR(50) NOT NULL, -- 組み込みロール ('owner', 'admin', 'editor', 'author', 'viewer') またはカスタムロール名
+		invited_by UUID, -- 招待したユーザー
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (project_id, user_id)
+	);
+
+	-- project_roles テーブル (プロジェクトごとのカスタムロール)
+	CREATE TABLE IF NOT EXISTS project_roles (
+		id SERIAL PRIMARY KEY,
+		project_id INT NOT NULL, -- プロジェクトID
+		name VARCHAR(50) NOT NULL, -- ロール名 (組み込みロールと同じ名前は使えない)
+		description TEXT,
+		permissions JSONB NOT NULL DEFAULT '[]', -- 権限の配列 (例: ["entries:read", "entries:write"])
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (project_id, name)
+	);
+
+	-- usage_buckets テーブル (プロジェクト・APIキー・コレクションごとの1時間あたりのSDKの利用量)
+	CREATE TABLE IF NOT EXISTS usage_buckets (
+		project_id INT NOT NULL, -- プロジェクトID
+		api_key_id INT NOT NULL DEFAULT 0, -- APIキーID (0: APIキー以外)
+		collection_id INT NOT NULL DEFAULT 0, -- コレクションID (0: コレクション一覧など)
+		bucket_start TIMESTAMP WITH TIME ZONE NOT NULL, -- 1時間の始まり
+		requests BIGINT NOT NULL DEFAULT 0,
+		bytes_served BIGINT NOT NULL DEFAULT 0,
+		entries_created BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (project_id, api_key_id, collection_id, bucket_start)
+	);
 	`
 	if err := db.Exec(createSQL).Error; err != nil {
 		log.Fatalf("Error executing table creation: %v", err)
@@ -220,11 +335,156 @@ func SetupDB() *gorm.DB {
 		END IF;
 	END $$;
 
-	-- Add collection_ids to api_keys if not exists
+	-- Move api_keys.collection_ids to api_key_collections if exists（別のプロジェクトのコレクション・削除済みのコレクションは移さない）
+	DO $$
+	BEGIN
+		IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_keys' AND column_name = 'collection_ids') THEN
+			INSERT INTO api_key_collections (api_key_id, collection_id)
+			SELECT k.id, c.id FROM api_keys k
+			JOIN api_collections c ON c.id = ANY(k.collection_ids) AND c.project_id = k.project_id
+			ON CONFLICT DO NOTHING;
+			ALTER TABLE api_keys DROP COLUMN collection_ids;
+		END IF;
+	END $$;
+
+	-- Add email_verified to users if not exists
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'email_verified') THEN
+			ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT false;
+		END IF;
+	END $$;
+
+	-- Add effect to user_permissions if not exists
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'user_permissions' AND column_name = 'effect') THEN
+			ALTER TABLE user_permissions ADD COLUMN effect VARCHAR(10) NOT NULL DEFAULT 'allow' CHECK (effect IN ('allow', 'deny'));
+		END IF;
+	END $$;
+
+	-- Add project_id and expires_at to user_permissions if not exists
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'user_permissions' AND column_name = 'project_id') THEN
+			ALTER TABLE user_permissions ADD COLUMN project_id INT NOT NULL DEFAULT 1;
+		END IF;
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'user_permissions' AND column_name = 'expires_at') THEN
+			ALTER TABLE user_permissions ADD COLUMN expires_at TIMESTAMP;
+		END IF;
+	END $$;
+
+	-- Store api_keys as prefix + secret hash (PostgreSQL 11+ for sha256)
+	-- 形式を変更する前のキーは先頭8文字を prefix、キー全体を secret として移行する
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_keys' AND column_name = 'key_prefix') THEN
+			ALTER TABLE api_keys ADD COLUMN key_prefix VARCHAR(20) NOT NULL DEFAULT '';
+		END IF;
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_keys' AND column_name = 'key_hash') THEN
+			ALTER TABLE api_keys ADD COLUMN key_hash VARCHAR(64);
+		END IF;
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_keys' AND column_name = 'previous_key_prefix') THEN
+			ALTER TABLE api_keys ADD COLUMN previous_key_prefix VARCHAR(20);
+			ALTER TABLE api_keys ADD COLUMN previous_key_hash VARCHAR(64);
+		END IF;
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_keys' AND column_name = 'previous_key_expire_at') THEN
+			ALTER TABLE api_keys ADD COLUMN previous_key_expire_at TIMESTAMP;
+		END IF;
+		IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_keys' AND column_name = 'key') THEN
+			UPDATE api_keys SET key_prefix = LEFT(key, 8), key_hash = encode(sha256(convert_to(key, 'UTF8')), 'hex') WHERE key_hash IS NULL;
+			ALTER TABLE api_keys DROP COLUMN key;
+		END IF;
+		IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_keys' AND column_name = 'previous_key') THEN
+			UPDATE api_keys SET previous_key_prefix = LEFT(previous_key, 8), previous_key_hash = encode(sha256(convert_to(previous_key, 'UTF8')), 'hex') WHERE previous_key IS NOT NULL;
+			ALTER TABLE api_keys DROP COLUMN previous_key;
+		END IF;
+		ALTER TABLE api_keys ALTER COLUMN key_prefix DROP DEFAULT;
+		ALTER TABLE api_keys ALTER COLUMN key_hash SET NOT NULL;
+	END $$;
+
+	-- Add scopes to api_keys and api_key_collections if not exists（既存のキーは読み取りのみ）
 	DO $$
 	BEGIN
-		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_keys' AND column_name = 'collection_ids') THEN
-			ALTER TABLE api_keys ADD COLUMN collection_ids INT[] NOT NULL DEFAULT '{}';
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_keys' AND column_name = 'scopes') THEN
+			ALTER TABLE api_keys ADD COLUMN scopes JSONB NOT NULL DEFAULT '["collections:read", "entries:read"]';
+		END IF;
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_key_collections' AND column_name = 'scopes') THEN
+			ALTER TABLE api_key_collections ADD COLUMN scopes JSONB;
+		END IF;
+	END $$;
+
+	-- Add deleted_at to api_collections if not exists（ゴミ箱）
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_collections' AND column_name = 'deleted_at') THEN
+			ALTER TABLE api_collections ADD COLUMN deleted_at TIMESTAMP;
+		END IF;
+	END $$;
+
+	-- Add slug to api_collections if not exists（既存のコレクションは collection-<id>）
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_collections' AND column_name = 'slug') THEN
+			ALTER TABLE api_collections ADD COLUMN slug VARCHAR(100);
+			UPDATE api_collections SET slug = 'collection-' || id;
+			ALTER TABLE api_collections ALTER COLUMN slug SET NOT NULL;
+		END IF;
+	END $$;
+
+	-- Add reject_unknown_fields to api_collections if not exists
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_collections' AND column_name = 'reject_unknown_fields') THEN
+			ALTER TABLE api_collections ADD COLUMN reject_unknown_fields BOOLEAN NOT NULL DEFAULT false;
+		END IF;
+	END $$;
+
+	-- list_options を field_data の選択肢にする（label と position を追加）
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'list_options' AND column_name = 'label') THEN
+			ALTER TABLE list_options ADD COLUMN label VARCHAR(255) NOT NULL DEFAULT '';
+			UPDATE list_options SET label = value;
+		END IF;
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'list_options' AND column_name = 'position') THEN
+			ALTER TABLE list_options ADD COLUMN position INT NOT NULL DEFAULT 0;
+			UPDATE list_options SET position = id;
+		END IF;
+		IF EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'list_options_field_id_fkey' AND confrelid = 'api_fields'::regclass) THEN
+			ALTER TABLE list_options DROP CONSTRAINT list_options_field_id_fkey;
+			ALTER TABLE list_options ADD CONSTRAINT list_options_field_id_fkey FOREIGN KEY (field_id) REFERENCES field_data(id) ON DELETE CASCADE NOT VALID;
+		END IF;
+	END $$;
+
+	-- api_kind_relation を relation 型のフィールドに結び付ける（field_id と on_delete を追加）
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_kind_relation' AND column_name = 'field_id') THEN
+			ALTER TABLE api_kind_relation ADD COLUMN field_id INT REFERENCES field_data(id) ON DELETE CASCADE;
+		END IF;
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_kind_relation' AND column_name = 'on_delete') THEN
+			ALTER TABLE api_kind_relation ADD COLUMN on_delete VARCHAR(20) NOT NULL DEFAULT 'restrict';
+		END IF;
+	END $$;
+
+	-- Add validations to api_fields / field_data if not exists
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'api_fields' AND column_name = 'validations') THEN
+			ALTER TABLE api_fields ADD COLUMN validations JSONB NOT NULL DEFAULT '{}';
+		END IF;
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'field_data' AND column_name = 'validations') THEN
+			ALTER TABLE field_data ADD COLUMN validations JSONB NOT NULL DEFAULT '{}';
+		END IF;
+	END $$;
+
+	-- Add monthly_request_quota to projects if not exists（NULL は上限なし）
+	DO $$
+	BEGIN
+		IF EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = 'projects')
+			AND NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'projects' AND column_name = 'monthly_request_quota') THEN
+			ALTER TABLE projects ADD COLUMN monthly_request_quota INT CHECK (monthly_request_quota > 0);
 		END IF;
 	END $$;
 	`
@@ -270,9 +530,9 @@ func SetupDB() *gorm.DB {
 	DECLARE
 		f_type VARCHAR(50);
 	BEGIN
-		SELECT field_type INTO f_type FROM api_fields WHERE id = NEW.field_id;
-		IF f_type NOT IN ('select', 'dropdown') THEN
-			RAISE EXCEPTION 'list_options can only be added to select or dropdown fields';
+		SELECT field_type INTO f_type FROM field_data WHERE id = NEW.field_id;
+		IF f_type NOT IN ('select', 'multiselect', 'dropdown') THEN
+			RAISE EXCEPTION 'list_options can only be added to select or multiselect fields';
 		END IF;
 		RETURN NEW;
 	END;
@@ -447,14 +707,16 @@ func SetupDB() *gorm.DB {
 	-- インデックス追加
 	CREATE INDEX IF NOT EXISTS idx_content_versions_created_by ON content_versions(created_by);
 
-	-- user_permissions のユニーク制約（NULL セマンティクスを保持）
+	-- user_permissions のユニーク制約（NULL セマンティクスを保持）。権限はプロジェクトごとに管理する
+	DROP INDEX IF EXISTS uniq_user_permissions_global;
+	DROP INDEX IF EXISTS uniq_user_permissions_scoped;
 	-- グローバル権限（resource_type, resource_id が両方 NULL）のユニーク制約
-	CREATE UNIQUE INDEX IF NOT EXISTS uniq_user_permissions_global
-	  ON user_permissions(user_id, permission_type)
+	CREATE UNIQUE INDEX IF NOT EXISTS uniq_user_permissions_project_global
+	  ON user_permissions(project_id, user_id, permission_type)
 	  WHERE resource_type IS NULL AND resource_id IS NULL;
 	-- スコープ付き権限（resource_type, resource_id が両方 NOT NULL）のユニーク制約
-	CREATE UNIQUE INDEX IF NOT EXISTS uniq_user_permissions_scoped
-	  ON user_permissions(user_id, permission_type, resource_type, resource_id)
+	CREATE UNIQUE INDEX IF NOT EXISTS uniq_user_permissions_project_scoped
+	  ON user_permissions(project_id, user_id, permission_type, resource_type, resource_id)
 	  WHERE resource_type IS NOT NULL AND resource_id IS NOT NULL;
 
 	-- user_permissions: resource_type と resource_id の整合性を保証する CHECK 制約
@@ -477,10 +739,53 @@ func SetupDB() *gorm.DB {
 
 	-- user_permissions の外部キー用インデックス
 	CREATE INDEX IF NOT EXISTS idx_user_permissions_user_id ON user_permissions(user_id);
+	-- 権限チェックでは project_id, user_id, permission_type で候補を取得してからリソースを照合する
+	DROP INDEX IF EXISTS idx_user_permissions_user_permission;
+	CREATE INDEX IF NOT EXISTS idx_user_permissions_project_user_permission ON user_permissions(project_id, user_id, permission_type);
+	-- 期限切れの権限の削除用
+	CREATE INDEX IF NOT EXISTS idx_user_permissions_expires_at ON user_permissions(expires_at) WHERE expires_at IS NOT NULL;
+
+	-- api_keys 検索用インデックス（認証では prefix で候補を取得してから secret のハッシュを照合する）
+	CREATE INDEX IF NOT EXISTS idx_api_keys_project_id ON api_keys(project_id);
+	DROP INDEX IF EXISTS idx_api_keys_previous_key;
+	CREATE INDEX IF NOT EXISTS idx_api_keys_key_prefix ON api_keys(key_prefix);
+	CREATE INDEX IF NOT EXISTS idx_api_keys_previous_key_prefix ON api_keys(previous_key_prefix) WHERE previous_key_prefix IS NOT NULL;
+	-- コレクションの削除時に api_key_collections の行を削除する（ON DELETE CASCADE）ためのインデックス
+	CREATE INDEX IF NOT EXISTS idx_api_key_collections_collection_id ON api_key_collections(collection_id);
 
 	-- audit_logs 検索用インデックス
 	CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs(user_id);
 	CREATE INDEX IF NOT EXISTS idx_audit_logs_resource ON audit_logs(resource_type, resource_id);
+
+	-- sessions 検索用インデックス
+	CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions(family_id);
+	CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+
+	-- user_tokens 検索用インデックス
+	CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens(user_id, purpose);
+
+	-- mfa_recovery_codes 検索用インデックス
+	CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
+
+	-- user_identities 検索用インデックス
+	CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
+
+	-- project_members 検索用インデックス
+	CREATE INDEX IF NOT EXISTS idx_project_members_user_id ON project_members(user_id);
+
+	-- usage_buckets 集計用インデックス（今月・直近1時間のプロジェクトの利用量を合計する）
+	CREATE INDEX IF NOT EXISTS idx_usage_buckets_project_bucket_start ON usage_buckets(project_id, bucket_start);
+
+	-- api_collections ゴミ箱用インデックス（期限切れのコレクションを削除する）
+	CREATE INDEX IF NOT EXISTS idx_api_collections_deleted_at ON api_collections(deleted_at) WHERE deleted_at IS NOT NULL;
+
+	-- api_collections の slug はプロジェクト内で一意（ゴミ箱にあるコレクションを除く）
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_api_collections_project_slug ON api_collections(project_id, slug) WHERE deleted_at IS NULL;
+	-- 選択肢の値はフィールド内で一意
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_list_options_field_value ON list_options(field_id, value);
+	-- 1つのフィールドに1つのリレーション。参照先のコレクションからリレーションを探す
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_api_kind_relation_field ON api_kind_relation(field_id);
+	CREATE INDEX IF NOT EXISTS idx_api_kind_relation_related ON api_kind_relation(related_collection_id);
 	`
 
 	if err := db.Exec(triggerSQL).Error; err != nil {
@@ -0,0 +1,226 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"w3st/infra/logger"
+)
+
+const (
+	// DefaultTTL 取得した鍵を使い続ける期間
+	DefaultTTL = 10 * time.Minute
+	// DefaultMinRefreshInterval 未知の kid による再取得の最短間隔
+	DefaultMinRefreshInterval = 30 * time.Second
+	// DefaultFetchTimeout JWKSエンドポイントへのリクエストのタイムアウト
+	DefaultFetchTimeout = 5 * time.Second
+
+	// レスポンスの上限（異常に大きいレスポンスでメモリを消費しないため）
+	maxResponseSize = 1 << 20
+)
+
+// ErrKeyNotFound 再取得後も kid に対応する鍵が見つからない
+var ErrKeyNotFound = errors.New("jwks: key not found")
+
+// Cache JWKSを取得してメモリに保持する。
+// 期限切れの鍵は次の取得まで使い続け、取得に失敗しても直前の鍵で検証を継続する
+type Cache struct {
+	url                string
+	client             *http.Client
+	ttl                time.Duration
+	minRefreshInterval time.Duration
+	now                func() time.Time
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+
+	// 同時に複数のリクエストが取得を始めないようにする
+	fetchMu sync.Mutex
+}
+
+type Option func(*Cache)
+
+// WithHTTPClient 取得に使うHTTPクライアントを指定する
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Cache) {
+		c.client = client
+	}
+}
+
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+func WithMinRefreshInterval(interval time.Duration) Option {
+	return func(c *Cache) {
+		c.minRefreshInterval = interval
+	}
+}
+
+// WithClock テスト用に現在時刻を差し替える
+func WithClock(now func() time.Time) Option {
+	return func(c *Cache) {
+		c.now = now
+	}
+}
+
+func NewCache(url string, opts ...Option) *Cache {
+	c := &Cache{
+		url:                url,
+		client:             &http.Client{Timeout: DefaultFetchTimeout},
+		ttl:                DefaultTTL,
+		minRefreshInterval: DefaultMinRefreshInterval,
+		now:                time.Now,
+		keys:               map[string]crypto.PublicKey{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Start ctx がキャンセルされるまで TTL ごとにバックグラウンドで鍵を更新する
+func (c *Cache) Start(ctx context.Context) {
+	if err := c.Refresh(ctx); err != nil {
+		logger.Error("failed to fetch JWKS", "url", c.url, "error", err.Error())
+	}
+
+	go func() {
+		ticker := time.NewTicker(c.ttl)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Refresh(ctx); err != nil {
+					logger.Error("failed to refresh JWKS", "url", c.url, "error", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// Key kid に対応する公開鍵を返す。
+// 鍵が期限切れの場合や未知の kid の場合は再取得するが、再取得は minRefreshInterval に1回までに制限する
+func (c *Cache) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, found, fresh := c.lookup(kid)
+	if found && fresh {
+		return key, nil
+	}
+
+	if err := c.refreshIfAllowed(ctx); err != nil {
+		// 取得に失敗しても保持している鍵があれば検証を継続する
+		if found {
+			logger.Error("failed to refresh JWKS, using cached keys", "url", c.url, "error", err.Error())
+			return key, nil
+		}
+		return nil, err
+	}
+
+	key, found, _ = c.lookup(kid)
+	if !found {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// Refresh JWKSを取得して保持している鍵を置き換える
+func (c *Cache) Refresh(ctx context.Context) error {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+	return c.fetch(ctx)
+}
+
+func (c *Cache) lookup(kid string) (crypto.PublicKey, bool, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, found := c.keys[kid]
+	fresh := !c.fetchedAt.IsZero() && c.now().Sub(c.fetchedAt) < c.ttl
+	return key, found, fresh
+}
+
+// refreshIfAllowed 直前の取得から minRefreshInterval が経過していれば再取得する。
+// 未知の kid を大量に送りつけられてもJWKSエンドポイントへの負荷が増えないようにする
+func (c *Cache) refreshIfAllowed(ctx context.Context) error {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	c.mu.RLock()
+	lastAttempt := c.lastAttempt
+	c.mu.RUnlock()
+	if !lastAttempt.IsZero() && c.now().Sub(lastAttempt) < c.minRefreshInterval {
+		return nil
+	}
+	return c.fetch(ctx)
+}
+
+// fetch fetchMu を保持した状態で呼び出す
+func (c *Cache) fetch(ctx context.Context) error {
+	c.mu.Lock()
+	c.lastAttempt = c.now()
+	c.mu.Unlock()
+
+	set, err := c.download(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		// 暗号化用の鍵は署名検証に使わない
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			logger.Error("skipping invalid JWK", "kid", jwk.Kid, "error", err.Error())
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return errors.New("jwks: no usable keys in response")
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = c.now()
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Cache) download(ctx context.Context) (*Set, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get JWK set: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get JWK set: unexpected status %d", resp.StatusCode)
+	}
+
+	var set Set
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWK set: %w", err)
+	}
+	return &set, nil
+}
@@ -0,0 +1,365 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jwksServer JWKSエンドポイントの代わりに使うテスト用サーバー
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	keys     []JWK
+	status   int
+	requests atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys ...JWK) *jwksServer {
+	t.Helper()
+	s := &jwksServer{keys: keys, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.status != http.StatusOK {
+			w.WriteHeader(s.status)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(Set{Keys: s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...JWK) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// fakeClock テストから進められる時計
+type fakeClock struct {
+	now atomic.Int64
+}
+
+func newFakeClock() *fakeClock {
+	c := &fakeClock{}
+	c.now.Store(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	return time.Unix(0, c.now.Load())
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now.Add(int64(d))
+}
+
+func rsaJWK(t *testing.T, kid string) (JWK, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}, key
+}
+
+func ecJWK(t *testing.T, kid string) (JWK, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	return JWK{
+		Kty: "EC",
+		Use: "sig",
+		Kid: kid,
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}, key
+}
+
+func TestCache_KeyIsCached(t *testing.T) {
+	t.Parallel()
+
+	jwk, _ := rsaJWK(t, "key-1")
+	server := newJWKSServer(t, jwk)
+	cache := NewCache(server.URL)
+
+	for range 3 {
+		if _, err := cache.Key(context.Background(), "key-1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got := server.requests.Load(); got != 1 {
+		t.Fatalf("expected 1 request, got %d", got)
+	}
+}
+
+func TestCache_RefetchAfterTTL(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	jwk, _ := rsaJWK(t, "key-1")
+	server := newJWKSServer(t, jwk)
+	cache := NewCache(server.URL, WithClock(clock.Now), WithTTL(time.Minute))
+
+	if _, err := cache.Key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clock.Advance(2 * time.Minute)
+	if _, err := cache.Key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := server.requests.Load(); got != 2 {
+		t.Fatalf("expected 2 requests, got %d", got)
+	}
+}
+
+func TestCache_UnknownKidRefetchIsRateLimited(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	oldKey, _ := rsaJWK(t, "old")
+	newKey, _ := ecJWK(t, "new")
+	server := newJWKSServer(t, oldKey)
+	cache := NewCache(server.URL, WithClock(clock.Now), WithMinRefreshInterval(30*time.Second))
+
+	if _, err := cache.Key(context.Background(), "old"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 鍵のローテーション後、未知の kid で再取得される
+	server.setKeys(oldKey, newKey)
+	clock.Advance(time.Minute)
+	if _, err := cache.Key(context.Background(), "new"); err != nil {
+		t.Fatalf("expected rotated key to be found: %v", err)
+	}
+
+	// 直後に未知の kid が続いてもエンドポイントには問い合わせない
+	for range 5 {
+		if _, err := cache.Key(context.Background(), "unknown"); !errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("expected ErrKeyNotFound, got %v", err)
+		}
+	}
+
+	if got := server.requests.Load(); got != 2 {
+		t.Fatalf("expected 2 requests, got %d", got)
+	}
+}
+
+func TestCache_UsesStaleKeysWhenFetchFails(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	jwk, _ := rsaJWK(t, "key-1")
+	server := newJWKSServer(t, jwk)
+	cache := NewCache(server.URL, WithClock(clock.Now), WithTTL(time.Minute))
+
+	if _, err := cache.Key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server.setStatus(http.StatusInternalServerError)
+	clock.Advance(2 * time.Minute)
+
+	if _, err := cache.Key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("expected stale key to be used: %v", err)
+	}
+}
+
+func TestCache_FetchTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	cache := NewCache(server.URL, WithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}))
+
+	if _, err := cache.Key(context.Background(), "key-1"); err == nil {
+		t.Fatalf("expected timeout error")
+	}
+}
+
+func TestCache_StartRefreshesInBackground(t *testing.T) {
+	t.Parallel()
+
+	jwk, _ := rsaJWK(t, "key-1")
+	server := newJWKSServer(t, jwk)
+	cache := NewCache(server.URL, WithTTL(20*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache.Start(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for server.requests.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected background refresh, got %d requests", server.requests.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCache_SkipsEncryptionKeys(t *testing.T) {
+	t.Parallel()
+
+	sig, _ := rsaJWK(t, "sig")
+	enc, _ := rsaJWK(t, "enc")
+	enc.Use = "enc"
+	server := newJWKSServer(t, sig, enc)
+	cache := NewCache(server.URL)
+
+	if _, err := cache.Key(context.Background(), "enc"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestJWK_PublicKey_EC(t *testing.T) {
+	t.Parallel()
+
+	jwk, private := ecJWK(t, "ec")
+
+	key, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !private.PublicKey.Equal(key) {
+		t.Fatalf("public key does not match")
+	}
+}
+
+func TestJWK_PublicKey_InvalidECPoint(t *testing.T) {
+	t.Parallel()
+
+	jwk, _ := ecJWK(t, "ec")
+	jwk.Y = jwk.X
+
+	if _, err := jwk.PublicKey(); err == nil {
+		t.Fatalf("expected error for a point not on the curve")
+	}
+}
+
+// certificateChain 中間CAで署名した証明書チェーン（先頭がリーフ）を作る
+func certificateChain(t *testing.T, leafKey crypto.Signer) []string {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("failed to parse CA certificate: %v", err)
+	}
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test-leaf"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, caCert, leafKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("failed to create leaf certificate: %v", err)
+	}
+
+	return []string{
+		base64.StdEncoding.EncodeToString(leafDER),
+		base64.StdEncoding.EncodeToString(caDER),
+	}
+}
+
+func TestJWK_PublicKey_X5cChain(t *testing.T) {
+	t.Parallel()
+
+	jwk, private := rsaJWK(t, "x5c")
+	jwk.X5c = certificateChain(t, private)
+
+	key, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !private.PublicKey.Equal(key) {
+		t.Fatalf("public key does not match")
+	}
+
+	// n/e を省略して証明書だけでも鍵を取り出せる
+	jwk.N, jwk.E = "", ""
+	if _, err := jwk.PublicKey(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestJWK_PublicKey_X5cMismatch(t *testing.T) {
+	t.Parallel()
+
+	jwk, _ := rsaJWK(t, "x5c")
+	_, other := rsaJWK(t, "other")
+	jwk.X5c = certificateChain(t, other)
+
+	if _, err := jwk.PublicKey(); err == nil {
+		t.Fatalf("expected error for mismatched certificate")
+	}
+}
+
+func TestJWK_PublicKey_X5cBrokenChain(t *testing.T) {
+	t.Parallel()
+
+	jwk, private := rsaJWK(t, "x5c")
+	chain := certificateChain(t, private)
+	// 別のCAの証明書に差し替えると署名の確認に失敗する
+	chain[1] = certificateChain(t, private)[1]
+	jwk.X5c = chain
+
+	if _, err := jwk.PublicKey(); err == nil {
+		t.Fatalf("expected error for broken chain")
+	}
+}
@@ -0,0 +1,177 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK JSON Web Key（RFC 7517）のうち署名検証に必要な項目
+type JWK struct {
+	Kty string   `json:"kty"`
+	Use string   `json:"use"`
+	Alg string   `json:"alg"`
+	Kid string   `json:"kid"`
+	N   string   `json:"n"`
+	E   string   `json:"e"`
+	Crv string   `json:"crv"`
+	X   string   `json:"x"`
+	Y   string   `json:"y"`
+	X5c []string `json:"x5c"`
+}
+
+// Set JWKSエンドポイントのレスポンス
+type Set struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKey JWKから公開鍵を生成する。
+// x5c がある場合は証明書チェーンの署名を確認し、先頭の証明書の鍵と n/e, x/y が一致することを確認する
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	var key crypto.PublicKey
+	var err error
+
+	switch k.Kty {
+	case "RSA":
+		if k.N != "" || k.E != "" {
+			key, err = rsaPublicKey(k.N, k.E)
+		}
+	case "EC":
+		if k.X != "" || k.Y != "" {
+			key, err = ecdsaPublicKey(k.Crv, k.X, k.Y)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type: %q", k.Kty)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(k.X5c) == 0 {
+		if key == nil {
+			return nil, errors.New("key material not found")
+		}
+		return key, nil
+	}
+
+	leafKey, err := certificateChainKey(k.X5c)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		key = leafKey
+	} else if !sameKey(key, leafKey) {
+		return nil, errors.New("x5c certificate does not match the key parameters")
+	}
+
+	// kty と証明書の鍵の種類が食い違う場合は使わない
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if k.Kty != "RSA" {
+			return nil, errors.New("x5c certificate key type does not match kty")
+		}
+	case *ecdsa.PublicKey:
+		if k.Kty != "EC" {
+			return nil, errors.New("x5c certificate key type does not match kty")
+		}
+	default:
+		return nil, errors.New("unsupported x5c certificate key type")
+	}
+	return key, nil
+}
+
+func rsaPublicKey(n, e string) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode N: %w", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode E: %w", err)
+	}
+	if len(nBytes) == 0 || len(eBytes) == 0 || len(eBytes) > 4 {
+		return nil, errors.New("invalid RSA key parameters")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
+
+func ecdsaPublicKey(crv, x, y string) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	var ecdhCurve ecdh.Curve
+	switch crv {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve: %q", crv)
+	}
+
+	xBytes, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode X: %w", err)
+	}
+	yBytes, err := base64.RawURLEncoding.DecodeString(y)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Y: %w", err)
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	if len(xBytes) != size || len(yBytes) != size {
+		return nil, errors.New("invalid EC key length")
+	}
+
+	// 曲線上の点であることを確認する（非圧縮形式 0x04 || X || Y）
+	point := append(append([]byte{4}, xBytes...), yBytes...)
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("invalid EC point: %w", err)
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(xBytes),
+		Y:     new(big.Int).SetBytes(yBytes),
+	}, nil
+}
+
+// certificateChainKey x5c の各証明書が次の証明書で署名されていることを確認し、先頭の証明書の公開鍵を返す
+func certificateChainKey(x5c []string) (crypto.PublicKey, error) {
+	certs := make([]*x509.Certificate, len(x5c))
+	for i, encoded := range x5c {
+		// x5c は base64url ではなく通常の base64（RFC 7517 4.7）
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode x5c[%d]: %w", i, err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse x5c[%d]: %w", i, err)
+		}
+		certs[i] = cert
+	}
+
+	for i := 0; i < len(certs)-1; i++ {
+		if err := certs[i].CheckSignatureFrom(certs[i+1]); err != nil {
+			return nil, fmt.Errorf("x5c chain is broken at %d: %w", i, err)
+		}
+	}
+	return certs[0].PublicKey, nil
+}
+
+func sameKey(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(x crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
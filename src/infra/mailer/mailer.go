@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"w3st/domain/services"
+)
+
+// NewMailerFromEnv MAIL_DRIVER に応じて送信方法を切り替える。
+// smtp は SMTP で送信し、outbox は送信せずに保持する（ローカル環境用）。
+// 未設定の場合はメールが届かないことに気づけないため、起動時にエラーにする
+func NewMailerFromEnv() (services.Mailer, error) {
+	switch driver := os.Getenv("MAIL_DRIVER"); driver {
+	case "smtp":
+		if os.Getenv("SMTP_HOST") == "" {
+			return nil, errors.New("MAIL_DRIVER=smtp の場合は SMTP_HOST を設定してください")
+		}
+		return NewSMTPMailer(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		}), nil
+	case "outbox":
+		return NewOutboxMailer(os.Getenv("MAIL_OUTBOX_DIR")), nil
+	case "":
+		return nil, errors.New("MAIL_DRIVER を設定してください（smtp または outbox）")
+	default:
+		return nil, fmt.Errorf("MAIL_DRIVER には smtp または outbox を指定してください: %s", driver)
+	}
+}
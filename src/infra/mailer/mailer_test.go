@@ -0,0 +1,123 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"w3st/domain/models"
+)
+
+func TestOutboxMailer_KeepsMessages(t *testing.T) {
+	t.Parallel()
+
+	m := NewOutboxMailer("")
+	mail := &models.Mail{To: "alice@example.com", Subject: "件名", Body: "本文"}
+
+	if err := m.Send(context.Background(), mail); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	messages := m.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if messages[0].To != "alice@example.com" {
+		t.Fatalf("unexpected recipient: %s", messages[0].To)
+	}
+}
+
+func TestOutboxMailer_KeepsRecentMessagesOnly(t *testing.T) {
+	t.Parallel()
+
+	m := NewOutboxMailer("")
+	for i := 0; i < outboxMaxMessages+5; i++ {
+		if err := m.Send(context.Background(), &models.Mail{To: fmt.Sprintf("user%d@example.com", i), Subject: "s", Body: "b"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	messages := m.Messages()
+	if len(messages) != outboxMaxMessages {
+		t.Fatalf("expected %d messages, got %d", outboxMaxMessages, len(messages))
+	}
+	if messages[0].To != "user5@example.com" || messages[len(messages)-1].To != fmt.Sprintf("user%d@example.com", outboxMaxMessages+4) {
+		t.Fatalf("unexpected messages kept: %s ... %s", messages[0].To, messages[len(messages)-1].To)
+	}
+}
+
+func TestNewMailerFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		driver   string
+		smtpHost string
+		wantErr  bool
+	}{
+		{name: "unset", wantErr: true},
+		{name: "unknown driver", driver: "sendmail", wantErr: true},
+		{name: "smtp without host", driver: "smtp", wantErr: true},
+		{name: "smtp", driver: "smtp", smtpHost: "smtp.example.com"},
+		{name: "outbox", driver: "outbox"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MAIL_DRIVER", tt.driver)
+			t.Setenv("SMTP_HOST", tt.smtpHost)
+
+			m, err := NewMailerFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %T", m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestOutboxMailer_WritesFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	m := NewOutboxMailer(dir)
+
+	for i := 0; i < 2; i++ {
+		if err := m.Send(context.Background(), &models.Mail{To: "bob@example.com", Subject: "s", Body: "b"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read outbox dir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(entries))
+	}
+}
+
+func TestBuildMessage_EncodesSubject(t *testing.T) {
+	t.Parallel()
+
+	msg := string(buildMessage("noreply@example.com", &models.Mail{
+		To:      "carol@example.com",
+		Subject: "パスワード再設定",
+		Body:    "本文です",
+	}, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	if !strings.Contains(msg, "Subject: =?UTF-8?b?") {
+		t.Fatalf("subject is not MIME encoded: %s", msg)
+	}
+	if !strings.Contains(msg, "To: carol@example.com\r\n") {
+		t.Fatalf("missing To header: %s", msg)
+	}
+	if !strings.HasSuffix(msg, "\r\n\r\n本文です") {
+		t.Fatalf("unexpected body: %s", msg)
+	}
+}
@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+)
+
+// outboxMaxMessages OutboxMailer がメモリに保持するメールの上限。超えた場合は古いものから捨てる
+const outboxMaxMessages = 100
+
+// OutboxMailer 送信せずに保持するメーラー。テストやローカル環境で使用する。
+// 直近の outboxMaxMessages 通をメモリに保持し、dir を指定した場合は1通ごとに .eml ファイルとして書き出す
+type OutboxMailer struct {
+	mu    sync.Mutex
+	dir   string
+	mails []models.Mail
+	sent  int
+}
+
+func NewOutboxMailer(dir string) *OutboxMailer {
+	return &OutboxMailer{dir: dir}
+}
+
+func (m *OutboxMailer) Send(_ context.Context, mail *models.Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent++
+	if len(m.mails) >= outboxMaxMessages {
+		m.mails = append(m.mails[:0], m.mails[len(m.mails)-outboxMaxMessages+1:]...)
+	}
+	m.mails = append(m.mails, *mail)
+
+	if m.dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return myerrors.NewDomainError(myerrors.ErrorUnknown, err)
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%s-%03d.eml", now.Format("20060102T150405.000000000"), m.sent)
+	if err := os.WriteFile(filepath.Join(m.dir, name), buildMessage("outbox@localhost", mail, now), 0o644); err != nil {
+		return myerrors.NewDomainError(myerrors.ErrorUnknown, err)
+	}
+	return nil
+}
+
+// Messages 保持しているメール（直近の outboxMaxMessages 通）のコピーを返す
+func (m *OutboxMailer) Messages() []models.Mail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mails := make([]models.Mail, len(m.mails))
+	copy(mails, m.mails)
+	return mails
+}
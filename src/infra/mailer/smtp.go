@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/domain/services"
+	myerrors "w3st/errors"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type smtpMailer struct {
+	config SMTPConfig
+}
+
+func NewSMTPMailer(config SMTPConfig) services.Mailer {
+	if config.Port == "" {
+		config.Port = "587"
+	}
+	return &smtpMailer{config: config}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, mail *models.Mail) error {
+	if err := ctx.Err(); err != nil {
+		return myerrors.NewDomainError(myerrors.ErrorUnknown, err)
+	}
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+
+	addr := net.JoinHostPort(m.config.Host, m.config.Port)
+	msg := buildMessage(m.config.From, mail, time.Now())
+	if err := smtp.SendMail(addr, auth, m.config.From, []string{mail.To}, msg); err != nil {
+		return myerrors.NewDomainError(myerrors.ErrorUnknown, fmt.Errorf("メールの送信に失敗しました: %w", err))
+	}
+	return nil
+}
+
+// buildMessage 件名をMIMEエンコードしたUTF-8のプレーンテキストメールを組み立てる
+func buildMessage(from string, mail *models.Mail, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", mail.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(mail.Body)
+	return buf.Bytes()
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// memoryShards ロックの競合を減らすためにキーを分けるシャードの数
+const memoryShards = 64
+
+// memorySweepInterval シャードごとに期限切れのカウントを捨てる間隔
+const memorySweepInterval = time.Minute
+
+// MemoryStore サーバーのメモリにカウントを保持する Store。カウントはサーバーごとになる。
+// 期限切れのカウントは Add のときにシャードごとに memorySweepInterval おきに捨てる
+type MemoryStore struct {
+	now    func() time.Time
+	shards [memoryShards]memoryShard
+}
+
+type memoryShard struct {
+	mu        sync.Mutex
+	counts    map[string]*windowCount
+	nextSweep time.Time
+}
+
+type windowCount struct {
+	window    int64
+	current   int64
+	previous  int64
+	expiresAt time.Time
+}
+
+func NewMemoryStore(opts ...Option) *MemoryStore {
+	o := newOptions(opts)
+	s := &MemoryStore{now: o.now}
+	for i := range s.shards {
+		s.shards[i].counts = make(map[string]*windowCount)
+	}
+	return s
+}
+
+func (s *MemoryStore) Add(_ context.Context, key string, window int64, ttl time.Duration, n int64) (int64, int64, error) {
+	shard := s.shard(key)
+	now := s.now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if !now.Before(shard.nextSweep) {
+		for k, c := range shard.counts {
+			if !now.Before(c.expiresAt) {
+				delete(shard.counts, k)
+			}
+		}
+		shard.nextSweep = now.Add(memorySweepInterval)
+	}
+
+	c, ok := shard.counts[key]
+	switch {
+	case !ok || !now.Before(c.expiresAt) || c.window < window-1:
+		c = &windowCount{window: window}
+		shard.counts[key] = c
+	case c.window == window-1:
+		c.window, c.previous, c.current = window, c.current, 0
+	}
+	c.current = max(c.current+n, 0)
+	c.expiresAt = now.Add(ttl)
+	return c.current, c.previous, nil
+}
+
+// Len 保持しているキーの数（期限切れでまだ捨てていないものを含む）
+func (s *MemoryStore) Len() int {
+	n := 0
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+		n += len(s.shards[i].counts)
+		s.shards[i].mu.Unlock()
+	}
+	return n
+}
+
+func (s *MemoryStore) shard(key string) *memoryShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return &s.shards[h.Sum32()%memoryShards]
+}
@@ -0,0 +1,120 @@
+// Package ratelimit スライディングウィンドウ（直前のウィンドウのカウントを経過時間で按分する方式）のレート制限。
+// カウントは Store に保持するため、複数のサーバーで動かす場合は RedisStore を使う
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Store ウィンドウごとのリクエスト数を保持する。複数の goroutine から同時に使える
+type Store interface {
+	// Add key の window 番目のウィンドウのカウントに n を加え、加えた後のカウントと1つ前のウィンドウのカウントを返す。
+	// カウントは ttl を過ぎたら捨ててよい
+	Add(ctx context.Context, key string, window int64, ttl time.Duration, n int64) (current int64, previous int64, err error)
+}
+
+// Result レート制限の判定結果。X-RateLimit-* ヘッダーに使う
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Used このリクエストを含めたウィンドウ内のリクエスト数（1つ前のウィンドウの按分を含む）
+	Used      int
+	Remaining int
+	// Reset 拒否した場合は次のリクエストを受け付けられる時刻、受け付けた場合は現在のウィンドウが終わる時刻
+	Reset time.Time
+	// RetryAfter 拒否した場合に次のリクエストを受け付けられるまでの時間
+	RetryAfter time.Duration
+}
+
+type options struct {
+	now func() time.Time
+}
+
+type Option func(*options)
+
+// WithClock テスト用に現在時刻を差し替える
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Limiter key ごとに window あたりのリクエスト数を制限する
+type Limiter struct {
+	store  Store
+	window time.Duration
+	now    func() time.Time
+}
+
+func NewLimiter(store Store, window time.Duration, opts ...Option) *Limiter {
+	o := newOptions(opts)
+	return &Limiter{store: store, window: window, now: o.now}
+}
+
+// Allow key のリクエストを1件数え、limit を超えていなければ受け付ける。拒否したリクエストは数えない。
+// limit が0以下の場合は制限しない
+func (l *Limiter) Allow(ctx context.Context, key string, limit int) (Result, error) {
+	if limit <= 0 {
+		return Result{Allowed: true}, nil
+	}
+	now := l.now()
+	window := now.UnixNano() / int64(l.window)
+	windowStart := time.Unix(0, window*int64(l.window))
+	elapsed := now.Sub(windowStart)
+
+	current, previous, err := l.store.Add(ctx, key, window, 2*l.window, 1)
+	if err != nil {
+		return Result{}, err
+	}
+	// 1つ前のウィンドウのカウントは、現在のウィンドウと重ならない部分の割合だけ数える
+	weight := 1 - float64(elapsed)/float64(l.window)
+	used := int(math.Ceil(float64(previous)*weight)) + int(current)
+	if used <= limit {
+		return Result{
+			Allowed:   true,
+			Limit:     limit,
+			Used:      used,
+			Remaining: limit - used,
+			Reset:     windowStart.Add(l.window),
+		}, nil
+	}
+
+	if _, _, err := l.store.Add(ctx, key, window, 2*l.window, -1); err != nil {
+		return Result{}, err
+	}
+	retryAfter := l.retryAfter(elapsed, float64(current-1), float64(previous), float64(limit))
+	return Result{
+		Allowed:    false,
+		Limit:      limit,
+		Used:       used - 1,
+		Remaining:  0,
+		Reset:      now.Add(retryAfter),
+		RetryAfter: retryAfter,
+	}, nil
+}
+
+// retryAfter previous*(1-経過時間/window) + current + 1 が limit 以下になるまでの時間
+func (l *Limiter) retryAfter(elapsed time.Duration, current, previous, limit float64) time.Duration {
+	window := float64(l.window)
+	if current+1 <= limit && previous > 0 {
+		// 現在のウィンドウのうちに1つ前のウィンドウの按分が減って受け付けられるようになる
+		wait := window*(1-(limit-current-1)/previous) - float64(elapsed)
+		return max(time.Duration(math.Ceil(wait)), time.Nanosecond)
+	}
+	// 次のウィンドウで、現在のウィンドウのカウントの按分が減るのを待つ
+	wait := window - float64(elapsed)
+	if current > 0 {
+		wait += window * max(0, 1-(limit-1)/current)
+	}
+	return max(time.Duration(math.Ceil(wait)), time.Nanosecond)
+}
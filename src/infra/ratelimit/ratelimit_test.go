@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock テスト用の進められる時計
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newClock() *fakeClock {
+	// ウィンドウ（1分）の始まりから10秒後
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 10, 0, time.UTC)}
+}
+
+// testSlidingWindow limit を超えたリクエストを拒否し、1つ前のウィンドウのカウントを経過時間で按分することを確認する
+func testSlidingWindow(t *testing.T, store Store, clock *fakeClock) {
+	t.Helper()
+	ctx := context.Background()
+	limiter := NewLimiter(store, time.Minute, WithClock(clock.Now))
+
+	for i := 1; i <= 10; i++ {
+		res, err := limiter.Allow(ctx, "project:1", 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !res.Allowed || res.Remaining != 10-i {
+			t.Fatalf("request %d: expected allowed with %d remaining, got %+v", i, 10-i, res)
+		}
+	}
+	res, err := limiter.Allow(ctx, "project:1", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 次のウィンドウで、このウィンドウの10件の按分が9件以下になるまで待つ（残り50秒 + 6秒）
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != 56*time.Second {
+		t.Fatalf("expected denied with retry after 56s, got %+v", res)
+	}
+	if !res.Reset.Equal(clock.Now().Add(56 * time.Second)) {
+		t.Fatalf("expected reset at retry after, got %v", res.Reset)
+	}
+	// キーごとに数える
+	if res, _ := limiter.Allow(ctx, "project:2", 10); !res.Allowed {
+		t.Fatalf("expected other key to be allowed, got %+v", res)
+	}
+
+	// 拒否したリクエストは数えないため、Retry-After の直前はまだ拒否し、Retry-After を過ぎると受け付ける
+	clock.Advance(55 * time.Second)
+	if res, _ := limiter.Allow(ctx, "project:1", 10); res.Allowed {
+		t.Fatalf("expected denied before retry after, got %+v", res)
+	}
+	clock.Advance(time.Second)
+	res, err = limiter.Allow(ctx, "project:1", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Allowed || res.Used != 10 {
+		t.Fatalf("expected allowed with 9 weighted + 1, got %+v", res)
+	}
+
+	// 2つ前のウィンドウのカウントは数えない
+	clock.Advance(2 * time.Minute)
+	if res, _ := limiter.Allow(ctx, "project:1", 10); !res.Allowed || res.Used != 1 {
+		t.Fatalf("expected counts to be reset, got %+v", res)
+	}
+}
+
+// testConcurrent 同時に数えても limit 件を超えて受け付けない
+func testConcurrent(t *testing.T, store Store) {
+	t.Helper()
+	clock := newClock()
+	limiter := NewLimiter(store, time.Minute, WithClock(clock.Now))
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				res, err := limiter.Allow(context.Background(), "api_key:1", 100)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if res.Allowed {
+					allowed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed.Load() != 100 {
+		t.Fatalf("expected exactly 100 allowed, got %d", allowed.Load())
+	}
+}
+
+func TestLimiter_MemoryStore(t *testing.T) {
+	t.Parallel()
+	clock := newClock()
+	testSlidingWindow(t, NewMemoryStore(WithClock(clock.Now)), clock)
+}
+
+func TestLimiter_MemoryStore_Concurrent(t *testing.T) {
+	t.Parallel()
+	testConcurrent(t, NewMemoryStore())
+}
+
+func TestLimiter_NoLimit(t *testing.T) {
+	t.Parallel()
+	store := NewMemoryStore()
+	res, err := NewLimiter(store, time.Minute).Allow(context.Background(), "project:1", 0)
+	if err != nil || !res.Allowed {
+		t.Fatalf("expected allowed without limit, got %+v (%v)", res, err)
+	}
+	if store.Len() != 0 {
+		t.Fatalf("expected nothing to be counted, got %d", store.Len())
+	}
+}
+
+func TestMemoryStore_EvictsExpiredCounts(t *testing.T) {
+	t.Parallel()
+	clock := newClock()
+	store := NewMemoryStore(WithClock(clock.Now))
+	limiter := NewLimiter(store, time.Minute, WithClock(clock.Now))
+
+	if _, err := limiter.Allow(context.Background(), "project:1", 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 同じシャードの別のキー
+	other := ""
+	for i := 0; other == ""; i++ {
+		if key := fmt.Sprintf("project:%d", i+2); store.shard(key) == store.shard("project:1") {
+			other = key
+		}
+	}
+
+	// 使われなくなったキーは2ウィンドウ分を過ぎたら、同じシャードのキーを数えるときに捨てる
+	clock.Advance(2 * time.Minute)
+	if _, err := limiter.Allow(context.Background(), other, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.Len() != 1 {
+		t.Fatalf("expected expired key to be removed, got %d keys", store.Len())
+	}
+}
@@ -0,0 +1,274 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisStore Redis（RESP を話すサーバー）にカウントを保持する Store。複数のサーバーでカウントを共有できる。
+// カウントは MULTI/EXEC で増やすため、同じキーを同時に数えても取りこぼさない
+type RedisStore struct {
+	addr     string
+	password string
+	db       int
+	prefix   string
+	timeout  time.Duration
+	pool     chan *redisConn
+}
+
+// RedisKeyPrefix RedisStore が使うキーの prefix
+const RedisKeyPrefix = "w3st:ratelimit:"
+
+const (
+	redisPoolSize = 16
+	redisTimeout  = time.Second
+)
+
+// NewRedisStore redis://[:password@]host:port[/db] 形式の URL の Redis を使う。接続はリクエストのときに行う
+func NewRedisStore(rawURL string) (*RedisStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+	if u.Scheme != "redis" || u.Host == "" {
+		return nil, fmt.Errorf("invalid redis url: %q", rawURL)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	password, _ := u.User.Password()
+	db := 0
+	if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+		if db, err = strconv.Atoi(path); err != nil {
+			return nil, fmt.Errorf("invalid redis db: %q", path)
+		}
+	}
+	return &RedisStore{
+		addr:     addr,
+		password: password,
+		db:       db,
+		prefix:   RedisKeyPrefix,
+		timeout:  redisTimeout,
+		pool:     make(chan *redisConn, redisPoolSize),
+	}, nil
+}
+
+func (s *RedisStore) Add(ctx context.Context, key string, window int64, ttl time.Duration, n int64) (int64, int64, error) {
+	currentKey := s.prefix + key + ":" + strconv.FormatInt(window, 10)
+	previousKey := s.prefix + key + ":" + strconv.FormatInt(window-1, 10)
+
+	conn, err := s.get(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	replies, err := conn.do(ctx, s.timeout,
+		[]string{"MULTI"},
+		[]string{"INCRBY", currentKey, strconv.FormatInt(n, 10)},
+		[]string{"PEXPIRE", currentKey, strconv.FormatInt(ttl.Milliseconds(), 10)},
+		[]string{"GET", previousKey},
+		[]string{"EXEC"},
+	)
+	if err != nil {
+		conn.close()
+		return 0, 0, err
+	}
+	s.put(conn)
+
+	exec, ok := replies[4].([]any)
+	if !ok || len(exec) != 3 {
+		return 0, 0, fmt.Errorf("redis: unexpected EXEC reply %v", replies[4])
+	}
+	for _, reply := range exec {
+		if re, ok := reply.(redisError); ok {
+			return 0, 0, re
+		}
+	}
+	current, ok := exec[0].(int64)
+	if !ok {
+		return 0, 0, fmt.Errorf("redis: unexpected INCRBY reply %v", exec[0])
+	}
+	var previous int64
+	if v, ok := exec[2].(string); ok {
+		if previous, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("redis: unexpected GET reply %q", v)
+		}
+	}
+	return current, previous, nil
+}
+
+// Close 使っていない接続を閉じる
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case conn := <-s.pool:
+			conn.close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (s *RedisStore) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-s.pool:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: s.timeout}
+	nc, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	conn := &redisConn{conn: nc, reader: bufio.NewReader(nc)}
+	var setup [][]string
+	if s.password != "" {
+		setup = append(setup, []string{"AUTH", s.password})
+	}
+	if s.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(s.db)})
+	}
+	if len(setup) > 0 {
+		if _, err := conn.do(ctx, s.timeout, setup...); err != nil {
+			conn.close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (s *RedisStore) put(conn *redisConn) {
+	select {
+	case s.pool <- conn:
+	default:
+		conn.close()
+	}
+}
+
+// redisError Redis が返したエラー（-ERR ...）
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisConn RESP で Redis と通信する接続。同時に1つの goroutine からだけ使う
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// do commands をまとめて送り、それぞれの応答を返す。いずれかの応答がエラーの場合はそのエラーを返す
+func (c *redisConn) do(ctx context.Context, timeout time.Duration, commands ...[]string) ([]any, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+
+	var b strings.Builder
+	for _, args := range commands {
+		b.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+		for _, arg := range args {
+			b.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+		}
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+
+	replies := make([]any, len(commands))
+	var replyErr error
+	for i := range commands {
+		reply, err := readReply(c.reader)
+		if err != nil {
+			var re redisError
+			if !errors.As(err, &re) {
+				return nil, err
+			}
+			// 残りの応答を読み切ってから返す
+			replyErr = errors.Join(replyErr, err)
+		}
+		replies[i] = reply
+	}
+	if replyErr != nil {
+		return nil, replyErr
+	}
+	return replies, nil
+}
+
+func (c *redisConn) close() {
+	_ = c.conn.Close()
+}
+
+// readReply RESP の応答を1つ読む。単純文字列・バルク文字列は string、整数は int64、配列は []any、nil は nil になる
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		n, err := strconv.ParseInt(body, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed integer %q", body)
+		}
+		return n, nil
+	case '$':
+		size, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		return string(buf[:size]), nil
+	case '*':
+		size, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		// EXEC の応答にはコマンドごとのエラーが含まれることがあるため、要素のエラーは値として返す
+		items := make([]any, size)
+		for i := range items {
+			item, err := readReply(r)
+			var re redisError
+			if err != nil && !errors.As(err, &re) {
+				return nil, err
+			}
+			if err != nil {
+				item = re
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", line)
+	}
+}
@@ -0,0 +1,233 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis RedisStore が使うコマンド（AUTH SELECT MULTI INCRBY PEXPIRE GET EXEC）だけを実装した RESP のサーバー
+type fakeRedis struct {
+	listener net.Listener
+	password string
+
+	mu     sync.Mutex
+	values map[string]int64
+	ttls   map[string]time.Duration
+	// commands 受け付けたコマンド名（テストで確認する）
+	commands []string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	r := &fakeRedis{listener: listener, password: password, values: map[string]int64{}, ttls: map[string]time.Duration{}}
+	go r.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+	return r
+}
+
+func (r *fakeRedis) url() string {
+	if r.password != "" {
+		return "redis://:" + r.password + "@" + r.listener.Addr().String() + "/2"
+	}
+	return "redis://" + r.listener.Addr().String()
+}
+
+func (r *fakeRedis) serve() {
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+		go r.handle(conn)
+	}
+}
+
+func (r *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := r.password == ""
+	var queued [][]string
+	inMulti := false
+
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		name := strings.ToUpper(args[0])
+		r.mu.Lock()
+		r.commands = append(r.commands, name)
+		r.mu.Unlock()
+
+		var reply string
+		switch {
+		case name == "AUTH":
+			authenticated = len(args) == 2 && args[1] == r.password
+			reply = "+OK\r\n"
+			if !authenticated {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case name == "SELECT":
+			reply = "+OK\r\n"
+		case name == "MULTI":
+			inMulti, queued = true, nil
+			reply = "+OK\r\n"
+		case name == "EXEC":
+			r.mu.Lock()
+			reply = "*" + strconv.Itoa(len(queued)) + "\r\n"
+			for _, cmd := range queued {
+				reply += r.exec(cmd)
+			}
+			r.mu.Unlock()
+			inMulti = false
+		case inMulti:
+			queued = append(queued, args)
+			reply = "+QUEUED\r\n"
+		default:
+			r.mu.Lock()
+			reply = r.exec(args)
+			r.mu.Unlock()
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// exec r.mu を持った状態で呼ぶ
+func (r *fakeRedis) exec(args []string) string {
+	switch strings.ToUpper(args[0]) {
+	case "INCRBY":
+		n, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		r.values[args[1]] += n
+		return ":" + strconv.FormatInt(r.values[args[1]], 10) + "\r\n"
+	case "PEXPIRE":
+		ms, _ := strconv.ParseInt(args[2], 10, 64)
+		r.ttls[args[1]] = time.Duration(ms) * time.Millisecond
+		return ":1\r\n"
+	case "GET":
+		v, ok := r.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		s := strconv.FormatInt(v, 10)
+		return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+	default:
+		return "-ERR unknown command '" + args[0] + "'\r\n"
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	reply, err := readReply(r)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]any)
+	if !ok || len(items) == 0 {
+		return nil, errors.New("expected array")
+	}
+	args := make([]string, len(items))
+	for i, item := range items {
+		if args[i], ok = item.(string); !ok {
+			return nil, errors.New("expected bulk string")
+		}
+	}
+	return args, nil
+}
+
+func newRedisStore(t *testing.T, server *fakeRedis) *RedisStore {
+	t.Helper()
+	store, err := NewRedisStore(server.url())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func TestLimiter_RedisStore(t *testing.T) {
+	t.Parallel()
+	server := newFakeRedis(t, "")
+	clock := newClock()
+	testSlidingWindow(t, newRedisStore(t, server), clock)
+
+	// カウントはウィンドウごとのキーに保持し、2ウィンドウ分で期限切れにする
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	window := clock.Now().UnixNano() / int64(time.Minute)
+	key := RedisKeyPrefix + "project:1:" + strconv.FormatInt(window, 10)
+	if server.values[key] != 1 || server.ttls[key] != 2*time.Minute {
+		t.Fatalf("unexpected redis state: %d %v", server.values[key], server.ttls[key])
+	}
+}
+
+func TestLimiter_RedisStore_Concurrent(t *testing.T) {
+	t.Parallel()
+	testConcurrent(t, newRedisStore(t, newFakeRedis(t, "")))
+}
+
+func TestRedisStore_AuthAndSelect(t *testing.T) {
+	t.Parallel()
+	server := newFakeRedis(t, "secret")
+	store := newRedisStore(t, server)
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := store.Add(context.Background(), "project:1", 1, time.Minute, 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// 接続ごとに1回だけ認証し、接続は使い回す
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	got := strings.Join(server.commands, " ")
+	want := "AUTH SELECT MULTI INCRBY PEXPIRE GET EXEC MULTI INCRBY PEXPIRE GET EXEC"
+	if got != want {
+		t.Fatalf("unexpected commands:\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestRedisStore_Errors(t *testing.T) {
+	t.Parallel()
+
+	// パスワードが違う場合は Redis のエラーを返す
+	server := newFakeRedis(t, "secret")
+	store, err := NewRedisStore("redis://:wrong@" + server.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := store.Add(context.Background(), "project:1", 1, time.Minute, 1); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Fatalf("expected WRONGPASS error, got %v", err)
+	}
+
+	// 接続できない場合
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := listener.Addr().String()
+	_ = listener.Close()
+	store, _ = NewRedisStore("redis://" + addr)
+	if _, _, err := store.Add(context.Background(), "project:1", 1, time.Minute, 1); err == nil {
+		t.Fatal("expected connection error")
+	}
+
+	for _, rawURL := range []string{"http://localhost:6379", "redis://", "redis://localhost/db"} {
+		if _, err := NewRedisStore(rawURL); err == nil {
+			t.Fatalf("expected error for %q", rawURL)
+		}
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"w3st/domain/models"
 	"w3st/domain/repositories"
 	myerrors "w3st/errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ApiKeyRepositoryImpl struct {
@@ -20,6 +22,7 @@ func NewApiKeyRepositoryImpl(db *gorm.DB) repositories.ApiKeyRepository {
 	return &ApiKeyRepositoryImpl{db: db}
 }
 
+// Create キーと利用できるコレクション（Collections）を保存する
 func (r *ApiKeyRepositoryImpl) Create(ctx context.Context, apiKey *models.ApiKeys) *myerrors.DomainError {
 	result := r.db.WithContext(ctx).Create(apiKey)
 	if result.Error != nil {
@@ -28,11 +31,22 @@ func (r *ApiKeyRepositoryImpl) Create(ctx context.Context, apiKey *models.ApiKey
 	return nil
 }
 
-func (r *ApiKeyRepositoryImpl) FindByKey(ctx context.Context, key string) (*models.ApiKeys, *myerrors.DomainError) {
+func (r *ApiKeyRepositoryImpl) FindByPrefix(ctx context.Context, prefix string) ([]*models.ApiKeys, *myerrors.DomainError) {
+	var apiKeys []*models.ApiKeys
+	result := r.db.WithContext(ctx).
+		Preload("Collections").
+		Where("key_prefix = ? OR (previous_key_prefix = ? AND previous_key_expire_at > ?)", prefix, prefix, time.Now()).
+		Find(&apiKeys)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return apiKeys, nil
+}
+
+func (r *ApiKeyRepositoryImpl) FindByID(ctx context.Context, projectID int, id int) (*models.ApiKeys, *myerrors.DomainError) {
 	var apiKey models.ApiKeys
-	result := r.db.WithContext(ctx).Where("key = ? AND revoked = false", key).First(&apiKey)
+	result := r.db.WithContext(ctx).Preload("Collections").Where("project_id = ? AND id = ?", projectID, id).First(&apiKey)
 	if result.Error != nil {
-		// sqlmock may return sql.ErrNoRows while gorm uses gorm.ErrRecordNotFound in some setups.
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) || errors.Is(result.Error, sql.ErrNoRows) {
 			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "APIキーが見つかりません")
 		}
@@ -41,6 +55,15 @@ func (r *ApiKeyRepositoryImpl) FindByKey(ctx context.Context, key string) (*mode
 	return &apiKey, nil
 }
 
+func (r *ApiKeyRepositoryImpl) FindByProjectID(ctx context.Context, projectID int) ([]*models.ApiKeys, *myerrors.DomainError) {
+	var apiKeys []*models.ApiKeys
+	result := r.db.WithContext(ctx).Preload("Collections").Where("project_id = ?", projectID).Order("created_at DESC, id DESC").Find(&apiKeys)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return apiKeys, nil
+}
+
 func (r *ApiKeyRepositoryImpl) FindByUserID(ctx context.Context, userID string) ([]*models.ApiKeys, *myerrors.DomainError) {
 	var apiKeys []*models.ApiKeys
 	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&apiKeys)
@@ -50,16 +73,80 @@ func (r *ApiKeyRepositoryImpl) FindByUserID(ctx context.Context, userID string)
 	return apiKeys, nil
 }
 
-func (r *ApiKeyRepositoryImpl) Update(ctx context.Context, apiKey *models.ApiKeys) *myerrors.DomainError {
-	result := r.db.WithContext(ctx).Save(apiKey)
+// Update columns のカラムだけを更新し、collections が nil でない場合は利用できるコレクションを置き換える
+func (r *ApiKeyRepositoryImpl) Update(ctx context.Context, id int, columns map[string]interface{}, collections []models.ApiKeyCollections) *myerrors.DomainError {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(columns) > 0 {
+			if err := tx.Model(&models.ApiKeys{}).Where("id = ?", id).Updates(columns).Error; err != nil {
+				return err
+			}
+		}
+		if collections == nil {
+			return nil
+		}
+		if err := tx.Where("api_key_id = ?", id).Delete(&models.ApiKeyCollections{}).Error; err != nil {
+			return err
+		}
+		if len(collections) == 0 {
+			return nil
+		}
+		for i := range collections {
+			collections[i].ApiKeyID = id
+		}
+		return tx.Create(&collections).Error
+	})
+	if err != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, err)
+	}
+	return nil
+}
+
+// Rotate 読み込んでから保存するまでの間にローテーション・取り消しされていた場合は保存しない
+func (r *ApiKeyRepositoryImpl) Rotate(ctx context.Context, apiKey *models.ApiKeys, currentKeyPrefix string) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).
+		Model(&models.ApiKeys{}).
+		Where("id = ? AND key_prefix = ? AND revoked = ?", apiKey.Id, currentKeyPrefix, false).
+		Updates(map[string]interface{}{
+			"key_prefix":             apiKey.KeyPrefix,
+			"key_hash":               apiKey.KeyHash,
+			"previous_key_prefix":    apiKey.PreviousKeyPrefix,
+			"previous_key_hash":      apiKey.PreviousKeyHash,
+			"previous_key_expire_at": apiKey.PreviousKeyExpireAt,
+		})
 	if result.Error != nil {
 		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
 	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "APIキーは他の操作で変更されています。取得し直してから操作してください")
+	}
+	return nil
+}
+
+// AttachCollection キーで利用できるコレクションを追加する。追加済みの場合はスコープを置き換える
+func (r *ApiKeyRepositoryImpl) AttachCollection(ctx context.Context, collection *models.ApiKeyCollections) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "api_key_id"}, {Name: "collection_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scopes"}),
+	}).Create(collection)
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return nil
+}
+
+func (r *ApiKeyRepositoryImpl) DetachCollection(ctx context.Context, apiKeyID int, collectionID int) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Where("api_key_id = ? AND collection_id = ?", apiKeyID, collectionID).Delete(&models.ApiKeyCollections{})
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "APIキーでこのコレクションは利用できません")
+	}
 	return nil
 }
 
-func (r *ApiKeyRepositoryImpl) Delete(ctx context.Context, id int) *myerrors.DomainError {
-	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.ApiKeys{})
+func (r *ApiKeyRepositoryImpl) Delete(ctx context.Context, projectID int, id int) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Where("project_id = ? AND id = ?", projectID, id).Delete(&models.ApiKeys{})
 	if result.Error != nil {
 		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
 	}
@@ -6,10 +6,12 @@ import (
 	"testing"
 	"time"
 
+	"w3st/domain/models"
 	"w3st/errors"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -38,28 +40,33 @@ func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
 	return gdb, mock, cleanup
 }
 
-func TestFindByKey_NotFound(t *testing.T) {
+func TestCreate_StoresIpWhiteListAndRateLimit(t *testing.T) {
 	t.Parallel()
 
 	gdb, mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
 	repo := NewApiKeyRepositoryImpl(gdb)
+	userID := uuid.New()
+	expireAt := time.Now().Add(time.Hour)
+	apiKey := &models.ApiKeys{
+		UserID: userID, ProjectID: 1, Name: "Website", KeyPrefix: "Ab12Cd34", KeyHash: "hash",
+		IpWhiteList: models.TextArray{"203.0.113.7", "10.0.0.0/8"}, ExpireAt: expireAt, RateLimit: 500,
+		Scopes: datatypes.JSONSlice[string]{"entries:read"},
+	}
 
-	// Expect query and return no rows
-	mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE key = \$1 AND revoked = false ORDER BY "api_keys"\."id" LIMIT (?:\$?\d+)`).
-		WithArgs("missing-key", sqlmock.AnyArg()).
-		WillReturnError(sql.ErrNoRows)
+	// ip_whitelist は text[]、1時間あたりの上限は rate_limit_per_hour に保存する
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "api_keys" \("user_id","project_id","name","key_prefix","key_hash","previous_key_prefix","previous_key_hash","previous_key_expire_at","ip_whitelist","expire_at","revoked","rate_limit_per_hour","scopes"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13\) RETURNING "created_at","id"`).
+		WithArgs(userID, 1, "Website", "Ab12Cd34", "hash", nil, nil, nil, `{"203.0.113.7","10.0.0.0/8"}`, expireAt, false, 500, `["entries:read"]`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "id"}).AddRow(time.Now(), 5))
+	mock.ExpectCommit()
 
-	apiKey, de := repo.FindByKey(context.Background(), "missing-key")
-	if apiKey != nil {
-		t.Fatalf("expected nil apiKey when not found, got %+v", apiKey)
-	}
-	if de == nil {
-		t.Fatalf("expected domain error when key not found")
+	if de := repo.Create(context.Background(), apiKey); de != nil {
+		t.Fatalf("unexpected domain error: %v", de)
 	}
-	if de.GetType() != errors.QueryDataNotFoundError {
-		t.Fatalf("expected QueryDataNotFoundError, got %v", de.GetType())
+	if apiKey.Id != 5 {
+		t.Fatalf("expected id 5, got %d", apiKey.Id)
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -67,7 +74,7 @@ func TestFindByKey_NotFound(t *testing.T) {
 	}
 }
 
-func TestFindByKey_Success(t *testing.T) {
+func TestFindByPrefix_IncludesPreviousKeyInGracePeriod(t *testing.T) {
 	t.Parallel()
 
 	gdb, mock, cleanup := setupMockDB(t)
@@ -75,48 +82,203 @@ func TestFindByKey_Success(t *testing.T) {
 
 	repo := NewApiKeyRepositoryImpl(gdb)
 
-	id := 1
 	userID := uuid.New()
-	projectID := 1
-	name := "Public API Key"
-	key := "public-key-123"
-	var collectionIDs interface{} = nil // use nil to avoid driver string scan issues in sqlmock
-	var ipWhitelist interface{} = nil
-	expireAt := time.Now()
-	revoked := false
-	rateLimit := 1000
-	createdAt := time.Now()
-
 	rows := sqlmock.NewRows([]string{
-		"id", "user_id", "project_id", "name", "key", "collection_ids", "ip_whitelist", "expire_at", "revoked", "rate_limit_per_hour", "created_at",
+		"id", "user_id", "project_id", "name", "key_prefix", "key_hash", "ip_whitelist", "expire_at", "revoked", "rate_limit_per_hour", "created_at",
 	}).AddRow(
-		id, userID.String(), projectID, name, key, collectionIDs, ipWhitelist, expireAt, revoked, rateLimit, createdAt,
+		1, userID.String(), 1, "Public API Key", "Ab12Cd34", "hash", `{203.0.113.7,"10.0.0.0/8"}`, time.Now(), false, 1000, time.Now(),
 	)
 
-	mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE key = \$1 AND revoked = false ORDER BY "api_keys"\."id" LIMIT (?:\$?\d+)`).
-		WithArgs(key, sqlmock.AnyArg()).
+	// 猶予期間中のローテーション前のキーの prefix でも検索する。取り消し済みのキーも理由を返すために検索する
+	mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE key_prefix = \$1 OR \(previous_key_prefix = \$2 AND previous_key_expire_at > \$3\)$`).
+		WithArgs("Ab12Cd34", "Ab12Cd34", sqlmock.AnyArg()).
 		WillReturnRows(rows)
+	// 利用できるコレクションも読み込む。scopes が NULL のコレクションはキーの scopes を使う
+	mock.ExpectQuery(`SELECT \* FROM "api_key_collections" WHERE "api_key_collections"\."api_key_id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"api_key_id", "collection_id", "scopes"}).
+			AddRow(1, 3, `["entries:write"]`).
+			AddRow(1, 4, nil))
 
-	apiKey, de := repo.FindByKey(context.Background(), key)
+	apiKeys, de := repo.FindByPrefix(context.Background(), "Ab12Cd34")
 	if de != nil {
 		t.Fatalf("unexpected domain error: %v", de)
 	}
-	if apiKey == nil {
-		t.Fatalf("expected apiKey, got nil")
+	if len(apiKeys) != 1 || apiKeys[0].KeyPrefix != "Ab12Cd34" || apiKeys[0].KeyHash != "hash" {
+		t.Fatalf("unexpected api keys: %+v", apiKeys)
 	}
-	if apiKey.Key != key {
-		t.Fatalf("expected key %s, got %s", key, apiKey.Key)
+	// ip_whitelist（text[]）と rate_limit_per_hour も読み込む
+	if len(apiKeys[0].IpWhiteList) != 2 || apiKeys[0].IpWhiteList[0] != "203.0.113.7" || apiKeys[0].IpWhiteList[1] != "10.0.0.0/8" ||
+		apiKeys[0].RateLimit != 1000 {
+		t.Fatalf("unexpected ip whitelist or rate limit: %+v", apiKeys[0])
 	}
-	if apiKey.Name != name {
-		t.Fatalf("expected name %s, got %s", name, apiKey.Name)
+	collections := apiKeys[0].Collections
+	if len(collections) != 2 || collections[0].CollectionID != 3 || collections[0].Scopes[0] != "entries:write" ||
+		collections[1].CollectionID != 4 || collections[1].Scopes != nil {
+		t.Fatalf("unexpected collections: %+v", collections)
 	}
-	if apiKey.ProjectID != projectID {
-		t.Fatalf("expected project id %d, got %d", projectID, apiKey.ProjectID)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
 	}
-	// collection ids may be empty slice or nil depending on driver; accept both
-	if apiKey.CollectionIds == nil {
-		// nothing to assert, just log for visibility
-		t.Log("collection ids is nil (driver returned NULL/empty)")
+}
+
+func TestFindByID_ScopedToProject(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewApiKeyRepositoryImpl(gdb)
+
+	// 別のプロジェクトのAPIキーは見つからない
+	mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE project_id = \$1 AND id = \$2 ORDER BY "api_keys"\."id" LIMIT (?:\$?\d+)`).
+		WithArgs(2, 5, sqlmock.AnyArg()).
+		WillReturnError(sql.ErrNoRows)
+
+	apiKey, de := repo.FindByID(context.Background(), 2, 5)
+	if apiKey != nil {
+		t.Fatalf("expected nil apiKey when not found, got %+v", apiKey)
+	}
+	if de == nil || de.GetType() != errors.QueryDataNotFoundError {
+		t.Fatalf("expected QueryDataNotFoundError, got %v", de)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestUpdate_ReplacesCollections(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewApiKeyRepositoryImpl(gdb)
+	collections := []models.ApiKeyCollections{
+		{CollectionID: 3, Scopes: models.CollectionScopes{"entries:write"}},
+		{CollectionID: 4},
+	}
+
+	// 変更したカラムを保存し、利用できるコレクションを削除してから登録し直す。スコープを上書きしないコレクションの scopes は NULL
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_keys" SET "name"=\$1 WHERE id = \$2`).
+		WithArgs("Website", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM "api_key_collections" WHERE api_key_id = \$1`).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO "api_key_collections" \("api_key_id","collection_id","scopes"\) VALUES \(\$1,\$2,\$3\),\(\$4,\$5,\$6\)`).
+		WithArgs(5, 3, `["entries:write"]`, 5, 4, nil).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	if de := repo.Update(context.Background(), 5, map[string]interface{}{"name": "Website"}, collections); de != nil {
+		t.Fatalf("unexpected domain error: %v", de)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestUpdate_OnlyChangedColumns(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewApiKeyRepositoryImpl(gdb)
+
+	// 同時に変更された名前などを上書きしないよう、取り消しだけを保存する。コレクションはそのまま
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_keys" SET "revoked"=\$1 WHERE id = \$2`).
+		WithArgs(true, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if de := repo.Update(context.Background(), 5, map[string]interface{}{"revoked": true}, nil); de != nil {
+		t.Fatalf("unexpected domain error: %v", de)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestRotate_ChangedConcurrently(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewApiKeyRepositoryImpl(gdb)
+	previousPrefix, previousHash := "Ab12Cd34", "hash"
+	expireAt := time.Now().Add(time.Hour)
+	apiKey := &models.ApiKeys{
+		Id: 5, KeyPrefix: "Ef56Gh78", KeyHash: "new-hash",
+		PreviousKeyPrefix: &previousPrefix, PreviousKeyHash: &previousHash, PreviousKeyExpireAt: &expireAt,
+	}
+
+	// 読み込んだ後にローテーション・取り消しされたキーは更新しない
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_keys" SET "key_hash"=\$1,"key_prefix"=\$2,"previous_key_expire_at"=\$3,"previous_key_hash"=\$4,"previous_key_prefix"=\$5 WHERE id = \$6 AND key_prefix = \$7 AND revoked = \$8`).
+		WithArgs("new-hash", "Ef56Gh78", expireAt, previousHash, previousPrefix, 5, "Ab12Cd34", false).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	de := repo.Rotate(context.Background(), apiKey, "Ab12Cd34")
+	if de == nil || de.ErrType != errors.AlreadyExist {
+		t.Fatalf("expected AlreadyExist, got %v", de)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestAttachCollection_Upserts(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewApiKeyRepositoryImpl(gdb)
+
+	// 追加済みのコレクションはスコープだけを置き換える
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "api_key_collections" \("api_key_id","collection_id","scopes"\) VALUES \(\$1,\$2,\$3\) ON CONFLICT \("api_key_id","collection_id"\) DO UPDATE SET "scopes"="excluded"\."scopes"`).
+		WithArgs(5, 3, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if de := repo.AttachCollection(context.Background(), &models.ApiKeyCollections{ApiKeyID: 5, CollectionID: 3}); de != nil {
+		t.Fatalf("unexpected domain error: %v", de)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestDetachCollection_NotAttached(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewApiKeyRepositoryImpl(gdb)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "api_key_collections" WHERE api_key_id = \$1 AND collection_id = \$2`).
+		WithArgs(5, 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	de := repo.DetachCollection(context.Background(), 5, 3)
+	if de == nil || de.GetType() != errors.QueryDataNotFoundError {
+		t.Fatalf("expected QueryDataNotFoundError, got %v", de)
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -1,15 +1,22 @@
 package infrastructure
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"w3st/domain/models"
 	myerrors "w3st/errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// errDuplicatedCollectionSlug api_collections の一意制約は (project_id, slug) だけ
+var errDuplicatedCollectionSlug = myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "同じ slug のコレクションがすでに存在します")
+
 type CollectionsRepository struct {
 	db *gorm.DB
 }
@@ -24,6 +31,9 @@ func (r *CollectionsRepository) CreateCollection(newCollection *models.ApiCollec
 	result := r.db.Create(newCollection)
 
 	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return errDuplicatedCollectionSlug
+		}
 		// クエリの実行中に発生したエラー
 		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
 	}
@@ -34,7 +44,7 @@ func (r *CollectionsRepository) CreateCollection(newCollection *models.ApiCollec
 
 func (r *CollectionsRepository) GetCollectionByProjectId(projectId int) ([]models.ApiCollection, error) {
 	var collection []models.ApiCollection
-	result := r.db.Where("project_id = ?", projectId).Find(&collection)
+	result := r.db.Where("project_id = ? AND deleted_at IS NULL", projectId).Find(&collection)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -52,7 +62,7 @@ func (r *CollectionsRepository) GetCollectionByProjectId(projectId int) ([]model
 
 func (r *CollectionsRepository) GetCollectionsByCollectionId(collectionId int, projectId int) (*models.ApiCollection, error) {
 	var collection models.ApiCollection
-	result := r.db.Where("id = ? AND project_id = ?", collectionId, projectId).First(&collection)
+	result := r.db.Where("id = ? AND project_id = ? AND deleted_at IS NULL", collectionId, projectId).First(&collection)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -64,3 +74,194 @@ func (r *CollectionsRepository) GetCollectionsByCollectionId(collectionId int, p
 
 	return &collection, nil
 }
+
+func (r *CollectionsRepository) GetCollectionsByIds(projectId int, collectionIds []int) ([]models.ApiCollection, error) {
+	collections := []models.ApiCollection{}
+	if len(collectionIds) == 0 {
+		return collections, nil
+	}
+	result := r.db.Where("project_id = ? AND id IN ? AND deleted_at IS NULL", projectId, collectionIds).Order("id").Find(&collections)
+
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+
+	return collections, nil
+}
+
+func (r *CollectionsRepository) GetCollectionBySlug(ctx context.Context, projectId int, slug string) (*models.ApiCollection, error) {
+	var collection models.ApiCollection
+	result := dbFromContext(ctx, r.db).Where("project_id = ? AND slug = ? AND deleted_at IS NULL", projectId, slug).First(&collection)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません")
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+
+	return &collection, nil
+}
+
+func (r *CollectionsRepository) UpdateCollection(ctx context.Context, collectionId int, update models.CollectionUpdate) (*models.ApiCollection, error) {
+	columns := map[string]interface{}{}
+	if update.Name != nil {
+		columns["name"] = *update.Name
+	}
+	if update.Description != nil {
+		columns["description"] = *update.Description
+	}
+	if update.Slug != nil {
+		columns["slug"] = *update.Slug
+	}
+	if update.RejectUnknownFields != nil {
+		columns["reject_unknown_fields"] = *update.RejectUnknownFields
+	}
+
+	// updated_at はトリガーで更新されるため、更新後の行を返してもらう
+	var collection models.ApiCollection
+	result := dbFromContext(ctx, r.db).
+		Model(&collection).
+		Clauses(clause.Returning{}).
+		Where("id = ? AND deleted_at IS NULL", collectionId).
+		Updates(columns)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return nil, errDuplicatedCollectionSlug
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません")
+	}
+
+	return &collection, nil
+}
+
+func (r *CollectionsRepository) LockCollection(ctx context.Context, collectionId int, projectId int) (*models.ApiCollection, error) {
+	var collection models.ApiCollection
+	result := dbFromContext(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND project_id = ?", collectionId, projectId).
+		First(&collection)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません")
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+
+	return &collection, nil
+}
+
+func (r *CollectionsRepository) GetReferencingCollectionIds(ctx context.Context, collectionId int) ([]int, error) {
+	ids := []int{}
+	result := dbFromContext(ctx, r.db).
+		Table("api_kind_relation AS r").
+		Joins("JOIN api_collections AS c ON c.id = r.collection_id").
+		Where("r.related_collection_id = ? AND r.collection_id <> ? AND c.deleted_at IS NULL", collectionId, collectionId).
+		Distinct().
+		Order("r.collection_id").
+		Pluck("r.collection_id", &ids)
+
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+
+	return ids, nil
+}
+
+func (r *CollectionsRepository) SoftDeleteCollection(ctx context.Context, collectionId int, deletedAt time.Time) error {
+	result := dbFromContext(ctx, r.db).
+		Model(&models.ApiCollection{}).
+		Where("id = ? AND deleted_at IS NULL", collectionId).
+		Update("deleted_at", deletedAt)
+
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません")
+	}
+
+	return nil
+}
+
+func (r *CollectionsRepository) RestoreCollection(ctx context.Context, collectionId int) error {
+	result := dbFromContext(ctx, r.db).
+		Model(&models.ApiCollection{}).
+		Where("id = ? AND deleted_at IS NOT NULL", collectionId).
+		Update("deleted_at", nil)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return errDuplicatedCollectionSlug
+		}
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "ゴミ箱にコレクションが見つかりません")
+	}
+
+	return nil
+}
+
+func (r *CollectionsRepository) DeleteCollection(ctx context.Context, collectionId int) error {
+	db := dbFromContext(ctx, r.db)
+	// 外部キーの ON DELETE CASCADE に任せず、参照している側から順に削除する
+	statements := []string{
+		"DELETE FROM api_key_collections WHERE collection_id = @id",
+		"DELETE FROM api_kind_relation WHERE collection_id = @id OR related_collection_id = @id",
+		"DELETE FROM content_versions WHERE content_entry_id IN (SELECT id FROM content_entries WHERE collection_id = @id)",
+		"DELETE FROM content_entries WHERE collection_id = @id",
+		"DELETE FROM entries WHERE collection_id = @id",
+		"DELETE FROM list_options WHERE field_id IN (SELECT id FROM field_data WHERE collection_id = @id)",
+		"DELETE FROM api_fields WHERE collection_id = @id",
+		"DELETE FROM field_data WHERE collection_id = @id",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement, sql.Named("id", collectionId)).Error; err != nil {
+			return myerrors.NewDomainError(myerrors.QueryError, err)
+		}
+	}
+
+	result := db.Where("id = ?", collectionId).Delete(&models.ApiCollection{})
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません")
+	}
+
+	return nil
+}
+
+func (r *CollectionsRepository) GetDeletedCollections(ctx context.Context, projectId int) ([]models.ApiCollection, error) {
+	collections := []models.ApiCollection{}
+	result := dbFromContext(ctx, r.db).
+		Where("project_id = ? AND deleted_at IS NOT NULL", projectId).
+		Order("deleted_at DESC, id").
+		Find(&collections)
+
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+
+	return collections, nil
+}
+
+func (r *CollectionsRepository) GetDeletedCollectionsBefore(ctx context.Context, before time.Time) ([]models.ApiCollection, error) {
+	collections := []models.ApiCollection{}
+	result := dbFromContext(ctx, r.db).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at, id").
+		Find(&collections)
+
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+
+	return collections, nil
+}
@@ -1,11 +1,210 @@
-package infrastructure_test
+package infrastructure
 
 import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func TestCollectionsRepositoryImpl_Make_Success(t *testing.T) {
 	t.Parallel()
 
-	// TODO: Implement test with sqlmock
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewCollectionsRepository(gdb)
+	collection := &models.ApiCollection{UserID: uuid.New(), ProjectID: 1, Name: "posts", Slug: "posts", Description: "記事"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "api_collections" \("user_id","project_id","name","slug","description","reject_unknown_fields","deleted_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7\) RETURNING "created_at","updated_at","id"`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "id"}).AddRow(time.Now(), time.Now(), 3))
+	mock.ExpectCommit()
+	// slug が重複する場合（TranslateError で gorm.ErrDuplicatedKey になる）
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "api_collections"`).WillReturnError(gorm.ErrDuplicatedKey)
+	mock.ExpectRollback()
+
+	if err := repo.CreateCollection(collection); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if collection.ID != 3 {
+		t.Fatalf("expected id to be set, got %d", collection.ID)
+	}
+	if err := repo.CreateCollection(&models.ApiCollection{ProjectID: 1, Name: "posts", Slug: "posts"}); !errors.Is(err, &myerrors.DomainError{ErrType: myerrors.AlreadyExist}) {
+		t.Fatalf("expected already exist, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestCollectionsRepository_ExcludesTrashedCollections(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewCollectionsRepository(gdb)
+
+	mock.ExpectQuery(`SELECT \* FROM "api_collections" WHERE id = \$1 AND project_id = \$2 AND deleted_at IS NULL`).
+		WithArgs(2, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "api_collections" WHERE project_id = \$1 AND id IN \(\$2,\$3\) AND deleted_at IS NULL`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project_id"}).AddRow(3, 1))
+
+	if _, err := repo.GetCollectionsByCollectionId(2, 1); !errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	collections, err := repo.GetCollectionsByIds(1, []int{2, 3})
+	if err != nil || len(collections) != 1 {
+		t.Fatalf("unexpected result: %+v (%v)", collections, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestCollectionsRepository_DeleteCollectionInTransaction(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewCollectionsRepository(gdb)
+	tx := NewTransactionRepositoryImpl(gdb)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "api_collections" WHERE id = \$1 AND project_id = \$2 ORDER BY "api_collections"."id" LIMIT \$3 FOR UPDATE`).
+		WithArgs(2, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project_id"}).AddRow(2, 1))
+	for _, statement := range []string{
+		`DELETE FROM api_key_collections WHERE collection_id = \$1`,
+		`DELETE FROM api_kind_relation WHERE collection_id = \$1 OR related_collection_id = \$2`,
+		`DELETE FROM content_versions WHERE content_entry_id IN \(SELECT id FROM content_entries WHERE collection_id = \$1\)`,
+		`DELETE FROM content_entries WHERE collection_id = \$1`,
+		`DELETE FROM entries WHERE collection_id = \$1`,
+		`DELETE FROM list_options WHERE field_id IN \(SELECT id FROM field_data WHERE collection_id = \$1\)`,
+		`DELETE FROM api_fields WHERE collection_id = \$1`,
+		`DELETE FROM field_data WHERE collection_id = \$1`,
+	} {
+		args := []driver.Value{2}
+		if strings.Contains(statement, `\$2`) {
+			args = append(args, 2)
+		}
+		mock.ExpectExec(statement).WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec(`DELETE FROM "api_collections" WHERE id = \$1`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Do の中ではトランザクションを使う（Begin・Commit は1回だけ）
+	err := tx.Do(context.Background(), func(ctx context.Context) error {
+		if _, err := repo.LockCollection(ctx, 2, 1); err != nil {
+			return err
+		}
+		return repo.DeleteCollection(ctx, 2)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestCollectionsRepository_Trash(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewCollectionsRepository(gdb)
+	ctx := context.Background()
+	deletedAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_collections" SET "deleted_at"=\$1,"updated_at"=\$2 WHERE id = \$3 AND deleted_at IS NULL`).
+		WithArgs(deletedAt, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// すでにゴミ箱にある場合
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_collections" SET "deleted_at"=\$1,"updated_at"=\$2 WHERE id = \$3 AND deleted_at IS NULL`).
+		WithArgs(deletedAt, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_collections" SET "deleted_at"=\$1,"updated_at"=\$2 WHERE id = \$3 AND deleted_at IS NOT NULL`).
+		WithArgs(nil, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT DISTINCT "r"."collection_id" FROM api_kind_relation AS r JOIN api_collections AS c ON c.id = r.collection_id WHERE r.related_collection_id = \$1 AND r.collection_id <> \$2 AND c.deleted_at IS NULL ORDER BY r.collection_id`).
+		WithArgs(2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"collection_id"}).AddRow(3).AddRow(5))
+
+	if err := repo.SoftDeleteCollection(ctx, 2, deletedAt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.SoftDeleteCollection(ctx, 2, deletedAt); !errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := repo.RestoreCollection(ctx, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids, err := repo.GetReferencingCollectionIds(ctx, 2)
+	if err != nil || len(ids) != 2 || ids[0] != 3 || ids[1] != 5 {
+		t.Fatalf("unexpected referencing collections: %v (%v)", ids, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestCollectionsRepository_UpdateCollection(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewCollectionsRepository(gdb)
+	updatedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	name, slug := "Products", "products"
+
+	// 指定した項目だけを変更し、更新後の行を返す
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE "api_collections" SET "name"=\$1,"slug"=\$2,"updated_at"=\$3 WHERE id = \$4 AND deleted_at IS NULL RETURNING \*`).
+		WithArgs(name, slug, sqlmock.AnyArg(), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project_id", "name", "slug", "description", "updated_at"}).
+			AddRow(2, 1, name, slug, "商品", updatedAt))
+	mock.ExpectCommit()
+	// 見つからない場合
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE "api_collections" SET "slug"=\$1,"updated_at"=\$2 WHERE id = \$3 AND deleted_at IS NULL RETURNING \*`).
+		WithArgs(slug, sqlmock.AnyArg(), 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	collection, err := repo.UpdateCollection(context.Background(), 2, models.CollectionUpdate{Name: &name, Slug: &slug, UpdatedAt: updatedAt})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if collection.ID != 2 || collection.Slug != slug || collection.Description != "商品" || !collection.UpdatedAt.Equal(updatedAt) {
+		t.Fatalf("unexpected collection: %+v", collection)
+	}
+	if _, err := repo.UpdateCollection(context.Background(), 4, models.CollectionUpdate{Slug: &slug}); !errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
 }
@@ -1,12 +1,15 @@
 package infrastructure
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 
 	"w3st/domain/models"
 	myerrors "w3st/errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type EntriesRepository struct {
@@ -19,8 +22,8 @@ func NewEntriesRepository(db *gorm.DB) *EntriesRepository {
 	}
 }
 
-func (r *EntriesRepository) CreateEntry(newEntry *models.Entry) error {
-	result := r.db.Create(newEntry)
+func (r *EntriesRepository) CreateEntry(ctx context.Context, newEntry *models.Entry) error {
+	result := dbFromContext(ctx, r.db).Create(newEntry)
 
 	if result.Error != nil {
 		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
@@ -54,8 +57,19 @@ func (r *EntriesRepository) GetEntryByIdAndProjectId(entryId int, projectId int)
 	return &entry, nil
 }
 
-func (r *EntriesRepository) UpdateEntry(entry *models.Entry) error {
-	result := r.db.Save(entry)
+func (r *EntriesRepository) GetEntriesByIds(collectionId int, projectId int, entryIds []int) ([]models.Entry, error) {
+	var entries []models.Entry
+	result := r.db.Where("collection_id = ? AND project_id = ? AND id IN ?", collectionId, projectId, entryIds).Find(&entries)
+
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+
+	return entries, nil
+}
+
+func (r *EntriesRepository) UpdateEntry(ctx context.Context, entry *models.Entry) error {
+	result := dbFromContext(ctx, r.db).Save(entry)
 
 	if result.Error != nil {
 		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
@@ -64,8 +78,8 @@ func (r *EntriesRepository) UpdateEntry(entry *models.Entry) error {
 	return nil
 }
 
-func (r *EntriesRepository) DeleteEntry(entryId int, projectId int) error {
-	result := r.db.Where("id = ? AND project_id = ?", entryId, projectId).Delete(&models.Entry{})
+func (r *EntriesRepository) DeleteEntry(ctx context.Context, entryId int, projectId int) error {
+	result := dbFromContext(ctx, r.db).Where("id = ? AND project_id = ?", entryId, projectId).Delete(&models.Entry{})
 
 	if result.Error != nil {
 		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
@@ -77,3 +91,136 @@ func (r *EntriesRepository) DeleteEntry(entryId int, projectId int) error {
 
 	return nil
 }
+
+func (r *EntriesRepository) LockEntry(ctx context.Context, entryId int, projectId int) (*models.Entry, error) {
+	var entry models.Entry
+	result := dbFromContext(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND project_id = ?", entryId, projectId).
+		First(&entry)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, myerrors.NewDomainError(myerrors.QueryDataNotFoundError, result.Error)
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+
+	return &entry, nil
+}
+
+func (r *EntriesRepository) LockFieldValues(ctx context.Context, collectionId int, fieldId string) error {
+	// コレクションとフィールドごとのアドバイザリーロック。トランザクションの終わりに解放される
+	result := dbFromContext(ctx, r.db).Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", collectionId, fieldId)
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return nil
+}
+
+func (r *EntriesRepository) ExistsFieldValue(ctx context.Context, collectionId int, fieldId string, value string, excludeEntryId int) (bool, error) {
+	var exists bool
+	result := dbFromContext(ctx, r.db).
+		Raw("SELECT EXISTS (SELECT 1 FROM entries WHERE collection_id = ? AND id <> ? AND data -> ? = ?::jsonb)", collectionId, excludeEntryId, fieldId, value).
+		Scan(&exists)
+	if result.Error != nil {
+		return false, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return exists, nil
+}
+
+// optionValueCondition フィールドの値が @value の文字列（select）か、@value を含む配列（multiselect）
+const optionValueCondition = "collection_id = @collection AND (data -> @field = to_jsonb(CAST(@value AS text)) OR data -> @field @> jsonb_build_array(CAST(@value AS text)))"
+
+func (r *EntriesRepository) CountOptionValue(ctx context.Context, collectionId int, fieldId string, value string) (int64, error) {
+	var count int64
+	result := dbFromContext(ctx, r.db).
+		Model(&models.Entry{}).
+		Where(optionValueCondition, sql.Named("collection", collectionId), sql.Named("field", fieldId), sql.Named("value", value)).
+		Count(&count)
+	if result.Error != nil {
+		return 0, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return count, nil
+}
+
+func (r *EntriesRepository) ReplaceOptionValue(ctx context.Context, collectionId int, fieldId string, oldValue string, newValue string) (int64, error) {
+	// 配列の場合は要素の順番を変えずに書き換える
+	result := dbFromContext(ctx, r.db).Exec(`
+		UPDATE entries SET data = jsonb_set(data, ARRAY[CAST(@field AS text)], CASE jsonb_typeof(data -> @field)
+			WHEN 'array' THEN (
+				SELECT jsonb_agg(CASE WHEN t.item = to_jsonb(CAST(@old AS text)) THEN to_jsonb(CAST(@new AS text)) ELSE t.item END ORDER BY t.i)
+				FROM jsonb_array_elements(data -> @field) WITH ORDINALITY AS t(item, i)
+			)
+			ELSE to_jsonb(CAST(@new AS text))
+		END)
+		WHERE collection_id = @collection AND (data -> @field = to_jsonb(CAST(@old AS text)) OR data -> @field @> jsonb_build_array(CAST(@old AS text)))`,
+		sql.Named("collection", collectionId), sql.Named("field", fieldId), sql.Named("old", oldValue), sql.Named("new", newValue))
+	if result.Error != nil {
+		return 0, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *EntriesRepository) LockReferencedEntries(ctx context.Context, collectionId int, entryIds []int) ([]int, error) {
+	ids := []int{}
+	// FOR SHARE で、参照を保存するまで参照先のエントリが削除されないようにする
+	result := dbFromContext(ctx, r.db).
+		Model(&models.Entry{}).
+		Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("collection_id = ? AND id IN ?", collectionId, entryIds).
+		Order("id").
+		Pluck("id", &ids)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return ids, nil
+}
+
+// referenceCondition フィールドの値が @entry のID（one-to-many）か、@entry を含む配列（many-to-many）
+const referenceCondition = "collection_id = @collection AND (data -> @field = to_jsonb(CAST(@entry AS int)) OR data -> @field @> jsonb_build_array(CAST(@entry AS int)))"
+
+func (r *EntriesRepository) GetReferencingEntries(ctx context.Context, collectionId int, fieldId string, entryId int) ([]models.Entry, error) {
+	var entries []models.Entry
+	result := dbFromContext(ctx, r.db).
+		Where(referenceCondition, sql.Named("collection", collectionId), sql.Named("field", fieldId), sql.Named("entry", entryId)).
+		Order("id").
+		Find(&entries)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return entries, nil
+}
+
+func (r *EntriesRepository) LockReferencingEntryIds(ctx context.Context, collectionId int, fieldId string, entryId int) ([]int, error) {
+	ids := []int{}
+	result := dbFromContext(ctx, r.db).
+		Model(&models.Entry{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(referenceCondition, sql.Named("collection", collectionId), sql.Named("field", fieldId), sql.Named("entry", entryId)).
+		Order("id").
+		Pluck("id", &ids)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return ids, nil
+}
+
+func (r *EntriesRepository) RemoveReference(ctx context.Context, collectionId int, fieldId string, entryId int) (int64, error) {
+	// 配列の場合は残りの要素の順番を変えない
+	result := dbFromContext(ctx, r.db).Exec(`
+		UPDATE entries SET data = jsonb_set(data, ARRAY[CAST(@field AS text)], CASE jsonb_typeof(data -> @field)
+			WHEN 'array' THEN COALESCE((
+				SELECT jsonb_agg(t.item ORDER BY t.i)
+				FROM jsonb_array_elements(data -> @field) WITH ORDINALITY AS t(item, i)
+				WHERE t.item <> to_jsonb(CAST(@entry AS int))
+			), '[]')
+			ELSE 'null'
+		END)
+		WHERE `+referenceCondition,
+		sql.Named("collection", collectionId), sql.Named("field", fieldId), sql.Named("entry", entryId))
+	if result.Error != nil {
+		return 0, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return result.RowsAffected, nil
+}
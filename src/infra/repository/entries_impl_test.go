@@ -0,0 +1,140 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestEntriesRepository_ExistsFieldValue(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewEntriesRepository(gdb)
+	ctx := context.Background()
+
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1, hashtext\(\$2\)\)`).
+		WithArgs(3, "sku").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// 値は JSON のまま jsonb として比べ、更新するエントリ自身は除く
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM entries WHERE collection_id = \$1 AND id <> \$2 AND data -> \$3 = \$4::jsonb\)`).
+		WithArgs(3, 10, "sku", `"A-001"`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	if err := repo.LockFieldValues(ctx, 3, "sku"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exists, err := repo.ExistsFieldValue(ctx, 3, "sku", `"A-001"`, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !exists {
+		t.Fatal("expected value to exist")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestEntriesRepository_CountOptionValue(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewEntriesRepository(gdb)
+
+	// select の文字列と multiselect の配列の両方を数える
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "entries" WHERE collection_id = \$1 AND \(data -> \$2 = to_jsonb\(CAST\(\$3 AS text\)\) OR data -> \$4 @> jsonb_build_array\(CAST\(\$5 AS text\)\)\)`).
+		WithArgs(3, "size", "small", "size", "small").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	count, err := repo.CountOptionValue(context.Background(), 3, "size", "small")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2, got %d", count)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestEntriesRepository_ReplaceOptionValue(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewEntriesRepository(gdb)
+
+	mock.ExpectExec(`UPDATE entries SET data = jsonb_set\(data, ARRAY\[CAST\(\$1 AS text\)\], CASE jsonb_typeof\(data -> \$2\).+WHERE collection_id = \$\d+ AND`).
+		WithArgs("size", "size", "small", "s", "size", "s", 3, "size", "small", "size", "small").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	updated, err := repo.ReplaceOptionValue(context.Background(), 3, "size", "small", "s")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated != 4 {
+		t.Fatalf("expected 4, got %d", updated)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestEntriesRepository_RemoveReference(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewEntriesRepository(gdb)
+
+	// ID は null に、配列からは取り除く
+	mock.ExpectExec(`UPDATE entries SET data = jsonb_set\(data, ARRAY\[CAST\(\$1 AS text\)\], CASE jsonb_typeof\(data -> \$2\).+ELSE 'null'.+WHERE collection_id = \$\d+ AND \(data -> \$\d+ = to_jsonb\(CAST\(\$\d+ AS int\)\) OR data -> \$\d+ @> jsonb_build_array\(CAST\(\$\d+ AS int\)\)\)`).
+		WithArgs("author", "author", "author", 10, 3, "author", 10, "author", 10).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	updated, err := repo.RemoveReference(context.Background(), 3, "author", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated != 2 {
+		t.Fatalf("expected 2, got %d", updated)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestEntriesRepository_GetEntriesByIds(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewEntriesRepository(gdb)
+
+	mock.ExpectQuery(`SELECT \* FROM "entries" WHERE collection_id = \$1 AND project_id = \$2 AND id IN \(\$3,\$4\)`).
+		WithArgs(4, 1, 7, 8).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "collection_id", "project_id", "data"}).
+			AddRow(7, 4, 1, `{"name":"alice"}`).
+			AddRow(8, 4, 1, `{"name":"bob"}`))
+
+	entries, err := repo.GetEntriesByIds(4, 1, []int{7, 8})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != 7 || entries[1].ID != 8 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
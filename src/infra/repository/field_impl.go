@@ -1,6 +1,8 @@
 package infrastructure
 
 import (
+	"errors"
+
 	"w3st/domain/models"
 	myerrors "w3st/errors"
 
@@ -31,13 +33,30 @@ func (r *FieldRepository) CreateField(newField *models.FieldData) error {
 
 func (r *FieldRepository) GetFieldsByCollectionId(collectionId int, projectId int) ([]models.FieldData, error) {
 	var fields []models.FieldData
-	result := r.db.Where("collection_id = ? AND project_id = ?", collectionId, projectId).Find(&fields)
+	result := r.db.Preload("Options", orderListOptions).Preload("Relation").Where("collection_id = ? AND project_id = ?", collectionId, projectId).Find(&fields)
 	if result.Error != nil {
 		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
 	}
 	return fields, nil
 }
 
+func (r *FieldRepository) GetField(collectionId int, projectId int, fieldId int) (*models.FieldData, error) {
+	var field models.FieldData
+	result := r.db.Preload("Options", orderListOptions).Preload("Relation").Where("id = ? AND collection_id = ? AND project_id = ?", fieldId, collectionId, projectId).First(&field)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "フィールドが見つかりません")
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return &field, nil
+}
+
+// orderListOptions 選択肢を並び順で読み込む
+func orderListOptions(db *gorm.DB) *gorm.DB {
+	return db.Order("position, id")
+}
+
 func (r *FieldRepository) UpdateField(newField *models.FieldData) error {
 	result := r.db.Save(newField)
 	if result.Error != nil {
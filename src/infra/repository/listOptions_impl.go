@@ -0,0 +1,103 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ListOptionRepository struct {
+	db *gorm.DB
+}
+
+func NewListOptionRepository(db *gorm.DB) *ListOptionRepository {
+	return &ListOptionRepository{
+		db: db,
+	}
+}
+
+var errDuplicatedOptionValue = myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "同じ値の選択肢がすでにあります")
+
+func (r *ListOptionRepository) GetOptionsByFieldId(ctx context.Context, fieldId int) ([]models.ListOption, error) {
+	var options []models.ListOption
+	result := dbFromContext(ctx, r.db).Where("field_id = ?", fieldId).Order("position, id").Find(&options)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return options, nil
+}
+
+func (r *ListOptionRepository) LockOption(ctx context.Context, fieldId int, optionId int) (*models.ListOption, error) {
+	var option models.ListOption
+	result := dbFromContext(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND field_id = ?", optionId, fieldId).
+		First(&option)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "選択肢が見つかりません")
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return &option, nil
+}
+
+func (r *ListOptionRepository) CreateOption(ctx context.Context, option *models.ListOption) error {
+	result := dbFromContext(ctx, r.db).Create(option)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return errDuplicatedOptionValue
+		}
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return nil
+}
+
+func (r *ListOptionRepository) UpdateOption(ctx context.Context, option *models.ListOption) error {
+	result := dbFromContext(ctx, r.db).
+		Model(option).
+		Clauses(clause.Returning{}).
+		Updates(map[string]interface{}{"value": option.Value, "label": option.Label})
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return errDuplicatedOptionValue
+		}
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "選択肢が見つかりません")
+	}
+	return nil
+}
+
+func (r *ListOptionRepository) DeleteOption(ctx context.Context, fieldId int, optionId int) error {
+	result := dbFromContext(ctx, r.db).Where("id = ? AND field_id = ?", optionId, fieldId).Delete(&models.ListOption{})
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "選択肢が見つかりません")
+	}
+	return nil
+}
+
+func (r *ListOptionRepository) UpdateOptionPositions(ctx context.Context, fieldId int, optionIds []int) error {
+	db := dbFromContext(ctx, r.db)
+	for position, optionId := range optionIds {
+		result := db.Model(&models.ListOption{}).
+			Where("id = ? AND field_id = ?", optionId, fieldId).
+			Update("position", position)
+		if result.Error != nil {
+			return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "選択肢が見つかりません")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MFARepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewMFARepositoryImpl(db *gorm.DB) repositories.MFARepository {
+	return &MFARepositoryImpl{db: db}
+}
+
+func (r *MFARepositoryImpl) FindByUserID(ctx context.Context, userID uuid.UUID) (*models.UserMFA, *myerrors.DomainError) {
+	var mfa models.UserMFA
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&mfa)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "二要素認証が設定されていません")
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return &mfa, nil
+}
+
+func (r *MFARepositoryImpl) Save(ctx context.Context, mfa *models.UserMFA) *myerrors.DomainError {
+	if err := r.db.WithContext(ctx).Save(mfa).Error; err != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, err)
+	}
+	return nil
+}
+
+func (r *MFARepositoryImpl) UpdateLastUsedStep(ctx context.Context, userID uuid.UUID, step int64) (bool, *myerrors.DomainError) {
+	result := r.db.WithContext(ctx).Model(&models.UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *MFARepositoryImpl) UpdateLockout(ctx context.Context, userID uuid.UUID, failedAttempts int, lockedUntil *time.Time) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Model(&models.UserMFA{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"failed_attempts": failedAttempts, "locked_until": lockedUntil})
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return nil
+}
+
+func (r *MFARepositoryImpl) Delete(ctx context.Context, userID uuid.UUID) *myerrors.DomainError {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error
+	})
+	if err != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, err)
+	}
+	return nil
+}
+
+func (r *MFARepositoryImpl) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) *myerrors.DomainError {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.MFARecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = models.MFARecoveryCode{ID: uuid.New(), UserID: userID, CodeHash: hash}
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+	if err != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, err)
+	}
+	return nil
+}
+
+func (r *MFARepositoryImpl) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, *myerrors.DomainError) {
+	result := r.db.WithContext(ctx).Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,64 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestMFAUpdateLastUsedStep_Replay(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewMFARepositoryImpl(gdb)
+	userID := uuid.New()
+
+	// 使用済みのステップ以前のコードは条件に一致せず更新件数が0になる
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "user_mfa" SET .* WHERE user_id = \$\d+ AND last_used_step < \$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	updated, de := repo.UpdateLastUsedStep(context.Background(), userID, 100)
+	if de != nil {
+		t.Fatalf("unexpected domain error: %v", de)
+	}
+	if updated {
+		t.Fatalf("expected updated to be false for a replayed step")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestMFAUseRecoveryCode_Success(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewMFARepositoryImpl(gdb)
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "mfa_recovery_codes" SET .* WHERE user_id = \$\d+ AND code_hash = \$\d+ AND used_at IS NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	used, de := repo.UseRecoveryCode(context.Background(), userID, "hash")
+	if de != nil {
+		t.Fatalf("unexpected domain error: %v", de)
+	}
+	if !used {
+		t.Fatalf("expected used to be true")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -3,12 +3,14 @@ package infrastructure
 import (
 	"context"
 	"errors"
+	"time"
 
 	"w3st/domain/models"
 	"w3st/domain/repositories"
 	myerrors "w3st/errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PermissionRepositoryImpl struct {
@@ -19,51 +21,91 @@ func NewPermissionRepositoryImpl(db *gorm.DB) repositories.PermissionRepository
 	return &PermissionRepositoryImpl{db: db}
 }
 
-func (r *PermissionRepositoryImpl) Create(ctx context.Context, permission *models.UserPermission) *myerrors.DomainError {
-	result := r.db.WithContext(ctx).Create(permission)
+// active プロジェクトの期限切れでない権限に絞り込む
+func (r *PermissionRepositoryImpl) active(ctx context.Context, projectID int) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now())
+}
+
+func (r *PermissionRepositoryImpl) CreateBatch(ctx context.Context, permissions []*models.UserPermission) (int, *myerrors.DomainError) {
+	if len(permissions) == 0 {
+		return 0, nil
+	}
+	// 部分インデックスのユニーク制約にも一致するよう、ON CONFLICT の対象は指定しない
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&permissions)
 	if result.Error != nil {
-		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+		return 0, myerrors.NewDomainError(myerrors.QueryError, result.Error)
 	}
-	return nil
+	return int(result.RowsAffected), nil
 }
 
-func (r *PermissionRepositoryImpl) FindByID(ctx context.Context, id string) (*models.UserPermission, *myerrors.DomainError) {
+func (r *PermissionRepositoryImpl) FindByID(ctx context.Context, projectID int, id string) (*models.UserPermission, *myerrors.DomainError) {
 	var permission models.UserPermission
-	result := r.db.WithContext(ctx).Where("id = ?", id).First(&permission)
+	result := r.active(ctx, projectID).Where("id = ?", id).First(&permission)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "権限が見つかりません")
 		}
 		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
 	}
+	loadResources([]*models.UserPermission{&permission})
 	return &permission, nil
 }
 
-func (r *PermissionRepositoryImpl) FindByUserID(ctx context.Context, userID string) ([]*models.UserPermission, *myerrors.DomainError) {
+func (r *PermissionRepositoryImpl) FindByUserID(ctx context.Context, projectID int, userID string) ([]*models.UserPermission, *myerrors.DomainError) {
 	var permissions []*models.UserPermission
-	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&permissions)
+	result := r.active(ctx, projectID).Where("user_id = ?", userID).Order("id").Find(&permissions)
 	if result.Error != nil {
 		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
 	}
+	loadResources(permissions)
 	return permissions, nil
 }
 
-func (r *PermissionRepositoryImpl) FindByUserIDAndResource(ctx context.Context, userID, resource string) ([]*models.UserPermission, *myerrors.DomainError) {
+func (r *PermissionRepositoryImpl) FindByUserIDAndPermission(ctx context.Context, projectID int, userID, permission string) ([]*models.UserPermission, *myerrors.DomainError) {
 	var permissions []*models.UserPermission
-	result := r.db.WithContext(ctx).Where("user_id = ? AND resource = ?", userID, resource).Find(&permissions)
+	result := r.active(ctx, projectID).Where("user_id = ? AND permission_type = ?", userID, permission).Find(&permissions)
 	if result.Error != nil {
 		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
 	}
+	loadResources(permissions)
 	return permissions, nil
 }
 
-func (r *PermissionRepositoryImpl) Delete(ctx context.Context, id string) *myerrors.DomainError {
-	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.UserPermission{})
+func (r *PermissionRepositoryImpl) DeleteByResource(ctx context.Context, projectID int, userIDs []string, permissions []string, resource models.Resource) (int, *myerrors.DomainError) {
+	query := r.db.WithContext(ctx).
+		Where("project_id = ? AND user_id IN ? AND permission_type IN ?", projectID, userIDs, permissions)
+	resourceType, resourceID := resource.Columns()
+	if resourceType == nil {
+		query = query.Where("resource_type IS NULL AND resource_id IS NULL")
+	} else {
+		query = query.Where("resource_type = ? AND resource_id = ?", *resourceType, *resourceID)
+	}
+	result := query.Delete(&models.UserPermission{})
 	if result.Error != nil {
-		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+		return 0, myerrors.NewDomainError(myerrors.QueryError, result.Error)
 	}
-	if result.RowsAffected == 0 {
-		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "権限が見つかりません")
+	return int(result.RowsAffected), nil
+}
+
+func (r *PermissionRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time) (int, *myerrors.DomainError) {
+	result := r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.UserPermission{})
+	if result.Error != nil {
+		return 0, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
+
+// loadResources resource_type, resource_id から Resource を設定する。
+// 読み取れない値はそのまま連結して返し、権限チェックでは一致しないものとして扱う
+func loadResources(permissions []*models.UserPermission) {
+	for _, permission := range permissions {
+		resource, err := permission.ParsedResource()
+		if err != nil {
+			permission.Resource = *permission.ResourceType + ":" + *permission.ResourceID
+			continue
+		}
+		permission.Resource = resource.String()
 	}
-	return nil
 }
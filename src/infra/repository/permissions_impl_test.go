@@ -1,11 +1,111 @@
-package infrastructure_test
+package infrastructure
 
 import (
+	"context"
 	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"w3st/domain/models"
 )
 
-func TestPermissionRepositoryImpl_Create_Success(t *testing.T) {
+func TestPermissionRepositoryImpl_CreateBatch_Success(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewPermissionRepositoryImpl(gdb)
+	resource, err := models.ParseResource("project:1/collection:5/entry:*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	permission := &models.UserPermission{UserID: uuid.New(), ProjectID: 1, Permission: models.PermissionEntriesWrite, Effect: models.PermissionEffectDeny}
+	permission.SetResource(resource)
+
+	// リソースは resource_type, resource_id に分けて保存し、登録済みの組み合わせは無視する
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "user_permissions" .* ON CONFLICT DO NOTHING`).
+		WithArgs(permission.UserID, 1, models.PermissionEntriesWrite, models.PermissionEffectDeny, "project/collection/entry", "1/5/*", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, nil, nil))
+	mock.ExpectCommit()
+
+	created, de := repo.CreateBatch(context.Background(), []*models.UserPermission{permission})
+	if de != nil {
+		t.Fatalf("unexpected error: %v", de)
+	}
+	if created != 1 || permission.ID != 1 {
+		t.Fatalf("expected 1 created permission with id 1, got %d (id %d)", created, permission.ID)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestPermissionRepositoryImpl_FindByUserIDAndPermission_LoadsResource(t *testing.T) {
 	t.Parallel()
 
-	// TODO: Implement test with sqlmock
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewPermissionRepositoryImpl(gdb)
+	userID := uuid.New()
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "permission_type", "effect", "resource_type", "resource_id"}).
+		AddRow(1, userID, models.PermissionEntriesRead, models.PermissionEffectAllow, "project/collection", "1/5").
+		AddRow(2, userID, models.PermissionEntriesRead, models.PermissionEffectAllow, nil, nil).
+		AddRow(3, userID, models.PermissionEntriesRead, models.PermissionEffectAllow, "collection", "5")
+	// 別のプロジェクトの権限と期限切れの権限は返さない
+	mock.ExpectQuery(`SELECT \* FROM "user_permissions" WHERE project_id = \$1 AND \(expires_at IS NULL OR expires_at > \$2\) AND \(user_id = \$3 AND permission_type = \$4\)`).
+		WithArgs(1, sqlmock.AnyArg(), userID.String(), models.PermissionEntriesRead).
+		WillReturnRows(rows)
+
+	permissions, de := repo.FindByUserIDAndPermission(context.Background(), 1, userID.String(), models.PermissionEntriesRead)
+	if de != nil {
+		t.Fatalf("unexpected error: %v", de)
+	}
+
+	want := []string{"project:1/collection:5", "*", "collection:5"}
+	if len(permissions) != len(want) {
+		t.Fatalf("expected %d permissions, got %d", len(want), len(permissions))
+	}
+	for i, permission := range permissions {
+		if permission.Resource != want[i] {
+			t.Fatalf("expected resource %q, got %q", want[i], permission.Resource)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestPermissionRepositoryImpl_DeleteExpired(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewPermissionRepositoryImpl(gdb)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "user_permissions" WHERE expires_at <= \$1`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	deleted, de := repo.DeleteExpired(context.Background(), time.Now())
+	if de != nil {
+		t.Fatalf("unexpected error: %v", de)
+	}
+	if deleted != 3 {
+		t.Fatalf("expected 3 deleted, got %d", deleted)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
 }
@@ -0,0 +1,111 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProjectMemberRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewProjectMemberRepositoryImpl(db *gorm.DB) repositories.ProjectMemberRepository {
+	return &ProjectMemberRepositoryImpl{db: db}
+}
+
+func (r *ProjectMemberRepositoryImpl) FindByProjectAndUser(ctx context.Context, projectID int, userID uuid.UUID) (*models.ProjectMember, *myerrors.DomainError) {
+	var member models.ProjectMember
+	result := r.db.WithContext(ctx).Where("project_id = ? AND user_id = ?", projectID, userID).First(&member)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "プロジェクトのメンバーが見つかりません")
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return &member, nil
+}
+
+func (r *ProjectMemberRepositoryImpl) FindByProjectID(ctx context.Context, projectID int) ([]models.ProjectMemberDetail, *myerrors.DomainError) {
+	var members []models.ProjectMemberDetail
+	result := r.db.WithContext(ctx).Table("project_members").
+		Select("project_members.user_id, users.name, users.email, project_members.role, project_members.created_at").
+		Joins("JOIN users ON users.id = project_members.user_id").
+		Where("project_members.project_id = ?", projectID).
+		Order("project_members.created_at ASC").
+		Scan(&members)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return members, nil
+}
+
+func (r *ProjectMemberRepositoryImpl) FindProjectsByUserID(ctx context.Context, userID uuid.UUID) ([]models.Project, *myerrors.DomainError) {
+	var projects []models.Project
+	result := r.db.WithContext(ctx).
+		Joins("JOIN project_members ON project_members.project_id = projects.id").
+		Where("project_members.user_id = ?", userID).
+		Order("projects.id ASC").
+		Find(&projects)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return projects, nil
+}
+
+func (r *ProjectMemberRepositoryImpl) Create(ctx context.Context, member *models.ProjectMember) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project_id"}, {Name: "user_id"}},
+		DoNothing: true,
+	}).Create(member)
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "このユーザーはすでにプロジェクトのメンバーです")
+	}
+	return nil
+}
+
+func (r *ProjectMemberRepositoryImpl) CreateProjectWithOwner(ctx context.Context, project *models.Project, owner *models.ProjectMember) *myerrors.DomainError {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+		owner.ProjectID = project.ID
+		return tx.Create(owner).Error
+	})
+	if err != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, err)
+	}
+	return nil
+}
+
+func (r *ProjectMemberRepositoryImpl) CountByRole(ctx context.Context, projectID int, role string) (int64, *myerrors.DomainError) {
+	var count int64
+	result := r.db.WithContext(ctx).Model(&models.ProjectMember{}).
+		Where("project_id = ? AND role = ?", projectID, role).
+		Count(&count)
+	if result.Error != nil {
+		return 0, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return count, nil
+}
+
+func (r *ProjectMemberRepositoryImpl) Delete(ctx context.Context, projectID int, userID uuid.UUID) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Where("project_id = ? AND user_id = ?", projectID, userID).Delete(&models.ProjectMember{})
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "プロジェクトのメンバーが見つかりません")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+)
+
+func TestProjectMemberCreate_AlreadyMember(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewProjectMemberRepositoryImpl(gdb)
+	member := &models.ProjectMember{ProjectID: 1, UserID: uuid.New(), Role: models.ProjectRoleEditor}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "project_members" .* ON CONFLICT \("project_id","user_id"\) DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}))
+	mock.ExpectCommit()
+
+	de := repo.Create(context.Background(), member)
+	if de == nil {
+		t.Fatalf("expected domain error")
+	}
+	if de.ErrType != myerrors.AlreadyExist {
+		t.Fatalf("expected AlreadyExist, got %v", de.ErrType)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestProjectMemberDelete_NotFound(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewProjectMemberRepositoryImpl(gdb)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "project_members" WHERE project_id = \$1 AND user_id = \$2`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	de := repo.Delete(context.Background(), 1, uuid.New())
+	if de == nil {
+		t.Fatalf("expected domain error")
+	}
+	if de.ErrType != myerrors.QueryDataNotFoundError {
+		t.Fatalf("expected QueryDataNotFoundError, got %v", de.ErrType)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestProjectMemberFindProjectsByUserID(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewProjectMemberRepositoryImpl(gdb)
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT "projects"."id",.* FROM "projects" JOIN project_members ON project_members.project_id = projects.id WHERE project_members.user_id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Default Project"))
+
+	projects, de := repo.FindProjectsByUserID(context.Background(), userID)
+	if de != nil {
+		t.Fatalf("unexpected error: %v", de)
+	}
+	if len(projects) != 1 || projects[0].Name != "Default Project" {
+		t.Fatalf("unexpected projects: %+v", projects)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+
+	"gorm.io/gorm"
+)
+
+type RelationRepository struct {
+	db *gorm.DB
+}
+
+func NewRelationRepository(db *gorm.DB) *RelationRepository {
+	return &RelationRepository{
+		db: db,
+	}
+}
+
+var errDuplicatedRelationField = myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "このフィールドにはすでにリレーションがあります")
+
+// relationsWithFieldKey フィールドのキーと一緒にリレーションを読み込む（フィールドに結び付いていないリレーションは除く）
+func relationsWithFieldKey(db *gorm.DB) *gorm.DB {
+	return db.Table("api_kind_relation AS r").
+		Select("r.*, f.field_id AS field_key").
+		Joins("JOIN field_data AS f ON f.id = r.field_id")
+}
+
+func (r *RelationRepository) GetRelationsByCollectionId(ctx context.Context, collectionId int) ([]models.ApiKindRelation, error) {
+	relations := []models.ApiKindRelation{}
+	result := relationsWithFieldKey(dbFromContext(ctx, r.db)).Where("r.collection_id = ?", collectionId).Order("r.id").Find(&relations)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return relations, nil
+}
+
+func (r *RelationRepository) GetRelationsByRelatedCollectionId(ctx context.Context, relatedCollectionId int) ([]models.ApiKindRelation, error) {
+	relations := []models.ApiKindRelation{}
+	result := relationsWithFieldKey(dbFromContext(ctx, r.db)).Where("r.related_collection_id = ?", relatedCollectionId).Order("r.id").Find(&relations)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return relations, nil
+}
+
+func (r *RelationRepository) GetRelation(ctx context.Context, collectionId int, relationId int) (*models.ApiKindRelation, error) {
+	var relation models.ApiKindRelation
+	result := relationsWithFieldKey(dbFromContext(ctx, r.db)).Where("r.id = ? AND r.collection_id = ?", relationId, collectionId).Take(&relation)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "リレーションが見つかりません")
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return &relation, nil
+}
+
+func (r *RelationRepository) CreateRelation(ctx context.Context, relation *models.ApiKindRelation) error {
+	result := dbFromContext(ctx, r.db).Create(relation)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return errDuplicatedRelationField
+		}
+		// check_cyclic_relation トリガーが循環するリレーションを拒否する
+		if strings.Contains(result.Error.Error(), "Cyclic relation detected") {
+			return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "リレーションが循環しています")
+		}
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return nil
+}
+
+func (r *RelationRepository) UpdateRelationOnDelete(ctx context.Context, relation *models.ApiKindRelation) error {
+	result := dbFromContext(ctx, r.db).
+		Model(&models.ApiKindRelation{}).
+		Where("id = ? AND collection_id = ?", relation.ID, relation.CollectionID).
+		Update("on_delete", relation.OnDelete)
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "リレーションが見つかりません")
+	}
+	return nil
+}
+
+func (r *RelationRepository) DeleteRelation(ctx context.Context, collectionId int, relationId int) error {
+	result := dbFromContext(ctx, r.db).Where("id = ? AND collection_id = ?", relationId, collectionId).Delete(&models.ApiKindRelation{})
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "リレーションが見つかりません")
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+)
+
+func TestRelationRepository_GetRelationsByRelatedCollectionId(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewRelationRepository(gdb)
+
+	// フィールドのキーと一緒に読み込む
+	mock.ExpectQuery(`SELECT r.\*, f.field_id AS field_key FROM api_kind_relation AS r JOIN field_data AS f ON f.id = r.field_id WHERE r.related_collection_id = \$1 ORDER BY r.id`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "collection_id", "field_id", "related_collection_id", "relation_type", "on_delete", "field_key"}).
+			AddRow(1, 3, 7, 4, "one-to-many", "cascade", "author"))
+
+	relations, err := repo.GetRelationsByRelatedCollectionId(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(relations) != 1 || relations[0].FieldKey != "author" || relations[0].OnDelete != "cascade" {
+		t.Fatalf("unexpected relations: %+v", relations)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestRelationRepository_CreateRelation_Cyclic(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewRelationRepository(gdb)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "api_kind_relation"`).
+		WillReturnError(errors.New("ERROR: Cyclic relation detected (SQLSTATE P0001)"))
+	mock.ExpectRollback()
+
+	err := repo.CreateRelation(context.Background(), &models.ApiKindRelation{CollectionID: 3, FieldID: 7, RelatedCollectionID: 4, RelationType: "one-to-many", OnDelete: "restrict"})
+	var domainErr *myerrors.DomainError
+	if !errors.As(err, &domainErr) || domainErr.ErrType != myerrors.InvalidParameter {
+		t.Fatalf("expected InvalidParameter, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
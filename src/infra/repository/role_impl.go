@@ -0,0 +1,75 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoleRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRoleRepositoryImpl(db *gorm.DB) repositories.RoleRepository {
+	return &RoleRepositoryImpl{db: db}
+}
+
+func (r *RoleRepositoryImpl) FindByProjectID(ctx context.Context, projectID int) ([]models.ProjectRole, *myerrors.DomainError) {
+	var roles []models.ProjectRole
+	result := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("name ASC").Find(&roles)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return roles, nil
+}
+
+func (r *RoleRepositoryImpl) FindByName(ctx context.Context, projectID int, name string) (*models.ProjectRole, *myerrors.DomainError) {
+	return r.findOne(ctx, "project_id = ? AND name = ?", projectID, name)
+}
+
+func (r *RoleRepositoryImpl) FindByID(ctx context.Context, projectID int, id int) (*models.ProjectRole, *myerrors.DomainError) {
+	return r.findOne(ctx, "project_id = ? AND id = ?", projectID, id)
+}
+
+func (r *RoleRepositoryImpl) findOne(ctx context.Context, query string, args ...interface{}) (*models.ProjectRole, *myerrors.DomainError) {
+	var role models.ProjectRole
+	result := r.db.WithContext(ctx).Where(query, args...).First(&role)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "ロールが見つかりません")
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return &role, nil
+}
+
+func (r *RoleRepositoryImpl) Create(ctx context.Context, role *models.ProjectRole) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project_id"}, {Name: "name"}},
+		DoNothing: true,
+	}).Create(role)
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "同じ名前のロールがすでに存在します")
+	}
+	return nil
+}
+
+func (r *RoleRepositoryImpl) Delete(ctx context.Context, projectID int, id int) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Where("project_id = ? AND id = ?", projectID, id).Delete(&models.ProjectRole{})
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "ロールが見つかりません")
+	}
+	return nil
+}
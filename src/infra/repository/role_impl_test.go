@@ -0,0 +1,38 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+)
+
+func TestRoleCreate_DuplicateName(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewRoleRepositoryImpl(gdb)
+	role := &models.ProjectRole{ProjectID: 1, Name: "translator", Permissions: []string{models.PermissionEntriesRead}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "project_roles" .* ON CONFLICT \("project_id","name"\) DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}))
+	mock.ExpectCommit()
+
+	de := repo.Create(context.Background(), role)
+	if de == nil {
+		t.Fatalf("expected domain error")
+	}
+	if de.ErrType != myerrors.AlreadyExist {
+		t.Fatalf("expected AlreadyExist, got %v", de.ErrType)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SessionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewSessionRepositoryImpl(db *gorm.DB) repositories.SessionRepository {
+	return &SessionRepositoryImpl{db: db}
+}
+
+func (r *SessionRepositoryImpl) Create(ctx context.Context, session *models.Session) *myerrors.DomainError {
+	if err := r.db.WithContext(ctx).Create(session).Error; err != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, err)
+	}
+	return nil
+}
+
+func (r *SessionRepositoryImpl) FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, *myerrors.DomainError) {
+	var session models.Session
+	result := r.db.WithContext(ctx).Where("refresh_token_hash = ?", tokenHash).First(&session)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "セッションが見つかりません")
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return &session, nil
+}
+
+func (r *SessionRepositoryImpl) FindActiveByUserID(ctx context.Context, userID uuid.UUID) ([]models.Session, *myerrors.DomainError) {
+	var sessions []models.Session
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND rotated_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return sessions, nil
+}
+
+func (r *SessionRepositoryImpl) IsFamilyActive(ctx context.Context, familyID uuid.UUID) (bool, *myerrors.DomainError) {
+	var count int64
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL AND rotated_at IS NULL AND expires_at > ?", familyID, time.Now()).
+		Count(&count)
+	if result.Error != nil {
+		return false, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return count > 0, nil
+}
+
+func (r *SessionRepositoryImpl) MarkRotated(ctx context.Context, id uuid.UUID) (bool, *myerrors.DomainError) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"rotated_at": now, "last_used_at": now})
+	if result.Error != nil {
+		return false, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *SessionRepositoryImpl) RevokeFamily(ctx context.Context, familyID uuid.UUID) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return nil
+}
+
+func (r *SessionRepositoryImpl) RevokeFamilyByUser(ctx context.Context, userID uuid.UUID, familyID uuid.UUID) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "セッションが見つかりません")
+	}
+	return nil
+}
+
+func (r *SessionRepositoryImpl) RevokeAllByUser(ctx context.Context, userID uuid.UUID) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return nil
+}
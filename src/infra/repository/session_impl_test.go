@@ -0,0 +1,64 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestSessionMarkRotated_AlreadyRotated(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewSessionRepositoryImpl(gdb)
+	id := uuid.New()
+
+	// 使用済みのトークンは条件に一致せず更新件数が0になる
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "sessions" SET .* WHERE id = \$\d+ AND rotated_at IS NULL AND revoked_at IS NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	rotated, de := repo.MarkRotated(context.Background(), id)
+	if de != nil {
+		t.Fatalf("unexpected domain error: %v", de)
+	}
+	if rotated {
+		t.Fatalf("expected rotated to be false for an already rotated token")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestSessionMarkRotated_Success(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewSessionRepositoryImpl(gdb)
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "sessions" SET .* WHERE id = \$\d+ AND rotated_at IS NULL AND revoked_at IS NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rotated, de := repo.MarkRotated(context.Background(), id)
+	if de != nil {
+		t.Fatalf("unexpected domain error: %v", de)
+	}
+	if !rotated {
+		t.Fatalf("expected rotated to be true")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
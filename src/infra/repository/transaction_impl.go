@@ -28,6 +28,8 @@ func (t *TransactionRepositoryImpl) Do(ctx context.Context, f func(ctx context.C
 		return f(ctx)
 	}
 
+	// f が返したエラーは種類（見つからない・競合など）に応じて扱えるようにそのまま返す
+	var fErr error
 	err := t.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		defer func() {
 			if r := recover(); r != nil {
@@ -41,21 +43,13 @@ func (t *TransactionRepositoryImpl) Do(ctx context.Context, f func(ctx context.C
 
 		txCtx := context.WithValue(ctx, txKey, tx)
 
-		if err := f(txCtx); err != nil {
-			if rollbackErr := tx.Rollback().Error; rollbackErr != nil {
-				return errors.NewDomainErrorWithMessage(
-					errors.TransactionError,
-					fmt.Sprintf("トランザクションのロールバックに失敗しました: %v", rollbackErr),
-				)
-			}
-			return errors.NewDomainErrorWithMessage(
-				errors.TransactionError,
-				fmt.Sprintf("トランザクション中にエラーが発生しました: %v", err),
-			)
-		}
-
-		return nil
+		// エラーを返すとロールバックされる
+		fErr = f(txCtx)
+		return fErr
 	})
+	if fErr != nil {
+		return fErr
+	}
 	if err != nil {
 		return errors.NewDomainErrorWithMessage(
 			errors.TransactionError,
@@ -65,3 +59,11 @@ func (t *TransactionRepositoryImpl) Do(ctx context.Context, f func(ctx context.C
 
 	return nil
 }
+
+// dbFromContext Do の中で呼ばれた場合はトランザクションを、そうでなければ db を返す
+func dbFromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey).(*gorm.DB); ok {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
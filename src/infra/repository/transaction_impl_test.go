@@ -1,11 +1,81 @@
 package infrastructure_test
 
 import (
+	"context"
+	"errors"
 	"testing"
+
+	myerrors "w3st/errors"
+	infrastructure "w3st/infra/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 )
 
+func newTransactionMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open gorm db: %v", err)
+	}
+	return gdb, mock
+}
+
 func TestTransactionRepositoryImpl_Do_Success(t *testing.T) {
 	t.Parallel()
 
-	// TODO: Implement test with sqlmock
+	gdb, mock := newTransactionMockDB(t)
+
+	// 入れ子にした場合も、リポジトリの操作も同じトランザクションを使う
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "entries" WHERE id = \$1 AND project_id = \$2`).
+		WithArgs(10, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx := infrastructure.NewTransactionRepositoryImpl(gdb)
+	entriesRepo := infrastructure.NewEntriesRepository(gdb)
+	err := tx.Do(context.Background(), func(ctx context.Context) error {
+		return tx.Do(ctx, func(ctx context.Context) error {
+			return entriesRepo.DeleteEntry(ctx, 10, 1)
+		})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepositoryImpl_Do_ReturnsErrorAsIs(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock := newTransactionMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	// f のエラーはロールバックしてそのまま返す
+	notFound := myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません")
+	err := infrastructure.NewTransactionRepositoryImpl(gdb).Do(context.Background(), func(ctx context.Context) error {
+		return notFound
+	})
+	if !errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
 }
@@ -0,0 +1,89 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// usageBatchSize 1回の INSERT で書き込むバケットの数
+const usageBatchSize = 500
+
+type UsageRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewUsageRepositoryImpl(db *gorm.DB) repositories.UsageRepository {
+	return &UsageRepositoryImpl{db: db}
+}
+
+func (r *UsageRepositoryImpl) AddBuckets(ctx context.Context, buckets []models.UsageBucket) *myerrors.DomainError {
+	if len(buckets) == 0 {
+		return nil
+	}
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "project_id"}, {Name: "api_key_id"}, {Name: "collection_id"}, {Name: "bucket_start"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":        gorm.Expr("usage_buckets.requests + excluded.requests"),
+			"bytes_served":    gorm.Expr("usage_buckets.bytes_served + excluded.bytes_served"),
+			"entries_created": gorm.Expr("usage_buckets.entries_created + excluded.entries_created"),
+		}),
+	}).CreateInBatches(&buckets, usageBatchSize)
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return nil
+}
+
+// Aggregate 期間の区切りは UTC で数える
+func (r *UsageRepositoryImpl) Aggregate(ctx context.Context, query models.UsageQuery) ([]models.UsagePoint, *myerrors.DomainError) {
+	db := r.db.WithContext(ctx).Model(&models.UsageBucket{}).
+		Select("date_trunc(?, bucket_start AT TIME ZONE 'UTC') AS period_start, SUM(requests) AS requests, SUM(bytes_served) AS bytes_served, SUM(entries_created) AS entries_created", query.Granularity).
+		Where("project_id = ? AND bucket_start >= ? AND bucket_start < ?", query.ProjectID, query.From, query.To)
+	if query.ApiKeyID != 0 {
+		db = db.Where("api_key_id = ?", query.ApiKeyID)
+	}
+	if query.CollectionID != 0 {
+		db = db.Where("collection_id = ?", query.CollectionID)
+	}
+
+	points := []models.UsagePoint{}
+	if result := db.Group("period_start").Order("period_start").Scan(&points); result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	for i := range points {
+		// タイムゾーンなしの UTC の時刻として返るため UTC にする
+		start := points[i].PeriodStart
+		points[i].PeriodStart = time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, time.UTC)
+	}
+	return points, nil
+}
+
+func (r *UsageRepositoryImpl) SumRequests(ctx context.Context, projectIDs []int, from time.Time) (map[int]int64, *myerrors.DomainError) {
+	sums := make(map[int]int64, len(projectIDs))
+	if len(projectIDs) == 0 {
+		return sums, nil
+	}
+	var rows []struct {
+		ProjectID int
+		Requests  int64
+	}
+	result := r.db.WithContext(ctx).Model(&models.UsageBucket{}).
+		Select("project_id, SUM(requests) AS requests").
+		Where("project_id IN ? AND bucket_start >= ?", projectIDs, from).
+		Group("project_id").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	for _, row := range rows {
+		sums[row.ProjectID] = row.Requests
+	}
+	return sums, nil
+}
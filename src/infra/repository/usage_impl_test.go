@@ -0,0 +1,103 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"w3st/domain/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestUsageAddBuckets_IncrementsExistingBuckets(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUsageRepositoryImpl(gdb)
+	hour := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	// 同じバケットがある場合は利用量を加える
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "usage_buckets" \("project_id","api_key_id","collection_id","bucket_start","requests","bytes_served","entries_created"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7\),\(\$8,\$9,\$10,\$11,\$12,\$13,\$14\) ON CONFLICT \("project_id","api_key_id","collection_id","bucket_start"\) DO UPDATE SET "bytes_served"=usage_buckets.bytes_served \+ excluded.bytes_served,"entries_created"=usage_buckets.entries_created \+ excluded.entries_created,"requests"=usage_buckets.requests \+ excluded.requests`).
+		WithArgs(1, 10, 100, hour, int64(2), int64(500), int64(1), 1, 10, 0, hour, int64(1), int64(50), int64(0)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	if err := repo.AddBuckets(context.Background(), []models.UsageBucket{
+		{ProjectID: 1, ApiKeyID: 10, CollectionID: 100, BucketStart: hour, Requests: 2, BytesServed: 500, EntriesCreated: 1},
+		{ProjectID: 1, ApiKeyID: 10, CollectionID: 0, BucketStart: hour, Requests: 1, BytesServed: 50},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 書き込む利用量がない場合はDBを使わない
+	if err := repo.AddBuckets(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestUsageAggregate_GroupsByGranularity(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUsageRepositoryImpl(gdb)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	// タイムゾーンなしの UTC の時刻として返る（ドライバーによってはローカルタイムゾーンになる）
+	day := time.Date(2026, 3, 14, 0, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	rows := sqlmock.NewRows([]string{"period_start", "requests", "bytes_served", "entries_created"}).
+		AddRow(day, 10, 1000, 1)
+	mock.ExpectQuery(`SELECT date_trunc\(\$1, bucket_start AT TIME ZONE 'UTC'\) AS period_start, SUM\(requests\) AS requests, SUM\(bytes_served\) AS bytes_served, SUM\(entries_created\) AS entries_created FROM "usage_buckets" WHERE \(project_id = \$2 AND bucket_start >= \$3 AND bucket_start < \$4\) AND api_key_id = \$5 AND collection_id = \$6 GROUP BY "period_start" ORDER BY period_start$`).
+		WithArgs("day", 1, from, to, 10, 100).
+		WillReturnRows(rows)
+
+	points, err := repo.Aggregate(context.Background(), models.UsageQuery{
+		ProjectID: 1, From: from, To: to, Granularity: models.UsageGranularityDay, ApiKeyID: 10, CollectionID: 100,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(points) != 1 || !points[0].PeriodStart.Equal(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)) || points[0].Requests != 10 || points[0].BytesServed != 1000 {
+		t.Fatalf("unexpected points: %+v", points)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestUsageSumRequests_ByProject(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUsageRepositoryImpl(gdb)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"project_id", "requests"}).AddRow(1, 120)
+	mock.ExpectQuery(`SELECT project_id, SUM\(requests\) AS requests FROM "usage_buckets" WHERE project_id IN \(\$1,\$2\) AND bucket_start >= \$3 GROUP BY "project_id"$`).
+		WithArgs(1, 2, from).
+		WillReturnRows(rows)
+
+	sums, err := repo.SumRequests(context.Background(), []int{1, 2}, from)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sums) != 1 || sums[1] != 120 {
+		t.Fatalf("unexpected sums: %v", sums)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
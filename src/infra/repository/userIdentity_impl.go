@@ -0,0 +1,99 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errIdentityConflict 同時に同じ subject で作成された場合にトランザクションを中断する
+var errIdentityConflict = errors.New("identity already linked")
+
+type UserIdentityRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepositoryImpl(db *gorm.DB) repositories.UserIdentityRepository {
+	return &UserIdentityRepositoryImpl{db: db}
+}
+
+func (r *UserIdentityRepositoryImpl) FindBySubject(ctx context.Context, provider string, subject string) (*models.UserIdentity, *myerrors.DomainError) {
+	var identity models.UserIdentity
+	result := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "連携されたユーザーが見つかりません")
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return &identity, nil
+}
+
+func (r *UserIdentityRepositoryImpl) Create(ctx context.Context, identity *models.UserIdentity) *myerrors.DomainError {
+	inserted, err := insertIdentity(r.db.WithContext(ctx), identity)
+	if err != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, err)
+	}
+	if !inserted {
+		return errIdentityAlreadyLinked()
+	}
+	return nil
+}
+
+func (r *UserIdentityRepositoryImpl) CreateWithUser(ctx context.Context, user *models.Users, identity *models.UserIdentity) *myerrors.DomainError {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		inserted, err := insertIdentity(tx, identity)
+		if err != nil {
+			return err
+		}
+		if !inserted {
+			return errIdentityConflict
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errIdentityConflict) {
+			return errIdentityAlreadyLinked()
+		}
+		return myerrors.NewDomainError(myerrors.QueryError, err)
+	}
+	return nil
+}
+
+func (r *UserIdentityRepositoryImpl) UpdateLastLogin(ctx context.Context, id uuid.UUID) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Model(&models.UserIdentity{}).
+		Where("id = ?", id).
+		Update("last_login_at", time.Now())
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return nil
+}
+
+// insertIdentity provider + subject が重複する場合は何もせず false を返す
+func insertIdentity(db *gorm.DB, identity *models.UserIdentity) (bool, error) {
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}, {Name: "subject"}},
+		DoNothing: true,
+	}).Create(identity)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func errIdentityAlreadyLinked() *myerrors.DomainError {
+	return myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "このアカウントはすでに連携されています")
+}
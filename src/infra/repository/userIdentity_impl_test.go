@@ -0,0 +1,40 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+)
+
+func TestUserIdentityCreate_AlreadyLinked(t *testing.T) {
+	t.Parallel()
+
+	gdb, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUserIdentityRepositoryImpl(gdb)
+	identity := &models.UserIdentity{ID: uuid.New(), UserID: uuid.New(), Provider: "auth0", Subject: "auth0|abc"}
+
+	// 同じ provider + subject がすでにある場合は挿入されない
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "user_identities" .* ON CONFLICT \("provider","subject"\) DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "last_login_at", "created_at"}))
+	mock.ExpectCommit()
+
+	de := repo.Create(context.Background(), identity)
+	if de == nil {
+		t.Fatalf("expected domain error")
+	}
+	if de.ErrType != myerrors.AlreadyExist {
+		t.Fatalf("expected AlreadyExist, got %v", de.ErrType)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UserTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepositoryImpl(db *gorm.DB) repositories.UserTokenRepository {
+	return &UserTokenRepositoryImpl{db: db}
+}
+
+func (r *UserTokenRepositoryImpl) Create(ctx context.Context, token *models.UserToken) *myerrors.DomainError {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, err)
+	}
+	return nil
+}
+
+func (r *UserTokenRepositoryImpl) FindByHash(ctx context.Context, purpose string, tokenHash string) (*models.UserToken, *myerrors.DomainError) {
+	var token models.UserToken
+	result := r.db.WithContext(ctx).Where("purpose = ? AND token_hash = ?", purpose, tokenHash).First(&token)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "トークンが見つかりません")
+		}
+		return nil, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return &token, nil
+}
+
+func (r *UserTokenRepositoryImpl) MarkUsed(ctx context.Context, id uuid.UUID) (bool, *myerrors.DomainError) {
+	result := r.db.WithContext(ctx).Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *UserTokenRepositoryImpl) InvalidateByUser(ctx context.Context, userID uuid.UUID, purpose string) *myerrors.DomainError {
+	result := r.db.WithContext(ctx).Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return myerrors.NewDomainError(myerrors.QueryError, result.Error)
+	}
+	return nil
+}
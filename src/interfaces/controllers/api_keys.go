@@ -2,20 +2,29 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
+	"w3st/domain/models"
 	"w3st/dto"
+	"w3st/presenter"
 	"w3st/usecase"
 )
 
+// ApiKeyController APIキーは操作中のプロジェクト（ProjectContextMiddleware で設定した projectID）ごとに管理する
 type ApiKeyController struct {
-	apiKeyUsecase usecase.ApiKeyUsecase
+	BaseController
+	apiKeyUsecase   usecase.ApiKeyUsecase
+	apiKeyPresenter presenter.ApiKeyPresenter
 }
 
-func NewApiKeyController(apiKeyUsecase usecase.ApiKeyUsecase) *ApiKeyController {
+func NewApiKeyController(apiKeyUsecase usecase.ApiKeyUsecase, apiKeyPresenter presenter.ApiKeyPresenter) *ApiKeyController {
 	return &ApiKeyController{
-		apiKeyUsecase: apiKeyUsecase,
+		apiKeyUsecase:   apiKeyUsecase,
+		apiKeyPresenter: apiKeyPresenter,
 	}
 }
 
@@ -26,51 +35,197 @@ func (c *ApiKeyController) CreateApiKey(ctx *gin.Context) {
 		return
 	}
 
-	userID, exists := ctx.Get("userID")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userUUID := c.getUserUUID(ctx)
+	if userUUID == uuid.Nil {
 		return
 	}
 
-	userIDStr, ok := userID.(string)
+	apiKey, err := c.apiKeyUsecase.CreateApiKey(ctx.Request.Context(), models.ApiKeyCreate{
+		UserID:           userUUID,
+		ProjectID:        ctx.GetInt("projectID"),
+		Name:             req.Name,
+		CollectionIds:    req.CollectionIds,
+		IpWhiteList:      req.IpWhiteList,
+		ExpireAt:         req.ExpireAt,
+		RateLimit:        req.RateLimit,
+		Scopes:           req.Scopes,
+		CollectionScopes: req.CollectionScopes,
+	})
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, c.apiKeyPresenter.ResponseIssuedApiKey(apiKey))
+}
+
+func (c *ApiKeyController) GetApiKeys(ctx *gin.Context) {
+	apiKeys, err := c.apiKeyUsecase.ListApiKeys(ctx.Request.Context(), ctx.GetInt("projectID"))
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"api_keys": c.apiKeyPresenter.ResponseApiKeys(apiKeys)})
+}
+
+func (c *ApiKeyController) GetApiKey(ctx *gin.Context) {
+	id, ok := parseApiKeyID(ctx)
+	if !ok {
+		return
+	}
+
+	apiKey, err := c.apiKeyUsecase.GetApiKey(ctx.Request.Context(), ctx.GetInt("projectID"), id)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.apiKeyPresenter.ResponseApiKey(apiKey))
+}
+
+// UpdateApiKey 名前・アクセスできるコレクション・スコープの変更と、キーの取り消し（revoked: true）を行う
+func (c *ApiKeyController) UpdateApiKey(ctx *gin.Context) {
+	id, ok := parseApiKeyID(ctx)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateApiKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userUUID := c.getUserUUID(ctx)
+	if userUUID == uuid.Nil {
+		return
+	}
+
+	apiKey, err := c.apiKeyUsecase.UpdateApiKey(ctx.Request.Context(), userUUID, ctx.GetInt("projectID"), id, models.ApiKeyUpdate{
+		Name:             req.Name,
+		CollectionIds:    req.CollectionIds,
+		Revoked:          req.Revoked,
+		Scopes:           req.Scopes,
+		CollectionScopes: req.CollectionScopes,
+	})
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.apiKeyPresenter.ResponseApiKey(apiKey))
+}
+
+// RotateApiKey 新しいキーを発行する。リクエストボディは省略できる
+func (c *ApiKeyController) RotateApiKey(ctx *gin.Context) {
+	id, ok := parseApiKeyID(ctx)
 	if !ok {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	projectID, exists := ctx.Get("projectID")
-	if !exists {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Project ID not found"})
+	var req dto.RotateApiKeyRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	gracePeriod := usecase.ApiKeyRotationGracePeriod
+	if req.GracePeriodSeconds != nil {
+		gracePeriod = time.Duration(*req.GracePeriodSeconds) * time.Second
+	}
+
+	userUUID := c.getUserUUID(ctx)
+	if userUUID == uuid.Nil {
+		return
+	}
+
+	apiKey, err := c.apiKeyUsecase.RotateApiKey(ctx.Request.Context(), userUUID, ctx.GetInt("projectID"), id, gracePeriod)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.apiKeyPresenter.ResponseIssuedApiKey(apiKey))
+}
+
+func (c *ApiKeyController) DeleteApiKey(ctx *gin.Context) {
+	id, ok := parseApiKeyID(ctx)
+	if !ok {
+		return
+	}
+
+	userUUID := c.getUserUUID(ctx)
+	if userUUID == uuid.Nil {
 		return
 	}
 
-	projectIDInt, ok := projectID.(int)
+	if err := c.apiKeyUsecase.DeleteApiKey(ctx.Request.Context(), userUUID, ctx.GetInt("projectID"), id); err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "API key deleted successfully"})
+}
+
+// AttachCollection キーで利用できるコレクションを追加する。追加済みの場合はスコープを置き換える
+func (c *ApiKeyController) AttachCollection(ctx *gin.Context) {
+	id, ok := parseApiKeyID(ctx)
 	if !ok {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid project ID"})
 		return
 	}
 
-	apiKey, err := c.apiKeyUsecase.CreateApiKey(userIDStr, projectIDInt, req.Name, req.CollectionIds)
+	var req dto.AttachApiKeyCollectionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userUUID := c.getUserUUID(ctx)
+	if userUUID == uuid.Nil {
+		return
+	}
+
+	apiKey, err := c.apiKeyUsecase.AttachCollection(ctx.Request.Context(), userUUID, ctx.GetInt("projectID"), id, req.CollectionID, req.Scopes)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ErrorHandler(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"api_key": apiKey})
+	ctx.JSON(http.StatusOK, c.apiKeyPresenter.ResponseApiKey(apiKey))
 }
 
-func (c *ApiKeyController) ValidateApiKey(ctx *gin.Context) {
-	apiKey := ctx.Query("key")
-	if apiKey == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "API key is required"})
+func (c *ApiKeyController) DetachCollection(ctx *gin.Context) {
+	id, ok := parseApiKeyID(ctx)
+	if !ok {
+		return
+	}
+	collectionID, err := strconv.Atoi(ctx.Param("collectionId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	userUUID := c.getUserUUID(ctx)
+	if userUUID == uuid.Nil {
 		return
 	}
 
-	token, err := c.apiKeyUsecase.ValidateApiKey(apiKey)
+	apiKey, err := c.apiKeyUsecase.DetachCollection(ctx.Request.Context(), userUUID, ctx.GetInt("projectID"), id, collectionID)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		ErrorHandler(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"token": token})
+	ctx.JSON(http.StatusOK, c.apiKeyPresenter.ResponseApiKey(apiKey))
+}
+
+func parseApiKeyID(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return 0, false
+	}
+	return id, true
 }
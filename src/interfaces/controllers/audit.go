@@ -38,13 +38,8 @@ func (c *AuditController) LogAction(ctx *gin.Context) {
 		return
 	}
 
-	// プロジェクトIDを取得（APIキー認証の場合はコンテキストから、GUIの場合はデフォルト）
-	projectID := 1 // デフォルト
-	if pID, exists := ctx.Get("projectID"); exists {
-		if pid, ok := pID.(int); ok {
-			projectID = pid
-		}
-	}
+	// プロジェクトIDは ProjectContextMiddleware で設定される
+	projectID := ctx.GetInt("projectID")
 
 	// アクションログ
 	err := c.auditUsecase.LogActionWithProject(ctx.Request.Context(), userUUID, projectID, input.Action, input.Resource, input.Details)
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"w3st/domain/models"
 	myerrors "w3st/errors"
 )
 
@@ -31,6 +32,14 @@ func (c *BaseController) getUserUUID(ctx *gin.Context) uuid.UUID {
 	return userUUID
 }
 
+// getProjectRole 操作中のプロジェクトでのロール。システム管理者はメンバーでなくてもすべての操作ができるため owner として扱う
+func (c *BaseController) getProjectRole(ctx *gin.Context) string {
+	if ctx.GetString("userRole") == models.UserRoleAdmin {
+		return models.ProjectRoleOwner
+	}
+	return ctx.GetString("projectRole")
+}
+
 func (c *BaseController) marshalDataToString(data interface{}) (string, error) {
 	dataBytes, err := json.Marshal(data)
 	if err != nil {
@@ -31,6 +31,12 @@ func ErrorHandle(domainErr *myerrors.DomainError) *connect.Error {
 	case myerrors.QueryDataNotFoundError:
 		logger.Error(domainErr.Error())
 		return connect.NewError(connect.CodeNotFound, domainErr)
+		// 認証に失敗した場合
+	case myerrors.Unauthenticated:
+		return connect.NewError(connect.CodeUnauthenticated, domainErr)
+		// 入力の検証エラー（ErrorHandler では 422 として項目ごとのエラーを返す）
+	case myerrors.ValidationFailed:
+		return connect.NewError(connect.CodeInvalidArgument, domainErr)
 		// トランザクションエラー
 	case myerrors.TransactionError:
 		logger.Error(domainErr.Error())
@@ -55,6 +61,8 @@ func HttpStatusCodeFromConnectCode(code connect.Code) int {
 		return http.StatusInternalServerError
 	case connect.CodeNotFound:
 		return http.StatusNotFound
+	case connect.CodeUnauthenticated:
+		return http.StatusUnauthorized
 	default:
 		return http.StatusInternalServerError
 	}
@@ -65,6 +73,13 @@ func ErrorHandler(c *gin.Context, err error) {
 	// エラーが connect.Error 型かどうかを確認
 	var domainErr *myerrors.DomainError
 	if errors.As(err, &domainErr) {
+		// 検証エラーは項目ごとのエラーをまとめて返す
+		var validationErr *myerrors.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Validation failed", "details": validationErr.Errors})
+			return
+		}
+
 		// connect.Error に変換
 		connectErr := ErrorHandle(domainErr)
 
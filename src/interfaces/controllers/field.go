@@ -67,6 +67,7 @@ func (f *FieldController) Create(ctx *gin.Context) {
 		FieldType:    input.FieldType,
 		IsRequired:   input.IsRequired,
 		DefaultValue: input.DefaultValue,
+		Validations:  fieldValidationsFromDTO(input.Validations),
 	}
 
 	err = f.fieldUsecase.Create(projectIDInt, newField)
@@ -135,6 +136,7 @@ func (f *FieldController) Update(ctx *gin.Context) {
 		FieldType:    input.FieldType,
 		IsRequired:   input.IsRequired,
 		DefaultValue: input.DefaultValue,
+		Validations:  fieldValidationsFromDTO(input.Validations),
 	}
 
 	// フィールドの更新
@@ -192,3 +194,19 @@ func (f *FieldController) Delete(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Field deleted successfully"})
 }
+
+// fieldValidationsFromDTO リクエストの検証ルールをモデルに変換する
+func fieldValidationsFromDTO(input dto.FieldValidations) models.FieldValidations {
+	return models.FieldValidations{
+		MinLength:      input.MinLength,
+		MaxLength:      input.MaxLength,
+		Min:            input.Min,
+		Max:            input.Max,
+		Pattern:        input.Pattern,
+		PatternMessage: input.PatternMessage,
+		Enum:           input.Enum,
+		MinItems:       input.MinItems,
+		MaxItems:       input.MaxItems,
+		Unique:         input.Unique,
+	}
+}
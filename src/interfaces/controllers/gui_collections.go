@@ -60,14 +60,16 @@ func (c *GUICollectionsController) MakeCollection(ctx *gin.Context) {
 
 	// コレクション
 	newCollection := &models.ApiCollection{
-		UserID:      userUuid,
-		ProjectID:   projectID,
-		Name:        input.Name,
-		Description: input.Description,
+		UserID:              userUuid,
+		ProjectID:           projectID,
+		Name:                input.Name,
+		Slug:                input.Slug,
+		Description:         input.Description,
+		RejectUnknownFields: input.RejectUnknownFields,
 	}
 
 	// collectionを作成
-	err = c.collectionUsecase.Make(newCollection)
+	err = c.collectionUsecase.Make(ctx.Request.Context(), newCollection)
 	if err != nil {
 		var domainErr *myerrors.DomainError
 		if errors.As(err, &domainErr) {
@@ -78,7 +80,7 @@ func (c *GUICollectionsController) MakeCollection(ctx *gin.Context) {
 		return
 	}
 	// レスポンスを返す
-	ctx.JSON(http.StatusOK, gin.H{"message": "Collection created successfully", "id": newCollection.ID})
+	ctx.JSON(http.StatusOK, gin.H{"message": "Collection created successfully", "id": newCollection.ID, "slug": newCollection.Slug})
 }
 
 // CreateField - GUI用：フィールド作成
@@ -110,6 +112,7 @@ func (c *GUICollectionsController) CreateField(ctx *gin.Context) {
 		FieldType:    input.FieldType,
 		IsRequired:   input.IsRequired,
 		DefaultValue: input.DefaultValue,
+		Validations:  fieldValidationsFromDTO(input.Validations),
 	}
 
 	err = c.fieldUsecase.Create(projectID, fieldData)
@@ -163,6 +166,7 @@ func (c *GUICollectionsController) UpdateField(ctx *gin.Context) {
 		FieldType:    input.FieldType,
 		IsRequired:   input.IsRequired,
 		DefaultValue: input.DefaultValue,
+		Validations:  fieldValidationsFromDTO(input.Validations),
 	}
 
 	err = c.fieldUsecase.Update(projectID, fieldData)
@@ -250,7 +254,7 @@ func (c *GUICollectionsController) GetCollections(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, collections)
 }
 
-// UpdateCollection - GUI用：コレクション更新
+// UpdateCollection - GUI用：コレクション更新（省略した項目は変更しない）
 func (c *GUICollectionsController) UpdateCollection(ctx *gin.Context) {
 	collectionId := ctx.Param("collectionId")
 
@@ -261,7 +265,7 @@ func (c *GUICollectionsController) UpdateCollection(ctx *gin.Context) {
 		return
 	}
 
-	var input dto.MakeCollection
+	var input dto.UpdateCollection
 	if err := ctx.ShouldBindJSON(&input); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -270,8 +274,14 @@ func (c *GUICollectionsController) UpdateCollection(ctx *gin.Context) {
 	// プロジェクトIDを取得
 	projectID := ctx.GetInt("projectID")
 
-	// コレクションを取得して更新
-	existingCollection, err := c.collectionUsecase.GetCollectionsByCollectionId(collectionIdInt, projectID)
+	update := models.CollectionUpdate{
+		Name:                input.Name,
+		Description:         input.Description,
+		Slug:                input.Slug,
+		RejectUnknownFields: input.RejectUnknownFields,
+		UpdatedAt:           input.UpdatedAt,
+	}
+	collection, err := c.collectionUsecase.Update(ctx.Request.Context(), collectionIdInt, projectID, update)
 	if err != nil {
 		var domainErr *myerrors.DomainError
 		if errors.As(err, &domainErr) {
@@ -282,11 +292,31 @@ func (c *GUICollectionsController) UpdateCollection(ctx *gin.Context) {
 		return
 	}
 
-	// 更新
-	existingCollection.Name = input.Name
-	existingCollection.Description = input.Description
+	ctx.JSON(http.StatusOK, collection)
+}
+
+// DeleteCollection - GUI用：コレクション削除（?permanent=true でなければゴミ箱に移す）
+func (c *GUICollectionsController) DeleteCollection(ctx *gin.Context) {
+	collectionId := ctx.Param("collectionId")
 
-	err = c.collectionUsecase.Make(existingCollection)
+	// int型に変換
+	collectionIdInt, err := strconv.Atoi(collectionId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Collection ID"})
+		return
+	}
+
+	var query dto.DeleteCollectionQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// プロジェクトIDを取得
+	projectID := ctx.GetInt("projectID")
+
+	opts := usecase.DeleteCollectionOptions{Force: query.Force, Permanent: query.Permanent}
+	err = c.collectionUsecase.Delete(ctx.Request.Context(), collectionIdInt, projectID, opts)
 	if err != nil {
 		var domainErr *myerrors.DomainError
 		if errors.As(err, &domainErr) {
@@ -297,11 +327,56 @@ func (c *GUICollectionsController) UpdateCollection(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Collection updated successfully"})
+	if query.Permanent {
+		ctx.JSON(http.StatusOK, gin.H{"message": "Collection deleted successfully"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Collection moved to trash"})
 }
 
-// DeleteCollection - GUI用：コレクション削除
-func (c *GUICollectionsController) DeleteCollection(ctx *gin.Context) {
-	// TODO: コレクション削除のusecaseを実装
-	ctx.JSON(http.StatusOK, gin.H{"message": "Collection deleted successfully"})
+// GetTrash - GUI用：ゴミ箱にあるコレクション一覧取得
+func (c *GUICollectionsController) GetTrash(ctx *gin.Context) {
+	// プロジェクトIDを取得
+	projectID := ctx.GetInt("projectID")
+
+	collections, err := c.collectionUsecase.GetTrash(ctx.Request.Context(), projectID)
+	if err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, collections)
+}
+
+// RestoreCollection - GUI用：ゴミ箱からコレクションを戻す
+func (c *GUICollectionsController) RestoreCollection(ctx *gin.Context) {
+	collectionId := ctx.Param("collectionId")
+
+	// int型に変換
+	collectionIdInt, err := strconv.Atoi(collectionId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Collection ID"})
+		return
+	}
+
+	// プロジェクトIDを取得
+	projectID := ctx.GetInt("projectID")
+
+	collection, err := c.collectionUsecase.Restore(ctx.Request.Context(), collectionIdInt, projectID)
+	if err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, collection)
 }
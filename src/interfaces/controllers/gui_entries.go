@@ -39,7 +39,7 @@ func (c *GUIEntriesController) GetEntries(ctx *gin.Context) {
 	projectID := ctx.GetInt("projectID")
 
 	// entriesを取得
-	entries, err := c.entriesUsecase.GetEntriesByCollectionId(collectionIdInt, projectID)
+	entries, err := c.entriesUsecase.GetEntriesByCollectionId(collectionIdInt, projectID, ctx.Query("populate"))
 	if err != nil {
 		var domainErr *myerrors.DomainError
 		if errors.As(err, &domainErr) {
@@ -89,7 +89,7 @@ func (c *GUIEntriesController) CreateEntry(ctx *gin.Context) {
 	}
 
 	// entryを作成
-	err = c.entriesUsecase.CreateEntry(newEntry, projectID)
+	err = c.entriesUsecase.CreateEntry(ctx.Request.Context(), newEntry, projectID)
 	if err != nil {
 		var domainErr *myerrors.DomainError
 		if errors.As(err, &domainErr) {
@@ -125,7 +125,7 @@ func (c *GUIEntriesController) UpdateEntry(ctx *gin.Context) {
 	}
 
 	// entryを更新
-	err = c.entriesUsecase.UpdateEntry(entryIdInt, input.Data, projectID)
+	err = c.entriesUsecase.UpdateEntry(ctx.Request.Context(), entryIdInt, input.Data, projectID)
 	if err != nil {
 		var domainErr *myerrors.DomainError
 		if errors.As(err, &domainErr) {
@@ -154,7 +154,7 @@ func (c *GUIEntriesController) DeleteEntry(ctx *gin.Context) {
 	projectID := ctx.GetInt("projectID")
 
 	// entryを削除
-	err = c.entriesUsecase.DeleteEntry(entryIdInt, projectID)
+	err = c.entriesUsecase.DeleteEntry(ctx.Request.Context(), entryIdInt, projectID)
 	if err != nil {
 		var domainErr *myerrors.DomainError
 		if errors.As(err, &domainErr) {
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"w3st/domain/models"
+	"w3st/dto"
+	"w3st/usecase"
+)
+
+type ListOptionsController struct {
+	listOptionsUsecase usecase.ListOptionsUsecase
+}
+
+func NewListOptionsController(listOptionsUsecase usecase.ListOptionsUsecase) *ListOptionsController {
+	return &ListOptionsController{
+		listOptionsUsecase: listOptionsUsecase,
+	}
+}
+
+// parseFieldPath パスの collectionId と fieldId を返す。正しくない場合はレスポンスを返して ok を false にする
+func parseFieldPath(ctx *gin.Context) (collectionId int, fieldId int, ok bool) {
+	collectionId, err := strconv.Atoi(ctx.Param("collectionId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Collection ID"})
+		return 0, 0, false
+	}
+	fieldId, err = strconv.Atoi(ctx.Param("fieldId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Field ID"})
+		return 0, 0, false
+	}
+	return collectionId, fieldId, true
+}
+
+// parseOptionPath パスの collectionId, fieldId, optionId を返す
+func parseOptionPath(ctx *gin.Context) (collectionId int, fieldId int, optionId int, ok bool) {
+	collectionId, fieldId, ok = parseFieldPath(ctx)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	optionId, err := strconv.Atoi(ctx.Param("optionId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Option ID"})
+		return 0, 0, 0, false
+	}
+	return collectionId, fieldId, optionId, true
+}
+
+// GetOptions - GUI用：フィールドの選択肢を並び順で取得
+func (c *ListOptionsController) GetOptions(ctx *gin.Context) {
+	collectionId, fieldId, ok := parseFieldPath(ctx)
+	if !ok {
+		return
+	}
+
+	options, err := c.listOptionsUsecase.GetOptions(ctx.Request.Context(), ctx.GetInt("projectID"), collectionId, fieldId)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, options)
+}
+
+// CreateOption - GUI用：選択肢を最後に追加
+func (c *ListOptionsController) CreateOption(ctx *gin.Context) {
+	collectionId, fieldId, ok := parseFieldPath(ctx)
+	if !ok {
+		return
+	}
+
+	var input dto.CreateListOption
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	option := &models.ListOption{FieldID: fieldId, Value: input.Value, Label: input.Label}
+	if err := c.listOptionsUsecase.CreateOption(ctx.Request.Context(), ctx.GetInt("projectID"), collectionId, option); err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, option)
+}
+
+// UpdateOption - GUI用：選択肢の値・表示名を変更（値を変更した場合はエントリの値も書き換える）
+func (c *ListOptionsController) UpdateOption(ctx *gin.Context) {
+	collectionId, fieldId, optionId, ok := parseOptionPath(ctx)
+	if !ok {
+		return
+	}
+
+	var input dto.UpdateListOption
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := models.ListOptionUpdate{Value: input.Value, Label: input.Label}
+	option, entriesUpdated, err := c.listOptionsUsecase.UpdateOption(ctx.Request.Context(), ctx.GetInt("projectID"), collectionId, fieldId, optionId, update)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"option": option, "entries_updated": entriesUpdated})
+}
+
+// DeleteOption - GUI用：選択肢を削除（エントリで使われている場合は 409）
+func (c *ListOptionsController) DeleteOption(ctx *gin.Context) {
+	collectionId, fieldId, optionId, ok := parseOptionPath(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.listOptionsUsecase.DeleteOption(ctx.Request.Context(), ctx.GetInt("projectID"), collectionId, fieldId, optionId); err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Option deleted successfully"})
+}
+
+// ReorderOptions - GUI用：選択肢を並べ替え
+func (c *ListOptionsController) ReorderOptions(ctx *gin.Context) {
+	collectionId, fieldId, ok := parseFieldPath(ctx)
+	if !ok {
+		return
+	}
+
+	var input dto.ReorderListOptions
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	options, err := c.listOptionsUsecase.ReorderOptions(ctx.Request.Context(), ctx.GetInt("projectID"), collectionId, fieldId, input.OptionIDs)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, options)
+}
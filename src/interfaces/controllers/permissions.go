@@ -1,42 +1,37 @@
 package controllers
 
 import (
-	"context"
-	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"w3st/domain/models"
 	"w3st/dto"
-	myerrors "w3st/errors"
+	"w3st/presenter"
 	"w3st/usecase"
 )
 
-type PermissionActionOptions struct {
-	Action         func(context.Context, uuid.UUID, string, string) error
-	SuccessMessage string
-	StatusCode     int
-}
-
+// PermissionController 個別の権限は操作中のプロジェクト（ProjectContextMiddleware で設定した projectID）ごとに管理する
 type PermissionController struct {
 	BaseController
-	permissionUsecase usecase.PermissionUsecase
+	permissionUsecase   usecase.PermissionUsecase
+	permissionPresenter presenter.PermissionPresenter
 }
 
-func NewPermissionController(permissionUsecase usecase.PermissionUsecase) *PermissionController {
+func NewPermissionController(permissionUsecase usecase.PermissionUsecase, permissionPresenter presenter.PermissionPresenter) *PermissionController {
 	return &PermissionController{
-		permissionUsecase: permissionUsecase,
+		permissionUsecase:   permissionUsecase,
+		permissionPresenter: permissionPresenter,
 	}
 }
 
-// handlePermissionAction handles grant or revoke permission actions
-func (c *PermissionController) handlePermissionAction(ctx *gin.Context, opts PermissionActionOptions) {
-	var input dto.CreatePermission
+func (c *PermissionController) CheckPermission(ctx *gin.Context) {
+	permission := ctx.Query("permission")
+	resource := ctx.Query("resource")
 
-	// リクエストのバインド
-	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if permission == "" || resource == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "permission and resource are required"})
 		return
 	}
 
@@ -45,22 +40,18 @@ func (c *PermissionController) handlePermissionAction(ctx *gin.Context, opts Per
 		return
 	}
 
-	// 権限操作
-	err := opts.Action(ctx.Request.Context(), userUUID, input.Permission, input.Resource)
+	// 権限チェック
+	hasPermission, err := c.permissionUsecase.CheckPermission(ctx.Request.Context(), ctx.GetInt("projectID"), userUUID, permission, resource)
 	if err != nil {
-		var domainErr *myerrors.DomainError
-		if errors.As(err, &domainErr) {
-			ErrorHandler(ctx, err)
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		ErrorHandler(ctx, err)
 		return
 	}
 
-	ctx.JSON(opts.StatusCode, gin.H{"message": opts.SuccessMessage})
+	ctx.JSON(http.StatusOK, gin.H{"has_permission": hasPermission})
 }
 
-func (c *PermissionController) CheckPermission(ctx *gin.Context) {
+// ExplainPermission user_id（省略時はログイン中のユーザー）の権限チェックの結果と、根拠になった権限を返す
+func (c *PermissionController) ExplainPermission(ctx *gin.Context) {
 	permission := ctx.Query("permission")
 	resource := ctx.Query("resource")
 
@@ -73,36 +64,129 @@ func (c *PermissionController) CheckPermission(ctx *gin.Context) {
 	if userUUID == uuid.Nil {
 		return
 	}
-
-	// 権限チェック
-	hasPermission, err := c.permissionUsecase.CheckPermission(ctx.Request.Context(), userUUID, permission, resource)
-	if err != nil {
-		var domainErr *myerrors.DomainError
-		if errors.As(err, &domainErr) {
-			ErrorHandler(ctx, err)
+	if userID := ctx.Query("user_id"); userID != "" {
+		parsed, err := uuid.Parse(userID)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		userUUID = parsed
+	}
+
+	explanation, err := c.permissionUsecase.ExplainPermission(ctx.Request.Context(), ctx.GetInt("projectID"), userUUID, permission, resource)
+	if err != nil {
+		ErrorHandler(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"has_permission": hasPermission})
+	ctx.JSON(http.StatusOK, c.permissionPresenter.ResponseExplanation(explanation))
 }
 
 func (c *PermissionController) GrantPermission(ctx *gin.Context) {
-	c.handlePermissionAction(ctx, PermissionActionOptions{
-		Action:         c.permissionUsecase.GrantPermission,
-		SuccessMessage: "Permission granted",
-		StatusCode:     http.StatusCreated,
+	var input dto.CreatePermission
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.permissionUsecase.GrantPermissions(ctx.Request.Context(), models.PermissionGrant{
+		ProjectID:   ctx.GetInt("projectID"),
+		UserIDs:     []uuid.UUID{uuid.MustParse(input.UserID)},
+		Permissions: []string{input.Permission},
+		Resource:    input.Resource,
+		Effect:      input.Effect,
+		ExpiresAt:   input.ExpiresAt,
+		ActorRole:   c.getProjectRole(ctx),
 	})
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, c.permissionPresenter.ResponseBatch("Permission granted", result))
 }
 
 func (c *PermissionController) RevokePermission(ctx *gin.Context) {
-	c.handlePermissionAction(ctx, PermissionActionOptions{
-		Action:         c.permissionUsecase.RevokePermission,
-		SuccessMessage: "Permission revoked",
-		StatusCode:     http.StatusOK,
+	var input dto.CreatePermission
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.permissionUsecase.RevokePermissions(ctx.Request.Context(), ctx.GetInt("projectID"),
+		[]uuid.UUID{uuid.MustParse(input.UserID)}, []string{input.Permission}, input.Resource)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.permissionPresenter.ResponseBatch("Permission revoked", result))
+}
+
+// BulkGrantPermissions 複数のユーザーに複数の権限をまとめて付与する。登録済みの組み合わせはスキップする
+func (c *PermissionController) BulkGrantPermissions(ctx *gin.Context) {
+	var input dto.BulkPermission
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.permissionUsecase.GrantPermissions(ctx.Request.Context(), models.PermissionGrant{
+		ProjectID:   ctx.GetInt("projectID"),
+		UserIDs:     parseUUIDs(input.UserIDs),
+		Permissions: input.Permissions,
+		Resource:    input.Resource,
+		Effect:      input.Effect,
+		ExpiresAt:   input.ExpiresAt,
+		ActorRole:   c.getProjectRole(ctx),
 	})
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, c.permissionPresenter.ResponseBatch("Permissions granted", result))
+}
+
+// BulkRevokePermissions 複数のユーザーから複数の権限をまとめて削除する
+func (c *PermissionController) BulkRevokePermissions(ctx *gin.Context) {
+	var input dto.BulkPermission
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.permissionUsecase.RevokePermissions(ctx.Request.Context(), ctx.GetInt("projectID"),
+		parseUUIDs(input.UserIDs), input.Permissions, input.Resource)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.permissionPresenter.ResponseBatch("Permissions revoked", result))
+}
+
+// CopyPermissions 別のプロジェクトでのユーザーの権限を操作中のプロジェクトにコピーする
+func (c *PermissionController) CopyPermissions(ctx *gin.Context) {
+	var input dto.CopyPermissions
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userUUID := c.getUserUUID(ctx)
+	if userUUID == uuid.Nil {
+		return
+	}
+
+	result, err := c.permissionUsecase.CopyPermissions(ctx.Request.Context(), userUUID, c.getProjectRole(ctx),
+		uuid.MustParse(input.UserID), input.FromProjectID, ctx.GetInt("projectID"))
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, c.permissionPresenter.ResponseBatch("Permissions copied", result))
 }
 
 func (c *PermissionController) GetPermissionsByUser(ctx *gin.Context) {
@@ -112,28 +196,21 @@ func (c *PermissionController) GetPermissionsByUser(ctx *gin.Context) {
 	}
 
 	// ユーザー権限一覧取得
-	permissions, err := c.permissionUsecase.GetPermissionsByUser(ctx.Request.Context(), userUUID)
+	permissions, err := c.permissionUsecase.GetPermissionsByUser(ctx.Request.Context(), ctx.GetInt("projectID"), userUUID)
 	if err != nil {
-		var domainErr *myerrors.DomainError
-		if errors.As(err, &domainErr) {
-			ErrorHandler(ctx, err)
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		ErrorHandler(ctx, err)
 		return
 	}
 
 	// レスポンス
-	responses := make([]dto.PermissionResponse, 0, len(permissions))
-	for _, perm := range permissions {
-		responses = append(responses, dto.PermissionResponse{
-			ID:         perm.ID.String(),
-			UserID:     perm.UserID.String(),
-			Permission: perm.Permission,
-			Resource:   perm.Resource,
-			CreatedAt:  perm.CreatedAt.String(),
-			UpdatedAt:  perm.UpdatedAt.String(),
-		})
-	}
-	ctx.JSON(http.StatusOK, responses)
+	ctx.JSON(http.StatusOK, c.permissionPresenter.ResponsePermissions(permissions))
+}
+
+// parseUUIDs binding で UUID であることを確認済みの文字列を変換する
+func parseUUIDs(values []string) []uuid.UUID {
+	ids := make([]uuid.UUID, len(values))
+	for i, v := range values {
+		ids[i] = uuid.MustParse(v)
+	}
+	return ids
 }
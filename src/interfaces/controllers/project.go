@@ -4,25 +4,37 @@ import (
 	"net/http"
 	"strconv"
 
+	"w3st/domain/models"
+	"w3st/dto"
 	"w3st/usecase"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type ProjectController struct {
+	BaseController
 	projectUsecase usecase.ProjectUsecase
+	roleUsecase    usecase.RoleUsecase
 }
 
-func NewProjectController(projectUsecase usecase.ProjectUsecase) *ProjectController {
+func NewProjectController(projectUsecase usecase.ProjectUsecase, roleUsecase usecase.RoleUsecase) *ProjectController {
 	return &ProjectController{
 		projectUsecase: projectUsecase,
+		roleUsecase:    roleUsecase,
 	}
 }
 
+// GetAllProjects ログインユーザーが参加しているプロジェクトの一覧を返す
 func (c *ProjectController) GetAllProjects(ctx *gin.Context) {
-	projects, err := c.projectUsecase.GetAllProjects(ctx.Request.Context())
+	userUUID := c.getUserUUID(ctx)
+	if userUUID == uuid.Nil {
+		return
+	}
+
+	projects, err := c.projectUsecase.GetProjectsByUser(ctx.Request.Context(), userUUID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ErrorHandler(ctx, err)
 		return
 	}
 
@@ -41,33 +53,128 @@ func (c *ProjectController) CreateProject(ctx *gin.Context) {
 		return
 	}
 
+	userUUID := c.getUserUUID(ctx)
+	if userUUID == uuid.Nil {
+		return
+	}
+
 	// デフォルトのレート制限を設定
 	if request.RateLimit == 0 {
 		request.RateLimit = 1000
 	}
 
-	project, err := c.projectUsecase.CreateProject(ctx.Request.Context(), request.Name, request.Description, request.RateLimit)
+	project, err := c.projectUsecase.CreateProject(ctx.Request.Context(), userUUID, request.Name, request.Description, request.RateLimit)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ErrorHandler(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, gin.H{"project": project})
 }
 
+// GetProjectByID ProjectContextMiddleware でメンバーであることを確認済みのプロジェクトを返す
 func (c *ProjectController) GetProjectByID(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	id, err := strconv.Atoi(idStr)
+	project, err := c.projectUsecase.GetProjectByID(ctx.Request.Context(), ctx.GetInt("projectID"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		ErrorHandler(ctx, err)
 		return
 	}
 
-	project, err := c.projectUsecase.GetProjectByID(ctx.Request.Context(), id)
+	ctx.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+func (c *ProjectController) GetMembers(ctx *gin.Context) {
+	members, err := c.projectUsecase.ListMembers(ctx.Request.Context(), ctx.GetInt("projectID"))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ErrorHandler(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"project": project})
+	ctx.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+func (c *ProjectController) InviteMember(ctx *gin.Context) {
+	var input dto.InviteProjectMember
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Role == "" {
+		input.Role = models.ProjectRoleEditor
+	}
+
+	userUUID := c.getUserUUID(ctx)
+	if userUUID == uuid.Nil {
+		return
+	}
+
+	member, err := c.projectUsecase.InviteMember(ctx.Request.Context(), ctx.GetInt("projectID"), userUUID, input.Email, input.Role)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"member": member})
+}
+
+func (c *ProjectController) RemoveMember(ctx *gin.Context) {
+	targetID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	userUUID := c.getUserUUID(ctx)
+	if userUUID == uuid.Nil {
+		return
+	}
+
+	if err := c.projectUsecase.RemoveMember(ctx.Request.Context(), ctx.GetInt("projectID"), userUUID, targetID); err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// GetRoles 組み込みロールとカスタムロールの一覧を返す
+func (c *ProjectController) GetRoles(ctx *gin.Context) {
+	roles, err := c.roleUsecase.ListRoles(ctx.Request.Context(), ctx.GetInt("projectID"))
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+func (c *ProjectController) CreateRole(ctx *gin.Context) {
+	var input dto.CreateProjectRole
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := c.roleUsecase.CreateRole(ctx.Request.Context(), ctx.GetInt("projectID"), c.getProjectRole(ctx), input.Name, input.Description, input.Permissions)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"role": role})
+}
+
+func (c *ProjectController) DeleteRole(ctx *gin.Context) {
+	roleID, err := strconv.Atoi(ctx.Param("roleId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role ID"})
+		return
+	}
+
+	if err := c.roleUsecase.DeleteRole(ctx.Request.Context(), ctx.GetInt("projectID"), roleID); err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
 }
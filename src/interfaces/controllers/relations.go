@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"w3st/domain/models"
+	"w3st/dto"
+	"w3st/usecase"
+)
+
+type RelationsController struct {
+	relationsUsecase usecase.RelationsUsecase
+}
+
+func NewRelationsController(relationsUsecase usecase.RelationsUsecase) *RelationsController {
+	return &RelationsController{
+		relationsUsecase: relationsUsecase,
+	}
+}
+
+// parseRelationPath パスの collectionId と relationId を返す。正しくない場合はレスポンスを返して ok を false にする
+func parseRelationPath(ctx *gin.Context) (collectionId int, relationId int, ok bool) {
+	collectionId, err := strconv.Atoi(ctx.Param("collectionId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Collection ID"})
+		return 0, 0, false
+	}
+	relationId, err = strconv.Atoi(ctx.Param("relationId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Relation ID"})
+		return 0, 0, false
+	}
+	return collectionId, relationId, true
+}
+
+// GetRelations - GUI用：コレクションのフィールドが参照するコレクションの一覧
+func (c *RelationsController) GetRelations(ctx *gin.Context) {
+	collectionId, err := strconv.Atoi(ctx.Param("collectionId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Collection ID"})
+		return
+	}
+
+	relations, err := c.relationsUsecase.GetRelations(ctx.Request.Context(), ctx.GetInt("projectID"), collectionId)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, relations)
+}
+
+// CreateRelation - GUI用：relation 型のフィールドに参照先のコレクションを設定
+func (c *RelationsController) CreateRelation(ctx *gin.Context) {
+	collectionId, err := strconv.Atoi(ctx.Param("collectionId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Collection ID"})
+		return
+	}
+
+	var input dto.CreateRelation
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	relation := &models.ApiKindRelation{
+		CollectionID:        collectionId,
+		FieldID:             input.FieldID,
+		RelatedCollectionID: input.RelatedCollectionID,
+		RelationType:        input.RelationType,
+		OnDelete:            input.OnDelete,
+	}
+	if err := c.relationsUsecase.CreateRelation(ctx.Request.Context(), ctx.GetInt("projectID"), relation); err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, relation)
+}
+
+// UpdateRelation - GUI用：参照先のエントリを削除したときの扱いを変更
+func (c *RelationsController) UpdateRelation(ctx *gin.Context) {
+	collectionId, relationId, ok := parseRelationPath(ctx)
+	if !ok {
+		return
+	}
+
+	var input dto.UpdateRelation
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	relation, err := c.relationsUsecase.UpdateRelationOnDelete(ctx.Request.Context(), ctx.GetInt("projectID"), collectionId, relationId, input.OnDelete)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, relation)
+}
+
+// DeleteRelation - GUI用：リレーションを削除（フィールドとエントリの値は残す）
+func (c *RelationsController) DeleteRelation(ctx *gin.Context) {
+	collectionId, relationId, ok := parseRelationPath(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.relationsUsecase.DeleteRelation(ctx.Request.Context(), ctx.GetInt("projectID"), collectionId, relationId); err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Relation deleted successfully"})
+}
+
+// GetReferences - GUI用：エントリを参照しているエントリをリレーションごとに取得
+func (c *RelationsController) GetReferences(ctx *gin.Context) {
+	collectionId, err := strconv.Atoi(ctx.Param("collectionId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Collection ID"})
+		return
+	}
+	entryId, err := strconv.Atoi(ctx.Param("entryId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entry ID"})
+		return
+	}
+
+	references, err := c.relationsUsecase.GetReferences(ctx.Request.Context(), ctx.GetInt("projectID"), collectionId, entryId)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, references)
+}
@@ -3,9 +3,11 @@ package controllers
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
+	"w3st/dto"
 	myerrors "w3st/errors"
 	"w3st/usecase"
 )
@@ -27,7 +29,7 @@ func (c *SDKEntriesController) GetEntries(ctx *gin.Context) {
 		return
 	}
 
-	entries, err := c.entriesUsecase.GetEntriesByCollectionIdForSDK(collectionIdInt, projectID, collectionIds)
+	entries, err := c.entriesUsecase.GetEntriesByCollectionIdForSDK(collectionIdInt, projectID, collectionIds, ctx.Query("populate"))
 	if err != nil {
 		var domainErr *myerrors.DomainError
 		if errors.As(err, &domainErr) {
@@ -40,3 +42,75 @@ func (c *SDKEntriesController) GetEntries(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, entries)
 }
+
+// CreateEntry - SDK用：エントリ作成（entries:write）
+func (c *SDKEntriesController) CreateEntry(ctx *gin.Context) {
+	collectionIdInt, projectID, collectionIds, status, errMsg := parseCollectionRequest(ctx)
+	if status != 0 {
+		ctx.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	var input dto.CreateEntry
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := c.entriesUsecase.CreateEntryForSDK(ctx.Request.Context(), collectionIdInt, projectID, collectionIds, input.Data)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, entry)
+}
+
+// UpdateEntry - SDK用：エントリ更新（entries:write）
+func (c *SDKEntriesController) UpdateEntry(ctx *gin.Context) {
+	collectionIdInt, projectID, collectionIds, status, errMsg := parseCollectionRequest(ctx)
+	if status != 0 {
+		ctx.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+	entryIdInt, err := strconv.Atoi(ctx.Param("entryId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entry ID"})
+		return
+	}
+
+	var input dto.UpdateEntry
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := c.entriesUsecase.UpdateEntryForSDK(ctx.Request.Context(), entryIdInt, collectionIdInt, projectID, collectionIds, input.Data)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entry)
+}
+
+// DeleteEntry - SDK用：エントリ削除（entries:write）
+func (c *SDKEntriesController) DeleteEntry(ctx *gin.Context) {
+	collectionIdInt, projectID, collectionIds, status, errMsg := parseCollectionRequest(ctx)
+	if status != 0 {
+		ctx.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+	entryIdInt, err := strconv.Atoi(ctx.Param("entryId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entry ID"})
+		return
+	}
+
+	if err := c.entriesUsecase.DeleteEntryForSDK(ctx.Request.Context(), entryIdInt, collectionIdInt, projectID, collectionIds); err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Entry deleted successfully"})
+}
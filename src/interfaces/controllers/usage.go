@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"net/http"
+
+	"w3st/domain/models"
+	"w3st/dto"
+	"w3st/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UsageController struct {
+	usageUsecase usecase.UsageUsecase
+}
+
+func NewUsageController(usageUsecase usecase.UsageUsecase) *UsageController {
+	return &UsageController{
+		usageUsecase: usageUsecase,
+	}
+}
+
+// GetUsage プロジェクトのSDKの利用量を granularity（hour / day / month）ごとに返す
+func (c *UsageController) GetUsage(ctx *gin.Context) {
+	var query dto.GetUsageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := c.usageUsecase.GetUsage(ctx.Request.Context(), models.UsageQuery{
+		ProjectID:    ctx.GetInt("projectID"),
+		From:         query.From,
+		To:           query.To,
+		Granularity:  query.Granularity,
+		ApiKeyID:     query.ApiKeyID,
+		CollectionID: query.CollectionID,
+	})
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// SetMonthlyQuota プロジェクトの月間のリクエスト上限を変更する
+func (c *UsageController) SetMonthlyQuota(ctx *gin.Context) {
+	var request dto.SetMonthlyQuotaRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, err := c.usageUsecase.SetMonthlyQuota(ctx.Request.Context(), ctx.GetInt("projectID"), request.MonthlyRequestQuota)
+	if err != nil {
+		ErrorHandler(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"project": project})
+}
@@ -5,25 +5,33 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"w3st/domain/models"
 	"w3st/dto"
 	myerrors "w3st/errors"
+	"w3st/infra/logger"
 	"w3st/presenter"
 	"w3st/usecase"
 )
 
 type UserController struct {
-	userUsecase    usecase.UserUsecase
-	jwtAuthUsecase usecase.JwtUsecase
-	userPresenter  presenter.UserPresenter
+	userUsecase      usecase.UserUsecase
+	sessionUsecase   usecase.SessionUsecase
+	accountUsecase   usecase.AccountUsecase
+	mfaUsecase       usecase.MFAUsecase
+	userPresenter    presenter.UserPresenter
+	sessionPresenter presenter.SessionPresenter
 }
 
-func NewUserController(userUsecase usecase.UserUsecase, jwtAuthUsecase usecase.JwtUsecase, userPresenter presenter.UserPresenter) *UserController {
+func NewUserController(userUsecase usecase.UserUsecase, sessionUsecase usecase.SessionUsecase, accountUsecase usecase.AccountUsecase, mfaUsecase usecase.MFAUsecase, userPresenter presenter.UserPresenter, sessionPresenter presenter.SessionPresenter) *UserController {
 	return &UserController{
-		userUsecase:    userUsecase,
-		jwtAuthUsecase: jwtAuthUsecase,
-		userPresenter:  userPresenter,
+		userUsecase:      userUsecase,
+		sessionUsecase:   sessionUsecase,
+		accountUsecase:   accountUsecase,
+		mfaUsecase:       mfaUsecase,
+		userPresenter:    userPresenter,
+		sessionPresenter: sessionPresenter,
 	}
 }
 
@@ -52,10 +60,16 @@ func (c *UserController) Signup(ctx *gin.Context) {
 		}
 		// その他のエラー
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	// 確認メールの送信に失敗しても登録自体は完了させる
+	if err := c.accountUsecase.SendVerification(ctx.Request.Context(), newUser); err != nil {
+		logger.Error("failed to send verification mail", "user_id", newUser.ID.String(), "error", err.Error())
 	}
 
-	// トークン生成
-	token, err := c.jwtAuthUsecase.GenerateToken(newUser.ID)
+	// セッション開始（トークン生成）
+	pair, err := c.sessionUsecase.Start(ctx.Request.Context(), newUser.ID, sessionMetadata(ctx, input.DeviceName))
 	if err != nil {
 		var domainErr *myerrors.DomainError
 		if errors.As(err, &domainErr) {
@@ -64,10 +78,11 @@ func (c *UserController) Signup(ctx *gin.Context) {
 		}
 		// その他のエラー
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
 	}
 
-	// jwtトークンをクライアントに返す
-	ctx.JSON(http.StatusOK, gin.H{"token": token})
+	// アクセストークンとリフレッシュトークンをクライアントに返す
+	ctx.JSON(http.StatusOK, c.sessionPresenter.ResponseToken(pair))
 }
 
 func (c *UserController) Login(ctx *gin.Context) {
@@ -79,8 +94,20 @@ func (c *UserController) Login(ctx *gin.Context) {
 		return
 	}
 
-	// ログイン
-	user, err := c.userUsecase.FindByEmail(input.Email)
+	// ログイン（パスワード照合）
+	user, err := c.userUsecase.Authenticate(ctx.Request.Context(), input.Email, input.Password)
+	if err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	// 二要素認証が有効な場合はセッションを開始せず、コードの入力を求める
+	mfaEnabled, err := c.mfaUsecase.IsEnabled(ctx.Request.Context(), user.ID)
 	if err != nil {
 		var domainErr *myerrors.DomainError
 		if errors.As(err, &domainErr) {
@@ -90,8 +117,23 @@ func (c *UserController) Login(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
 		return
 	}
-	//	token生成
-	token, err := c.jwtAuthUsecase.GenerateToken(user.ID)
+	if mfaEnabled {
+		challengeToken, err := c.mfaUsecase.StartChallenge(user.ID)
+		if err != nil {
+			var domainErr *myerrors.DomainError
+			if errors.As(err, &domainErr) {
+				ErrorHandler(ctx, err)
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+		ctx.JSON(http.StatusOK, c.sessionPresenter.ResponseMFAChallenge(challengeToken, int64(usecase.MFAChallengeTTL.Seconds())))
+		return
+	}
+
+	// セッション開始（token生成）
+	pair, err := c.sessionUsecase.Start(ctx.Request.Context(), user.ID, sessionMetadata(ctx, input.DeviceName))
 	if err != nil {
 		var domainErr *myerrors.DomainError
 		if errors.As(err, &domainErr) {
@@ -101,8 +143,319 @@ func (c *UserController) Login(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
 		return
 	}
-	// jwtトークンをクライアントに返す
-	ctx.JSON(http.StatusOK, gin.H{"token": token})
+	// アクセストークンとリフレッシュトークンをクライアントに返す
+	ctx.JSON(http.StatusOK, c.sessionPresenter.ResponseToken(pair))
+}
+
+// LoginMFA 入力待ちトークンと認証コードを検証してログインを完了する
+func (c *UserController) LoginMFA(ctx *gin.Context) {
+	var input dto.MFALoginData
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := c.mfaUsecase.CompleteChallenge(ctx.Request.Context(), input.ChallengeToken, input.Code)
+	if err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	pair, err := c.sessionUsecase.Start(ctx.Request.Context(), userID, sessionMetadata(ctx, input.DeviceName))
+	if err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.sessionPresenter.ResponseToken(pair))
+}
+
+// EnrollMFA 二要素認証の登録を開始し、認証アプリに登録するシークレットを返す
+func (c *UserController) EnrollMFA(ctx *gin.Context) {
+	userID, _, ok := currentSession(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	enrollment, err := c.mfaUsecase.Enroll(ctx.Request.Context(), userID)
+	if err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &dto.MFAEnrollResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: enrollment.OTPAuthURI,
+	})
+}
+
+// ConfirmMFA 認証コードで登録を完了し、リカバリーコードを返す（再表示はできない）
+func (c *UserController) ConfirmMFA(ctx *gin.Context) {
+	userID, _, ok := currentSession(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input dto.MFACodeData
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := c.mfaUsecase.Confirm(ctx.Request.Context(), userID, input.Code)
+	if err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &dto.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA 認証コードを確認して二要素認証を無効化する
+func (c *UserController) DisableMFA(ctx *gin.Context) {
+	userID, _, ok := currentSession(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input dto.MFACodeData
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.mfaUsecase.Disable(ctx.Request.Context(), userID, input.Code); err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// ResetUserMFA 管理者が端末を紛失したユーザーの二要素認証を解除する
+func (c *UserController) ResetUserMFA(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := c.mfaUsecase.Reset(ctx.Request.Context(), userID); err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset"})
+}
+
+// RefreshToken リフレッシュトークンをローテーションして新しいトークンを発行する
+func (c *UserController) RefreshToken(ctx *gin.Context) {
+	var input dto.RefreshTokenData
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := c.sessionUsecase.Refresh(ctx.Request.Context(), input.RefreshToken, sessionMetadata(ctx, input.DeviceName))
+	if err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.sessionPresenter.ResponseToken(pair))
+}
+
+// Logout 現在のセッションを無効化する
+func (c *UserController) Logout(ctx *gin.Context) {
+	userID, sessionID, ok := currentSession(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := c.sessionUsecase.RevokeSession(ctx.Request.Context(), userID, sessionID); err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// GetSessions ログイン中のセッション一覧を取得する
+func (c *UserController) GetSessions(ctx *gin.Context) {
+	userID, sessionID, ok := currentSession(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessions, err := c.sessionUsecase.ListSessions(ctx.Request.Context(), userID)
+	if err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"sessions": c.sessionPresenter.ResponseSessions(sessions, sessionID.String())})
+}
+
+// RevokeSession 指定したセッションを無効化する
+func (c *UserController) RevokeSession(ctx *gin.Context) {
+	userID, _, ok := currentSession(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	targetID, err := uuid.Parse(ctx.Param("sessionId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := c.sessionUsecase.RevokeSession(ctx.Request.Context(), userID, targetID); err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// ForgotPassword パスワード再設定メールを送信する
+func (c *UserController) ForgotPassword(ctx *gin.Context) {
+	var input dto.ForgotPasswordData
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.accountUsecase.RequestPasswordReset(ctx.Request.Context(), input.Email); err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	// 登録の有無にかかわらず同じレスポンスを返す
+	ctx.JSON(http.StatusOK, gin.H{"message": "If the email is registered, a password reset link has been sent"})
+}
+
+// ResetPassword メールで受け取ったトークンでパスワードを再設定する
+func (c *UserController) ResetPassword(ctx *gin.Context) {
+	var input dto.ResetPasswordData
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.accountUsecase.ResetPassword(ctx.Request.Context(), input.Token, input.Password); err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
+
+// VerifyEmail メールで受け取ったトークンでメールアドレスを確認済みにする
+func (c *UserController) VerifyEmail(ctx *gin.Context) {
+	var input dto.VerifyEmailData
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.accountUsecase.VerifyEmail(ctx.Request.Context(), input.Token); err != nil {
+		var domainErr *myerrors.DomainError
+		if errors.As(err, &domainErr) {
+			ErrorHandler(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email has been verified"})
+}
+
+func sessionMetadata(ctx *gin.Context, deviceName string) models.SessionMetadata {
+	return models.SessionMetadata{
+		DeviceName: deviceName,
+		IPAddress:  ctx.ClientIP(),
+		UserAgent:  ctx.Request.UserAgent(),
+	}
+}
+
+// currentSession JwtAuthMiddleware がコンテキストに保存したユーザーIDとセッションIDを取得する
+func currentSession(ctx *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := uuid.Parse(ctx.GetString("userID"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	sessionID, err := uuid.Parse(ctx.GetString("sessionID"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, sessionID, true
 }
 
 func (c *UserController) GetUserInfo(ctx *gin.Context) {
@@ -0,0 +1,174 @@
+package middlewares
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/infra/jwks"
+	"w3st/infra/logger"
+	"w3st/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// auth0Leeway Auth0とサーバーの時計のずれとして許容する時間
+const auth0Leeway = 30 * time.Second
+
+type Auth0Claims struct {
+	Sub           string `json:"sub"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	jwt.RegisteredClaims
+}
+
+// KeyProvider kid に対応する署名検証用の公開鍵を返す
+type KeyProvider interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// Auth0Validator Auth0が発行したアクセストークンを検証する
+type Auth0Validator struct {
+	keys     KeyProvider
+	issuer   string
+	audience string
+}
+
+// NewAuth0Validator audience が空の場合は aud クレームを検証しない
+func NewAuth0Validator(keys KeyProvider, issuer string, audience string) *Auth0Validator {
+	return &Auth0Validator{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+	}
+}
+
+// NewAuth0ValidatorFromEnv AUTH0_DOMAIN と AUTH0_AUDIENCE から検証器を作り、JWKSのバックグラウンド更新を開始する
+func NewAuth0ValidatorFromEnv(ctx context.Context) *Auth0Validator {
+	domain := strings.TrimSuffix(os.Getenv("AUTH0_DOMAIN"), "/")
+	audience := os.Getenv("AUTH0_AUDIENCE")
+	if domain == "" {
+		logger.Error("AUTH0_DOMAIN environment variable is not set; /api requests will be rejected")
+		return NewAuth0Validator(nil, "", audience)
+	}
+	if audience == "" {
+		logger.Error("AUTH0_AUDIENCE environment variable is not set; token audience is not validated")
+	}
+
+	cache := jwks.NewCache(fmt.Sprintf("https://%s/.well-known/jwks.json", domain))
+	cache.Start(ctx)
+
+	return NewAuth0Validator(cache, fmt.Sprintf("https://%s/", domain), audience)
+}
+
+// Validate 署名・有効期限・発行者・対象者を検証してクレームを返す
+func (v *Auth0Validator) Validate(ctx context.Context, tokenString string) (*Auth0Claims, error) {
+	if v.keys == nil {
+		return nil, errors.New("AUTH0_DOMAIN environment variable is not set")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(auth0Leeway),
+	}
+	if v.audience != "" {
+		opts = append(opts, jwt.WithAudience(v.audience))
+	}
+
+	claims := &Auth0Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, ok := t.Header["kid"].(string)
+		if !ok || kid == "" {
+			return nil, errors.New("kid not found or not string")
+		}
+		key, err := v.keys.Key(ctx, kid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get signing key: %w", err)
+		}
+		if !keyMatchesMethod(key, t.Method) {
+			return nil, fmt.Errorf("key %q cannot verify %s", kid, t.Method.Alg())
+		}
+		return key, nil
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token with claims: %w", err)
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// keyMatchesMethod alg ヘッダーと鍵の種類が一致するか確認する（アルゴリズム取り違え対策）
+func keyMatchesMethod(key crypto.PublicKey, method jwt.SigningMethod) bool {
+	switch method.Alg() {
+	case jwt.SigningMethodRS256.Alg():
+		_, ok := key.(*rsa.PublicKey)
+		return ok
+	case jwt.SigningMethodES256.Alg():
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		return ok && ecKey.Curve == elliptic.P256()
+	default:
+		return false
+	}
+}
+
+// Auth0AuthMiddleware Auth0トークン検証ミドルウェア。
+// Auth0の sub をローカルユーザーに対応付け、JwtAuthMiddleware と同じくユーザーのUUIDを userID に保存する
+func Auth0AuthMiddleware(validator *Auth0Validator, identityUsecase usecase.IdentityUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// tokenをヘッダーから取得
+		authHeader := c.Request.Header.Get("Authorization")
+		token := strings.TrimPrefix(authHeader, "Bearer ")
+
+		// tokenの存在を確認
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+			c.Abort()
+			return
+		}
+
+		// Auth0トークンの検証
+		claims, err := validator.Validate(c.Request.Context(), token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Auth0 token"})
+			c.Abort()
+			return
+		}
+
+		// 初回ログイン時はユーザーを作成（またはメールアドレスが一致するユーザーに連携）する
+		user, err := identityUsecase.Resolve(c.Request.Context(), models.ExternalIdentity{
+			Provider:      models.IdentityProviderAuth0,
+			Subject:       claims.Sub,
+			Email:         claims.Email,
+			EmailVerified: claims.EmailVerified,
+			Name:          claims.Name,
+		})
+		if err != nil {
+			abortWithDomainError(c, err)
+			return
+		}
+
+		// 検証成功の場合、ユーザー情報をコンテキストに保存
+		c.Set("userID", user.ID.String())
+		c.Set("userEmail", user.Email)
+		c.Set("userName", user.Name)
+		c.Set("userRole", user.Role)
+		c.Set("authSubject", claims.Sub)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,199 @@
+package middlewares_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/domain/models"
+	"w3st/infra/jwks"
+	"w3st/interfaces/middlewares"
+)
+
+const (
+	testIssuer   = "https://tenant.example.com/"
+	testAudience = "https://api.w3st.example.com"
+)
+
+// localUserID Auth0のユーザーに対応付けられたローカルユーザー
+var localUserID = uuid.MustParse("4f3c2d1e-0000-4000-8000-000000000001")
+
+type stubIdentityUsecase struct{}
+
+func (stubIdentityUsecase) Resolve(_ context.Context, identity models.ExternalIdentity) (*models.Users, error) {
+	return &models.Users{ID: localUserID, Email: identity.Email, Name: identity.Name}, nil
+}
+
+type auth0Keys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+// newAuth0Router JWKSエンドポイントの代わりにhttptestのサーバーを使う
+func newAuth0Router(t *testing.T) (*gin.Engine, auth0Keys) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	set := jwks.Set{Keys: []jwks.JWK{
+		{
+			Kty: "RSA",
+			Kid: "rsa-key",
+			N:   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		},
+		{
+			Kty: "EC",
+			Kid: "ec-key",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+			Y:   base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+		},
+	}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(server.Close)
+
+	validator := middlewares.NewAuth0Validator(jwks.NewCache(server.URL), testIssuer, testAudience)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/ping", middlewares.Auth0AuthMiddleware(validator, stubIdentityUsecase{}), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"userID": c.GetString("userID")})
+	})
+	return r, auth0Keys{rsa: rsaKey, ec: ecKey}
+}
+
+func validAuth0Claims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "auth0|user-1",
+		"iss": testIssuer,
+		"aud": []string{testAudience},
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func signAuth0Token(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func requestWithToken(t *testing.T, r *gin.Engine, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/ping", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuth0AuthMiddleware_RS256(t *testing.T) {
+	t.Parallel()
+	r, keys := newAuth0Router(t)
+
+	w := requestWithToken(t, r, signAuth0Token(t, jwt.SigningMethodRS256, "rsa-key", keys.rsa, validAuth0Claims()))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// コンテキストには Auth0 の sub ではなくローカルユーザーのUUIDが入る
+	assert.Contains(t, w.Body.String(), localUserID.String())
+}
+
+func TestAuth0AuthMiddleware_ES256(t *testing.T) {
+	t.Parallel()
+	r, keys := newAuth0Router(t)
+
+	w := requestWithToken(t, r, signAuth0Token(t, jwt.SigningMethodES256, "ec-key", keys.ec, validAuth0Claims()))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuth0AuthMiddleware_Rejects(t *testing.T) {
+	t.Parallel()
+	r, keys := newAuth0Router(t)
+
+	tests := []struct {
+		name  string
+		token func() string
+	}{
+		{
+			name: "wrong audience",
+			token: func() string {
+				claims := validAuth0Claims()
+				claims["aud"] = []string{"https://other.example.com"}
+				return signAuth0Token(t, jwt.SigningMethodRS256, "rsa-key", keys.rsa, claims)
+			},
+		},
+		{
+			name: "wrong issuer",
+			token: func() string {
+				claims := validAuth0Claims()
+				claims["iss"] = "https://evil.example.com/"
+				return signAuth0Token(t, jwt.SigningMethodRS256, "rsa-key", keys.rsa, claims)
+			},
+		},
+		{
+			name: "expired",
+			token: func() string {
+				claims := validAuth0Claims()
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+				return signAuth0Token(t, jwt.SigningMethodRS256, "rsa-key", keys.rsa, claims)
+			},
+		},
+		{
+			name: "missing exp",
+			token: func() string {
+				claims := validAuth0Claims()
+				delete(claims, "exp")
+				return signAuth0Token(t, jwt.SigningMethodRS256, "rsa-key", keys.rsa, claims)
+			},
+		},
+		{
+			name: "unknown kid",
+			token: func() string {
+				return signAuth0Token(t, jwt.SigningMethodRS256, "unknown", keys.rsa, validAuth0Claims())
+			},
+		},
+		{
+			name: "key type does not match alg",
+			token: func() string {
+				return signAuth0Token(t, jwt.SigningMethodES256, "rsa-key", keys.ec, validAuth0Claims())
+			},
+		},
+		{
+			name: "HS256 is not allowed",
+			token: func() string {
+				return signAuth0Token(t, jwt.SigningMethodHS256, "rsa-key", []byte("secret"), validAuth0Claims())
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := requestWithToken(t, r, tt.token())
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package middlewares_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	"w3st/infra/ratelimit"
+	"w3st/interfaces/middlewares"
+	"w3st/usecase"
+)
+
+// stubApiKeyUsecase keys に登録されたキーだけを認証する。rejected のキーは理由を付けて拒否する
+type stubApiKeyUsecase struct {
+	usecase.ApiKeyUsecase
+	keys     map[string]*models.ApiKeyPrincipal
+	rejected map[string]string
+}
+
+func (s stubApiKeyUsecase) ValidateApiKey(_ context.Context, apiKey string) (*models.ApiKeyPrincipal, error) {
+	if reason, ok := s.rejected[apiKey]; ok {
+		return nil, &myerrors.DomainError{ErrType: myerrors.Unauthenticated, Message: "APIキーの有効期限が切れています", Err: &usecase.ApiKeyRejectedError{Reason: reason}}
+	}
+	principal, ok := s.keys[apiKey]
+	if !ok {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.Unauthenticated, "APIキーが無効です")
+	}
+	return principal, nil
+}
+
+func newApiKeyRouter(t *testing.T, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	require.NoError(t, r.SetTrustedProxies(trustedProxies))
+
+	apiKeys := stubApiKeyUsecase{
+		keys: map[string]*models.ApiKeyPrincipal{
+			"open":    {ApiKeyID: 1, UserID: uuid.New(), ProjectID: 1, RateLimit: 1000},
+			"office":  {ApiKeyID: 2, UserID: uuid.New(), ProjectID: 1, IpWhiteList: []string{"203.0.113.0/24"}, RateLimit: 1000},
+			"limited": {ApiKeyID: 3, UserID: uuid.New(), ProjectID: 1, RateLimit: 2},
+		},
+		rejected: map[string]string{"expired": usecase.ApiKeyRejectExpired},
+	}
+	sdk := r.Group("/collections")
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), middlewares.RateLimitWindow)
+	sdk.Use(middlewares.ApiKeyAuthMiddleware(apiKeys), middlewares.ApiKeyRateLimitMiddleware(limiter))
+	sdk.GET("", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"apiKeyID": c.GetInt("apiKeyID"), "projectID": c.GetInt("projectID")})
+	})
+	return r
+}
+
+func TestApiKeyAuthMiddleware(t *testing.T) {
+	t.Parallel()
+	// 10.0.0.0/8 のロードバランサー経由の X-Forwarded-For だけを信頼する
+	r := newApiKeyRouter(t, []string{"10.0.0.0/8"})
+
+	tests := []struct {
+		name         string
+		key          string
+		remoteAddr   string
+		forwardedFor string
+		wantStatus   int
+		wantBody     string
+	}{
+		{name: "no restriction", key: "open", remoteAddr: "198.51.100.1:1234", wantStatus: http.StatusOK, wantBody: `"apiKeyID":1`},
+		{name: "missing key", remoteAddr: "198.51.100.1:1234", wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", key: "unknown", remoteAddr: "198.51.100.1:1234", wantStatus: http.StatusUnauthorized},
+		{name: "expired key returns reason", key: "expired", remoteAddr: "198.51.100.1:1234", wantStatus: http.StatusUnauthorized, wantBody: `"reason":"expired"`},
+		{name: "ip in cidr", key: "office", remoteAddr: "203.0.113.9:1234", wantStatus: http.StatusOK, wantBody: `"apiKeyID":2`},
+		{name: "ip outside cidr", key: "office", remoteAddr: "198.51.100.1:1234", wantStatus: http.StatusForbidden, wantBody: `"reason":"ip_not_allowed"`},
+		{name: "forwarded by trusted proxy", key: "office", remoteAddr: "10.0.0.5:1234", forwardedFor: "203.0.113.9", wantStatus: http.StatusOK},
+		{name: "forwarded by untrusted client is ignored", key: "office", remoteAddr: "198.51.100.1:1234", forwardedFor: "203.0.113.9", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/collections", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.key != "" {
+				req.Header.Set("X-Api-Key", tt.key)
+			}
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantBody != "" {
+				assert.Contains(t, w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRequireApiKeyScope(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	// コレクション1, 2は読み取りのみ、コレクション2だけ書き込みもできる
+	apiKeys := stubApiKeyUsecase{keys: map[string]*models.ApiKeyPrincipal{
+		"writer": {
+			ApiKeyID: 1, ProjectID: 1, CollectionIds: []int{1, 2},
+			Scopes:           []string{models.ApiKeyScopeCollectionsRead, models.ApiKeyScopeEntriesRead},
+			CollectionScopes: map[int][]string{2: {models.ApiKeyScopeEntriesRead, models.ApiKeyScopeEntriesWrite}},
+		},
+	}}
+	sdk := r.Group("/collections", middlewares.ApiKeyAuthMiddleware(apiKeys))
+	handler := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"collectionIds": c.MustGet("collectionIds")})
+	}
+	sdk.GET("", middlewares.RequireApiKeyScope(models.ApiKeyScopeEntriesWrite), handler)
+	sdk.GET("/:collectionId/entries", middlewares.RequireApiKeyScope(models.ApiKeyScopeEntriesRead), handler)
+	sdk.POST("/:collectionId/entries", middlewares.RequireApiKeyScope(models.ApiKeyScopeEntriesWrite), handler)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "read with key scope", method: http.MethodGet, path: "/collections/1/entries", wantStatus: http.StatusOK},
+		{name: "write without scope", method: http.MethodPost, path: "/collections/1/entries", wantStatus: http.StatusForbidden, wantBody: `"required_scope":"entries:write"`},
+		{name: "write with collection scope", method: http.MethodPost, path: "/collections/2/entries", wantStatus: http.StatusOK},
+		{name: "collection not allowed", method: http.MethodGet, path: "/collections/3/entries", wantStatus: http.StatusNotFound},
+		{name: "list narrowed to collections with scope", method: http.MethodGet, path: "/collections", wantStatus: http.StatusOK, wantBody: `"collectionIds":[2]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequestWithContext(context.Background(), tt.method, tt.path, nil)
+			req.Header.Set("X-Api-Key", "writer")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantBody != "" {
+				assert.Contains(t, w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	myerrors "w3st/errors"
+	"w3st/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CollectionSlugMiddleware パスの :collectionId にコレクションの slug が指定された場合（/collections/products/entries）、
+// APIキーのプロジェクトのコレクションのIDに置き換える。以降のミドルウェアとハンドラーはIDとして扱える。
+// ApiKeyAuthMiddleware の後に使う
+func CollectionSlugMiddleware(collectionsUsecase usecase.CollectionsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		param := c.Param("collectionId")
+		if param == "" {
+			c.Next()
+			return
+		}
+		if _, err := strconv.Atoi(param); err == nil {
+			c.Next()
+			return
+		}
+
+		id, err := collectionsUsecase.ResolveCollectionId(c.Request.Context(), c.GetInt("projectID"), param)
+		if err != nil {
+			// キーで利用できないコレクションと同じレスポンスにし、slug が存在するかどうかを返さない
+			if errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Collection not accessible with this API key"})
+				c.Abort()
+				return
+			}
+			abortWithDomainError(c, err)
+			return
+		}
+
+		for i := range c.Params {
+			if c.Params[i].Key == "collectionId" {
+				c.Params[i].Value = strconv.Itoa(id)
+			}
+		}
+		c.Next()
+	}
+}
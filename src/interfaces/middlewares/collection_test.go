@@ -0,0 +1,62 @@
+package middlewares_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	myerrors "w3st/errors"
+	"w3st/interfaces/middlewares"
+	"w3st/usecase"
+)
+
+// stubCollectionsUsecase プロジェクト1の "products" だけをIDの3にする
+type stubCollectionsUsecase struct {
+	usecase.CollectionsUsecase
+}
+
+func (stubCollectionsUsecase) ResolveCollectionId(_ context.Context, projectId int, idOrSlug string) (int, error) {
+	if projectId == 1 && idOrSlug == "products" {
+		return 3, nil
+	}
+	return 0, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません")
+}
+
+func TestCollectionSlugMiddleware(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	sdk := r.Group("/collections")
+	sdk.Use(func(c *gin.Context) {
+		c.Set("projectID", 1)
+		c.Next()
+	})
+	sdk.Use(middlewares.CollectionSlugMiddleware(stubCollectionsUsecase{}))
+	sdk.GET("", func(c *gin.Context) { c.String(http.StatusOK, "list") })
+	sdk.GET("/:collectionId/entries", func(c *gin.Context) { c.String(http.StatusOK, c.Param("collectionId")) })
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		// slug はIDに置き換える
+		{"/collections/products/entries", http.StatusOK, "3"},
+		// IDはそのまま使う
+		{"/collections/5/entries", http.StatusOK, "5"},
+		{"/collections", http.StatusOK, "list"},
+		// 存在しない slug はキーで利用できないコレクションと同じ 404
+		{"/collections/unknown/entries", http.StatusNotFound, `{"error":"Collection not accessible with this API key"}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, tt.path, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tt.wantStatus, w.Code, tt.path)
+		assert.Equal(t, tt.wantBody, w.Body.String(), tt.path)
+	}
+}
@@ -0,0 +1,123 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"w3st/domain/models"
+	"w3st/infra/logger"
+	"w3st/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ResourceFunc リクエストから権限チェックの対象リソースを決める
+type ResourceFunc func(c *gin.Context) string
+
+// ResourceFromPath プロジェクトとパスパラメータから "project:1/collection:5/entry:9" の形式のリソースを作る。
+// パラメータ名の Id を取り除いたものを種類とし、:id のように種類が分からない場合は直前のパスの要素を使う
+func ResourceFromPath(c *gin.Context) string {
+	var parts []string
+	if projectID := c.GetInt("projectID"); projectID != 0 {
+		parts = append(parts, fmt.Sprintf("project:%d", projectID))
+	}
+
+	segments := strings.Split(strings.Trim(c.FullPath(), "/"), "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		name := segment[1:]
+		if name == "projectId" {
+			continue
+		}
+		kind := strings.TrimSuffix(strings.TrimSuffix(name, "Id"), "ID")
+		if (kind == "" || kind == "id") && i > 0 {
+			kind = strings.TrimSuffix(segments[i-1], "s")
+		}
+		parts = append(parts, kind+":"+c.Param(name))
+	}
+
+	if len(parts) == 0 {
+		return "*"
+	}
+	return strings.Join(parts, "/")
+}
+
+// Authorizer ロールと個別に付与された権限でルートへのアクセスを制御する
+type Authorizer struct {
+	roleUsecase       usecase.RoleUsecase
+	permissionUsecase usecase.PermissionUsecase
+}
+
+func NewAuthorizer(roleUsecase usecase.RoleUsecase, permissionUsecase usecase.PermissionUsecase) *Authorizer {
+	return &Authorizer{
+		roleUsecase:       roleUsecase,
+		permissionUsecase: permissionUsecase,
+	}
+}
+
+// RequirePermission permission を持たないユーザーのリクエストを 403 で中断する。
+// ProjectContextMiddleware の後に使い、次の順に確認する
+//   - システム管理者（users.role が admin）はすべて許可
+//   - user_permissions で resource（またはその上位のリソース）に禁止ルールがあれば拒否
+//   - ログインしていれば誰でも持っている権限（プロジェクトの作成など）
+//   - 操作対象のプロジェクトでのロール（組み込みロールまたはカスタムロール）
+//   - user_permissions で resource（またはその上位のリソース）に個別に付与された権限
+func (a *Authorizer) RequirePermission(permission string, resource ResourceFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("userRole") == models.UserRoleAdmin {
+			c.Next()
+			return
+		}
+
+		userID, err := uuid.Parse(c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+		explanation, err := a.permissionUsecase.ExplainPermission(c.Request.Context(), c.GetInt("projectID"), userID, permission, resource(c))
+		if err != nil {
+			abortWithDomainError(c, err)
+			return
+		}
+		if explanation.Grant != nil && explanation.Grant.IsDeny() {
+			logger.Info("permission denied by rule", "user_id", userID.String(), "permission", permission, "resource", explanation.Resource, "rule", explanation.Grant.Resource)
+			abortPermissionDenied(c, permission)
+			return
+		}
+
+		if models.IsBasePermission(permission) {
+			c.Next()
+			return
+		}
+
+		if projectID := c.GetInt("projectID"); projectID != 0 {
+			allowed, err := a.roleUsecase.HasPermission(c.Request.Context(), projectID, c.GetString("projectRole"), permission)
+			if err != nil {
+				abortWithDomainError(c, err)
+				return
+			}
+			if allowed {
+				c.Next()
+				return
+			}
+		}
+
+		if !explanation.Allowed {
+			logger.Info("permission denied", "user_id", userID.String(), "permission", permission, "path", c.FullPath())
+			abortPermissionDenied(c, permission)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func abortPermissionDenied(c *gin.Context, permission string) {
+	c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied", "permission": permission})
+	c.Abort()
+}
@@ -0,0 +1,121 @@
+package middlewares_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"w3st/domain/models"
+	"w3st/interfaces/middlewares"
+	"w3st/usecase"
+)
+
+// stubRoleUsecase viewer には entries:read だけを与える
+type stubRoleUsecase struct {
+	usecase.RoleUsecase
+}
+
+func (stubRoleUsecase) HasPermission(_ context.Context, _ int, role string, permission string) (bool, error) {
+	return role == models.ProjectRoleViewer && permission == models.PermissionEntriesRead, nil
+}
+
+// stubPermissionUsecase granted のリソースにだけ個別の権限が付与され、denied のリソースは禁止されている
+type stubPermissionUsecase struct {
+	usecase.PermissionUsecase
+	granted string
+	denied  string
+}
+
+func (s stubPermissionUsecase) ExplainPermission(_ context.Context, _ int, _ uuid.UUID, permission string, resource string) (*models.PermissionExplanation, error) {
+	explanation := &models.PermissionExplanation{Permission: permission, Resource: resource}
+	switch resource {
+	case s.granted:
+		explanation.Grant = &models.UserPermission{Permission: permission, Resource: resource, Effect: models.PermissionEffectAllow}
+		explanation.Allowed = true
+	case s.denied:
+		explanation.Grant = &models.UserPermission{Permission: permission, Resource: resource, Effect: models.PermissionEffectDeny}
+	}
+	return explanation, nil
+}
+
+func TestResourceFromPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		route     string
+		path      string
+		projectID int
+		want      string
+	}{
+		{route: "/api/collections/:collectionId/entries/:entryId", path: "/api/collections/5/entries/9", projectID: 1, want: "project:1/collection:5/entry:9"},
+		{route: "/api/projects/:projectId/members/:userId", path: "/api/projects/1/members/u1", projectID: 1, want: "project:1/user:u1"},
+		{route: "/api/media/:id", path: "/api/media/3", projectID: 2, want: "project:2/media:3"},
+		{route: "/api/system-alerts/:id/read", path: "/api/system-alerts/4/read", projectID: 1, want: "project:1/system-alert:4"},
+		{route: "/api/projects", path: "/api/projects", want: "*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			var got string
+			r.GET(tt.route, func(c *gin.Context) {
+				if tt.projectID != 0 {
+					c.Set("projectID", tt.projectID)
+				}
+				got = middlewares.ResourceFromPath(c)
+			})
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequestWithContext(context.Background(), http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAuthorizer_RequirePermission(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		role       string
+		permission string
+		path       string
+		want       int
+	}{
+		{name: "allowed by role", role: models.ProjectRoleViewer, permission: models.PermissionEntriesRead, path: "/api/collections/5/entries", want: http.StatusOK},
+		{name: "denied by role", role: models.ProjectRoleViewer, permission: models.PermissionEntriesWrite, path: "/api/collections/6/entries", want: http.StatusForbidden},
+		{name: "allowed by explicit grant", role: models.ProjectRoleViewer, permission: models.PermissionEntriesWrite, path: "/api/collections/5/entries", want: http.StatusOK},
+		{name: "base permission", permission: models.PermissionProjectsCreate, path: "/api/collections/6/entries", want: http.StatusOK},
+		{name: "deny rule overrides role", role: models.ProjectRoleViewer, permission: models.PermissionEntriesRead, path: "/api/collections/7/entries", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			authz := middlewares.NewAuthorizer(stubRoleUsecase{}, stubPermissionUsecase{granted: "project:1/collection:5", denied: "project:1/collection:7"})
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set("userID", localUserID.String())
+				c.Set("projectID", 1)
+				c.Set("projectRole", tt.role)
+				c.Next()
+			})
+			r.GET("/api/collections/:collectionId/entries", authz.RequirePermission(tt.permission, middlewares.ResourceFromPath), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+
+	"w3st/domain/models"
+	"w3st/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ProjectHeader 操作対象のプロジェクトを指定するヘッダー
+const ProjectHeader = "X-Project-Id"
+
+// ProjectContextMiddleware 操作対象のプロジェクトを /api/projects/:projectId/... のパス、
+// または X-Project-Id ヘッダーから決定し、メンバーであることを確認して projectID と projectRole をコンテキストに保存する。
+// Auth0AuthMiddleware の後に使う。プロジェクトが指定されていない場合は何もしない。
+// システム管理者の場合はメンバーでなくてもプロジェクトが存在すれば projectID を保存する（projectRole は空）
+func ProjectContextMiddleware(projectUsecase usecase.ProjectUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.Param("projectId")
+		if raw == "" {
+			raw = c.GetHeader(ProjectHeader)
+		}
+		if raw == "" {
+			c.Next()
+			return
+		}
+
+		projectID, err := strconv.Atoi(raw)
+		if err != nil || projectID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+			c.Abort()
+			return
+		}
+
+		userID, err := uuid.Parse(c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		// システム管理者はメンバーでなくてもすべてのプロジェクトを操作できる
+		if c.GetString("userRole") == models.UserRoleAdmin {
+			if _, err := projectUsecase.GetProjectByID(c.Request.Context(), projectID); err != nil {
+				abortWithDomainError(c, err)
+				return
+			}
+			c.Set("projectID", projectID)
+			c.Next()
+			return
+		}
+
+		member, err := projectUsecase.GetMembership(c.Request.Context(), projectID, userID)
+		if err != nil {
+			abortWithDomainError(c, err)
+			return
+		}
+
+		c.Set("projectID", member.ProjectID)
+		c.Set("projectRole", member.Role)
+
+		c.Next()
+	}
+}
+
+// RequireProjectMiddleware プロジェクトの指定が必須のルートで使う
+func RequireProjectMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetInt("projectID") == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ProjectHeader + " header is required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,88 @@
+package middlewares_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	"w3st/interfaces/middlewares"
+	"w3st/usecase"
+)
+
+// stubProjectUsecase memberOf のプロジェクトにだけ editor として参加している
+type stubProjectUsecase struct {
+	usecase.ProjectUsecase
+	memberOf int
+}
+
+func (s stubProjectUsecase) GetMembership(_ context.Context, projectID int, userID uuid.UUID) (*models.ProjectMember, error) {
+	if projectID != s.memberOf {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.UnPermittedOperation, "このプロジェクトのメンバーではありません")
+	}
+	return &models.ProjectMember{ProjectID: projectID, UserID: userID, Role: models.ProjectRoleEditor}, nil
+}
+
+func newProjectRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/api")
+	api.Use(func(c *gin.Context) {
+		c.Set("userID", localUserID.String())
+		c.Next()
+	})
+	api.Use(middlewares.ProjectContextMiddleware(stubProjectUsecase{memberOf: 3}))
+
+	handler := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"projectID": c.GetInt("projectID"), "projectRole": c.GetString("projectRole")})
+	}
+	api.GET("/projects", handler)
+	api.GET("/projects/:projectId/members", handler)
+	api.GET("/collections", middlewares.RequireProjectMiddleware(), handler)
+	return r
+}
+
+func TestProjectContextMiddleware(t *testing.T) {
+	t.Parallel()
+	r := newProjectRouter()
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "project from path", path: "/api/projects/3/members", wantStatus: http.StatusOK, wantBody: `"projectID":3`},
+		{name: "project from header", path: "/api/collections", header: "3", wantStatus: http.StatusOK, wantBody: `"projectRole":"editor"`},
+		{name: "path takes precedence over header", path: "/api/projects/4/members", header: "3", wantStatus: http.StatusForbidden},
+		{name: "not a member", path: "/api/collections", header: "4", wantStatus: http.StatusForbidden},
+		{name: "invalid project id", path: "/api/collections", header: "abc", wantStatus: http.StatusBadRequest},
+		{name: "project is required", path: "/api/collections", wantStatus: http.StatusBadRequest},
+		{name: "project is optional", path: "/api/projects", wantStatus: http.StatusOK, wantBody: `"projectID":0`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(middlewares.ProjectHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, strconv.Quote(w.Body.String()))
+			if tt.wantBody != "" {
+				assert.Contains(t, w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package middlewares
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"w3st/infra/cache"
+	"w3st/infra/logger"
+	"w3st/infra/ratelimit"
+	"w3st/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitWindow レート制限（rate_limit_per_hour）を数える期間
+const RateLimitWindow = time.Hour
+
+// プロジェクトのレート制限をキャッシュする件数と期間。変更は ProjectRateLimitCacheTTL の間反映されない
+const (
+	ProjectRateLimitCacheSize = 10000
+	ProjectRateLimitCacheTTL  = time.Minute
+)
+
+// defaultProjectRateLimit プロジェクトのレート制限を取得できない場合に使う値
+const defaultProjectRateLimit = 1000
+
+// ApiKeyRateLimitMiddleware APIキーごとの1時間あたりのレート制限。
+// キーのレート制限はプロジェクトのレート制限以下で設定されるため、ProjectRateLimitMiddleware より前に確認する
+func ApiKeyRateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKeyID := c.GetInt("apiKeyID")
+		rateLimit := c.GetInt("apiKeyRateLimit")
+		if apiKeyID == 0 || rateLimit <= 0 {
+			c.Next()
+			return
+		}
+
+		if !allowRequest(c, limiter, fmt.Sprintf("api_key:%d", apiKeyID), rateLimit, "Rate limit exceeded for this API key") {
+			return
+		}
+		c.Next()
+	}
+}
+
+// ProjectRateLimitMiddleware プロジェクト単位のレート制限ミドルウェア。プロジェクトのレート制限は ProjectRateLimitCacheTTL の間キャッシュする。
+// 上限に近づいたときのアラートは UsageUsecase が集計した利用量から作成する
+func ProjectRateLimitMiddleware(limiter *ratelimit.Limiter, projectUsecase usecase.ProjectUsecase) gin.HandlerFunc {
+	limits := cache.NewLRU[int, int](ProjectRateLimitCacheSize, ProjectRateLimitCacheTTL)
+
+	return func(c *gin.Context) {
+		projectID := c.GetInt("projectID")
+		if projectID == 0 {
+			c.Next()
+			return
+		}
+
+		// プロジェクトのレート制限を取得
+		rateLimit, ok := limits.Get(projectID)
+		if !ok {
+			limit, err := projectUsecase.GetRateLimitByProjectID(c.Request.Context(), projectID)
+			if err != nil {
+				// レート制限が取得できない場合はデフォルト値を使用（キャッシュせず次のリクエストで再取得する）
+				limit = defaultProjectRateLimit
+			} else {
+				limits.Add(projectID, limit)
+			}
+			rateLimit = limit
+		}
+
+		if !allowRequest(c, limiter, fmt.Sprintf("project:%d", projectID), rateLimit, "Rate limit exceeded for this project") {
+			return
+		}
+		c.Next()
+	}
+}
+
+// allowRequest key のリクエストを数えて X-RateLimit-* ヘッダーを設定する。limit を超えた場合は 429 を返して false を返す。
+// カウントを保持するストアに接続できない場合はリクエストを受け付ける
+func allowRequest(c *gin.Context, limiter *ratelimit.Limiter, key string, limit int, message string) bool {
+	res, err := limiter.Allow(c.Request.Context(), key, limit)
+	if err != nil {
+		logger.Error("failed to check rate limit", "key", key, "error", err.Error())
+		return true
+	}
+	setRateLimitHeaders(c, res)
+	if res.Allowed {
+		return true
+	}
+
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": message,
+		"limit": res.Limit,
+		"reset": res.Reset.Format(time.RFC3339),
+	})
+	c.Abort()
+	return false
+}
+
+// setRateLimitHeaders キーごととプロジェクトのレート制限を両方確認する場合は、残りの少ない方をヘッダーに設定する
+func setRateLimitHeaders(c *gin.Context, res ratelimit.Result) {
+	header := c.Writer.Header()
+	if current, err := strconv.Atoi(header.Get("X-RateLimit-Remaining")); err == nil && res.Allowed && current <= res.Remaining {
+		return
+	}
+	header.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(res.Reset.Unix(), 10))
+	if !res.Allowed {
+		header.Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+	}
+}
@@ -0,0 +1,128 @@
+package middlewares_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/infra/ratelimit"
+	"w3st/interfaces/middlewares"
+	"w3st/usecase"
+)
+
+// stubRateLimitProjectUsecase プロジェクトのレート制限を返し、取得した回数を数える
+type stubRateLimitProjectUsecase struct {
+	usecase.ProjectUsecase
+	rateLimit int
+	calls     atomic.Int64
+}
+
+func (s *stubRateLimitProjectUsecase) GetRateLimitByProjectID(_ context.Context, _ int) (int, error) {
+	s.calls.Add(1)
+	return s.rateLimit, nil
+}
+
+func TestApiKeyRateLimitMiddleware(t *testing.T) {
+	t.Parallel()
+	r := newApiKeyRouter(t, nil)
+
+	request := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/collections", nil)
+		req.Header.Set("X-Api-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// キーごとに1時間あたりのリクエスト数を数える
+	w := request("limited")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("X-RateLimit-Reset"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, request("limited").Code)
+
+	w = request("limited")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.Positive(t, retryAfter)
+	assert.Contains(t, w.Body.String(), `"limit":2`)
+
+	assert.Equal(t, http.StatusOK, request("open").Code)
+}
+
+func newProjectRateLimitRouter(projects *stubRateLimitProjectUsecase, apiKeyRateLimit int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), middlewares.RateLimitWindow)
+	r.Use(func(c *gin.Context) {
+		c.Set("apiKeyID", 1)
+		c.Set("apiKeyRateLimit", apiKeyRateLimit)
+		c.Set("projectID", 1)
+		c.Next()
+	})
+	r.Use(middlewares.ApiKeyRateLimitMiddleware(limiter), middlewares.ProjectRateLimitMiddleware(limiter, projects))
+	r.GET("/collections", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestProjectRateLimitMiddleware_Concurrent(t *testing.T) {
+	t.Parallel()
+	projects := &stubRateLimitProjectUsecase{rateLimit: 150}
+	r := newProjectRateLimitRouter(projects, 1000)
+
+	var allowed, limited atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/collections", nil)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				switch w.Code {
+				case http.StatusOK:
+					allowed.Add(1)
+				case http.StatusTooManyRequests:
+					limited.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 同時にリクエストしてもプロジェクトの上限を超えて受け付けない
+	assert.Equal(t, int64(150), allowed.Load())
+	assert.Equal(t, int64(50), limited.Load())
+	// プロジェクトのレート制限はキャッシュする（同時に取得した場合を除いて1回だけ取得する）
+	assert.LessOrEqual(t, projects.calls.Load(), int64(8))
+}
+
+func TestRateLimitHeaders_UseStricterLimit(t *testing.T) {
+	t.Parallel()
+	// キーの上限（1000）よりプロジェクトの上限（5）の方が残りが少ない
+	r := newProjectRateLimitRouter(&stubRateLimitProjectUsecase{rateLimit: 5}, 1000)
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/collections", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "5", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "4", w.Header().Get("X-RateLimit-Remaining"))
+}
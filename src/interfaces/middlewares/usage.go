@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"w3st/infra/logger"
+	"w3st/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageMiddleware プロジェクトの月間上限を確認し、SDKのリクエストの利用量（リクエスト数・レスポンスのバイト数・作成したエントリ数）を数える。
+// 上限に達した場合は 429 を返し、そのリクエストは数えない。上限を確認できない場合はリクエストを受け付ける
+func UsageMiddleware(usageUsecase usecase.UsageUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := c.GetInt("projectID")
+		if projectID == 0 {
+			c.Next()
+			return
+		}
+
+		quota, err := usageUsecase.CheckQuota(c.Request.Context(), projectID)
+		if err != nil {
+			logger.Error("failed to check monthly quota", "project_id", projectID, "error", err.Error())
+		} else if quota.Limit != nil {
+			header := c.Writer.Header()
+			header.Set("X-Quota-Limit", strconv.Itoa(*quota.Limit))
+			header.Set("X-Quota-Remaining", strconv.FormatInt(quota.Remaining(), 10))
+			header.Set("X-Quota-Reset", strconv.FormatInt(quota.Reset.Unix(), 10))
+			if quota.Exceeded() {
+				header.Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(quota.Reset).Seconds()))))
+				c.JSON(http.StatusTooManyRequests, gin.H{
+					"error": "Monthly quota exceeded for this project",
+					"limit": *quota.Limit,
+					"reset": quota.Reset.Format(time.RFC3339),
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+
+		// コレクションのIDを指定しないリクエスト（コレクション一覧）は collection_id を0として数える
+		collectionID, _ := strconv.Atoi(c.Param("collectionId"))
+		var entriesCreated int64
+		if c.Request.Method == http.MethodPost && strings.HasSuffix(c.FullPath(), "/entries") && c.Writer.Status() == http.StatusCreated {
+			entriesCreated = 1
+		}
+		usageUsecase.Record(projectID, c.GetInt("apiKeyID"), collectionID, int64(max(c.Writer.Size(), 0)), entriesCreated)
+	}
+}
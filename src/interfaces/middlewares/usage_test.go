@@ -0,0 +1,105 @@
+package middlewares_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"w3st/domain/models"
+	"w3st/interfaces/middlewares"
+	"w3st/usecase"
+)
+
+// stubUsageUsecase 月間上限を返し、数えた利用量を記録する
+type stubUsageUsecase struct {
+	usecase.UsageUsecase
+	quota models.MonthlyQuota
+
+	mu      sync.Mutex
+	records []usageRecord
+}
+
+type usageRecord struct {
+	projectID, apiKeyID, collectionID int
+	bytesServed, entriesCreated       int64
+}
+
+func (s *stubUsageUsecase) CheckQuota(_ context.Context, _ int) (models.MonthlyQuota, error) {
+	return s.quota, nil
+}
+
+func (s *stubUsageUsecase) Record(projectID, apiKeyID, collectionID int, bytesServed int64, entriesCreated int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, usageRecord{projectID, apiKeyID, collectionID, bytesServed, entriesCreated})
+}
+
+func newUsageRouter(usage *stubUsageUsecase) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("apiKeyID", 10)
+		c.Set("projectID", 1)
+		c.Next()
+	})
+	r.Use(middlewares.UsageMiddleware(usage))
+	r.GET("/collections", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	r.POST("/collections/:collectionId/entries", func(c *gin.Context) {
+		c.String(http.StatusCreated, "created")
+	})
+	return r
+}
+
+func TestUsageMiddleware_RecordsUsage(t *testing.T) {
+	t.Parallel()
+	usage := &stubUsageUsecase{}
+	r := newUsageRouter(usage)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/collections", nil),
+		httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/collections/3/entries", strings.NewReader("{}")),
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		// 月間上限がない場合は X-Quota-* ヘッダーを付けない
+		assert.Empty(t, w.Header().Get("X-Quota-Limit"))
+	}
+
+	assert.Equal(t, []usageRecord{
+		{projectID: 1, apiKeyID: 10, collectionID: 0, bytesServed: 2},
+		{projectID: 1, apiKeyID: 10, collectionID: 3, bytesServed: 7, entriesCreated: 1},
+	}, usage.records)
+}
+
+func TestUsageMiddleware_MonthlyQuota(t *testing.T) {
+	t.Parallel()
+	limit := 100
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	usage := &stubUsageUsecase{quota: models.MonthlyQuota{Limit: &limit, Used: 99, Reset: reset}}
+	r := newUsageRouter(usage)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/collections", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get("X-Quota-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-Quota-Remaining"))
+
+	// 上限に達した場合は 429 を返し、そのリクエストは数えない
+	usage.quota.Used = 100
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/collections", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-Quota-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "Monthly quota exceeded")
+	assert.Len(t, usage.records, 1)
+}
@@ -5,7 +5,9 @@
 package mock_repositories
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "w3st/domain/models"
 
@@ -49,6 +51,20 @@ func (mr *MockCollectionsRepositoryMockRecorder) CreateCollection(newCollection
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCollection", reflect.TypeOf((*MockCollectionsRepository)(nil).CreateCollection), newCollection)
 }
 
+// DeleteCollection mocks base method.
+func (m *MockCollectionsRepository) DeleteCollection(ctx context.Context, collectionId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCollection", ctx, collectionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCollection indicates an expected call of DeleteCollection.
+func (mr *MockCollectionsRepositoryMockRecorder) DeleteCollection(ctx, collectionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCollection", reflect.TypeOf((*MockCollectionsRepository)(nil).DeleteCollection), ctx, collectionId)
+}
+
 // GetCollectionByProjectId mocks base method.
 func (m *MockCollectionsRepository) GetCollectionByProjectId(projectId int) ([]models.ApiCollection, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +80,21 @@ func (mr *MockCollectionsRepositoryMockRecorder) GetCollectionByProjectId(projec
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectionByProjectId", reflect.TypeOf((*MockCollectionsRepository)(nil).GetCollectionByProjectId), projectId)
 }
 
+// GetCollectionBySlug mocks base method.
+func (m *MockCollectionsRepository) GetCollectionBySlug(ctx context.Context, projectId int, slug string) (*models.ApiCollection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCollectionBySlug", ctx, projectId, slug)
+	ret0, _ := ret[0].(*models.ApiCollection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCollectionBySlug indicates an expected call of GetCollectionBySlug.
+func (mr *MockCollectionsRepositoryMockRecorder) GetCollectionBySlug(ctx, projectId, slug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectionBySlug", reflect.TypeOf((*MockCollectionsRepository)(nil).GetCollectionBySlug), ctx, projectId, slug)
+}
+
 // GetCollectionsByCollectionId mocks base method.
 func (m *MockCollectionsRepository) GetCollectionsByCollectionId(collectionId, projectId int) (*models.ApiCollection, error) {
 	m.ctrl.T.Helper()
@@ -78,3 +109,121 @@ func (mr *MockCollectionsRepositoryMockRecorder) GetCollectionsByCollectionId(co
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectionsByCollectionId", reflect.TypeOf((*MockCollectionsRepository)(nil).GetCollectionsByCollectionId), collectionId, projectId)
 }
+
+// GetCollectionsByIds mocks base method.
+func (m *MockCollectionsRepository) GetCollectionsByIds(projectId int, collectionIds []int) ([]models.ApiCollection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCollectionsByIds", projectId, collectionIds)
+	ret0, _ := ret[0].([]models.ApiCollection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCollectionsByIds indicates an expected call of GetCollectionsByIds.
+func (mr *MockCollectionsRepositoryMockRecorder) GetCollectionsByIds(projectId, collectionIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectionsByIds", reflect.TypeOf((*MockCollectionsRepository)(nil).GetCollectionsByIds), projectId, collectionIds)
+}
+
+// GetDeletedCollections mocks base method.
+func (m *MockCollectionsRepository) GetDeletedCollections(ctx context.Context, projectId int) ([]models.ApiCollection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedCollections", ctx, projectId)
+	ret0, _ := ret[0].([]models.ApiCollection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedCollections indicates an expected call of GetDeletedCollections.
+func (mr *MockCollectionsRepositoryMockRecorder) GetDeletedCollections(ctx, projectId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedCollections", reflect.TypeOf((*MockCollectionsRepository)(nil).GetDeletedCollections), ctx, projectId)
+}
+
+// GetDeletedCollectionsBefore mocks base method.
+func (m *MockCollectionsRepository) GetDeletedCollectionsBefore(ctx context.Context, before time.Time) ([]models.ApiCollection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedCollectionsBefore", ctx, before)
+	ret0, _ := ret[0].([]models.ApiCollection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedCollectionsBefore indicates an expected call of GetDeletedCollectionsBefore.
+func (mr *MockCollectionsRepositoryMockRecorder) GetDeletedCollectionsBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedCollectionsBefore", reflect.TypeOf((*MockCollectionsRepository)(nil).GetDeletedCollectionsBefore), ctx, before)
+}
+
+// GetReferencingCollectionIds mocks base method.
+func (m *MockCollectionsRepository) GetReferencingCollectionIds(ctx context.Context, collectionId int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferencingCollectionIds", ctx, collectionId)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferencingCollectionIds indicates an expected call of GetReferencingCollectionIds.
+func (mr *MockCollectionsRepositoryMockRecorder) GetReferencingCollectionIds(ctx, collectionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferencingCollectionIds", reflect.TypeOf((*MockCollectionsRepository)(nil).GetReferencingCollectionIds), ctx, collectionId)
+}
+
+// LockCollection mocks base method.
+func (m *MockCollectionsRepository) LockCollection(ctx context.Context, collectionId, projectId int) (*models.ApiCollection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockCollection", ctx, collectionId, projectId)
+	ret0, _ := ret[0].(*models.ApiCollection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockCollection indicates an expected call of LockCollection.
+func (mr *MockCollectionsRepositoryMockRecorder) LockCollection(ctx, collectionId, projectId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockCollection", reflect.TypeOf((*MockCollectionsRepository)(nil).LockCollection), ctx, collectionId, projectId)
+}
+
+// RestoreCollection mocks base method.
+func (m *MockCollectionsRepository) RestoreCollection(ctx context.Context, collectionId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreCollection", ctx, collectionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreCollection indicates an expected call of RestoreCollection.
+func (mr *MockCollectionsRepositoryMockRecorder) RestoreCollection(ctx, collectionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreCollection", reflect.TypeOf((*MockCollectionsRepository)(nil).RestoreCollection), ctx, collectionId)
+}
+
+// SoftDeleteCollection mocks base method.
+func (m *MockCollectionsRepository) SoftDeleteCollection(ctx context.Context, collectionId int, deletedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteCollection", ctx, collectionId, deletedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftDeleteCollection indicates an expected call of SoftDeleteCollection.
+func (mr *MockCollectionsRepositoryMockRecorder) SoftDeleteCollection(ctx, collectionId, deletedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteCollection", reflect.TypeOf((*MockCollectionsRepository)(nil).SoftDeleteCollection), ctx, collectionId, deletedAt)
+}
+
+// UpdateCollection mocks base method.
+func (m *MockCollectionsRepository) UpdateCollection(ctx context.Context, collectionId int, update models.CollectionUpdate) (*models.ApiCollection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCollection", ctx, collectionId, update)
+	ret0, _ := ret[0].(*models.ApiCollection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCollection indicates an expected call of UpdateCollection.
+func (mr *MockCollectionsRepositoryMockRecorder) UpdateCollection(ctx, collectionId, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCollection", reflect.TypeOf((*MockCollectionsRepository)(nil).UpdateCollection), ctx, collectionId, update)
+}
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: src/domain/repositories/field.go
+// Source: domain/repositories/field.go
 
 // Package mock_repositories is a generated GoMock package.
 package mock_repositories
@@ -64,6 +64,21 @@ func (mr *MockFieldRepositoryMockRecorder) DeleteFieldById(projectId, fieldId in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFieldById", reflect.TypeOf((*MockFieldRepository)(nil).DeleteFieldById), projectId, fieldId)
 }
 
+// GetField mocks base method.
+func (m *MockFieldRepository) GetField(collectionId, projectId, fieldId int) (*models.FieldData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetField", collectionId, projectId, fieldId)
+	ret0, _ := ret[0].(*models.FieldData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetField indicates an expected call of GetField.
+func (mr *MockFieldRepositoryMockRecorder) GetField(collectionId, projectId, fieldId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetField", reflect.TypeOf((*MockFieldRepository)(nil).GetField), collectionId, projectId, fieldId)
+}
+
 // GetFieldsByCollectionId mocks base method.
 func (m *MockFieldRepository) GetFieldsByCollectionId(collectionId, projectId int) ([]models.FieldData, error) {
 	m.ctrl.T.Helper()
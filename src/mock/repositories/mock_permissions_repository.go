@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: domain/repositories/permissions.go
+// Source: src/domain/repositories/permissions.go
 
 // Package mock_repositories is a generated GoMock package.
 package mock_repositories
@@ -7,6 +7,7 @@ package mock_repositories
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "w3st/domain/models"
 	errors "w3st/errors"
@@ -37,75 +38,92 @@ func (m *MockPermissionRepository) EXPECT() *MockPermissionRepositoryMockRecorde
 	return m.recorder
 }
 
-// Create mocks base method.
-func (m *MockPermissionRepository) Create(ctx context.Context, permission *models.UserPermission) *errors.DomainError {
+// CreateBatch mocks base method.
+func (m *MockPermissionRepository) CreateBatch(ctx context.Context, permissions []*models.UserPermission) (int, *errors.DomainError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, permission)
-	ret0, _ := ret[0].(*errors.DomainError)
-	return ret0
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, permissions)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(*errors.DomainError)
+	return ret0, ret1
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockPermissionRepositoryMockRecorder) CreateBatch(ctx, permissions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockPermissionRepository)(nil).CreateBatch), ctx, permissions)
+}
+
+// DeleteByResource mocks base method.
+func (m *MockPermissionRepository) DeleteByResource(ctx context.Context, projectID int, userIDs, permissions []string, resource models.Resource) (int, *errors.DomainError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByResource", ctx, projectID, userIDs, permissions, resource)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(*errors.DomainError)
+	return ret0, ret1
 }
 
-// Create indicates an expected call of Create.
-func (mr *MockPermissionRepositoryMockRecorder) Create(ctx, permission interface{}) *gomock.Call {
+// DeleteByResource indicates an expected call of DeleteByResource.
+func (mr *MockPermissionRepositoryMockRecorder) DeleteByResource(ctx, projectID, userIDs, permissions, resource interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPermissionRepository)(nil).Create), ctx, permission)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByResource", reflect.TypeOf((*MockPermissionRepository)(nil).DeleteByResource), ctx, projectID, userIDs, permissions, resource)
 }
 
-// Delete mocks base method.
-func (m *MockPermissionRepository) Delete(ctx context.Context, id string) *errors.DomainError {
+// DeleteExpired mocks base method.
+func (m *MockPermissionRepository) DeleteExpired(ctx context.Context, now time.Time) (int, *errors.DomainError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id)
-	ret0, _ := ret[0].(*errors.DomainError)
-	return ret0
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(*errors.DomainError)
+	return ret0, ret1
 }
 
-// Delete indicates an expected call of Delete.
-func (mr *MockPermissionRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockPermissionRepositoryMockRecorder) DeleteExpired(ctx, now interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPermissionRepository)(nil).Delete), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockPermissionRepository)(nil).DeleteExpired), ctx, now)
 }
 
 // FindByID mocks base method.
-func (m *MockPermissionRepository) FindByID(ctx context.Context, id string) (*models.UserPermission, *errors.DomainError) {
+func (m *MockPermissionRepository) FindByID(ctx context.Context, projectID int, id string) (*models.UserPermission, *errors.DomainError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret := m.ctrl.Call(m, "FindByID", ctx, projectID, id)
 	ret0, _ := ret[0].(*models.UserPermission)
 	ret1, _ := ret[1].(*errors.DomainError)
 	return ret0, ret1
 }
 
 // FindByID indicates an expected call of FindByID.
-func (mr *MockPermissionRepositoryMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
+func (mr *MockPermissionRepositoryMockRecorder) FindByID(ctx, projectID, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockPermissionRepository)(nil).FindByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockPermissionRepository)(nil).FindByID), ctx, projectID, id)
 }
 
 // FindByUserID mocks base method.
-func (m *MockPermissionRepository) FindByUserID(ctx context.Context, userID string) ([]*models.UserPermission, *errors.DomainError) {
+func (m *MockPermissionRepository) FindByUserID(ctx context.Context, projectID int, userID string) ([]*models.UserPermission, *errors.DomainError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByUserID", ctx, userID)
+	ret := m.ctrl.Call(m, "FindByUserID", ctx, projectID, userID)
 	ret0, _ := ret[0].([]*models.UserPermission)
 	ret1, _ := ret[1].(*errors.DomainError)
 	return ret0, ret1
 }
 
 // FindByUserID indicates an expected call of FindByUserID.
-func (mr *MockPermissionRepositoryMockRecorder) FindByUserID(ctx, userID interface{}) *gomock.Call {
+func (mr *MockPermissionRepositoryMockRecorder) FindByUserID(ctx, projectID, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserID", reflect.TypeOf((*MockPermissionRepository)(nil).FindByUserID), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserID", reflect.TypeOf((*MockPermissionRepository)(nil).FindByUserID), ctx, projectID, userID)
 }
 
-// FindByUserIDAndResource mocks base method.
-func (m *MockPermissionRepository) FindByUserIDAndResource(ctx context.Context, userID, resource string) ([]*models.UserPermission, *errors.DomainError) {
+// FindByUserIDAndPermission mocks base method.
+func (m *MockPermissionRepository) FindByUserIDAndPermission(ctx context.Context, projectID int, userID, permission string) ([]*models.UserPermission, *errors.DomainError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByUserIDAndResource", ctx, userID, resource)
+	ret := m.ctrl.Call(m, "FindByUserIDAndPermission", ctx, projectID, userID, permission)
 	ret0, _ := ret[0].([]*models.UserPermission)
 	ret1, _ := ret[1].(*errors.DomainError)
 	return ret0, ret1
 }
 
-// FindByUserIDAndResource indicates an expected call of FindByUserIDAndResource.
-func (mr *MockPermissionRepositoryMockRecorder) FindByUserIDAndResource(ctx, userID, resource interface{}) *gomock.Call {
+// FindByUserIDAndPermission indicates an expected call of FindByUserIDAndPermission.
+func (mr *MockPermissionRepositoryMockRecorder) FindByUserIDAndPermission(ctx, projectID, userID, permission interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserIDAndResource", reflect.TypeOf((*MockPermissionRepository)(nil).FindByUserIDAndResource), ctx, userID, resource)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserIDAndPermission", reflect.TypeOf((*MockPermissionRepository)(nil).FindByUserIDAndPermission), ctx, projectID, userID, permission)
 }
@@ -0,0 +1,68 @@
+package presenter
+
+import (
+	"time"
+
+	"w3st/domain/models"
+	"w3st/dto"
+)
+
+type ApiKeyPresenter interface {
+	ResponseApiKey(apiKey *models.ApiKeys) *dto.ApiKeyResponse
+	ResponseApiKeys(apiKeys []*models.ApiKeys) []*dto.ApiKeyResponse
+	// ResponseIssuedApiKey 作成・ローテーション直後のみ、キーそのものを含めて返す
+	ResponseIssuedApiKey(issued *models.IssuedApiKey) *dto.IssuedApiKeyResponse
+}
+
+type apiKeyPresenter struct{}
+
+func NewApiKeyPresenter() ApiKeyPresenter {
+	return &apiKeyPresenter{}
+}
+
+func (p *apiKeyPresenter) ResponseApiKey(apiKey *models.ApiKeys) *dto.ApiKeyResponse {
+	var previousKeyExpireAt *string
+	if apiKey.InGracePeriod(time.Now()) {
+		formatted := apiKey.PreviousKeyExpireAt.Format(ISO8601Format)
+		previousKeyExpireAt = &formatted
+	}
+	ipWhiteList := apiKey.IpWhiteList
+	if ipWhiteList == nil {
+		ipWhiteList = []string{}
+	}
+	scopes := []string(apiKey.Scopes)
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return &dto.ApiKeyResponse{
+		ID:                  apiKey.Id,
+		Name:                apiKey.Name,
+		KeyPrefix:           apiKey.KeyPrefix,
+		UserID:              apiKey.UserID.String(),
+		ProjectID:           apiKey.ProjectID,
+		CollectionIds:       apiKey.CollectionIDs(),
+		IpWhiteList:         ipWhiteList,
+		ExpireAt:            apiKey.ExpireAt.Format(ISO8601Format),
+		Revoked:             apiKey.Revoked,
+		RateLimit:           apiKey.RateLimit,
+		Scopes:              scopes,
+		CollectionScopes:    apiKey.CollectionScopeOverrides(),
+		PreviousKeyExpireAt: previousKeyExpireAt,
+		CreatedAt:           apiKey.CreatedAt.Format(ISO8601Format),
+	}
+}
+
+func (p *apiKeyPresenter) ResponseApiKeys(apiKeys []*models.ApiKeys) []*dto.ApiKeyResponse {
+	responses := make([]*dto.ApiKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		responses[i] = p.ResponseApiKey(apiKey)
+	}
+	return responses
+}
+
+func (p *apiKeyPresenter) ResponseIssuedApiKey(issued *models.IssuedApiKey) *dto.IssuedApiKeyResponse {
+	return &dto.IssuedApiKeyResponse{
+		ApiKey:         issued.Key,
+		ApiKeyResponse: p.ResponseApiKey(issued.ApiKey),
+	}
+}
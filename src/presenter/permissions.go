@@ -1,6 +1,8 @@
 package presenter
 
 import (
+	"strconv"
+
 	"w3st/domain/models"
 	"w3st/dto"
 )
@@ -8,6 +10,8 @@ import (
 type PermissionPresenter interface {
 	ResponsePermission(permission *models.UserPermission) *dto.PermissionResponse
 	ResponsePermissions(permissions []*models.UserPermission) []*dto.PermissionResponse
+	ResponseExplanation(explanation *models.PermissionExplanation) *dto.PermissionExplainResponse
+	ResponseBatch(message string, result *models.PermissionBatchResult) *dto.PermissionBatchResponse
 }
 
 type permissionPresenter struct{}
@@ -17,11 +21,18 @@ func NewPermissionPresenter() PermissionPresenter {
 }
 
 func (p *permissionPresenter) ResponsePermission(permission *models.UserPermission) *dto.PermissionResponse {
+	var expiresAt *string
+	if permission.ExpiresAt != nil {
+		formatted := permission.ExpiresAt.Format(ISO8601Format)
+		expiresAt = &formatted
+	}
 	return &dto.PermissionResponse{
-		ID:         permission.ID.String(),
+		ID:         strconv.Itoa(permission.ID),
 		UserID:     permission.UserID.String(),
 		Permission: permission.Permission,
+		Effect:     permission.Effect,
 		Resource:   permission.Resource,
+		ExpiresAt:  expiresAt,
 		CreatedAt:  permission.CreatedAt.Format(ISO8601Format),
 		UpdatedAt:  permission.UpdatedAt.Format(ISO8601Format),
 	}
@@ -34,3 +45,25 @@ func (p *permissionPresenter) ResponsePermissions(permissions []*models.UserPerm
 	}
 	return responses
 }
+
+func (p *permissionPresenter) ResponseExplanation(explanation *models.PermissionExplanation) *dto.PermissionExplainResponse {
+	response := &dto.PermissionExplainResponse{
+		Permission: explanation.Permission,
+		Resource:   explanation.Resource,
+		Allowed:    explanation.Allowed,
+		Effect:     explanation.Effect,
+		Matches:    p.ResponsePermissions(explanation.Matches),
+	}
+	if explanation.Grant != nil {
+		response.Grant = p.ResponsePermission(explanation.Grant)
+	}
+	return response
+}
+
+func (p *permissionPresenter) ResponseBatch(message string, result *models.PermissionBatchResult) *dto.PermissionBatchResponse {
+	return &dto.PermissionBatchResponse{
+		Message:  message,
+		Affected: result.Affected,
+		Skipped:  result.Skipped,
+	}
+}
@@ -0,0 +1,53 @@
+package presenter
+
+import (
+	"w3st/domain/models"
+	"w3st/dto"
+)
+
+type SessionPresenter interface {
+	ResponseToken(pair *models.TokenPair) *dto.TokenResponse
+	ResponseSessions(sessions []models.Session, currentSessionID string) []*dto.SessionResponse
+	ResponseMFAChallenge(challengeToken models.Token, expiresIn int64) *dto.MFAChallengeResponse
+}
+
+type sessionPresenter struct{}
+
+func NewSessionPresenter() SessionPresenter {
+	return &sessionPresenter{}
+}
+
+func (s *sessionPresenter) ResponseToken(pair *models.TokenPair) *dto.TokenResponse {
+	return &dto.TokenResponse{
+		Token:        string(pair.AccessToken),
+		RefreshToken: pair.RefreshToken,
+		ExpiresIn:    pair.ExpiresIn,
+		SessionID:    pair.SessionID.String(),
+	}
+}
+
+func (s *sessionPresenter) ResponseSessions(sessions []models.Session, currentSessionID string) []*dto.SessionResponse {
+	responses := make([]*dto.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		// クライアントにはリフレッシュトークン単位ではなくセッション（ファミリー）単位で見せる
+		responses[i] = &dto.SessionResponse{
+			ID:         session.FamilyID.String(),
+			DeviceName: session.DeviceName,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			LastUsedAt: session.LastUsedAt.Format(ISO8601Format),
+			ExpiresAt:  session.ExpiresAt.Format(ISO8601Format),
+			Current:    session.FamilyID.String() == currentSessionID,
+		}
+	}
+	return responses
+}
+
+// ResponseMFAChallenge 二要素認証が必要な場合、トークンの代わりに入力待ちトークンを返す
+func (s *sessionPresenter) ResponseMFAChallenge(challengeToken models.Token, expiresIn int64) *dto.MFAChallengeResponse {
+	return &dto.MFAChallengeResponse{
+		MFARequired:    true,
+		ChallengeToken: string(challengeToken),
+		ExpiresIn:      expiresIn,
+	}
+}
@@ -0,0 +1,159 @@
+package router
+
+import (
+	"net/http"
+
+	"w3st/domain/models"
+	"w3st/interfaces/controllers"
+	"w3st/interfaces/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiRoute GUI用のルートと、実行に必要な権限
+type apiRoute struct {
+	method     string
+	path       string
+	permission string
+	// project 操作対象のプロジェクト（X-Project-Id または /api/projects/:projectId）の指定が必要か
+	project bool
+	handler gin.HandlerFunc
+}
+
+// apiControllers /api のルートで使うコントローラー
+type apiControllers struct {
+	user          *controllers.UserController
+	apiKey        *controllers.ApiKeyController
+	guiCollection *controllers.GUICollectionsController
+	guiEntries    *controllers.GUIEntriesController
+	listOptions   *controllers.ListOptionsController
+	relations     *controllers.RelationsController
+	media         *controllers.MediaController
+	version       *controllers.VersionController
+	permission    *controllers.PermissionController
+	audit         *controllers.AuditController
+	systemAlert   *controllers.SystemAlertController
+	project       *controllers.ProjectController
+	usage         *controllers.UsageController
+}
+
+func apiRoutes(c apiControllers) []apiRoute {
+	return []apiRoute{
+		// 管理者用ユーザー管理API（システム管理者のみ）
+		{http.MethodGet, "/users", models.PermissionUsersManage, false, c.user.GetAllUsers},
+		{http.MethodPut, "/users/:userId", models.PermissionUsersManage, false, c.user.UpdateUserById},
+		{http.MethodDelete, "/users/:userId", models.PermissionUsersManage, false, c.user.DeleteUser},
+		{http.MethodDelete, "/users/:userId/mfa", models.PermissionUsersManage, false, c.user.ResetUserMFA},
+
+		// API Keys
+		{http.MethodGet, "/api-keys", models.PermissionApiKeysRead, true, c.apiKey.GetApiKeys},
+		{http.MethodPost, "/api-keys", models.PermissionApiKeysWrite, true, c.apiKey.CreateApiKey},
+		{http.MethodGet, "/api-keys/:id", models.PermissionApiKeysRead, true, c.apiKey.GetApiKey},
+		{http.MethodPatch, "/api-keys/:id", models.PermissionApiKeysWrite, true, c.apiKey.UpdateApiKey},
+		{http.MethodDelete, "/api-keys/:id", models.PermissionApiKeysWrite, true, c.apiKey.DeleteApiKey},
+		{http.MethodPost, "/api-keys/:id/rotate", models.PermissionApiKeysWrite, true, c.apiKey.RotateApiKey},
+		{http.MethodPost, "/api-keys/:id/collections", models.PermissionApiKeysWrite, true, c.apiKey.AttachCollection},
+		{http.MethodDelete, "/api-keys/:id/collections/:collectionId", models.PermissionApiKeysWrite, true, c.apiKey.DetachCollection},
+
+		// Collections
+		{http.MethodGet, "/collections", models.PermissionCollectionsRead, true, c.guiCollection.GetCollections},
+		{http.MethodPost, "/collections", models.PermissionCollectionsWrite, true, c.guiCollection.MakeCollection},
+		{http.MethodPatch, "/collections/:collectionId", models.PermissionCollectionsWrite, true, c.guiCollection.UpdateCollection},
+		{http.MethodDelete, "/collections/:collectionId", models.PermissionCollectionsWrite, true, c.guiCollection.DeleteCollection},
+		{http.MethodGet, "/collections/trash", models.PermissionCollectionsRead, true, c.guiCollection.GetTrash},
+		{http.MethodPost, "/collections/:collectionId/restore", models.PermissionCollectionsWrite, true, c.guiCollection.RestoreCollection},
+
+		// Fields
+		{http.MethodGet, "/collections/:collectionId/fields", models.PermissionCollectionsRead, true, c.guiCollection.GetFields},
+		{http.MethodPost, "/collections/:collectionId/fields", models.PermissionCollectionsWrite, true, c.guiCollection.CreateField},
+		{http.MethodPut, "/collections/:collectionId/fields/:fieldId", models.PermissionCollectionsWrite, true, c.guiCollection.UpdateField},
+		{http.MethodDelete, "/collections/:collectionId/fields/:fieldId", models.PermissionCollectionsWrite, true, c.guiCollection.DeleteField},
+
+		// List Options（select・multiselect のフィールドの選択肢）
+		{http.MethodGet, "/collections/:collectionId/fields/:fieldId/options", models.PermissionCollectionsRead, true, c.listOptions.GetOptions},
+		{http.MethodPost, "/collections/:collectionId/fields/:fieldId/options", models.PermissionCollectionsWrite, true, c.listOptions.CreateOption},
+		{http.MethodPut, "/collections/:collectionId/fields/:fieldId/options/order", models.PermissionCollectionsWrite, true, c.listOptions.ReorderOptions},
+		{http.MethodPatch, "/collections/:collectionId/fields/:fieldId/options/:optionId", models.PermissionCollectionsWrite, true, c.listOptions.UpdateOption},
+		{http.MethodDelete, "/collections/:collectionId/fields/:fieldId/options/:optionId", models.PermissionCollectionsWrite, true, c.listOptions.DeleteOption},
+
+		// Entries
+		{http.MethodGet, "/collections/:collectionId/entries", models.PermissionEntriesRead, true, c.guiEntries.GetEntries},
+		{http.MethodPost, "/collections/:collectionId/entries", models.PermissionEntriesCreate, true, c.guiEntries.CreateEntry},
+		{http.MethodPut, "/collections/:collectionId/entries/:entryId", models.PermissionEntriesWrite, true, c.guiEntries.UpdateEntry},
+		{http.MethodDelete, "/collections/:collectionId/entries/:entryId", models.PermissionEntriesWrite, true, c.guiEntries.DeleteEntry},
+		// エントリを参照しているエントリ（リレーションの逆引き）
+		{http.MethodGet, "/collections/:collectionId/entries/:entryId/references", models.PermissionEntriesRead, true, c.relations.GetReferences},
+
+		// Relations（relation 型のフィールドが参照するコレクション）
+		{http.MethodGet, "/collections/:collectionId/relations", models.PermissionCollectionsRead, true, c.relations.GetRelations},
+		{http.MethodPost, "/collections/:collectionId/relations", models.PermissionCollectionsWrite, true, c.relations.CreateRelation},
+		{http.MethodPatch, "/collections/:collectionId/relations/:relationId", models.PermissionCollectionsWrite, true, c.relations.UpdateRelation},
+		{http.MethodDelete, "/collections/:collectionId/relations/:relationId", models.PermissionCollectionsWrite, true, c.relations.DeleteRelation},
+
+		// Media
+		{http.MethodPost, "/media", models.PermissionMediaWrite, true, c.media.Upload},
+		{http.MethodGet, "/media", models.PermissionMediaRead, true, c.media.GetByUserID},
+		{http.MethodGet, "/media/:id", models.PermissionMediaRead, true, c.media.GetByID},
+		{http.MethodDelete, "/media/:id", models.PermissionMediaWrite, true, c.media.Delete},
+
+		// Versions
+		{http.MethodPost, "/versions", models.PermissionVersionsWrite, true, c.version.CreateVersion},
+		{http.MethodGet, "/versions/:contentID", models.PermissionVersionsRead, true, c.version.GetVersionsByContentID},
+		{http.MethodGet, "/versions/:contentID/latest", models.PermissionVersionsRead, true, c.version.GetLatestVersion},
+		{http.MethodPost, "/versions/:contentID/restore/:versionID", models.PermissionVersionsWrite, true, c.version.RestoreVersion},
+
+		// Permissions（自分の権限の確認はメンバーであれば誰でもできる）
+		{http.MethodGet, "/permissions/check", models.PermissionProjectsRead, true, c.permission.CheckPermission},
+		{http.MethodGet, "/permissions/explain", models.PermissionPermissionsRead, true, c.permission.ExplainPermission},
+		{http.MethodPost, "/permissions/grant", models.PermissionPermissionsWrite, true, c.permission.GrantPermission},
+		{http.MethodPost, "/permissions/revoke", models.PermissionPermissionsWrite, true, c.permission.RevokePermission},
+		{http.MethodPost, "/permissions/bulk-grant", models.PermissionPermissionsWrite, true, c.permission.BulkGrantPermissions},
+		{http.MethodPost, "/permissions/bulk-revoke", models.PermissionPermissionsWrite, true, c.permission.BulkRevokePermissions},
+		{http.MethodPost, "/permissions/copy", models.PermissionPermissionsWrite, true, c.permission.CopyPermissions},
+		{http.MethodGet, "/permissions/user", models.PermissionProjectsRead, true, c.permission.GetPermissionsByUser},
+
+		// Audit
+		{http.MethodPost, "/audit", models.PermissionAuditWrite, true, c.audit.LogAction},
+		{http.MethodGet, "/audit/user", models.PermissionAuditReadOwn, false, c.audit.GetLogsByUser},
+		{http.MethodGet, "/audit/action/:action", models.PermissionAuditReadAll, false, c.audit.GetLogsByAction},
+		{http.MethodGet, "/audit/project/:projectId", models.PermissionAuditRead, true, c.audit.GetLogsByProject},
+		{http.MethodGet, "/audit/all", models.PermissionAuditReadAll, false, c.audit.GetAllLogs},
+
+		// System Alerts
+		{http.MethodGet, "/system-alerts", models.PermissionAlertsRead, true, c.systemAlert.GetAlerts},
+		{http.MethodGet, "/system-alerts/active", models.PermissionAlertsRead, true, c.systemAlert.GetActiveAlerts},
+		{http.MethodPost, "/system-alerts", models.PermissionAlertsWrite, true, c.systemAlert.CreateAlert},
+		{http.MethodPut, "/system-alerts/:id/read", models.PermissionAlertsRead, true, c.systemAlert.MarkAsRead},
+		{http.MethodDelete, "/system-alerts/:id", models.PermissionAlertsWrite, true, c.systemAlert.DeleteAlert},
+		{http.MethodGet, "/system-alerts/count", models.PermissionAlertsRead, true, c.systemAlert.GetAlertCount},
+
+		// Projects
+		{http.MethodPost, "/projects", models.PermissionProjectsCreate, false, c.project.CreateProject},
+		{http.MethodGet, "/projects", models.PermissionProjectsList, false, c.project.GetAllProjects},
+		{http.MethodGet, "/projects/:projectId", models.PermissionProjectsRead, true, c.project.GetProjectByID},
+		// プロジェクトメンバー（自分自身はメンバーであれば削除できるため、他のメンバーの削除権限はユースケースで確認する）
+		{http.MethodGet, "/projects/:projectId/members", models.PermissionMembersRead, true, c.project.GetMembers},
+		{http.MethodPost, "/projects/:projectId/members", models.PermissionMembersWrite, true, c.project.InviteMember},
+		{http.MethodDelete, "/projects/:projectId/members/:userId", models.PermissionMembersRead, true, c.project.RemoveMember},
+		// ロール
+		{http.MethodGet, "/projects/:projectId/roles", models.PermissionRolesRead, true, c.project.GetRoles},
+		{http.MethodPost, "/projects/:projectId/roles", models.PermissionRolesWrite, true, c.project.CreateRole},
+		{http.MethodDelete, "/projects/:projectId/roles/:roleId", models.PermissionRolesWrite, true, c.project.DeleteRole},
+		// 利用量と月間上限
+		{http.MethodGet, "/projects/:projectId/usage", models.PermissionProjectsRead, true, c.usage.GetUsage},
+		{http.MethodPut, "/projects/:projectId/quota", models.PermissionProjectsWrite, true, c.usage.SetMonthlyQuota},
+	}
+}
+
+// registerAPIRoutes すべてのルートにプロジェクトの指定と権限のチェックを設定する
+func registerAPIRoutes(api *gin.RouterGroup, authz *middlewares.Authorizer, routes []apiRoute) {
+	requireProject := middlewares.RequireProjectMiddleware()
+	for _, route := range routes {
+		handlers := make([]gin.HandlerFunc, 0, 3)
+		if route.project {
+			handlers = append(handlers, requireProject)
+		}
+		handlers = append(handlers, authz.RequirePermission(route.permission, middlewares.ResourceFromPath), route.handler)
+		api.Handle(route.method, route.path, handlers...)
+	}
+}
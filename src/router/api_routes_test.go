@@ -0,0 +1,281 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	"w3st/interfaces/middlewares"
+	mockRepositories "w3st/mock/repositories"
+	"w3st/usecase"
+)
+
+const testProjectID = 1
+
+// testUser プロジェクト1でのロール（空文字はメンバーでない）
+type testUser struct {
+	id         uuid.UUID
+	systemRole string
+	role       string
+}
+
+var testUsers = map[string]testUser{
+	"owner":      {id: uuid.New(), systemRole: models.UserRoleUser, role: models.ProjectRoleOwner},
+	"admin":      {id: uuid.New(), systemRole: models.UserRoleUser, role: models.ProjectRoleAdmin},
+	"editor":     {id: uuid.New(), systemRole: models.UserRoleUser, role: models.ProjectRoleEditor},
+	"author":     {id: uuid.New(), systemRole: models.UserRoleUser, role: models.ProjectRoleAuthor},
+	"viewer":     {id: uuid.New(), systemRole: models.UserRoleUser, role: models.ProjectRoleViewer},
+	"translator": {id: uuid.New(), systemRole: models.UserRoleUser, role: "translator"},
+	"outsider":   {id: uuid.New(), systemRole: models.UserRoleUser},
+	"sysadmin":   {id: uuid.New(), systemRole: models.UserRoleAdmin},
+}
+
+// stubProjectUsecase testUsers のロールでメンバーかどうかを返す
+type stubProjectUsecase struct {
+	usecase.ProjectUsecase
+}
+
+func (stubProjectUsecase) GetMembership(_ context.Context, projectID int, userID uuid.UUID) (*models.ProjectMember, error) {
+	for _, u := range testUsers {
+		if u.id == userID && u.role != "" && projectID == testProjectID {
+			return &models.ProjectMember{ProjectID: projectID, UserID: userID, Role: u.role}, nil
+		}
+	}
+	return nil, myerrors.NewDomainErrorWithMessage(myerrors.UnPermittedOperation, "このプロジェクトのメンバーではありません")
+}
+
+func (stubProjectUsecase) GetProjectByID(_ context.Context, id int) (*models.Project, error) {
+	return &models.Project{ID: id}, nil
+}
+
+// newTestAPIRouter 認証済みのユーザーを X-Test-User ヘッダーで切り替えられる /api ルーター。
+// ハンドラーは 200 を返すだけのものに置き換える
+func newTestAPIRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+
+	roleRepo := mockRepositories.NewMockRoleRepository(ctrl)
+	roleRepo.EXPECT().FindByName(gomock.Any(), testProjectID, "translator").Return(&models.ProjectRole{
+		ID:          1,
+		ProjectID:   testProjectID,
+		Name:        "translator",
+		Permissions: []string{models.PermissionCollectionsRead, models.PermissionEntriesRead, models.PermissionEntriesWrite},
+	}, nil).AnyTimes()
+	permissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
+	permissionRepo.EXPECT().FindByUserIDAndPermission(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	memberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	roleUsecase := usecase.NewRoleUsecase(roleRepo, memberRepo)
+	authz := middlewares.NewAuthorizer(roleUsecase, usecase.NewPermissionUsecase(permissionRepo, memberRepo, roleUsecase))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/api")
+	api.Use(func(c *gin.Context) {
+		u := testUsers[c.GetHeader("X-Test-User")]
+		c.Set("userID", u.id.String())
+		c.Set("userRole", u.systemRole)
+		c.Next()
+	})
+	api.Use(middlewares.ProjectContextMiddleware(stubProjectUsecase{}))
+
+	routes := apiRoutes(apiControllers{})
+	for i := range routes {
+		routes[i].handler = func(c *gin.Context) { c.Status(http.StatusOK) }
+	}
+	registerAPIRoutes(api, authz, routes)
+	return r
+}
+
+// requestPath パスパラメータを埋めたリクエストのパス
+func requestPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "1"
+		}
+	}
+	return "/api" + strings.Join(segments, "/")
+}
+
+// 権限の少ない順。minRole 以上のロールであれば実行できる
+var roleRank = map[string]int{
+	"any":    0,
+	"viewer": 1,
+	"author": 2,
+	"editor": 3,
+	"admin":  4,
+	"owner":  5,
+	// システム管理者のみ
+	"system": 99,
+}
+
+func TestAPIRoutes_RoleMatrix(t *testing.T) {
+	t.Parallel()
+	r := newTestAPIRouter(t)
+
+	// ルートごとに実行できる最小のロール
+	minRoles := map[string]string{
+		"GET /users":                                                          "system",
+		"PUT /users/:userId":                                                  "system",
+		"DELETE /users/:userId":                                               "system",
+		"DELETE /users/:userId/mfa":                                           "system",
+		"GET /api-keys":                                                       "admin",
+		"POST /api-keys":                                                      "admin",
+		"GET /api-keys/:id":                                                   "admin",
+		"PATCH /api-keys/:id":                                                 "admin",
+		"DELETE /api-keys/:id":                                                "admin",
+		"POST /api-keys/:id/rotate":                                           "admin",
+		"POST /api-keys/:id/collections":                                      "admin",
+		"DELETE /api-keys/:id/collections/:collectionId":                      "admin",
+		"GET /collections":                                                    "viewer",
+		"POST /collections":                                                   "admin",
+		"PATCH /collections/:collectionId":                                    "admin",
+		"DELETE /collections/:collectionId":                                   "admin",
+		"GET /collections/trash":                                              "viewer",
+		"POST /collections/:collectionId/restore":                             "admin",
+		"GET /collections/:collectionId/fields":                               "viewer",
+		"POST /collections/:collectionId/fields":                              "admin",
+		"PUT /collections/:collectionId/fields/:fieldId":                      "admin",
+		"DELETE /collections/:collectionId/fields/:fieldId":                   "admin",
+		"GET /collections/:collectionId/fields/:fieldId/options":              "viewer",
+		"POST /collections/:collectionId/fields/:fieldId/options":             "admin",
+		"PUT /collections/:collectionId/fields/:fieldId/options/order":        "admin",
+		"PATCH /collections/:collectionId/fields/:fieldId/options/:optionId":  "admin",
+		"DELETE /collections/:collectionId/fields/:fieldId/options/:optionId": "admin",
+		"GET /collections/:collectionId/relations":                            "viewer",
+		"POST /collections/:collectionId/relations":                           "admin",
+		"PATCH /collections/:collectionId/relations/:relationId":              "admin",
+		"DELETE /collections/:collectionId/relations/:relationId":             "admin",
+		"GET /collections/:collectionId/entries":                              "viewer",
+		"POST /collections/:collectionId/entries":                             "author",
+		"PUT /collections/:collectionId/entries/:entryId":                     "editor",
+		"GET /collections/:collectionId/entries/:entryId/references":          "viewer",
+		"DELETE /collections/:collectionId/entries/:entryId":                  "editor",
+		"POST /media":                     "author",
+		"GET /media":                      "viewer",
+		"GET /media/:id":                  "viewer",
+		"DELETE /media/:id":               "author",
+		"POST /versions":                  "editor",
+		"GET /versions/:contentID":        "viewer",
+		"GET /versions/:contentID/latest": "viewer",
+		"POST /versions/:contentID/restore/:versionID": "editor",
+		"GET /permissions/check":                       "viewer",
+		"GET /permissions/explain":                     "admin",
+		"POST /permissions/grant":                      "admin",
+		"POST /permissions/revoke":                     "admin",
+		"POST /permissions/bulk-grant":                 "admin",
+		"POST /permissions/bulk-revoke":                "admin",
+		"POST /permissions/copy":                       "admin",
+		"GET /permissions/user":                        "viewer",
+		"POST /audit":                                  "viewer",
+		"GET /audit/user":                              "any",
+		"GET /audit/action/:action":                    "system",
+		"GET /audit/project/:projectId":                "admin",
+		"GET /audit/all":                               "system",
+		"GET /system-alerts":                           "viewer",
+		"GET /system-alerts/active":                    "viewer",
+		"POST /system-alerts":                          "admin",
+		"PUT /system-alerts/:id/read":                  "viewer",
+		"DELETE /system-alerts/:id":                    "admin",
+		"GET /system-alerts/count":                     "viewer",
+		"POST /projects":                               "any",
+		"GET /projects":                                "any",
+		"GET /projects/:projectId":                     "viewer",
+		"GET /projects/:projectId/members":             "viewer",
+		"POST /projects/:projectId/members":            "admin",
+		"DELETE /projects/:projectId/members/:userId":  "viewer",
+		"GET /projects/:projectId/roles":               "viewer",
+		"POST /projects/:projectId/roles":              "admin",
+		"DELETE /projects/:projectId/roles/:roleId":    "admin",
+		"GET /projects/:projectId/usage":               "viewer",
+		"PUT /projects/:projectId/quota":               "owner",
+	}
+
+	routes := apiRoutes(apiControllers{})
+	assert.Len(t, routes, len(minRoles), "minRoles must cover every route")
+
+	for _, route := range routes {
+		key := route.method + " " + route.path
+		minRole, ok := minRoles[key]
+		if !assert.True(t, ok, "missing expectation for %s", key) {
+			continue
+		}
+
+		for _, userName := range []string{"owner", "admin", "editor", "author", "viewer", "outsider", "sysadmin"} {
+			want := http.StatusForbidden
+			switch {
+			case userName == "sysadmin", minRole == "any":
+				want = http.StatusOK
+			case userName == "outsider":
+				// メンバーでないプロジェクトは指定できない
+			case roleRank[userName] >= roleRank[minRole]:
+				want = http.StatusOK
+			}
+
+			t.Run(key+"/"+userName, func(t *testing.T) {
+				t.Parallel()
+				req := httptest.NewRequestWithContext(context.Background(), route.method, requestPath(route.path), nil)
+				req.Header.Set("X-Test-User", userName)
+				if route.project {
+					req.Header.Set(middlewares.ProjectHeader, "1")
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				assert.Equal(t, want, w.Code, w.Body.String())
+			})
+		}
+	}
+}
+
+func TestAPIRoutes_CustomRole(t *testing.T) {
+	t.Parallel()
+	r := newTestAPIRouter(t)
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/collections/:collectionId/entries", http.StatusOK},
+		{http.MethodPut, "/collections/:collectionId/entries/:entryId", http.StatusOK},
+		// entries:create が割り当てられていない
+		{http.MethodPost, "/collections/:collectionId/entries", http.StatusForbidden},
+		{http.MethodGet, "/media", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequestWithContext(context.Background(), tt.method, requestPath(tt.path), nil)
+			req.Header.Set("X-Test-User", "translator")
+			req.Header.Set(middlewares.ProjectHeader, "1")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestAPIRoutes_ProjectIsRequired(t *testing.T) {
+	t.Parallel()
+	r := newTestAPIRouter(t)
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/collections", nil)
+	req.Header.Set("X-Test-User", "owner")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -1,14 +1,19 @@
 package router
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"w3st/interfaces/middlewares"
 
+	"w3st/domain/models"
 	"w3st/factory"
 	"w3st/infra"
+	"w3st/infra/ratelimit"
+	"w3st/usecase"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -27,12 +32,17 @@ func Init() {
 	// ルーターの初期化
 	r := gin.Default()
 
+	// X-Forwarded-For を信頼するプロキシ（カンマ区切りのIPアドレスまたはCIDR）。未設定の場合は接続元のIPアドレスをそのまま使う
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		panic(fmt.Sprintf("TRUSTED_PROXIES が不正です: %v", err))
+	}
+
 	// CORSの設定
 	r.Use(cors.New(cors.Config{
-		AllowOrigins: []string{"*"},                                                                                                           // 許可するオリジン
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},                                                                     // 許可するHTTPメソッド
-		AllowHeaders: []string{"Access-Control-Allow-Credentials", "Access-Control-Allow-Headers", "Origin", "Content-Type", "Authorization"}, // 許可するヘッダー
-		MaxAge:       12 * time.Hour,                                                                                                          // キャッシュの最大時間
+		AllowOrigins: []string{"*"},                                                                                                                                      // 許可するオリジン
+		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},                                                                                       // 許可するHTTPメソッド
+		AllowHeaders: []string{"Access-Control-Allow-Credentials", "Access-Control-Allow-Headers", "Origin", "Content-Type", "Authorization", middlewares.ProjectHeader}, // 許可するヘッダー
+		MaxAge:       12 * time.Hour,                                                                                                                                     // キャッシュの最大時間
 	}))
 
 	// connectionTest
@@ -48,25 +58,48 @@ func Init() {
 
 	// Auth
 	authUsecase := f.InitAuthUsecase()
+	sessionUsecase := f.InitSessionUsecase()
+	jwtAuth := middlewares.JwtAuthMiddleware(authUsecase, sessionUsecase)
 
 	// API Key Auth
 	apiKeyUsecase := f.InitApiKeyUsecase()
 	apiKeyController := f.InitApiKeyController()
 
-	// Collections - SDK専用 (APIキー認証 + プロジェクトレート制限)
+	// Collections - SDK専用 (APIキー認証 + キーごとのレート制限 + プロジェクトレート制限)
+	// レート制限のカウントは RATE_LIMIT_REDIS_URL を設定した場合は Redis、未設定の場合はサーバーのメモリに保持する
+	limiter := ratelimit.NewLimiter(rateLimitStore(), middlewares.RateLimitWindow)
 	sdkCollections := r.Group("/collections")
 	sdkCollections.Use(middlewares.ApiKeyAuthMiddleware(apiKeyUsecase))
+	sdkCollections.Use(middlewares.ApiKeyRateLimitMiddleware(limiter))
 	projectUsecase := f.InitProjectUsecase()
-	systemAlertUsecase := f.InitSystemAlertUsecase()
-	sdkCollections.Use(middlewares.ProjectRateLimitMiddleware(projectUsecase, systemAlertUsecase))
+	sdkCollections.Use(middlewares.ProjectRateLimitMiddleware(limiter, projectUsecase))
+	// 月間上限の確認と利用量の計測。利用量は UsageFlushInterval ごとにまとめてDBに書き込む
+	usageUsecase := f.InitUsageUsecase()
+	usecase.StartUsageFlush(context.Background(), usageUsecase, usecase.UsageFlushInterval)
+	sdkCollections.Use(middlewares.UsageMiddleware(usageUsecase))
+	// パスの :collectionId には slug も指定できる
+	sdkCollections.Use(middlewares.CollectionSlugMiddleware(f.InitCollectionsUsecase()))
 	sdkCollectionController := f.InitSDKCollectionsController()
 	sdkEntriesController := f.InitSDKEntriesController()
 
 	// API - GUI専用 (Auth0認証)
 	api := r.Group("/api")
-	api.Use(middlewares.Auth0AuthMiddleware())
+	// JWKSはサーバー起動時に取得し、以降はバックグラウンドで更新する
+	auth0Validator := middlewares.NewAuth0ValidatorFromEnv(context.Background())
+	api.Use(middlewares.Auth0AuthMiddleware(auth0Validator, f.InitIdentityUsecase()))
+	// 操作対象のプロジェクト（X-Project-Id ヘッダー または /api/projects/:projectId）のメンバーか確認する
+	api.Use(middlewares.ProjectContextMiddleware(projectUsecase))
+	// ルートごとにロールと個別に付与された権限を確認する
+	permissionUsecase := f.InitPermissionUsecase()
+	authz := middlewares.NewAuthorizer(f.InitRoleUsecase(), permissionUsecase)
+	// 期限切れの個別の権限を定期的に削除する
+	usecase.StartExpiredPermissionSweep(context.Background(), permissionUsecase, usecase.PermissionSweepInterval)
+	// 保存期間を過ぎたゴミ箱のコレクションを定期的に削除する
+	usecase.StartCollectionTrashSweep(context.Background(), f.InitCollectionsUsecase(), usecase.CollectionTrashSweepInterval)
 	guiCollectionController := f.InitGUICollectionsController()
 	guiEntriesController := f.InitGUIEntriesController()
+	listOptionsController := f.InitListOptionsController()
+	relationsController := f.InitRelationsController()
 
 	// Media
 	mediaController := f.InitMediaController()
@@ -85,99 +118,98 @@ func Init() {
 
 	// Projects
 	projectController := f.InitProjectController()
+	usageController := f.InitUsageController(usageUsecase)
 
 	// ユーザー登録
 	users.POST("/signup", userController.Signup)
 
 	// ログイン
 	users.POST("/login", userController.Login)
+	// 二要素認証コードの入力
+	users.POST("/login/mfa", userController.LoginMFA)
+
+	// パスワード再設定
+	users.POST("/password/forgot", userController.ForgotPassword)
+	users.POST("/password/reset", userController.ResetPassword)
+
+	// メールアドレス確認
+	users.POST("/verify", userController.VerifyEmail)
+
+	// トークンのリフレッシュ
+	users.POST("/token/refresh", userController.RefreshToken)
+
+	// ログアウト
+	users.POST("/logout", jwtAuth, userController.Logout)
+
+	// セッション一覧・無効化
+	users.GET("/sessions", jwtAuth, userController.GetSessions)
+	users.DELETE("/sessions/:sessionId", jwtAuth, userController.RevokeSession)
 
 	// ユーザー情報取得
-	users.GET("/me", middlewares.JwtAuthMiddleware(authUsecase), userController.GetUserInfo)
+	users.GET("/me", jwtAuth, userController.GetUserInfo)
 	// ユーザー情報更新
-	users.PUT("/me", middlewares.JwtAuthMiddleware(authUsecase), userController.UpdateUser)
-
-	// 管理者用ユーザー管理API
-	apiUsers := api.Group("/users")
-	apiUsers.GET("", userController.GetAllUsers)
-	apiUsers.PUT("/:userId", userController.UpdateUserById)
-	apiUsers.DELETE("/:userId", userController.DeleteUser)
+	users.PUT("/me", jwtAuth, userController.UpdateUser)
 
-	// API Keys - GUI専用
-	api.POST("/api-keys", apiKeyController.CreateApiKey)
+	// 二要素認証（TOTP）
+	users.POST("/me/mfa/enroll", jwtAuth, userController.EnrollMFA)
+	users.POST("/me/mfa/confirm", jwtAuth, userController.ConfirmMFA)
+	users.DELETE("/me/mfa", jwtAuth, userController.DisableMFA)
 
-	// SDK専用ルート
-	// Collection一覧を取得
-	sdkCollections.GET("", sdkCollectionController.GetCollectionByProjectId)
+	// SDK専用ルート（APIキーのスコープを確認する）
+	// Collection一覧を取得（collections:read のあるコレクションのみ）
+	sdkCollections.GET("", middlewares.RequireApiKeyScope(models.ApiKeyScopeCollectionsRead), sdkCollectionController.GetCollectionByProjectId)
 	// Collection詳細取得
-	sdkCollections.GET("/:collectionId", sdkCollectionController.GetCollectionsByCollectionId)
+	sdkCollections.GET("/:collectionId", middlewares.RequireApiKeyScope(models.ApiKeyScopeCollectionsRead), sdkCollectionController.GetCollectionsByCollectionId)
 	// Entries - SDK専用
 	sdkEntries := sdkCollections.Group("/:collectionId/entries")
-	sdkEntries.GET("", sdkEntriesController.GetEntries)
-
-	// GUI専用ルート
-	// Collection一覧取得
-	api.GET("/collections", guiCollectionController.GetCollections)
-	// Collection作成
-	api.POST("/collections", guiCollectionController.MakeCollection)
-	// Collection更新
-	api.PUT("/collections/:collectionId", guiCollectionController.UpdateCollection)
-	// Collection削除
-	api.DELETE("/collections/:collectionId", guiCollectionController.DeleteCollection)
-	// Fields
-	apiFields := api.Group("/collections/:collectionId/fields")
-	apiFields.GET("", guiCollectionController.GetFields)
-	apiFields.POST("", guiCollectionController.CreateField)
-	apiFields.PUT("/:fieldId", guiCollectionController.UpdateField)
-	apiFields.DELETE("/:fieldId", guiCollectionController.DeleteField)
-
-	// Entries - GUI専用
-	guiEntries := api.Group("/collections/:collectionId/entries")
-	guiEntries.GET("", guiEntriesController.GetEntries)
-	guiEntries.POST("", guiEntriesController.CreateEntry)
-	guiEntries.PUT("/:entryId", guiEntriesController.UpdateEntry)
-	guiEntries.DELETE("/:entryId", guiEntriesController.DeleteEntry)
-
-	// Media routes - GUI専用
-	api.POST("/media", mediaController.Upload)
-	api.GET("/media", mediaController.GetByUserID)
-	api.GET("/media/:id", mediaController.GetByID)
-	api.DELETE("/media/:id", mediaController.Delete)
-
-	// Versions routes - GUI専用
-	api.POST("/versions", versionController.CreateVersion)
-	api.GET("/versions/:contentID", versionController.GetVersionsByContentID)
-	api.GET("/versions/:contentID/latest", versionController.GetLatestVersion)
-	api.POST("/versions/:contentID/restore/:versionID", versionController.RestoreVersion)
-
-	// Permissions routes - GUI専用
-	api.GET("/permissions/check", permissionController.CheckPermission)
-	api.POST("/permissions/grant", permissionController.GrantPermission)
-	api.POST("/permissions/revoke", permissionController.RevokePermission)
-	api.GET("/permissions/user", permissionController.GetPermissionsByUser)
-
-	// Audit routes - GUI専用
-	api.POST("/audit", auditController.LogAction)
-	api.GET("/audit/user", auditController.GetLogsByUser)
-	api.GET("/audit/action/:action", auditController.GetLogsByAction)
-	api.GET("/audit/project/:projectId", auditController.GetLogsByProject)
-	api.GET("/audit/all", auditController.GetAllLogs)
-
-	// System Alert routes - GUI専用
-	api.GET("/system-alerts", systemAlertController.GetAlerts)
-	api.GET("/system-alerts/active", systemAlertController.GetActiveAlerts)
-	api.POST("/system-alerts", systemAlertController.CreateAlert)
-	api.PUT("/system-alerts/:id/read", systemAlertController.MarkAsRead)
-	api.DELETE("/system-alerts/:id", systemAlertController.DeleteAlert)
-	api.GET("/system-alerts/count", systemAlertController.GetAlertCount)
-
-	// Project routes - GUI専用
-	api.POST("/projects", projectController.CreateProject)
-	api.GET("/projects", projectController.GetAllProjects)
-	api.GET("/projects/:id", projectController.GetProjectByID)
+	sdkEntries.GET("", middlewares.RequireApiKeyScope(models.ApiKeyScopeEntriesRead), sdkEntriesController.GetEntries)
+	sdkEntries.POST("", middlewares.RequireApiKeyScope(models.ApiKeyScopeEntriesWrite), sdkEntriesController.CreateEntry)
+	sdkEntries.PATCH("/:entryId", middlewares.RequireApiKeyScope(models.ApiKeyScopeEntriesWrite), sdkEntriesController.UpdateEntry)
+	sdkEntries.DELETE("/:entryId", middlewares.RequireApiKeyScope(models.ApiKeyScopeEntriesWrite), sdkEntriesController.DeleteEntry)
+
+	// GUI専用ルート（api_routes.go）
+	registerAPIRoutes(api, authz, apiRoutes(apiControllers{
+		user:          userController,
+		apiKey:        apiKeyController,
+		guiCollection: guiCollectionController,
+		guiEntries:    guiEntriesController,
+		listOptions:   listOptionsController,
+		relations:     relationsController,
+		media:         mediaController,
+		version:       versionController,
+		permission:    permissionController,
+		audit:         auditController,
+		systemAlert:   systemAlertController,
+		project:       projectController,
+		usage:         usageController,
+	}))
 
 	// 指定されたポートでサーバーを開始
 	if err := r.Run(fmt.Sprintf(":%s", port)); err != nil {
 		fmt.Printf("Failed to start server: %s\n", err)
 	}
 }
+
+// trustedProxies 環境変数 TRUSTED_PROXIES を分割する。空の場合はどのプロキシも信頼しない
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// rateLimitStore 環境変数 RATE_LIMIT_REDIS_URL（redis://[:password@]host:port[/db]）を設定した場合は Redis を使う
+func rateLimitStore() ratelimit.Store {
+	redisURL := os.Getenv("RATE_LIMIT_REDIS_URL")
+	if redisURL == "" {
+		return ratelimit.NewMemoryStore()
+	}
+	store, err := ratelimit.NewRedisStore(redisURL)
+	if err != nil {
+		panic(fmt.Sprintf("RATE_LIMIT_REDIS_URL が不正です: %v", err))
+	}
+	return store
+}
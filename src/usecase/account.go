@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	"w3st/domain/services"
+	myerrors "w3st/errors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// PasswordResetTokenTTL パスワード再設定トークンの有効期間
+	PasswordResetTokenTTL = time.Hour
+	// EmailVerificationTokenTTL メールアドレス確認トークンの有効期間
+	EmailVerificationTokenTTL = 24 * time.Hour
+	// DefaultAppBaseURL APP_BASE_URL が未設定の場合にメール内のリンクで使うURL
+	DefaultAppBaseURL = "http://localhost:8080"
+)
+
+// AccountUsecase メールを介したアカウント操作（メールアドレス確認・パスワード再設定）
+type AccountUsecase interface {
+	SendVerification(ctx context.Context, user *models.Users) error
+	VerifyEmail(ctx context.Context, token string) error
+	// RequestPasswordReset 登録の有無が推測されないよう、存在しないメールアドレスでもエラーを返さない
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, newPassword string) error
+}
+
+type accountUsecase struct {
+	userRepo          repositories.UserRepository
+	userTokenRepo     repositories.UserTokenRepository
+	sessionRepo       repositories.SessionRepository
+	credentialUsecase CredentialUsecase
+	mailer            services.Mailer
+	baseURL           string
+}
+
+func NewAccountUsecase(
+	userRepo repositories.UserRepository,
+	userTokenRepo repositories.UserTokenRepository,
+	sessionRepo repositories.SessionRepository,
+	credentialUsecase CredentialUsecase,
+	mailer services.Mailer,
+) AccountUsecase {
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = DefaultAppBaseURL
+	}
+	return &accountUsecase{
+		userRepo:          userRepo,
+		userTokenRepo:     userTokenRepo,
+		sessionRepo:       sessionRepo,
+		credentialUsecase: credentialUsecase,
+		mailer:            mailer,
+		baseURL:           strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (a *accountUsecase) SendVerification(ctx context.Context, user *models.Users) error {
+	if user.EmailVerified {
+		return nil
+	}
+
+	token, err := a.issueToken(ctx, user.ID, models.UserTokenPurposeEmailVerification, EmailVerificationTokenTTL)
+	if err != nil {
+		return myerrors.WrapDomainError("accountUsecase.SendVerification", err)
+	}
+
+	mail := &models.Mail{
+		To:      user.Email,
+		Subject: "メールアドレスの確認",
+		Body: fmt.Sprintf(
+			"%s 様\n\n以下のリンクからメールアドレスの確認を完了してください。\n%s\n\nこのリンクの有効期限は24時間です。\n",
+			user.Name, a.link("/verify", token),
+		),
+	}
+	if err := a.mailer.Send(ctx, mail); err != nil {
+		return myerrors.WrapDomainError("accountUsecase.SendVerification", err)
+	}
+	return nil
+}
+
+func (a *accountUsecase) VerifyEmail(ctx context.Context, token string) error {
+	userToken, err := a.consumeToken(ctx, models.UserTokenPurposeEmailVerification, token)
+	if err != nil {
+		return myerrors.WrapDomainError("accountUsecase.VerifyEmail", err)
+	}
+
+	user, domainErr := a.userRepo.FindByID(ctx, userToken.UserID.String())
+	if domainErr != nil {
+		return myerrors.WrapDomainError("accountUsecase.VerifyEmail", domainErr)
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	user.EmailVerified = true
+	if err := a.userRepo.Update(ctx, user); err != nil {
+		return myerrors.WrapDomainError("accountUsecase.VerifyEmail", err)
+	}
+	return nil
+}
+
+func (a *accountUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+	user, domainErr := a.userRepo.FindByEmail(ctx, email)
+	if domainErr != nil {
+		if errors.Is(domainErr, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return nil
+		}
+		return myerrors.WrapDomainError("accountUsecase.RequestPasswordReset", domainErr)
+	}
+
+	token, err := a.issueToken(ctx, user.ID, models.UserTokenPurposePasswordReset, PasswordResetTokenTTL)
+	if err != nil {
+		return myerrors.WrapDomainError("accountUsecase.RequestPasswordReset", err)
+	}
+
+	mail := &models.Mail{
+		To:      user.Email,
+		Subject: "パスワード再設定のご案内",
+		Body: fmt.Sprintf(
+			"%s 様\n\n以下のリンクからパスワードを再設定してください。\n%s\n\nこのリンクの有効期限は1時間です。心当たりがない場合はこのメールを破棄してください。\n",
+			user.Name, a.link("/password/reset", token),
+		),
+	}
+	if err := a.mailer.Send(ctx, mail); err != nil {
+		return myerrors.WrapDomainError("accountUsecase.RequestPasswordReset", err)
+	}
+	return nil
+}
+
+func (a *accountUsecase) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	// トークンを消費する前にポリシーを確認し、入力ミスでトークンが無駄にならないようにする
+	if err := a.credentialUsecase.ValidatePolicy(newPassword); err != nil {
+		return myerrors.WrapDomainError("accountUsecase.ResetPassword", err)
+	}
+
+	userToken, err := a.consumeToken(ctx, models.UserTokenPurposePasswordReset, token)
+	if err != nil {
+		return myerrors.WrapDomainError("accountUsecase.ResetPassword", err)
+	}
+
+	user, domainErr := a.userRepo.FindByID(ctx, userToken.UserID.String())
+	if domainErr != nil {
+		return myerrors.WrapDomainError("accountUsecase.ResetPassword", domainErr)
+	}
+
+	hashed, err := a.credentialUsecase.HashPassword(newPassword)
+	if err != nil {
+		return myerrors.WrapDomainError("accountUsecase.ResetPassword", err)
+	}
+	user.Password = hashed
+	// メールを受け取れたことでメールアドレスの所有も確認できている
+	user.EmailVerified = true
+	if err := a.userRepo.Update(ctx, user); err != nil {
+		return myerrors.WrapDomainError("accountUsecase.ResetPassword", err)
+	}
+
+	// 漏洩したパスワードで作られたセッションが残らないよう全て無効化する
+	if err := a.sessionRepo.RevokeAllByUser(ctx, user.ID); err != nil {
+		return myerrors.WrapDomainError("accountUsecase.ResetPassword", err)
+	}
+	return nil
+}
+
+// issueToken 同じ用途の古いトークンを無効化してから新しいトークンを発行する
+func (a *accountUsecase) issueToken(ctx context.Context, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	if err := a.userTokenRepo.InvalidateByUser(ctx, userID, purpose); err != nil {
+		return "", err
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	userToken := &models.UserToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := a.userTokenRepo.Create(ctx, userToken); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consumeToken トークンを検証し、使用済みにする
+func (a *accountUsecase) consumeToken(ctx context.Context, purpose string, token string) (*models.UserToken, error) {
+	if token == "" {
+		return nil, errInvalidUserToken()
+	}
+
+	userToken, err := a.userTokenRepo.FindByHash(ctx, purpose, hashToken(token))
+	if err != nil {
+		if errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return nil, errInvalidUserToken()
+		}
+		return nil, err
+	}
+
+	if userToken.UsedAt != nil || !userToken.ExpiresAt.After(time.Now()) {
+		return nil, errInvalidUserToken()
+	}
+
+	used, err := a.userTokenRepo.MarkUsed(ctx, userToken.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, errInvalidUserToken()
+	}
+	return userToken, nil
+}
+
+func (a *accountUsecase) link(path string, token string) string {
+	return a.baseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func errInvalidUserToken() *myerrors.DomainError {
+	return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "トークンが無効か有効期限が切れています")
+}
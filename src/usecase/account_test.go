@@ -0,0 +1,251 @@
+package usecase_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	mockRepositories "w3st/mock/repositories"
+	mockServices "w3st/mock/services"
+	"w3st/usecase"
+)
+
+// tokenFromMail メール本文のリンクからトークンを取り出す
+func tokenFromMail(t *testing.T, body string) string {
+	t.Helper()
+	_, rest, found := strings.Cut(body, "?token=")
+	require.True(t, found, "mail body has no token link: %s", body)
+	raw, _, _ := strings.Cut(rest, "\n")
+	token, err := url.QueryUnescape(raw)
+	require.NoError(t, err)
+	return token
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func validUserToken(userID uuid.UUID, purpose string) *models.UserToken {
+	return &models.UserToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func TestAccountUsecase_RequestPasswordReset_SendsMail(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	mockUserTokenRepo := mockRepositories.NewMockUserTokenRepository(ctrl)
+	mockMailer := mockServices.NewMockMailer(ctrl)
+	uc := usecase.NewAccountUsecase(mockUserRepo, mockUserTokenRepo, mockRepositories.NewMockSessionRepository(ctrl), newTestCredentialUsecase(), mockMailer)
+
+	user := &models.Users{ID: uuid.New(), Name: "Alice", Email: "alice@example.com"}
+
+	var stored *models.UserToken
+	var sent *models.Mail
+	mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "alice@example.com").Return(user, nil)
+	mockUserTokenRepo.EXPECT().InvalidateByUser(gomock.Any(), user.ID, models.UserTokenPurposePasswordReset).Return(nil)
+	mockUserTokenRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, token *models.UserToken) *myerrors.DomainError {
+			stored = token
+			return nil
+		})
+	mockMailer.EXPECT().
+		Send(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, mail *models.Mail) error {
+			sent = mail
+			return nil
+		})
+
+	err := uc.RequestPasswordReset(context.Background(), "alice@example.com")
+
+	require.NoError(t, err)
+	require.NotNil(t, sent)
+	assert.Equal(t, "alice@example.com", sent.To)
+	// DBにはメールで送ったトークンのハッシュだけが保存される
+	token := tokenFromMail(t, sent.Body)
+	assert.NotEqual(t, token, stored.TokenHash)
+	assert.Equal(t, sha256Hex(token), stored.TokenHash)
+	assert.WithinDuration(t, time.Now().Add(usecase.PasswordResetTokenTTL), stored.ExpiresAt, time.Minute)
+}
+
+func TestAccountUsecase_RequestPasswordReset_UnknownEmail(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewAccountUsecase(mockUserRepo, mockRepositories.NewMockUserTokenRepository(ctrl), mockRepositories.NewMockSessionRepository(ctrl), newTestCredentialUsecase(), mockServices.NewMockMailer(ctrl))
+
+	mockUserRepo.EXPECT().
+		FindByEmail(gomock.Any(), "nobody@example.com").
+		Return(&models.Users{}, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "ユーザーが見つかりません"))
+
+	err := uc.RequestPasswordReset(context.Background(), "nobody@example.com")
+
+	require.NoError(t, err)
+}
+
+func TestAccountUsecase_ResetPassword_Success(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	mockUserTokenRepo := mockRepositories.NewMockUserTokenRepository(ctrl)
+	mockSessionRepo := mockRepositories.NewMockSessionRepository(ctrl)
+	uc := usecase.NewAccountUsecase(mockUserRepo, mockUserTokenRepo, mockSessionRepo, newTestCredentialUsecase(), mockServices.NewMockMailer(ctrl))
+
+	credential := newTestCredentialUsecase()
+	user := &models.Users{ID: uuid.New(), Email: "alice@example.com", Password: "old"}
+	userToken := validUserToken(user.ID, models.UserTokenPurposePasswordReset)
+
+	mockUserTokenRepo.EXPECT().
+		FindByHash(gomock.Any(), models.UserTokenPurposePasswordReset, sha256Hex("reset-token")).
+		Return(userToken, nil)
+	mockUserTokenRepo.EXPECT().MarkUsed(gomock.Any(), userToken.ID).Return(true, nil)
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), user.ID.String()).Return(user, nil)
+	mockUserRepo.EXPECT().
+		Update(gomock.Any(), user).
+		DoAndReturn(func(_ context.Context, u *models.Users) *myerrors.DomainError {
+			ok, _ := credential.VerifyPassword(u.Password, "newpass123")
+			assert.True(t, ok)
+			assert.True(t, u.EmailVerified)
+			return nil
+		})
+	mockSessionRepo.EXPECT().RevokeAllByUser(gomock.Any(), user.ID).Return(nil)
+
+	err := uc.ResetPassword(context.Background(), "reset-token", "newpass123")
+
+	require.NoError(t, err)
+}
+
+func TestAccountUsecase_ResetPassword_ExpiredToken(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserTokenRepo := mockRepositories.NewMockUserTokenRepository(ctrl)
+	uc := usecase.NewAccountUsecase(mockRepositories.NewMockUserRepository(ctrl), mockUserTokenRepo, mockRepositories.NewMockSessionRepository(ctrl), newTestCredentialUsecase(), mockServices.NewMockMailer(ctrl))
+
+	userToken := validUserToken(uuid.New(), models.UserTokenPurposePasswordReset)
+	userToken.ExpiresAt = time.Now().Add(-time.Minute)
+
+	mockUserTokenRepo.EXPECT().FindByHash(gomock.Any(), models.UserTokenPurposePasswordReset, gomock.Any()).Return(userToken, nil)
+
+	err := uc.ResetPassword(context.Background(), "expired-token", "newpass123")
+
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.InvalidParameter, domainErr.ErrType)
+}
+
+func TestAccountUsecase_ResetPassword_TokenAlreadyUsed(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserTokenRepo := mockRepositories.NewMockUserTokenRepository(ctrl)
+	uc := usecase.NewAccountUsecase(mockRepositories.NewMockUserRepository(ctrl), mockUserTokenRepo, mockRepositories.NewMockSessionRepository(ctrl), newTestCredentialUsecase(), mockServices.NewMockMailer(ctrl))
+
+	userToken := validUserToken(uuid.New(), models.UserTokenPurposePasswordReset)
+
+	// 同時に使われた場合は後から来た方が失敗する
+	mockUserTokenRepo.EXPECT().FindByHash(gomock.Any(), models.UserTokenPurposePasswordReset, gomock.Any()).Return(userToken, nil)
+	mockUserTokenRepo.EXPECT().MarkUsed(gomock.Any(), userToken.ID).Return(false, nil)
+
+	err := uc.ResetPassword(context.Background(), "reset-token", "newpass123")
+
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.InvalidParameter, domainErr.ErrType)
+}
+
+func TestAccountUsecase_ResetPassword_PolicyViolationKeepsToken(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// ポリシー違反ではトークンを消費しない（リポジトリが呼ばれない）
+	uc := usecase.NewAccountUsecase(mockRepositories.NewMockUserRepository(ctrl), mockRepositories.NewMockUserTokenRepository(ctrl), mockRepositories.NewMockSessionRepository(ctrl), newTestCredentialUsecase(), mockServices.NewMockMailer(ctrl))
+
+	err := uc.ResetPassword(context.Background(), "reset-token", "short")
+
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.InvalidParameter, domainErr.ErrType)
+}
+
+func TestAccountUsecase_VerifyEmail_Success(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	mockUserTokenRepo := mockRepositories.NewMockUserTokenRepository(ctrl)
+	uc := usecase.NewAccountUsecase(mockUserRepo, mockUserTokenRepo, mockRepositories.NewMockSessionRepository(ctrl), newTestCredentialUsecase(), mockServices.NewMockMailer(ctrl))
+
+	user := &models.Users{ID: uuid.New(), Email: "alice@example.com"}
+	userToken := validUserToken(user.ID, models.UserTokenPurposeEmailVerification)
+
+	mockUserTokenRepo.EXPECT().
+		FindByHash(gomock.Any(), models.UserTokenPurposeEmailVerification, sha256Hex("verify-token")).
+		Return(userToken, nil)
+	mockUserTokenRepo.EXPECT().MarkUsed(gomock.Any(), userToken.ID).Return(true, nil)
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), user.ID.String()).Return(user, nil)
+	mockUserRepo.EXPECT().Update(gomock.Any(), user).Return(nil)
+
+	err := uc.VerifyEmail(context.Background(), "verify-token")
+
+	require.NoError(t, err)
+	assert.True(t, user.EmailVerified)
+}
+
+func TestAccountUsecase_VerifyEmail_UnknownToken(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserTokenRepo := mockRepositories.NewMockUserTokenRepository(ctrl)
+	uc := usecase.NewAccountUsecase(mockRepositories.NewMockUserRepository(ctrl), mockUserTokenRepo, mockRepositories.NewMockSessionRepository(ctrl), newTestCredentialUsecase(), mockServices.NewMockMailer(ctrl))
+
+	mockUserTokenRepo.EXPECT().
+		FindByHash(gomock.Any(), models.UserTokenPurposeEmailVerification, gomock.Any()).
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "トークンが見つかりません"))
+
+	err := uc.VerifyEmail(context.Background(), "unknown")
+
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.InvalidParameter, domainErr.ErrType)
+}
+
+func TestAccountUsecase_SendVerification_AlreadyVerified(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	uc := usecase.NewAccountUsecase(mockRepositories.NewMockUserRepository(ctrl), mockRepositories.NewMockUserTokenRepository(ctrl), mockRepositories.NewMockSessionRepository(ctrl), newTestCredentialUsecase(), mockServices.NewMockMailer(ctrl))
+
+	err := uc.SendVerification(context.Background(), &models.Users{ID: uuid.New(), EmailVerified: true})
+
+	require.NoError(t, err)
+}
@@ -0,0 +1,537 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	"w3st/errors"
+	"w3st/infra/cache"
+	"w3st/infra/logger"
+)
+
+// APIキーを認証できなかった理由
+const (
+	ApiKeyRejectInvalid      = "invalid"
+	ApiKeyRejectRevoked      = "revoked"
+	ApiKeyRejectExpired      = "expired"
+	ApiKeyRejectIPNotAllowed = "ip_not_allowed"
+)
+
+// ApiKeyRejectedError APIキーを認証できなかった理由。ValidateApiKey が返す Unauthenticated のエラーに含まれる
+type ApiKeyRejectedError struct {
+	Reason string
+}
+
+func (e *ApiKeyRejectedError) Error() string {
+	return "api key rejected: " + e.Reason
+}
+
+func apiKeyRejected(reason, message string) *errors.DomainError {
+	return &errors.DomainError{ErrType: errors.Unauthenticated, Message: message, Err: &ApiKeyRejectedError{Reason: reason}}
+}
+
+// ApiKeyRotationGracePeriod ローテーション後もローテーション前のキーを使える期間（指定がない場合）
+const ApiKeyRotationGracePeriod = 24 * time.Hour
+
+// MaxApiKeyRotationGracePeriod 指定できる猶予期間の上限
+const MaxApiKeyRotationGracePeriod = 7 * 24 * time.Hour
+
+// apiKeyTTL 作成したAPIキーの有効期間
+const apiKeyTTL = 365 * 24 * time.Hour
+
+// apiKeyDefaultRateLimit 作成したAPIキーの1時間あたりの最大リクエスト数
+const apiKeyDefaultRateLimit = 1000
+
+// 認証したキーをキャッシュする件数と期間。取り消し・ローテーション・変更・削除したキーはすぐにキャッシュから除くが、
+// 別のサーバーで行った変更は ApiKeyCacheTTL の間反映されない
+const (
+	ApiKeyCacheSize = 10000
+	ApiKeyCacheTTL  = 30 * time.Second
+)
+
+type ApiKeyUsecase interface {
+	// ValidateApiKey 取り消し・有効期限を確認し、キーの設定を返す。認証したキーは ApiKeyCacheTTL の間キャッシュする。
+	// 認証できない場合は理由（ApiKeyRejectedError）を含む Unauthenticated のエラーを返す
+	ValidateApiKey(ctx context.Context, apiKey string) (*models.ApiKeyPrincipal, error)
+	// CreateApiKey 作成したAPIキーを返す。キーそのものは保存しないため、返すのは作成時とローテーション時のみ
+	CreateApiKey(ctx context.Context, input models.ApiKeyCreate) (*models.IssuedApiKey, error)
+	ListApiKeys(ctx context.Context, projectID int) ([]*models.ApiKeys, error)
+	GetApiKey(ctx context.Context, projectID int, id int) (*models.ApiKeys, error)
+	UpdateApiKey(ctx context.Context, userID uuid.UUID, projectID int, id int, update models.ApiKeyUpdate) (*models.ApiKeys, error)
+	// RotateApiKey 新しいキーを発行する。ローテーション前のキーは gracePeriod の間だけ引き続き使える
+	RotateApiKey(ctx context.Context, userID uuid.UUID, projectID int, id int, gracePeriod time.Duration) (*models.IssuedApiKey, error)
+	DeleteApiKey(ctx context.Context, userID uuid.UUID, projectID int, id int) error
+	// AttachCollection キーで利用できるコレクションを追加する。scopes が nil の場合はキーの Scopes を使う。
+	// 追加済みのコレクションはスコープを置き換える
+	AttachCollection(ctx context.Context, userID uuid.UUID, projectID int, id int, collectionID int, scopes []string) (*models.ApiKeys, error)
+	// DetachCollection キーからコレクションを外す
+	DetachCollection(ctx context.Context, userID uuid.UUID, projectID int, id int, collectionID int) (*models.ApiKeys, error)
+}
+
+type apiKeyUsecase struct {
+	repo            repositories.ApiKeyRepository
+	projectRepo     repositories.ProjectRepository
+	collectionsRepo repositories.CollectionsRepository
+	auditUsecase    AuditUsecase
+	// cache キーの SHA-256 ごとに認証したキーの設定を保持する（キーそのものはメモリにも残さない）
+	cache *cache.LRU[string, *models.ApiKeyPrincipal]
+}
+
+type ApiKeyUsecaseOption func(*apiKeyUsecase)
+
+// WithApiKeyCache 認証したキーをキャッシュする件数と期間を指定する。ttl が0の場合はキャッシュしない
+func WithApiKeyCache(size int, ttl time.Duration) ApiKeyUsecaseOption {
+	return func(a *apiKeyUsecase) {
+		a.cache = cache.NewLRU[string, *models.ApiKeyPrincipal](size, ttl)
+	}
+}
+
+func NewApiKeyUsecase(repo repositories.ApiKeyRepository, projectRepo repositories.ProjectRepository, collectionsRepo repositories.CollectionsRepository, auditUsecase AuditUsecase, opts ...ApiKeyUsecaseOption) ApiKeyUsecase {
+	a := &apiKeyUsecase{
+		repo:            repo,
+		projectRepo:     projectRepo,
+		collectionsRepo: collectionsRepo,
+		auditUsecase:    auditUsecase,
+		cache:           cache.NewLRU[string, *models.ApiKeyPrincipal](ApiKeyCacheSize, ApiKeyCacheTTL),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+func (a *apiKeyUsecase) ValidateApiKey(ctx context.Context, apiKey string) (*models.ApiKeyPrincipal, error) {
+	cacheKey := models.HashApiKeySecret(apiKey)
+	if principal, ok := a.cache.Get(cacheKey); ok {
+		return principal, nil
+	}
+
+	prefix, secret, ok := models.ParseApiKey(apiKey)
+	if !ok {
+		return nil, apiKeyRejected(ApiKeyRejectInvalid, "APIキーが無効です")
+	}
+
+	candidates, err := a.repo.FindByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var apiKeyModel *models.ApiKeys
+	now := time.Now()
+	for _, candidate := range candidates {
+		if candidate.Matches(prefix, secret, now) {
+			apiKeyModel = candidate
+			break
+		}
+	}
+	// 取り消し・有効期限はキーが一致した場合にのみ理由を返す
+	if apiKeyModel == nil {
+		return nil, apiKeyRejected(ApiKeyRejectInvalid, "APIキーが無効です")
+	}
+	if apiKeyModel.Revoked {
+		return nil, apiKeyRejected(ApiKeyRejectRevoked, "APIキーは取り消されています")
+	}
+	if apiKeyModel.IsExpired(now) {
+		return nil, apiKeyRejected(ApiKeyRejectExpired, "APIキーの有効期限が切れています")
+	}
+
+	// キャッシュはキーの有効期限と、ローテーション前のキーの猶予期間を過ぎて使わない
+	principal := apiKeyModel.Principal()
+	until := apiKeyModel.ExpireAt
+	if apiKeyModel.InGracePeriod(now) && (until.IsZero() || apiKeyModel.PreviousKeyExpireAt.Before(until)) {
+		until = *apiKeyModel.PreviousKeyExpireAt
+	}
+	a.cache.AddUntil(cacheKey, principal, until)
+	return principal, nil
+}
+
+func (a *apiKeyUsecase) CreateApiKey(ctx context.Context, input models.ApiKeyCreate) (*models.IssuedApiKey, error) {
+	ipWhiteList, ipErr := models.NormalizeIPWhiteList(input.IpWhiteList)
+	if ipErr != nil {
+		return nil, errors.NewDomainErrorWithMessage(errors.InvalidParameter, "ip_whitelist にはIPアドレスまたはCIDRを指定してください")
+	}
+	expireAt := time.Now().Add(apiKeyTTL)
+	if input.ExpireAt != nil {
+		if !input.ExpireAt.After(time.Now()) {
+			return nil, errors.NewDomainErrorWithMessage(errors.InvalidParameter, "有効期限には未来の日時を指定してください")
+		}
+		expireAt = *input.ExpireAt
+	}
+	rateLimit, limitErr := a.keyRateLimit(ctx, input.ProjectID, input.RateLimit)
+	if limitErr != nil {
+		return nil, limitErr
+	}
+	scopes := slices.Clone(models.DefaultApiKeyScopes)
+	if input.Scopes != nil {
+		var scopeErr error
+		if scopes, scopeErr = normalizeApiKeyScopes(input.Scopes); scopeErr != nil {
+			return nil, scopeErr
+		}
+	}
+	collections, collectionErr := a.apiKeyCollections(input.ProjectID, input.CollectionIds, input.CollectionScopes, nil)
+	if collectionErr != nil {
+		return nil, collectionErr
+	}
+
+	apiKey := &models.ApiKeys{
+		UserID:      input.UserID,
+		ProjectID:   input.ProjectID,
+		Name:        input.Name,
+		IpWhiteList: ipWhiteList,
+		ExpireAt:    expireAt,
+		Revoked:     false,
+		RateLimit:   rateLimit,
+		Scopes:      scopes,
+		Collections: collections,
+	}
+	key, err := issueApiKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+	if de := a.repo.Create(ctx, apiKey); de != nil {
+		return nil, errors.WrapDomainError("apiKeyUsecase.CreateApiKey", de)
+	}
+
+	a.logAction(ctx, input.UserID, apiKey, models.AuditActionApiKeyCreate, map[string]any{
+		"collection_ids":      apiKey.CollectionIDs(),
+		"ip_whitelist":        apiKey.IpWhiteList,
+		"expire_at":           apiKey.ExpireAt,
+		"rate_limit_per_hour": apiKey.RateLimit,
+		"scopes":              apiKey.Scopes,
+		"collection_scopes":   input.CollectionScopes,
+	})
+	return &models.IssuedApiKey{ApiKey: apiKey, Key: key}, nil
+}
+
+func (a *apiKeyUsecase) ListApiKeys(ctx context.Context, projectID int) ([]*models.ApiKeys, error) {
+	apiKeys, err := a.repo.FindByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, errors.WrapDomainError("apiKeyUsecase.ListApiKeys", err)
+	}
+	return apiKeys, nil
+}
+
+func (a *apiKeyUsecase) GetApiKey(ctx context.Context, projectID int, id int) (*models.ApiKeys, error) {
+	apiKey, err := a.repo.FindByID(ctx, projectID, id)
+	if err != nil {
+		return nil, errors.WrapDomainError("apiKeyUsecase.GetApiKey", err)
+	}
+	return apiKey, nil
+}
+
+func (a *apiKeyUsecase) UpdateApiKey(ctx context.Context, userID uuid.UUID, projectID int, id int, update models.ApiKeyUpdate) (*models.ApiKeys, error) {
+	apiKey, err := a.repo.FindByID(ctx, projectID, id)
+	if err != nil {
+		return nil, errors.WrapDomainError("apiKeyUsecase.UpdateApiKey", err)
+	}
+
+	// 同時に更新されたカラムを上書きしないよう、変更したカラムだけを保存する
+	changes := map[string]any{}
+	columns := map[string]interface{}{}
+	var collections []models.ApiKeyCollections
+	if update.Name != nil && *update.Name != apiKey.Name {
+		apiKey.Name = *update.Name
+		changes["name"] = apiKey.Name
+		columns["name"] = apiKey.Name
+	}
+	if update.CollectionIds != nil || update.CollectionScopes != nil {
+		collectionIds := apiKey.CollectionIDs()
+		if update.CollectionIds != nil {
+			collectionIds = update.CollectionIds
+		}
+		// collection_scopes を省略した場合、引き続き利用できるコレクションのスコープはそのまま残す
+		var collectionErr error
+		collections, collectionErr = a.apiKeyCollections(projectID, collectionIds, update.CollectionScopes, apiKey.Collections)
+		if collectionErr != nil {
+			return nil, collectionErr
+		}
+		apiKey.Collections = collections
+		if update.CollectionIds != nil {
+			changes["collection_ids"] = apiKey.CollectionIDs()
+		}
+		if update.CollectionScopes != nil {
+			changes["collection_scopes"] = update.CollectionScopes
+		}
+	}
+	if update.Scopes != nil {
+		scopes, scopeErr := normalizeApiKeyScopes(update.Scopes)
+		if scopeErr != nil {
+			return nil, scopeErr
+		}
+		apiKey.Scopes = scopes
+		changes["scopes"] = scopes
+		columns["scopes"] = apiKey.Scopes
+	}
+	action := models.AuditActionApiKeyUpdate
+	if update.Revoked != nil && *update.Revoked != apiKey.Revoked {
+		// 取り消したキーを再び有効にすることはできない（新しいキーを作成する）
+		if !*update.Revoked {
+			return nil, errors.NewDomainErrorWithMessage(errors.UnPermittedOperation, "取り消したAPIキーは元に戻せません")
+		}
+		apiKey.Revoked = true
+		changes["revoked"] = true
+		columns["revoked"] = true
+		action = models.AuditActionApiKeyRevoke
+	}
+	if len(changes) == 0 {
+		return apiKey, nil
+	}
+
+	if de := a.repo.Update(ctx, apiKey.Id, columns, collections); de != nil {
+		return nil, errors.WrapDomainError("apiKeyUsecase.UpdateApiKey", de)
+	}
+	a.invalidate(apiKey.Id)
+
+	a.logAction(ctx, userID, apiKey, action, changes)
+	return apiKey, nil
+}
+
+func (a *apiKeyUsecase) RotateApiKey(ctx context.Context, userID uuid.UUID, projectID int, id int, gracePeriod time.Duration) (*models.IssuedApiKey, error) {
+	if gracePeriod < 0 || gracePeriod > MaxApiKeyRotationGracePeriod {
+		return nil, errors.NewDomainErrorWithMessage(errors.InvalidParameter, "猶予期間は0秒から7日の間で指定してください")
+	}
+
+	apiKey, err := a.repo.FindByID(ctx, projectID, id)
+	if err != nil {
+		return nil, errors.WrapDomainError("apiKeyUsecase.RotateApiKey", err)
+	}
+	if apiKey.Revoked {
+		return nil, errors.NewDomainErrorWithMessage(errors.UnPermittedOperation, "取り消されたAPIキーはローテーションできません")
+	}
+
+	// 猶予期間中に再度ローテーションした場合、それより前のキーはすぐに使えなくなる
+	previousKeyPrefix, previousKeyHash := apiKey.KeyPrefix, apiKey.KeyHash
+	previousKeyExpireAt := time.Now().Add(gracePeriod)
+	key, issueErr := issueApiKey(apiKey)
+	if issueErr != nil {
+		return nil, issueErr
+	}
+	apiKey.PreviousKeyPrefix = &previousKeyPrefix
+	apiKey.PreviousKeyHash = &previousKeyHash
+	apiKey.PreviousKeyExpireAt = &previousKeyExpireAt
+
+	// 同時にローテーション・取り消しされた場合は、どちらかの結果を上書きしないよう失敗させる
+	if de := a.repo.Rotate(ctx, apiKey, previousKeyPrefix); de != nil {
+		return nil, errors.WrapDomainError("apiKeyUsecase.RotateApiKey", de)
+	}
+	a.invalidate(apiKey.Id)
+
+	a.logAction(ctx, userID, apiKey, models.AuditActionApiKeyRotate, map[string]any{
+		"previous_key_prefix":    previousKeyPrefix,
+		"previous_key_expire_at": previousKeyExpireAt,
+	})
+	return &models.IssuedApiKey{ApiKey: apiKey, Key: key}, nil
+}
+
+func (a *apiKeyUsecase) DeleteApiKey(ctx context.Context, userID uuid.UUID, projectID int, id int) error {
+	apiKey, err := a.repo.FindByID(ctx, projectID, id)
+	if err != nil {
+		return errors.WrapDomainError("apiKeyUsecase.DeleteApiKey", err)
+	}
+
+	if de := a.repo.Delete(ctx, projectID, id); de != nil {
+		return errors.WrapDomainError("apiKeyUsecase.DeleteApiKey", de)
+	}
+	a.invalidate(apiKey.Id)
+
+	a.logAction(ctx, userID, apiKey, models.AuditActionApiKeyDelete, map[string]any{})
+	return nil
+}
+
+func (a *apiKeyUsecase) AttachCollection(ctx context.Context, userID uuid.UUID, projectID int, id int, collectionID int, scopes []string) (*models.ApiKeys, error) {
+	apiKey, err := a.repo.FindByID(ctx, projectID, id)
+	if err != nil {
+		return nil, errors.WrapDomainError("apiKeyUsecase.AttachCollection", err)
+	}
+	if _, collectionErr := a.collectionsRepo.GetCollectionsByCollectionId(collectionID, projectID); collectionErr != nil {
+		return nil, errors.WrapDomainError("apiKeyUsecase.AttachCollection", collectionErr)
+	}
+	var collectionScopes models.CollectionScopes
+	if scopes != nil {
+		normalized, scopeErr := normalizeApiKeyScopes(scopes)
+		if scopeErr != nil {
+			return nil, scopeErr
+		}
+		collectionScopes = normalized
+	}
+
+	collection := models.ApiKeyCollections{ApiKeyID: apiKey.Id, CollectionID: collectionID, Scopes: collectionScopes}
+	if de := a.repo.AttachCollection(ctx, &collection); de != nil {
+		return nil, errors.WrapDomainError("apiKeyUsecase.AttachCollection", de)
+	}
+	apiKey.Collections = slices.DeleteFunc(apiKey.Collections, func(c models.ApiKeyCollections) bool {
+		return c.CollectionID == collectionID
+	})
+	apiKey.Collections = append(apiKey.Collections, collection)
+	slices.SortFunc(apiKey.Collections, func(a, b models.ApiKeyCollections) int { return a.CollectionID - b.CollectionID })
+	a.invalidate(apiKey.Id)
+
+	a.logAction(ctx, userID, apiKey, models.AuditActionApiKeyUpdate, map[string]any{
+		"attached_collection_id": collectionID,
+		"scopes":                 collectionScopes,
+	})
+	return apiKey, nil
+}
+
+func (a *apiKeyUsecase) DetachCollection(ctx context.Context, userID uuid.UUID, projectID int, id int, collectionID int) (*models.ApiKeys, error) {
+	apiKey, err := a.repo.FindByID(ctx, projectID, id)
+	if err != nil {
+		return nil, errors.WrapDomainError("apiKeyUsecase.DetachCollection", err)
+	}
+
+	if de := a.repo.DetachCollection(ctx, apiKey.Id, collectionID); de != nil {
+		return nil, errors.WrapDomainError("apiKeyUsecase.DetachCollection", de)
+	}
+	apiKey.Collections = slices.DeleteFunc(apiKey.Collections, func(c models.ApiKeyCollections) bool {
+		return c.CollectionID == collectionID
+	})
+	a.invalidate(apiKey.Id)
+
+	a.logAction(ctx, userID, apiKey, models.AuditActionApiKeyUpdate, map[string]any{
+		"detached_collection_id": collectionID,
+	})
+	return apiKey, nil
+}
+
+func normalizeApiKeyScopes(scopes []string) ([]string, error) {
+	normalized, err := models.NormalizeApiKeyScopes(scopes)
+	if err != nil {
+		return nil, errors.NewDomainErrorWithMessage(errors.InvalidParameter,
+			"scopes には "+strings.Join(models.ApiKeyScopes, ", ")+" のいずれかを指定してください")
+	}
+	return normalized, nil
+}
+
+// apiKeyCollections キーで利用できるコレクションを api_key_collections の行にする。
+// コレクションはプロジェクトのものだけ、collectionScopes は collectionIds に含まれるコレクションにだけ指定できる。
+// collectionScopes が nil の場合は current のスコープを引き継ぐ
+func (a *apiKeyUsecase) apiKeyCollections(projectID int, collectionIds []int, collectionScopes map[int][]string, current []models.ApiKeyCollections) ([]models.ApiKeyCollections, error) {
+	ids := slices.Compact(slices.Sorted(slices.Values(collectionIds)))
+	for collectionID := range collectionScopes {
+		if _, found := slices.BinarySearch(ids, collectionID); !found {
+			return nil, errors.NewDomainErrorWithMessage(errors.InvalidParameter,
+				fmt.Sprintf("collection_scopes のコレクション（%d）は collection_ids に含めてください", collectionID))
+		}
+	}
+	if len(ids) > 0 {
+		found, err := a.collectionsRepo.GetCollectionsByIds(projectID, ids)
+		if err != nil {
+			return nil, errors.WrapDomainError("apiKeyUsecase.apiKeyCollections", err)
+		}
+		for _, collectionID := range ids {
+			if !slices.ContainsFunc(found, func(c models.ApiCollection) bool { return c.ID == collectionID }) {
+				return nil, errors.NewDomainErrorWithMessage(errors.InvalidParameter,
+					fmt.Sprintf("collection_ids のコレクション（%d）はプロジェクトにありません", collectionID))
+			}
+		}
+	}
+
+	rows := make([]models.ApiKeyCollections, len(ids))
+	for i, collectionID := range ids {
+		rows[i] = models.ApiKeyCollections{CollectionID: collectionID}
+		if collectionScopes == nil {
+			if j := slices.IndexFunc(current, func(c models.ApiKeyCollections) bool { return c.CollectionID == collectionID }); j >= 0 {
+				rows[i].Scopes = current[j].Scopes
+			}
+			continue
+		}
+		if scopes, ok := collectionScopes[collectionID]; ok {
+			normalized, err := normalizeApiKeyScopes(scopes)
+			if err != nil {
+				return nil, err
+			}
+			rows[i].Scopes = normalized
+		}
+	}
+	return rows, nil
+}
+
+// invalidate キャッシュからキーの設定を除く。ローテーション前のキーで認証したものも含めて除く
+func (a *apiKeyUsecase) invalidate(apiKeyID int) {
+	a.cache.RemoveFunc(func(_ string, principal *models.ApiKeyPrincipal) bool {
+		return principal.ApiKeyID == apiKeyID
+	})
+}
+
+// keyRateLimit キーごとのレート制限はプロジェクトのレート制限の範囲内で設定する。0 の場合は既定値を使う
+func (a *apiKeyUsecase) keyRateLimit(ctx context.Context, projectID int, rateLimit int) (int, error) {
+	if rateLimit < 0 {
+		return 0, errors.NewDomainErrorWithMessage(errors.InvalidParameter, "レート制限には1以上の値を指定してください")
+	}
+	project, err := a.projectRepo.FindByID(ctx, projectID)
+	if err != nil {
+		return 0, errors.WrapDomainError("apiKeyUsecase.keyRateLimit", err)
+	}
+	if rateLimit == 0 {
+		return min(apiKeyDefaultRateLimit, project.RateLimitPerHour), nil
+	}
+	if rateLimit > project.RateLimitPerHour {
+		return 0, errors.NewDomainErrorWithMessage(errors.InvalidParameter,
+			fmt.Sprintf("レート制限にはプロジェクトのレート制限（%d回/時）以下の値を指定してください", project.RateLimitPerHour))
+	}
+	return rateLimit, nil
+}
+
+// logAction APIキーの変更を監査ログに記録する。記録に失敗しても変更自体は取り消さない
+func (a *apiKeyUsecase) logAction(ctx context.Context, userID uuid.UUID, apiKey *models.ApiKeys, action string, details map[string]any) {
+	details["name"] = apiKey.Name
+	details["key_prefix"] = apiKey.KeyPrefix
+	data, err := json.Marshal(details)
+	if err != nil {
+		logger.Error("failed to marshal api key audit details", "api_key_id", apiKey.Id, "error", err.Error())
+		return
+	}
+
+	resource := "api_key:" + strconv.Itoa(apiKey.Id)
+	if err := a.auditUsecase.LogActionWithProject(ctx, userID, apiKey.ProjectID, action, resource, string(data)); err != nil {
+		logger.Error("failed to write api key audit log", "api_key_id", apiKey.Id, "action", action, "error", err.Error())
+	}
+}
+
+// issueApiKey 新しいキーを生成して prefix と secret のハッシュを apiKey に設定し、キーそのものを返す
+func issueApiKey(apiKey *models.ApiKeys) (string, *errors.DomainError) {
+	prefix, err := randomApiKeyChars(models.ApiKeyPrefixLength)
+	if err != nil {
+		return "", err
+	}
+	secret, err := randomApiKeyChars(models.ApiKeySecretLength)
+	if err != nil {
+		return "", err
+	}
+
+	key, checksummedSecret := models.FormatApiKey(prefix, secret)
+	apiKey.SetKey(prefix, checksummedSecret)
+	return key, nil
+}
+
+// randomApiKeyChars base62 の文字をランダムに n 文字選ぶ。偏りが出ないように 62 の倍数に収まらないバイトは捨てる
+func randomApiKeyChars(n int) (string, *errors.DomainError) {
+	const limit = 256 - 256%len(models.ApiKeyAlphabet)
+
+	out := make([]byte, 0, n)
+	buf := make([]byte, n*2)
+	for len(out) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", errors.NewDomainErrorWithMessage(errors.ErrorUnknown, "APIキーの生成に失敗しました")
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			out = append(out, models.ApiKeyAlphabet[int(b)%len(models.ApiKeyAlphabet)])
+			if len(out) == n {
+				break
+			}
+		}
+	}
+	return string(out), nil
+}
@@ -0,0 +1,628 @@
+package usecase_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+	mockRepositories "w3st/mock/repositories"
+	"w3st/usecase"
+)
+
+// expectAuditAction 指定したアクションの監査ログが1件記録されることを期待する
+func expectAuditAction(t *testing.T, auditRepo *mockRepositories.MockAuditRepository, action string) {
+	t.Helper()
+	auditRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, log *models.AuditLog) *myerrors.DomainError {
+		assert.Equal(t, action, log.Action)
+		return nil
+	})
+}
+
+func TestApiKeyUsecase_CreateApiKey(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	mockAuditRepo := mockRepositories.NewMockAuditRepository(ctrl)
+	mockProjectRepo := mockRepositories.NewMockProjectRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockProjectRepo, mockCollectionsRepo, usecase.NewAuditUsecase(mockAuditRepo))
+
+	userID := uuid.New()
+
+	// キーのレート制限を指定しない場合は既定値（プロジェクトの上限以下）になる
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 2).Return(&models.Project{ID: 2, RateLimitPerHour: 500}, nil)
+	// コレクションは重複を除いて、プロジェクトのものか確認する
+	mockCollectionsRepo.EXPECT().GetCollectionsByIds(2, []int{1, 2}).Return([]models.ApiCollection{{ID: 1}, {ID: 2}}, nil)
+	mockApiKeyRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, apiKey *models.ApiKeys) *myerrors.DomainError {
+		apiKey.Id = 3
+		return nil
+	})
+	mockAuditRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, log *models.AuditLog) *myerrors.DomainError {
+		assert.Equal(t, models.AuditActionApiKeyCreate, log.Action)
+		assert.Equal(t, "api_key:3", log.Resource)
+		assert.Equal(t, 2, log.ProjectID)
+		assert.Equal(t, userID, log.UserID)
+		// 監査ログにはキーそのものを残さない
+		assert.NotContains(t, log.Details, `"key"`)
+		return nil
+	})
+
+	issued, err := uc.CreateApiKey(context.Background(), models.ApiKeyCreate{
+		UserID:        userID,
+		ProjectID:     2,
+		Name:          "Website",
+		CollectionIds: []int{2, 1, 2},
+		IpWhiteList:   []string{"203.0.113.7", "10.1.2.3/8"},
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `^w3st_[0-9A-Za-z]{8}_[0-9A-Za-z]{38}$`, issued.Key)
+	assert.Equal(t, []int{1, 2}, issued.ApiKey.CollectionIDs())
+	assert.Equal(t, []string{"203.0.113.7", "10.0.0.0/8"}, []string(issued.ApiKey.IpWhiteList))
+	assert.Equal(t, 500, issued.ApiKey.RateLimit)
+	// スコープを指定しない場合は読み取りのみ
+	assert.Equal(t, models.DefaultApiKeyScopes, []string(issued.ApiKey.Scopes))
+	assert.Empty(t, issued.ApiKey.CollectionScopeOverrides())
+
+	// 保存するのは prefix と secret のハッシュだけ
+	prefix, secret, ok := models.ParseApiKey(issued.Key)
+	require.True(t, ok)
+	assert.Equal(t, prefix, issued.ApiKey.KeyPrefix)
+	assert.Equal(t, models.HashApiKeySecret(secret), issued.ApiKey.KeyHash)
+	assert.NotContains(t, issued.ApiKey.KeyHash, secret)
+}
+
+func TestApiKeyUsecase_CreateApiKey_InvalidSettings(t *testing.T) {
+	t.Parallel()
+
+	past := time.Now().Add(-time.Minute)
+	cases := map[string]models.ApiKeyCreate{
+		"IPアドレスでもCIDRでもない":  {ProjectID: 2, IpWhiteList: []string{"10.0.0.0/33"}},
+		"過去の有効期限":           {ProjectID: 2, ExpireAt: &past},
+		"プロジェクトの上限を超える":     {ProjectID: 2, RateLimit: 5001},
+		"存在しないスコープ":         {ProjectID: 2, Scopes: []string{"entries:admin"}},
+		"利用できないコレクションのスコープ": {ProjectID: 2, CollectionIds: []int{1}, CollectionScopes: map[int][]string{2: {models.ApiKeyScopeEntriesWrite}}},
+		"別のプロジェクトのコレクション":   {ProjectID: 2, CollectionIds: []int{1, 9}},
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockProjectRepo := mockRepositories.NewMockProjectRepository(ctrl)
+			mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+			uc := usecase.NewApiKeyUsecase(mockRepositories.NewMockApiKeyRepository(ctrl), mockProjectRepo, mockCollectionsRepo, usecase.NewAuditUsecase(mockRepositories.NewMockAuditRepository(ctrl)))
+
+			mockProjectRepo.EXPECT().FindByID(gomock.Any(), 2).Return(&models.Project{ID: 2, RateLimitPerHour: 5000}, nil).AnyTimes()
+			// コレクション9はプロジェクト2にない
+			mockCollectionsRepo.EXPECT().GetCollectionsByIds(2, gomock.Any()).Return([]models.ApiCollection{{ID: 1}}, nil).AnyTimes()
+
+			_, err := uc.CreateApiKey(context.Background(), input)
+			assertErrType(t, err, myerrors.InvalidParameter)
+		})
+	}
+}
+
+// newStoredApiKey 発行済みのキーと、それを保存したときのモデルを返す
+func newStoredApiKey(t *testing.T) (string, *models.ApiKeys) {
+	t.Helper()
+	key, secret := models.FormatApiKey("Ab12Cd34", "0123456789abcdefghijABCDEFGHIJkl")
+	apiKey := &models.ApiKeys{Id: 5, ProjectID: 1, UserID: uuid.New(), ExpireAt: time.Now().Add(time.Hour)}
+	apiKey.SetKey("Ab12Cd34", secret)
+	return key, apiKey
+}
+
+func TestApiKeyUsecase_ValidateApiKey(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockRepositories.NewMockAuditRepository(ctrl)))
+
+	key, apiKey := newStoredApiKey(t)
+
+	mockApiKeyRepo.EXPECT().FindByPrefix(gomock.Any(), "Ab12Cd34").Return([]*models.ApiKeys{apiKey}, nil).Times(2)
+
+	token, err := uc.ValidateApiKey(context.Background(), key)
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	// prefix が同じでも secret が違えば認証しない
+	forged, _ := models.FormatApiKey("Ab12Cd34", "0123456789abcdefghijABCDEFGHIJkm")
+	_, err = uc.ValidateApiKey(context.Background(), forged)
+	assertErrType(t, err, myerrors.Unauthenticated)
+}
+
+func TestApiKeyUsecase_ValidateApiKey_RejectsBadChecksumWithoutLookup(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	uc := usecase.NewApiKeyUsecase(mockRepositories.NewMockApiKeyRepository(ctrl), mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockRepositories.NewMockAuditRepository(ctrl)))
+
+	key, _ := newStoredApiKey(t)
+
+	// チェックサムが合わないキーはDBを検索せずに拒否する
+	tampered := key[:len(key)-1] + "0"
+	if tampered == key {
+		tampered = key[:len(key)-1] + "1"
+	}
+	_, err := uc.ValidateApiKey(context.Background(), tampered)
+	assertErrType(t, err, myerrors.Unauthenticated)
+
+	_, err = uc.ValidateApiKey(context.Background(), "w3st_short")
+	assertErrType(t, err, myerrors.Unauthenticated)
+}
+
+func TestApiKeyUsecase_ValidateApiKey_LegacyKey(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockRepositories.NewMockAuditRepository(ctrl)))
+
+	legacyKey := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	// 移行したキーは先頭8文字が prefix、キー全体のハッシュが key_hash になる
+	apiKey := &models.ApiKeys{Id: 5, ProjectID: 1, KeyPrefix: "01234567", KeyHash: models.HashApiKeySecret(legacyKey), ExpireAt: time.Now().Add(time.Hour)}
+	other := &models.ApiKeys{Id: 6, ProjectID: 1, KeyPrefix: "01234567", KeyHash: models.HashApiKeySecret("01234567-other"), ExpireAt: time.Now().Add(time.Hour)}
+
+	mockApiKeyRepo.EXPECT().FindByPrefix(gomock.Any(), "01234567").Return([]*models.ApiKeys{other, apiKey}, nil)
+
+	_, err := uc.ValidateApiKey(context.Background(), legacyKey)
+	require.NoError(t, err)
+}
+
+func TestApiKeyUsecase_ValidateApiKey_RejectionReason(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		modify func(apiKey *models.ApiKeys)
+		reason string
+	}{
+		"取り消し済み": {func(apiKey *models.ApiKeys) { apiKey.Revoked = true }, usecase.ApiKeyRejectRevoked},
+		"有効期限切れ": {func(apiKey *models.ApiKeys) { apiKey.ExpireAt = time.Now().Add(-time.Second) }, usecase.ApiKeyRejectExpired},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+			uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockRepositories.NewMockAuditRepository(ctrl)))
+
+			key, apiKey := newStoredApiKey(t)
+			tc.modify(apiKey)
+			mockApiKeyRepo.EXPECT().FindByPrefix(gomock.Any(), "Ab12Cd34").Return([]*models.ApiKeys{apiKey}, nil)
+
+			_, err := uc.ValidateApiKey(context.Background(), key)
+			assertErrType(t, err, myerrors.Unauthenticated)
+			var rejected *usecase.ApiKeyRejectedError
+			require.ErrorAs(t, err, &rejected)
+			assert.Equal(t, tc.reason, rejected.Reason)
+		})
+	}
+}
+
+func TestApiKeyUsecase_ValidateApiKey_CachesPrincipal(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockRepositories.NewMockAuditRepository(ctrl)))
+
+	key, apiKey := newStoredApiKey(t)
+	apiKey.Collections = []models.ApiKeyCollections{{ApiKeyID: 5, CollectionID: 1}, {ApiKeyID: 5, CollectionID: 2}}
+	apiKey.RateLimit = 100
+
+	// 2回目以降はDBを検索しない
+	mockApiKeyRepo.EXPECT().FindByPrefix(gomock.Any(), "Ab12Cd34").Return([]*models.ApiKeys{apiKey}, nil).Times(1)
+
+	for i := 0; i < 3; i++ {
+		principal, err := uc.ValidateApiKey(context.Background(), key)
+		require.NoError(t, err)
+		assert.Equal(t, 5, principal.ApiKeyID)
+		assert.Equal(t, apiKey.UserID, principal.UserID)
+		assert.Equal(t, []int{1, 2}, principal.CollectionIds)
+		assert.Equal(t, 100, principal.RateLimit)
+	}
+}
+
+func TestApiKeyUsecase_ValidateApiKey_InvalidatedOnChange(t *testing.T) {
+	t.Parallel()
+
+	revoked := true
+	cases := map[string]struct {
+		change     func(uc usecase.ApiKeyUsecase) error
+		expectSave func(mockApiKeyRepo *mockRepositories.MockApiKeyRepository)
+		wantReason string
+	}{
+		"取り消し": {
+			change: func(uc usecase.ApiKeyUsecase) error {
+				_, err := uc.UpdateApiKey(context.Background(), uuid.New(), 1, 5, models.ApiKeyUpdate{Revoked: &revoked})
+				return err
+			},
+			expectSave: func(mockApiKeyRepo *mockRepositories.MockApiKeyRepository) {
+				mockApiKeyRepo.EXPECT().Update(gomock.Any(), 5, map[string]interface{}{"revoked": true}, gomock.Nil()).Return(nil)
+			},
+			wantReason: usecase.ApiKeyRejectRevoked,
+		},
+		"猶予期間なしのローテーション": {
+			change: func(uc usecase.ApiKeyUsecase) error {
+				_, err := uc.RotateApiKey(context.Background(), uuid.New(), 1, 5, 0)
+				return err
+			},
+			expectSave: func(mockApiKeyRepo *mockRepositories.MockApiKeyRepository) {
+				mockApiKeyRepo.EXPECT().Rotate(gomock.Any(), gomock.Any(), "Ab12Cd34").Return(nil)
+			},
+			wantReason: usecase.ApiKeyRejectInvalid,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+			mockAuditRepo := mockRepositories.NewMockAuditRepository(ctrl)
+			uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockAuditRepo))
+
+			key, apiKey := newStoredApiKey(t)
+
+			// 変更後はキャッシュを使わずにDBを検索し直す
+			mockApiKeyRepo.EXPECT().FindByPrefix(gomock.Any(), "Ab12Cd34").Return([]*models.ApiKeys{apiKey}, nil).Times(2)
+			mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 5).Return(apiKey, nil)
+			tc.expectSave(mockApiKeyRepo)
+			mockAuditRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+			_, err := uc.ValidateApiKey(context.Background(), key)
+			require.NoError(t, err)
+			require.NoError(t, tc.change(uc))
+
+			_, err = uc.ValidateApiKey(context.Background(), key)
+			var rejected *usecase.ApiKeyRejectedError
+			require.ErrorAs(t, err, &rejected)
+			assert.Equal(t, tc.wantReason, rejected.Reason)
+		})
+	}
+}
+
+func TestApiKeyUsecase_UpdateApiKey_Revoke(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	mockAuditRepo := mockRepositories.NewMockAuditRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockAuditRepo))
+
+	revoked := true
+
+	mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 5).Return(&models.ApiKeys{Id: 5, ProjectID: 1, Name: "Website"}, nil)
+	// 同時に変更された名前などを上書きしないよう、取り消しだけを保存する
+	mockApiKeyRepo.EXPECT().Update(gomock.Any(), 5, map[string]interface{}{"revoked": true}, gomock.Nil()).Return(nil)
+	expectAuditAction(t, mockAuditRepo, models.AuditActionApiKeyRevoke)
+
+	apiKey, err := uc.UpdateApiKey(context.Background(), uuid.New(), 1, 5, models.ApiKeyUpdate{Revoked: &revoked})
+	require.NoError(t, err)
+	assert.True(t, apiKey.Revoked)
+}
+
+func TestApiKeyUsecase_UpdateApiKey_Scopes(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	mockAuditRepo := mockRepositories.NewMockAuditRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockCollectionsRepo, usecase.NewAuditUsecase(mockAuditRepo))
+
+	apiKey := &models.ApiKeys{
+		Id: 5, ProjectID: 1, Name: "Website",
+		Scopes: datatypes.JSONSlice[string]{models.ApiKeyScopeEntriesRead},
+		Collections: []models.ApiKeyCollections{
+			{ApiKeyID: 5, CollectionID: 1, Scopes: models.CollectionScopes{models.ApiKeyScopeCollectionsRead}},
+			{ApiKeyID: 5, CollectionID: 2, Scopes: models.CollectionScopes{models.ApiKeyScopeEntriesWrite}},
+		},
+	}
+
+	mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 5).Return(apiKey, nil)
+	mockCollectionsRepo.EXPECT().GetCollectionsByIds(1, []int{1, 3}).Return([]models.ApiCollection{{ID: 1}, {ID: 3}}, nil)
+	mockApiKeyRepo.EXPECT().Update(gomock.Any(), 5, map[string]interface{}{
+		"scopes": datatypes.JSONSlice[string]{models.ApiKeyScopeEntriesRead, models.ApiKeyScopeEntriesWrite},
+	}, gomock.Len(2)).Return(nil)
+	expectAuditAction(t, mockAuditRepo, models.AuditActionApiKeyUpdate)
+
+	// 重複を除いて ApiKeyScopes の順に並べる。引き続き利用できるコレクション1のスコープは残し、
+	// 利用できなくなったコレクション2のスコープは残さない
+	updated, err := uc.UpdateApiKey(context.Background(), uuid.New(), 1, 5, models.ApiKeyUpdate{
+		CollectionIds: []int{1, 3},
+		Scopes:        []string{models.ApiKeyScopeEntriesWrite, models.ApiKeyScopeEntriesRead, models.ApiKeyScopeEntriesWrite},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.ApiKeyScopeEntriesRead, models.ApiKeyScopeEntriesWrite}, []string(updated.Scopes))
+	assert.Equal(t, []int{1, 3}, updated.CollectionIDs())
+	assert.Equal(t, map[int][]string{1: {models.ApiKeyScopeCollectionsRead}}, updated.CollectionScopeOverrides())
+
+	principal := updated.Principal()
+	assert.True(t, principal.HasScope(3, models.ApiKeyScopeEntriesWrite))
+	assert.False(t, principal.HasScope(2, models.ApiKeyScopeEntriesRead))
+}
+
+func TestApiKeyUsecase_UpdateApiKey_CannotUnrevoke(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockRepositories.NewMockAuditRepository(ctrl)))
+
+	revoked := false
+
+	mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 5).Return(&models.ApiKeys{Id: 5, ProjectID: 1, Revoked: true}, nil)
+
+	_, err := uc.UpdateApiKey(context.Background(), uuid.New(), 1, 5, models.ApiKeyUpdate{Revoked: &revoked})
+	assertErrType(t, err, myerrors.UnPermittedOperation)
+}
+
+func TestApiKeyUsecase_UpdateApiKey_NoChanges(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockRepositories.NewMockAuditRepository(ctrl)))
+
+	name := "Website"
+
+	// 変更がなければ保存も監査ログの記録もしない
+	mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 5).Return(&models.ApiKeys{Id: 5, ProjectID: 1, Name: name}, nil)
+
+	_, err := uc.UpdateApiKey(context.Background(), uuid.New(), 1, 5, models.ApiKeyUpdate{Name: &name})
+	require.NoError(t, err)
+}
+
+func TestApiKeyUsecase_AttachCollection(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	mockAuditRepo := mockRepositories.NewMockAuditRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockCollectionsRepo, usecase.NewAuditUsecase(mockAuditRepo))
+
+	apiKey := &models.ApiKeys{Id: 5, ProjectID: 1, Collections: []models.ApiKeyCollections{{ApiKeyID: 5, CollectionID: 3}}}
+
+	mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 5).Return(apiKey, nil)
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(2, 1).Return(&models.ApiCollection{ID: 2}, nil)
+	mockApiKeyRepo.EXPECT().AttachCollection(gomock.Any(), &models.ApiKeyCollections{
+		ApiKeyID: 5, CollectionID: 2, Scopes: models.CollectionScopes{models.ApiKeyScopeEntriesRead, models.ApiKeyScopeEntriesWrite},
+	}).Return(nil)
+	expectAuditAction(t, mockAuditRepo, models.AuditActionApiKeyUpdate)
+
+	updated, err := uc.AttachCollection(context.Background(), uuid.New(), 1, 5, 2, []string{models.ApiKeyScopeEntriesWrite, models.ApiKeyScopeEntriesRead})
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3}, updated.CollectionIDs())
+	assert.True(t, updated.Principal().HasScope(2, models.ApiKeyScopeEntriesWrite))
+}
+
+func TestApiKeyUsecase_AttachCollection_OtherProject(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockCollectionsRepo, usecase.NewAuditUsecase(mockRepositories.NewMockAuditRepository(ctrl)))
+
+	// 別のプロジェクトのコレクションは追加できない
+	mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 5).Return(&models.ApiKeys{Id: 5, ProjectID: 1}, nil)
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(9, 1).
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません"))
+
+	_, err := uc.AttachCollection(context.Background(), uuid.New(), 1, 5, 9, nil)
+	assertErrType(t, err, myerrors.QueryDataNotFoundError)
+}
+
+func TestApiKeyUsecase_DetachCollection(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	mockAuditRepo := mockRepositories.NewMockAuditRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockAuditRepo))
+
+	key, apiKey := newStoredApiKey(t)
+	apiKey.Collections = []models.ApiKeyCollections{{ApiKeyID: 5, CollectionID: 2}, {ApiKeyID: 5, CollectionID: 3}}
+
+	mockApiKeyRepo.EXPECT().FindByPrefix(gomock.Any(), "Ab12Cd34").Return([]*models.ApiKeys{apiKey}, nil).Times(2)
+	mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 5).Return(apiKey, nil)
+	mockApiKeyRepo.EXPECT().DetachCollection(gomock.Any(), 5, 2).Return(nil)
+	expectAuditAction(t, mockAuditRepo, models.AuditActionApiKeyUpdate)
+
+	principal, err := uc.ValidateApiKey(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3}, principal.CollectionIds)
+
+	updated, err := uc.DetachCollection(context.Background(), uuid.New(), 1, 5, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, updated.CollectionIDs())
+
+	// キャッシュした設定も外したコレクションを含まなくなる
+	principal, err = uc.ValidateApiKey(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, principal.CollectionIds)
+}
+
+func TestApiKeyUsecase_RotateApiKey_KeepsPreviousKeyDuringGracePeriod(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	mockAuditRepo := mockRepositories.NewMockAuditRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockAuditRepo))
+
+	oldKey, apiKey := newStoredApiKey(t)
+
+	mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 5).Return(apiKey, nil)
+	mockApiKeyRepo.EXPECT().Rotate(gomock.Any(), apiKey, "Ab12Cd34").Return(nil)
+	expectAuditAction(t, mockAuditRepo, models.AuditActionApiKeyRotate)
+
+	before := time.Now()
+	issued, err := uc.RotateApiKey(context.Background(), uuid.New(), 1, 5, time.Hour)
+	require.NoError(t, err)
+	assert.NotEqual(t, oldKey, issued.Key)
+
+	// 猶予期間中は新旧どちらのキーでも認証でき、猶予期間を過ぎると新しいキーだけになる
+	newPrefix, newSecret, _ := models.ParseApiKey(issued.Key)
+	oldPrefix, oldSecret, _ := models.ParseApiKey(oldKey)
+	during, after := before.Add(59*time.Minute), before.Add(61*time.Minute)
+	assert.True(t, issued.ApiKey.Matches(newPrefix, newSecret, during))
+	assert.True(t, issued.ApiKey.Matches(oldPrefix, oldSecret, during))
+	assert.True(t, issued.ApiKey.Matches(newPrefix, newSecret, after))
+	assert.False(t, issued.ApiKey.Matches(oldPrefix, oldSecret, after))
+}
+
+func TestApiKeyUsecase_RotateApiKey_Rejected(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockRepositories.NewMockAuditRepository(ctrl)))
+
+	_, err := uc.RotateApiKey(context.Background(), uuid.New(), 1, 5, 8*24*time.Hour)
+	assertErrType(t, err, myerrors.InvalidParameter)
+
+	mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 6).Return(&models.ApiKeys{Id: 6, ProjectID: 1, Revoked: true}, nil)
+	_, err = uc.RotateApiKey(context.Background(), uuid.New(), 1, 6, time.Hour)
+	assertErrType(t, err, myerrors.UnPermittedOperation)
+
+	// 読み込んだ後に他のリクエストでローテーション・取り消しされた場合は上書きしない
+	_, apiKey := newStoredApiKey(t)
+	mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 5).Return(apiKey, nil)
+	mockApiKeyRepo.EXPECT().Rotate(gomock.Any(), apiKey, "Ab12Cd34").
+		Return(myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "APIキーは他の操作で変更されています。取得し直してから操作してください"))
+	_, err = uc.RotateApiKey(context.Background(), uuid.New(), 1, 5, time.Hour)
+	assertErrType(t, err, myerrors.AlreadyExist)
+}
+
+func TestApiKeyUsecase_DeleteApiKey(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mockRepositories.NewMockApiKeyRepository(ctrl)
+	mockAuditRepo := mockRepositories.NewMockAuditRepository(ctrl)
+	uc := usecase.NewApiKeyUsecase(mockApiKeyRepo, mockRepositories.NewMockProjectRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), usecase.NewAuditUsecase(mockAuditRepo))
+
+	mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 5).Return(&models.ApiKeys{Id: 5, ProjectID: 1}, nil)
+	mockApiKeyRepo.EXPECT().Delete(gomock.Any(), 1, 5).Return(nil)
+	expectAuditAction(t, mockAuditRepo, models.AuditActionApiKeyDelete)
+	require.NoError(t, uc.DeleteApiKey(context.Background(), uuid.New(), 1, 5))
+
+	// 別のプロジェクトのキーは見つからない
+	mockApiKeyRepo.EXPECT().FindByID(gomock.Any(), 1, 9).
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "APIキーが見つかりません"))
+	assertErrType(t, uc.DeleteApiKey(context.Background(), uuid.New(), 1, 9), myerrors.QueryDataNotFoundError)
+}
+
+// benchApiKeyRepository FindByPrefix だけを実装したベンチマーク用のリポジトリ。gomock の記録のコストを含めないために使う
+type benchApiKeyRepository struct {
+	repositories.ApiKeyRepository
+	apiKeys []*models.ApiKeys
+}
+
+func (r benchApiKeyRepository) FindByPrefix(_ context.Context, _ string) ([]*models.ApiKeys, *myerrors.DomainError) {
+	return r.apiKeys, nil
+}
+
+// BenchmarkApiKeyUsecase_ValidateApiKey SDKのリクエストごとのAPIキー認証のコスト。
+// before は以前の実装（DB検索のたびに HS256 のJWTを発行し、ミドルウェアでパースし直す）を再現したもの。
+// リポジトリはすぐに返すため、どちらもDBへの往復の時間は含まない
+func BenchmarkApiKeyUsecase_ValidateApiKey(b *testing.B) {
+	key, secret := models.FormatApiKey("Ab12Cd34", "0123456789abcdefghijABCDEFGHIJkl")
+	apiKey := &models.ApiKeys{Id: 5, ProjectID: 1, UserID: uuid.New(), RateLimit: 1000, ExpireAt: time.Now().Add(time.Hour)}
+	apiKey.SetKey("Ab12Cd34", secret)
+	repo := benchApiKeyRepository{apiKeys: []*models.ApiKeys{apiKey}}
+	secretKey := []byte(os.Getenv("SECRET_KEY"))
+
+	type apiKeyClaims struct {
+		ApiKeyID      int       `json:"api_key_id"`
+		UserID        uuid.UUID `json:"user_id"`
+		ProjectID     int       `json:"project_id"`
+		CollectionIds []int     `json:"collection_ids"`
+		RateLimit     int       `json:"rate_limit_per_hour"`
+		jwt.RegisteredClaims
+	}
+
+	b.Run("before: lookup and JWT round trip", func(b *testing.B) {
+		uc := usecase.NewApiKeyUsecase(repo, nil, nil, nil, usecase.WithApiKeyCache(0, 0))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			principal, err := uc.ValidateApiKey(context.Background(), key)
+			if err != nil {
+				b.Fatal(err)
+			}
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, apiKeyClaims{
+				ApiKeyID:      principal.ApiKeyID,
+				UserID:        principal.UserID,
+				ProjectID:     principal.ProjectID,
+				CollectionIds: principal.CollectionIds,
+				RateLimit:     principal.RateLimit,
+				RegisteredClaims: jwt.RegisteredClaims{
+					ExpiresAt: jwt.NewNumericDate(principal.ExpireAt),
+					IssuedAt:  jwt.NewNumericDate(time.Now()),
+				},
+			}).SignedString(secretKey)
+			if err != nil {
+				b.Fatal(err)
+			}
+			claims := &apiKeyClaims{}
+			if _, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) { return secretKey, nil }); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("lookup without cache", func(b *testing.B) {
+		uc := usecase.NewApiKeyUsecase(repo, nil, nil, nil, usecase.WithApiKeyCache(0, 0))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := uc.ValidateApiKey(context.Background(), key); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("after: cached principal", func(b *testing.B) {
+		uc := usecase.NewApiKeyUsecase(repo, nil, nil, nil)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := uc.ValidateApiKey(context.Background(), key); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
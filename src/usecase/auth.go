@@ -1,9 +1,6 @@
 package usecase
 
 import (
-	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"os"
 	"time"
 
@@ -11,25 +8,31 @@ import (
 	"github.com/google/uuid"
 
 	"w3st/domain/models"
-	"w3st/domain/repositories"
 	"w3st/errors"
 )
 
-type ApiKeyClaims struct {
-	UserID        uuid.UUID `json:"user_id"`
-	ProjectID     int       `json:"project_id"`
-	CollectionIds []int     `json:"collection_ids"`
-	jwt.RegisteredClaims
-}
+// AccessTokenTTL アクセストークンの有効期間。失効はリフレッシュトークンとセッションで管理する
+const AccessTokenTTL = 15 * time.Minute
 
-type JwtUsecase interface {
-	GenerateToken(userID uuid.UUID) (models.Token, error)
-	ValidateToken(token string) (string, error)
+// MFAChallengeTTL 二要素認証の入力待ちトークンの有効期間
+const MFAChallengeTTL = 5 * time.Minute
+
+// mfaChallengeTokenType 二要素認証の入力待ちトークンを表す typ クレーム
+const mfaChallengeTokenType = "mfa_challenge"
+
+// TokenClaims アクセストークンから取り出した情報
+type TokenClaims struct {
+	UserID    string
+	SessionID string
 }
 
-type ApiKeyUsecase interface {
-	ValidateApiKey(apiKey string) (string, error)
-	CreateApiKey(userID string, projectID int, name string, collectionIds []int) (string, error)
+type JwtUsecase interface {
+	GenerateToken(userID uuid.UUID, sessionID uuid.UUID) (models.Token, error)
+	ValidateToken(token string) (*TokenClaims, error)
+	// GenerateMFAChallengeToken パスワード認証のみ完了したことを示す短命なトークンを発行する
+	GenerateMFAChallengeToken(userID uuid.UUID) (models.Token, error)
+	// ValidateMFAChallengeToken 入力待ちトークンを検証し、userIDを取得する
+	ValidateMFAChallengeToken(token string) (string, error)
 }
 
 type jwtAuthUsecase struct {
@@ -47,10 +50,13 @@ func NewjwtAuthUsecase() JwtUsecase {
 }
 
 // トークンを生成する
-func (a *jwtAuthUsecase) GenerateToken(userID uuid.UUID) (models.Token, error) {
+func (a *jwtAuthUsecase) GenerateToken(userID uuid.UUID, sessionID uuid.UUID) (models.Token, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"sub": userID.String(),
-		"exp": time.Now().Add(24 * time.Hour).Unix(),
+		"sid": sessionID.String(),
+		"iat": now.Unix(),
+		"exp": now.Add(AccessTokenTTL).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
@@ -62,108 +68,98 @@ func (a *jwtAuthUsecase) GenerateToken(userID uuid.UUID) (models.Token, error) {
 	return models.Token(signedToken), nil
 }
 
-// トークンを検証し、userIDを取得する
-func (a *jwtAuthUsecase) ValidateToken(token string) (string, error) {
+// トークンを検証し、userIDとセッションIDを取得する
+func (a *jwtAuthUsecase) ValidateToken(token string) (*TokenClaims, error) {
 	claims := &jwt.MapClaims{}
 	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.NewDomainErrorWithMessage(errors.ErrorUnknown, "署名方式が不正です")
+			return nil, errors.NewDomainErrorWithMessage(errors.Unauthenticated, "署名方式が不正です")
 		}
 		return []byte(a.secretKey), nil
 	}, jwt.WithoutClaimsValidation())
 	if err != nil {
-		return "", errors.NewDomainErrorWithMessage(errors.ErrorUnknown, "トークンのパースに失敗しました")
+		return nil, errors.NewDomainErrorWithMessage(errors.Unauthenticated, "トークンのパースに失敗しました")
 	}
 
 	if (*claims)["exp"] != nil {
 		if exp, ok := (*claims)["exp"].(float64); ok {
 			if int64(exp) < time.Now().Unix() {
-				return "", errors.NewDomainErrorWithMessage(errors.ErrorUnknown, "無効なトークンです")
+				return nil, errors.NewDomainErrorWithMessage(errors.Unauthenticated, "無効なトークンです")
 			}
 		}
 	}
 
+	// 二要素認証の入力待ちトークンはアクセストークンとして使わせない
+	if _, ok := (*claims)["typ"]; ok {
+		return nil, errors.NewDomainErrorWithMessage(errors.Unauthenticated, "アクセストークンではありません")
+	}
+
 	if (*claims)["sub"] == nil {
-		return "", errors.NewDomainErrorWithMessage(errors.ErrorUnknown, "claimsの取得に失敗しました")
+		return nil, errors.NewDomainErrorWithMessage(errors.Unauthenticated, "claimsの取得に失敗しました")
 	}
 
 	subStr, ok := (*claims)["sub"].(string)
 	if !ok {
-		return "", errors.NewDomainErrorWithMessage(errors.ErrorUnknown, "subの型が不正です")
+		return nil, errors.NewDomainErrorWithMessage(errors.Unauthenticated, "subの型が不正です")
 	}
 
 	if _, err := uuid.Parse(subStr); err != nil {
-		return "", errors.NewDomainErrorWithMessage(errors.ErrorUnknown, "UUIDのパースに失敗しました")
+		return nil, errors.NewDomainErrorWithMessage(errors.Unauthenticated, "UUIDのパースに失敗しました")
 	}
 
-	return subStr, nil
-}
-
-type apiKeyUsecase struct {
-	repo repositories.ApiKeyRepository
-}
+	// セッションIDを持たないトークン（旧形式）は失効できないため受け付けない
+	sidStr, ok := (*claims)["sid"].(string)
+	if !ok || sidStr == "" {
+		return nil, errors.NewDomainErrorWithMessage(errors.Unauthenticated, "セッション情報を含まないトークンです")
+	}
+	if _, err := uuid.Parse(sidStr); err != nil {
+		return nil, errors.NewDomainErrorWithMessage(errors.Unauthenticated, "セッションIDのパースに失敗しました")
+	}
 
-func NewApiKeyUsecase(repo repositories.ApiKeyRepository) ApiKeyUsecase {
-	return &apiKeyUsecase{repo: repo}
+	return &TokenClaims{UserID: subStr, SessionID: sidStr}, nil
 }
 
-func (a *apiKeyUsecase) ValidateApiKey(apiKey string) (string, error) {
-	apiKeyModel, err := a.repo.FindByKey(context.Background(), apiKey)
-	if err != nil {
-		return "", err
-	}
-
-	// Generate JWT token with claims
-	claims := ApiKeyClaims{
-		UserID:        apiKeyModel.UserID,
-		ProjectID:     apiKeyModel.ProjectID,
-		CollectionIds: apiKeyModel.CollectionIds,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(apiKeyModel.ExpireAt),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+func (a *jwtAuthUsecase) GenerateMFAChallengeToken(userID uuid.UUID) (models.Token, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": userID.String(),
+		"typ": mfaChallengeTokenType,
+		"iat": now.Unix(),
+		"exp": now.Add(MFAChallengeTTL).Unix(),
 	}
-
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, jwtErr := token.SignedString([]byte(os.Getenv("SECRET_KEY")))
+
+	signedToken, jwtErr := token.SignedString([]byte(a.secretKey))
 	if jwtErr != nil {
 		return "", errors.NewDomainErrorWithMessage(errors.ErrorUnknown, "トークンの生成に失敗しました")
 	}
 
-	return signedToken, nil
+	return models.Token(signedToken), nil
 }
 
-func (a *apiKeyUsecase) CreateApiKey(userID string, projectID int, name string, collectionIds []int) (string, error) {
-	// Generate a random API key
-	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", errors.NewDomainErrorWithMessage(errors.ErrorUnknown, "APIキーの生成に失敗しました")
-	}
-	apiKey := hex.EncodeToString(bytes)
-
-	// Parse userID to UUID
-	parsedUserID, err := uuid.Parse(userID)
+func (a *jwtAuthUsecase) ValidateMFAChallengeToken(token string) (string, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.NewDomainErrorWithMessage(errors.Unauthenticated, "署名方式が不正です")
+		}
+		return []byte(a.secretKey), nil
+	}, jwt.WithExpirationRequired())
 	if err != nil {
-		return "", errors.NewDomainErrorWithMessage(errors.ErrorUnknown, "ユーザーIDのパースに失敗しました")
+		return "", errors.NewDomainErrorWithMessage(errors.Unauthenticated, "二要素認証の有効期限が切れたか、トークンが不正です")
 	}
 
-	// Create API key model
-	apiKeyModel := models.ApiKeys{
-		UserID:        parsedUserID,
-		ProjectID:     projectID,
-		Name:          name,
-		Key:           apiKey,
-		CollectionIds: collectionIds,
-		ExpireAt:      time.Now().Add(365 * 24 * time.Hour), // 1 year expiration
-		Revoked:       false,
-		RateLimit:     1000, // Default rate limit
+	if typ, _ := claims["typ"].(string); typ != mfaChallengeTokenType {
+		return "", errors.NewDomainErrorWithMessage(errors.Unauthenticated, "二要素認証用のトークンではありません")
 	}
 
-	// Save to database
-	de := a.repo.Create(context.Background(), &apiKeyModel)
-	if de != nil {
-		return "", de
+	subStr, ok := claims["sub"].(string)
+	if !ok {
+		return "", errors.NewDomainErrorWithMessage(errors.Unauthenticated, "subの型が不正です")
+	}
+	if _, err := uuid.Parse(subStr); err != nil {
+		return "", errors.NewDomainErrorWithMessage(errors.Unauthenticated, "UUIDのパースに失敗しました")
 	}
 
-	return apiKey, nil
+	return subStr, nil
 }
@@ -154,3 +154,20 @@ func TestValidateToken_SubIsInvalidUUID(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "UUIDのパース")
 }
+
+func TestValidateToken_MissingSessionID(t *testing.T) {
+	t.Parallel()
+	setupInvalidKey()
+	j := usecase.NewjwtAuthUsecase()
+
+	// sid を持たない旧形式のトークン
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": uuid.New().String(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	signed, _ := token.SignedString([]byte(os.Getenv("SECRET_KEY")))
+
+	_, err := j.ValidateToken(signed)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "セッション情報")
+}
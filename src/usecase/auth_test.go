@@ -24,7 +24,7 @@ func TestJwtUsecase_GenerateToken_Success(t *testing.T) {
 	auth := usecase.NewjwtAuthUsecase()
 	userID := uuid.New()
 
-	token, err := auth.GenerateToken(userID)
+	token, err := auth.GenerateToken(userID, uuid.New())
 
 	require.NoError(t, err)
 	assert.NotEmpty(t, token)
@@ -35,10 +35,49 @@ func TestJwtUsecase_ValidateToken_Success(t *testing.T) {
 	auth := usecase.NewjwtAuthUsecase()
 	userID := uuid.New()
 
-	token, err := auth.GenerateToken(userID)
+	sessionID := uuid.New()
+
+	token, err := auth.GenerateToken(userID, sessionID)
+	require.NoError(t, err)
+
+	claims, err := auth.ValidateToken(string(token))
+	require.NoError(t, err)
+	assert.Equal(t, userID.String(), claims.UserID)
+	assert.Equal(t, sessionID.String(), claims.SessionID)
+}
+
+func TestJwtUsecase_MFAChallengeToken_RoundTrip(t *testing.T) {
+	t.Parallel()
+	auth := usecase.NewjwtAuthUsecase()
+	userID := uuid.New()
+
+	token, err := auth.GenerateMFAChallengeToken(userID)
+	require.NoError(t, err)
+
+	subject, err := auth.ValidateMFAChallengeToken(string(token))
+	require.NoError(t, err)
+	assert.Equal(t, userID.String(), subject)
+}
+
+func TestJwtUsecase_MFAChallengeToken_NotAcceptedAsAccessToken(t *testing.T) {
+	t.Parallel()
+	auth := usecase.NewjwtAuthUsecase()
+
+	token, err := auth.GenerateMFAChallengeToken(uuid.New())
 	require.NoError(t, err)
 
-	resultID, err := auth.ValidateToken(string(token))
+	// 入力待ちトークンでAPIにアクセスできてはならない
+	_, err = auth.ValidateToken(string(token))
+	require.Error(t, err)
+}
+
+func TestJwtUsecase_AccessToken_NotAcceptedAsMFAChallenge(t *testing.T) {
+	t.Parallel()
+	auth := usecase.NewjwtAuthUsecase()
+
+	token, err := auth.GenerateToken(uuid.New(), uuid.New())
 	require.NoError(t, err)
-	assert.Equal(t, userID.String(), resultID)
+
+	_, err = auth.ValidateMFAChallengeToken(string(token))
+	require.Error(t, err)
 }
@@ -1,30 +1,80 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
 	"w3st/domain/models"
 	"w3st/domain/repositories"
 	myerrors "w3st/errors"
+	"w3st/infra/logger"
+)
+
+const (
+	// CollectionTrashRetention ゴミ箱に移したコレクションを復元できる期間。過ぎたものは CollectionTrashSweepInterval ごとに削除する
+	CollectionTrashRetention = 30 * 24 * time.Hour
+	// CollectionTrashSweepInterval ゴミ箱から期限切れのコレクションを削除する間隔
+	CollectionTrashSweepInterval = time.Hour
 )
 
+// DeleteCollectionOptions コレクションの削除方法
+type DeleteCollectionOptions struct {
+	// Force 他のコレクションからリレーションで参照されていても削除する
+	Force bool
+	// Permanent ゴミ箱に移さず、関連するデータごと削除する
+	Permanent bool
+}
+
 type CollectionsUsecase interface {
-	Make(newCollection *models.ApiCollection) error
+	// Make コレクションを作成する。Slug を省略した場合は Name から重複しない slug を作る
+	Make(ctx context.Context, newCollection *models.ApiCollection) error
+	// Update update の nil でない項目を変更する。update.UpdatedAt が現在の updated_at と異なる場合は変更しない
+	Update(ctx context.Context, collectionId int, projectId int, update models.CollectionUpdate) (*models.ApiCollection, error)
+	// ResolveCollectionId idOrSlug がIDの場合はそのまま、slug の場合はコレクションのIDを返す
+	ResolveCollectionId(ctx context.Context, projectId int, idOrSlug string) (int, error)
 	GetCollectionByProjectId(projectId int) ([]models.ApiCollection, error)
 	GetCollectionsByCollectionId(collectionId int, projectId int) (*models.ApiCollection, error)
 	GetCollectionByProjectIdForSDK(projectId int, collectionIds []int) ([]models.ApiCollection, error)
 	GetCollectionsByCollectionIdForSDK(collectionId int, projectId int, collectionIds []int) (*models.ApiCollection, error)
+	// Delete コレクションをゴミ箱に移す。opts.Permanent の場合はフィールド・エントリ・バージョン・リレーション・APIキーへの付与ごと削除する。
+	// 他のコレクションからリレーションで参照されている場合は opts.Force でなければ削除しない
+	Delete(ctx context.Context, collectionId int, projectId int, opts DeleteCollectionOptions) error
+	// GetTrash プロジェクトのゴミ箱にあるコレクションを返す
+	GetTrash(ctx context.Context, projectId int) ([]models.ApiCollection, error)
+	// Restore ゴミ箱に移してから CollectionTrashRetention 以内のコレクションを戻す。slug が他のコレクションで使われている場合は戻さない
+	Restore(ctx context.Context, collectionId int, projectId int) (*models.ApiCollection, error)
+	// PurgeExpiredCollections CollectionTrashRetention を過ぎたゴミ箱のコレクションを削除し、削除した件数を返す
+	PurgeExpiredCollections(ctx context.Context) (int, error)
 }
 
 type collectionsUsecase struct {
 	collectionsRepo repositories.CollectionsRepository
+	transactionRepo repositories.TransactionRepository
 }
 
-func NewCollectionsUsecase(collectionsRepo repositories.CollectionsRepository) CollectionsUsecase {
+func NewCollectionsUsecase(collectionsRepo repositories.CollectionsRepository, transactionRepo repositories.TransactionRepository) CollectionsUsecase {
 	return &collectionsUsecase{
 		collectionsRepo: collectionsRepo,
+		transactionRepo: transactionRepo,
 	}
 }
 
-func (c *collectionsUsecase) Make(newCollection *models.ApiCollection) error {
+func (c *collectionsUsecase) Make(ctx context.Context, newCollection *models.ApiCollection) error {
+	if newCollection.Slug == "" {
+		slug, err := c.availableSlug(ctx, newCollection.ProjectID, models.CollectionSlugFromName(newCollection.Name))
+		if err != nil {
+			return myerrors.WrapDomainError("collectionsUsecase.Make", err)
+		}
+		newCollection.Slug = slug
+	} else if !models.ValidCollectionSlug(newCollection.Slug) {
+		return invalidCollectionSlugError()
+	}
+
 	// コレクションを作成する
 	err := c.collectionsRepo.CreateCollection(newCollection)
 	if err != nil {
@@ -34,6 +84,72 @@ func (c *collectionsUsecase) Make(newCollection *models.ApiCollection) error {
 	return nil
 }
 
+// collectionSlugMaxSuffix slug を作るときに重複を避けるため付ける番号の最大
+const collectionSlugMaxSuffix = 100
+
+// availableSlug base、base-2、base-3… のうちプロジェクトでまだ使われていない slug を返す
+func (c *collectionsUsecase) availableSlug(ctx context.Context, projectId int, base string) (string, error) {
+	for i := 1; i <= collectionSlugMaxSuffix; i++ {
+		slug := base
+		if i > 1 {
+			slug = fmt.Sprintf("%s-%d", base, i)
+		}
+		_, err := c.collectionsRepo.GetCollectionBySlug(ctx, projectId, slug)
+		if errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return slug, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "slug を作れませんでした。slug を指定してください")
+}
+
+func invalidCollectionSlugError() *myerrors.DomainError {
+	return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter,
+		fmt.Sprintf("slug は英小文字で始まり、英小文字・数字・-・_ だけを含む%d文字以内の文字列にしてください", models.CollectionSlugMaxLength))
+}
+
+func (c *collectionsUsecase) Update(ctx context.Context, collectionId int, projectId int, update models.CollectionUpdate) (*models.ApiCollection, error) {
+	if update.Name == nil && update.Description == nil && update.Slug == nil && update.RejectUnknownFields == nil {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "変更する項目を指定してください")
+	}
+	if update.Slug != nil && !models.ValidCollectionSlug(*update.Slug) {
+		return nil, invalidCollectionSlugError()
+	}
+
+	var updated *models.ApiCollection
+	err := c.transactionRepo.Do(ctx, func(ctx context.Context) error {
+		collection, err := c.collectionsRepo.LockCollection(ctx, collectionId, projectId)
+		if err != nil {
+			return err
+		}
+		if collection.DeletedAt != nil {
+			return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません")
+		}
+		if !collection.UpdatedAt.Equal(update.UpdatedAt) {
+			return myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "コレクションは他の更新で変更されています。取得し直してから変更してください")
+		}
+		updated, err = c.collectionsRepo.UpdateCollection(ctx, collectionId, update)
+		return err
+	})
+	if err != nil {
+		return nil, myerrors.WrapDomainError("collectionsUsecase.Update", err)
+	}
+	return updated, nil
+}
+
+func (c *collectionsUsecase) ResolveCollectionId(ctx context.Context, projectId int, idOrSlug string) (int, error) {
+	if id, err := strconv.Atoi(idOrSlug); err == nil {
+		return id, nil
+	}
+	collection, err := c.collectionsRepo.GetCollectionBySlug(ctx, projectId, idOrSlug)
+	if err != nil {
+		return 0, myerrors.WrapDomainError("collectionsUsecase.ResolveCollectionId", err)
+	}
+	return collection.ID, nil
+}
+
 func (c *collectionsUsecase) GetCollectionByProjectId(projectId int) ([]models.ApiCollection, error) {
 	collection, err := c.collectionsRepo.GetCollectionByProjectId(projectId)
 	if err != nil {
@@ -50,43 +166,148 @@ func (c *collectionsUsecase) GetCollectionsByCollectionId(collectionId int, proj
 	return collection, nil
 }
 
+// GetCollectionByProjectIdForSDK APIキーで利用できるコレクションだけをDBから取得する
 func (c *collectionsUsecase) GetCollectionByProjectIdForSDK(projectId int, collectionIds []int) ([]models.ApiCollection, error) {
-	// Get all collections for the project
-	allCollections, err := c.collectionsRepo.GetCollectionByProjectId(projectId)
+	collections, err := c.collectionsRepo.GetCollectionsByIds(projectId, collectionIds)
 	if err != nil {
 		return nil, myerrors.WrapDomainError("collectionsUsecase.GetCollectionByProjectIdForSDK", err)
 	}
+	return collections, nil
+}
+
+func (c *collectionsUsecase) GetCollectionsByCollectionIdForSDK(collectionId int, projectId int, collectionIds []int) (*models.ApiCollection, error) {
+	if !slices.Contains(collectionIds, collectionId) {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "Collection not accessible with this API key")
+	}
+
+	collection, err := c.collectionsRepo.GetCollectionsByCollectionId(collectionId, projectId)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("collectionsUsecase.GetCollectionsByCollectionIdForSDK", err)
+	}
+	return collection, nil
+}
 
-	// Filter collections based on allowed collectionIds
-	var filteredCollections []models.ApiCollection
-	for _, collection := range allCollections {
-		for _, allowedId := range collectionIds {
-			if collection.ID == allowedId {
-				filteredCollections = append(filteredCollections, collection)
-				break
+func (c *collectionsUsecase) Delete(ctx context.Context, collectionId int, projectId int, opts DeleteCollectionOptions) error {
+	err := c.transactionRepo.Do(ctx, func(ctx context.Context) error {
+		collection, err := c.collectionsRepo.LockCollection(ctx, collectionId, projectId)
+		if err != nil {
+			return err
+		}
+		// ゴミ箱にあるコレクションは完全に削除する場合だけ対象にする
+		if collection.DeletedAt != nil && !opts.Permanent {
+			return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません")
+		}
+
+		referencing, err := c.collectionsRepo.GetReferencingCollectionIds(ctx, collectionId)
+		if err != nil {
+			return err
+		}
+		if len(referencing) > 0 && !opts.Force {
+			ids := make([]string, len(referencing))
+			for i, id := range referencing {
+				ids[i] = strconv.Itoa(id)
 			}
+			return myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist,
+				fmt.Sprintf("他のコレクション（ID: %s）からリレーションで参照されています。削除する場合は force=true を指定してください", strings.Join(ids, ", ")))
 		}
+
+		if opts.Permanent {
+			return c.collectionsRepo.DeleteCollection(ctx, collectionId)
+		}
+		return c.collectionsRepo.SoftDeleteCollection(ctx, collectionId, time.Now())
+	})
+	if err != nil {
+		return myerrors.WrapDomainError("collectionsUsecase.Delete", err)
 	}
+	return nil
+}
 
-	return filteredCollections, nil
+func (c *collectionsUsecase) GetTrash(ctx context.Context, projectId int) ([]models.ApiCollection, error) {
+	collections, err := c.collectionsRepo.GetDeletedCollections(ctx, projectId)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("collectionsUsecase.GetTrash", err)
+	}
+	return collections, nil
 }
 
-func (c *collectionsUsecase) GetCollectionsByCollectionIdForSDK(collectionId int, projectId int, collectionIds []int) (*models.ApiCollection, error) {
-	// Check if collectionId is in allowed collectionIds
-	allowed := false
-	for _, id := range collectionIds {
-		if id == collectionId {
-			allowed = true
-			break
+func (c *collectionsUsecase) Restore(ctx context.Context, collectionId int, projectId int) (*models.ApiCollection, error) {
+	var restored *models.ApiCollection
+	err := c.transactionRepo.Do(ctx, func(ctx context.Context) error {
+		collection, err := c.collectionsRepo.LockCollection(ctx, collectionId, projectId)
+		if err != nil {
+			return err
 		}
+		if collection.DeletedAt == nil {
+			return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "ゴミ箱にコレクションが見つかりません")
+		}
+		// 期限を過ぎたものは次の削除を待っているだけなので戻さない
+		if time.Since(*collection.DeletedAt) >= CollectionTrashRetention {
+			return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "ゴミ箱の保存期間を過ぎたため復元できません")
+		}
+		if err := c.collectionsRepo.RestoreCollection(ctx, collectionId); err != nil {
+			return err
+		}
+		collection.DeletedAt = nil
+		restored = collection
+		return nil
+	})
+	if err != nil {
+		return nil, myerrors.WrapDomainError("collectionsUsecase.Restore", err)
 	}
-	if !allowed {
-		return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "Collection not accessible with this API key")
-	}
+	return restored, nil
+}
 
-	collection, err := c.collectionsRepo.GetCollectionsByCollectionId(collectionId, projectId)
+func (c *collectionsUsecase) PurgeExpiredCollections(ctx context.Context) (int, error) {
+	before := time.Now().Add(-CollectionTrashRetention)
+	expired, err := c.collectionsRepo.GetDeletedCollectionsBefore(ctx, before)
 	if err != nil {
-		return nil, myerrors.WrapDomainError("collectionsUsecase.GetCollectionsByCollectionIdForSDK", err)
+		return 0, myerrors.WrapDomainError("collectionsUsecase.PurgeExpiredCollections", err)
 	}
-	return collection, nil
+
+	purged := 0
+	for _, collection := range expired {
+		// 取得してから削除するまでに復元された場合は削除しない
+		deleted := false
+		err := c.transactionRepo.Do(ctx, func(ctx context.Context) error {
+			locked, err := c.collectionsRepo.LockCollection(ctx, collection.ID, collection.ProjectID)
+			if err != nil {
+				return err
+			}
+			if locked.DeletedAt == nil || !locked.DeletedAt.Before(before) {
+				return nil
+			}
+			deleted = true
+			return c.collectionsRepo.DeleteCollection(ctx, collection.ID)
+		})
+		if err != nil {
+			return purged, myerrors.WrapDomainError("collectionsUsecase.PurgeExpiredCollections", err)
+		}
+		if deleted {
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// StartCollectionTrashSweep ctx がキャンセルされるまで interval ごとにゴミ箱から期限切れのコレクションを削除する
+func StartCollectionTrashSweep(ctx context.Context, collectionsUsecase CollectionsUsecase, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purged, err := collectionsUsecase.PurgeExpiredCollections(ctx)
+				if err != nil {
+					logger.Error("failed to purge deleted collections", "error", err.Error())
+					continue
+				}
+				if purged > 0 {
+					logger.Info("purged deleted collections", "count", purged)
+				}
+			}
+		}
+	}()
 }
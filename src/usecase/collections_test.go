@@ -1,8 +1,10 @@
 package usecase_test
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
@@ -10,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"w3st/domain/models"
+	myerrors "w3st/errors"
 	mockRepositories "w3st/mock/repositories"
 	"w3st/usecase"
 )
@@ -20,20 +23,25 @@ func TestCollectionsUsecase_Make_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
-	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo)
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockRepositories.NewMockTransactionRepository(ctrl))
 
 	newCollection := &models.ApiCollection{
 		Name:   "Test Collection",
 		UserID: uuid.New(),
 	}
 
+	// slug を省略した場合は name から作る
+	mockCollectionsRepo.EXPECT().
+		GetCollectionBySlug(gomock.Any(), 0, "test-collection").
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません"))
 	mockCollectionsRepo.EXPECT().
 		CreateCollection(newCollection).
 		Return(nil)
 
-	err := uc.Make(newCollection)
+	err := uc.Make(context.Background(), newCollection)
 
 	require.NoError(t, err)
+	assert.Equal(t, "test-collection", newCollection.Slug)
 }
 
 func TestCollectionsUsecase_Make_Failure(t *testing.T) {
@@ -42,10 +50,11 @@ func TestCollectionsUsecase_Make_Failure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
-	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo)
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockRepositories.NewMockTransactionRepository(ctrl))
 
 	newCollection := &models.ApiCollection{
 		Name:   "Test Collection",
+		Slug:   "tests",
 		UserID: uuid.New(),
 	}
 
@@ -53,7 +62,7 @@ func TestCollectionsUsecase_Make_Failure(t *testing.T) {
 		CreateCollection(newCollection).
 		Return(errors.New("test error"))
 
-	err := uc.Make(newCollection)
+	err := uc.Make(context.Background(), newCollection)
 
 	require.Error(t, err)
 }
@@ -64,7 +73,7 @@ func TestCollectionsUsecase_GetCollectionByProjectId_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
-	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo)
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockRepositories.NewMockTransactionRepository(ctrl))
 
 	projectID := 1
 	expectedCollections := []models.ApiCollection{
@@ -89,7 +98,7 @@ func TestCollectionsUsecase_GetCollectionsByCollectionId_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
-	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo)
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockRepositories.NewMockTransactionRepository(ctrl))
 
 	collectionID := 1
 	projectID := 1
@@ -107,3 +116,275 @@ func TestCollectionsUsecase_GetCollectionsByCollectionId_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, expectedCollection, collection)
 }
+
+func TestCollectionsUsecase_GetCollectionByProjectIdForSDK(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockRepositories.NewMockTransactionRepository(ctrl))
+
+	// APIキーで利用できるコレクションだけをDBで絞り込む
+	mockCollectionsRepo.EXPECT().
+		GetCollectionsByIds(1, []int{2, 3}).
+		Return([]models.ApiCollection{{ID: 2, ProjectID: 1}, {ID: 3, ProjectID: 1}}, nil)
+
+	collections, err := uc.GetCollectionByProjectIdForSDK(1, []int{2, 3})
+
+	require.NoError(t, err)
+	assert.Len(t, collections, 2)
+}
+
+func TestCollectionsUsecase_Delete_MovesToTrash(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo)
+	ctx := context.Background()
+
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 2, 1).Return(&models.ApiCollection{ID: 2, ProjectID: 1}, nil)
+	mockCollectionsRepo.EXPECT().GetReferencingCollectionIds(gomock.Any(), 2).Return([]int{}, nil)
+	mockCollectionsRepo.EXPECT().SoftDeleteCollection(gomock.Any(), 2, gomock.Any()).Return(nil)
+
+	require.NoError(t, uc.Delete(ctx, 2, 1, usecase.DeleteCollectionOptions{}))
+}
+
+func TestCollectionsUsecase_Delete_ReferencedByOtherCollections(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo)
+	ctx := context.Background()
+
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 2, 1).Return(&models.ApiCollection{ID: 2, ProjectID: 1}, nil).Times(2)
+	mockCollectionsRepo.EXPECT().GetReferencingCollectionIds(gomock.Any(), 2).Return([]int{3, 5}, nil).Times(2)
+
+	// force を指定しなければ削除しない
+	err := uc.Delete(ctx, 2, 1, usecase.DeleteCollectionOptions{Permanent: true})
+	assertErrType(t, err, myerrors.AlreadyExist)
+	assert.Contains(t, err.Error(), "3, 5")
+
+	// force を指定すると関連するデータごと削除する
+	mockCollectionsRepo.EXPECT().DeleteCollection(gomock.Any(), 2).Return(nil)
+	require.NoError(t, uc.Delete(ctx, 2, 1, usecase.DeleteCollectionOptions{Force: true, Permanent: true}))
+}
+
+func TestCollectionsUsecase_Delete_Trashed(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo)
+	ctx := context.Background()
+	deletedAt := time.Now().Add(-time.Hour)
+	trashed := &models.ApiCollection{ID: 2, ProjectID: 1, DeletedAt: &deletedAt}
+
+	// ゴミ箱にあるコレクションをもう一度ゴミ箱に移すことはできない
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 2, 1).Return(trashed, nil)
+	assertErrType(t, uc.Delete(ctx, 2, 1, usecase.DeleteCollectionOptions{}), myerrors.QueryDataNotFoundError)
+
+	// 完全に削除することはできる
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 2, 1).Return(trashed, nil)
+	mockCollectionsRepo.EXPECT().GetReferencingCollectionIds(gomock.Any(), 2).Return([]int{}, nil)
+	mockCollectionsRepo.EXPECT().DeleteCollection(gomock.Any(), 2).Return(nil)
+	require.NoError(t, uc.Delete(ctx, 2, 1, usecase.DeleteCollectionOptions{Permanent: true}))
+}
+
+func TestCollectionsUsecase_Delete_NotFound(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo)
+
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 2, 1).
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません"))
+
+	assertErrType(t, uc.Delete(context.Background(), 2, 1, usecase.DeleteCollectionOptions{Permanent: true}), myerrors.QueryDataNotFoundError)
+}
+
+func TestCollectionsUsecase_Restore(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo)
+	ctx := context.Background()
+
+	deletedAt := time.Now().Add(-24 * time.Hour)
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 2, 1).Return(&models.ApiCollection{ID: 2, ProjectID: 1, DeletedAt: &deletedAt}, nil)
+	mockCollectionsRepo.EXPECT().RestoreCollection(gomock.Any(), 2).Return(nil)
+
+	collection, err := uc.Restore(ctx, 2, 1)
+	require.NoError(t, err)
+	assert.Nil(t, collection.DeletedAt)
+
+	// ゴミ箱にないコレクション
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 3, 1).Return(&models.ApiCollection{ID: 3, ProjectID: 1}, nil)
+	_, err = uc.Restore(ctx, 3, 1)
+	assertErrType(t, err, myerrors.QueryDataNotFoundError)
+
+	// 保存期間を過ぎたコレクション
+	expiredAt := time.Now().Add(-usecase.CollectionTrashRetention - time.Minute)
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 4, 1).Return(&models.ApiCollection{ID: 4, ProjectID: 1, DeletedAt: &expiredAt}, nil)
+	_, err = uc.Restore(ctx, 4, 1)
+	assertErrType(t, err, myerrors.QueryDataNotFoundError)
+}
+
+func TestCollectionsUsecase_PurgeExpiredCollections(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo)
+
+	expiredAt := time.Now().Add(-usecase.CollectionTrashRetention - time.Hour)
+	mockCollectionsRepo.EXPECT().GetDeletedCollectionsBefore(gomock.Any(), gomock.Any()).
+		Return([]models.ApiCollection{{ID: 2, ProjectID: 1, DeletedAt: &expiredAt}, {ID: 3, ProjectID: 1, DeletedAt: &expiredAt}}, nil)
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 2, 1).Return(&models.ApiCollection{ID: 2, ProjectID: 1, DeletedAt: &expiredAt}, nil)
+	mockCollectionsRepo.EXPECT().DeleteCollection(gomock.Any(), 2).Return(nil)
+	// 取得した後に復元されたコレクションは削除しない
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 3, 1).Return(&models.ApiCollection{ID: 3, ProjectID: 1}, nil)
+
+	purged, err := uc.PurgeExpiredCollections(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
+
+func TestCollectionsUsecase_Make_Slug(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo)
+	ctx := context.Background()
+	notFound := myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません")
+
+	// 使われている slug には番号を付ける。英数字がない name は "collection" にする
+	mockCollectionsRepo.EXPECT().GetCollectionBySlug(gomock.Any(), 1, "collection").Return(&models.ApiCollection{ID: 2}, nil)
+	mockCollectionsRepo.EXPECT().GetCollectionBySlug(gomock.Any(), 1, "collection-2").Return(nil, notFound)
+	mockCollectionsRepo.EXPECT().CreateCollection(gomock.Any()).Return(nil)
+	collection := &models.ApiCollection{ProjectID: 1, Name: "商品"}
+	require.NoError(t, uc.Make(ctx, collection))
+	assert.Equal(t, "collection-2", collection.Slug)
+
+	// 数字で始まる name
+	mockCollectionsRepo.EXPECT().GetCollectionBySlug(gomock.Any(), 1, "c-2024-news").Return(nil, notFound)
+	mockCollectionsRepo.EXPECT().CreateCollection(gomock.Any()).Return(nil)
+	collection = &models.ApiCollection{ProjectID: 1, Name: "2024 News!"}
+	require.NoError(t, uc.Make(ctx, collection))
+	assert.Equal(t, "c-2024-news", collection.Slug)
+
+	// 指定した slug は形式を確認する（数字だけの slug はIDと区別できない）
+	for _, slug := range []string{"123", "Products", "-products", "商品"} {
+		err := uc.Make(ctx, &models.ApiCollection{ProjectID: 1, Name: "Products", Slug: slug})
+		assertErrType(t, err, myerrors.InvalidParameter)
+	}
+}
+
+func TestCollectionsUsecase_Update(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo)
+	ctx := context.Background()
+	updatedAt := time.Date(2026, 3, 1, 10, 0, 0, 123456000, time.UTC)
+	name, slug := "Products", "products"
+
+	// 省略した項目は変更しない
+	update := models.CollectionUpdate{Name: &name, Slug: &slug, UpdatedAt: updatedAt}
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 2, 1).Return(&models.ApiCollection{ID: 2, ProjectID: 1, Name: "Items", Slug: "items", UpdatedAt: updatedAt}, nil)
+	mockCollectionsRepo.EXPECT().UpdateCollection(gomock.Any(), 2, update).
+		Return(&models.ApiCollection{ID: 2, ProjectID: 1, Name: name, Slug: slug, UpdatedAt: updatedAt.Add(time.Minute)}, nil)
+
+	collection, err := uc.Update(ctx, 2, 1, update)
+	require.NoError(t, err)
+	assert.Equal(t, "products", collection.Slug)
+
+	// 取得した後に他の更新があった場合は変更しない
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 2, 1).Return(&models.ApiCollection{ID: 2, ProjectID: 1, UpdatedAt: updatedAt.Add(time.Minute)}, nil)
+	_, err = uc.Update(ctx, 2, 1, update)
+	assertErrType(t, err, myerrors.AlreadyExist)
+
+	// slug が重複する場合
+	mockCollectionsRepo.EXPECT().LockCollection(gomock.Any(), 3, 1).Return(&models.ApiCollection{ID: 3, ProjectID: 1, UpdatedAt: updatedAt}, nil)
+	mockCollectionsRepo.EXPECT().UpdateCollection(gomock.Any(), 3, update).
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "同じ slug のコレクションがすでに存在します"))
+	_, err = uc.Update(ctx, 3, 1, update)
+	assertErrType(t, err, myerrors.AlreadyExist)
+
+	// 変更する項目がない・slug の形式が違う場合はDBを使わない
+	_, err = uc.Update(ctx, 2, 1, models.CollectionUpdate{UpdatedAt: updatedAt})
+	assertErrType(t, err, myerrors.InvalidParameter)
+	invalid := "1"
+	_, err = uc.Update(ctx, 2, 1, models.CollectionUpdate{Slug: &invalid, UpdatedAt: updatedAt})
+	assertErrType(t, err, myerrors.InvalidParameter)
+}
+
+func TestCollectionsUsecase_ResolveCollectionId(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo)
+	ctx := context.Background()
+
+	// IDはそのまま返す
+	id, err := uc.ResolveCollectionId(ctx, 1, "12")
+	require.NoError(t, err)
+	assert.Equal(t, 12, id)
+
+	mockCollectionsRepo.EXPECT().GetCollectionBySlug(gomock.Any(), 1, "products").Return(&models.ApiCollection{ID: 3, ProjectID: 1}, nil)
+	id, err = uc.ResolveCollectionId(ctx, 1, "products")
+	require.NoError(t, err)
+	assert.Equal(t, 3, id)
+}
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"crypto/subtle"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+
+	myerrors "w3st/errors"
+)
+
+const (
+	// DefaultPasswordHashCost 環境変数で指定がない場合のbcryptコスト
+	DefaultPasswordHashCost = 12
+	// PasswordMinLength パスワードの最小文字数
+	PasswordMinLength = 8
+	// PasswordMaxBytes bcryptが扱える最大バイト数
+	PasswordMaxBytes = 72
+)
+
+type CredentialUsecase interface {
+	// ValidatePolicy パスワードポリシーを満たしているか検証する
+	ValidatePolicy(password string) error
+	// HashPassword パスワードをハッシュ化する
+	HashPassword(password string) (string, error)
+	// VerifyPassword 保存済みの値とパスワードを照合する。
+	// needsRehash が true の場合は平文や古いコストで保存されているため再ハッシュが必要
+	VerifyPassword(stored, password string) (ok bool, needsRehash bool)
+}
+
+type credentialUsecase struct {
+	cost      int
+	dummyOnce sync.Once
+	dummyHash []byte
+}
+
+// NewCredentialUsecase PASSWORD_HASH_COST からコストを読み込んで生成する
+func NewCredentialUsecase() CredentialUsecase {
+	cost := DefaultPasswordHashCost
+	if v := os.Getenv("PASSWORD_HASH_COST"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			cost = parsed
+		}
+	}
+	return NewCredentialUsecaseWithCost(cost)
+}
+
+func NewCredentialUsecaseWithCost(cost int) CredentialUsecase {
+	if cost < bcrypt.MinCost {
+		cost = bcrypt.MinCost
+	}
+	if cost > bcrypt.MaxCost {
+		cost = bcrypt.MaxCost
+	}
+	return &credentialUsecase{cost: cost}
+}
+
+func (c *credentialUsecase) ValidatePolicy(password string) error {
+	if len([]rune(password)) < PasswordMinLength {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "パスワードは8文字以上で入力してください")
+	}
+	if len(password) > PasswordMaxBytes {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "パスワードが長すぎます")
+	}
+
+	hasLetter := false
+	hasDigit := false
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "パスワードには英字と数字をそれぞれ1文字以上含めてください")
+	}
+
+	return nil
+}
+
+func (c *credentialUsecase) HashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), c.cost)
+	if err != nil {
+		return "", myerrors.NewDomainErrorWithMessage(myerrors.ErrorUnknown, "パスワードのハッシュ化に失敗しました")
+	}
+	return string(hashed), nil
+}
+
+func (c *credentialUsecase) VerifyPassword(stored, password string) (bool, bool) {
+	// パスワード未設定（外部IdPのみのユーザーなど）は常に失敗させる。
+	// 応答時間で存在を推測されないようダミーの比較を行う
+	if stored == "" {
+		c.compareDummy(password)
+		return false, false
+	}
+
+	if !isBcryptHash(stored) {
+		// 旧データ（平文保存）との互換性のため定数時間で比較し、成功したら再ハッシュさせる
+		ok := subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return ok, ok
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)); err != nil {
+		return false, false
+	}
+
+	cost, err := bcrypt.Cost([]byte(stored))
+	if err != nil {
+		return true, true
+	}
+	return true, cost < c.cost
+}
+
+func (c *credentialUsecase) compareDummy(password string) {
+	c.dummyOnce.Do(func() {
+		c.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password-0"), c.cost)
+	})
+	_ = bcrypt.CompareHashAndPassword(c.dummyHash, []byte(password))
+}
+
+func isBcryptHash(s string) bool {
+	return strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$")
+}
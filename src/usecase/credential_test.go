@@ -0,0 +1,101 @@
+package usecase_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	myerrors "w3st/errors"
+	"w3st/usecase"
+)
+
+// テストでは最小コストを使って高速化する
+func newTestCredentialUsecase() usecase.CredentialUsecase {
+	return usecase.NewCredentialUsecaseWithCost(bcrypt.MinCost)
+}
+
+func TestCredentialUsecase_HashAndVerify(t *testing.T) {
+	t.Parallel()
+	c := newTestCredentialUsecase()
+
+	hashed, err := c.HashPassword("password123")
+	require.NoError(t, err)
+	assert.NotEqual(t, "password123", hashed)
+	assert.True(t, strings.HasPrefix(hashed, "$2"))
+
+	ok, needsRehash := c.VerifyPassword(hashed, "password123")
+	assert.True(t, ok)
+	assert.False(t, needsRehash)
+
+	ok, _ = c.VerifyPassword(hashed, "wrong-password1")
+	assert.False(t, ok)
+}
+
+func TestCredentialUsecase_VerifyPassword_LegacyPlaintext(t *testing.T) {
+	t.Parallel()
+	c := newTestCredentialUsecase()
+
+	ok, needsRehash := c.VerifyPassword("password", "password")
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+
+	ok, needsRehash = c.VerifyPassword("password", "other")
+	assert.False(t, ok)
+	assert.False(t, needsRehash)
+}
+
+func TestCredentialUsecase_VerifyPassword_CostUpgrade(t *testing.T) {
+	t.Parallel()
+	weak := usecase.NewCredentialUsecaseWithCost(bcrypt.MinCost)
+	strong := usecase.NewCredentialUsecaseWithCost(bcrypt.MinCost + 1)
+
+	hashed, err := weak.HashPassword("password123")
+	require.NoError(t, err)
+
+	ok, needsRehash := strong.VerifyPassword(hashed, "password123")
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+}
+
+func TestCredentialUsecase_VerifyPassword_EmptyStoredNeverMatches(t *testing.T) {
+	t.Parallel()
+	c := newTestCredentialUsecase()
+
+	ok, needsRehash := c.VerifyPassword("", "")
+	assert.False(t, ok)
+	assert.False(t, needsRehash)
+}
+
+func TestCredentialUsecase_ValidatePolicy(t *testing.T) {
+	t.Parallel()
+	c := newTestCredentialUsecase()
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{name: "valid", password: "password123", wantErr: false},
+		{name: "too short", password: "pass1", wantErr: true},
+		{name: "no digit", password: "passwordpassword", wantErr: true},
+		{name: "no letter", password: "1234567890", wantErr: true},
+		{name: "too long", password: strings.Repeat("a1", 40), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := c.ValidatePolicy(tt.password)
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			var domainErr *myerrors.DomainError
+			require.ErrorAs(t, err, &domainErr)
+			assert.Equal(t, myerrors.InvalidParameter, domainErr.ErrType)
+		})
+	}
+}
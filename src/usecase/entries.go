@@ -1,7 +1,11 @@
 package usecase
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
 
 	"w3st/domain/models"
 	"w3st/domain/repositories"
@@ -9,40 +13,59 @@ import (
 )
 
 type EntriesUsecase interface {
-	CreateEntry(newEntry *models.Entry, projectId int) error
-	GetEntriesByCollectionId(collectionId int, projectId int) ([]models.Entry, error)
-	GetEntriesByCollectionIdForSDK(collectionId int, projectId int, collectionIds []int) ([]models.Entry, error)
-	UpdateEntry(entryId int, data map[string]interface{}, projectId int) error
-	DeleteEntry(entryId int, projectId int) error
+	CreateEntry(ctx context.Context, newEntry *models.Entry, projectId int) error
+	// GetEntriesByCollectionId, GetEntriesByCollectionIdForSDK populate（"author,tags.category" のような指定）の relation のフィールドは、参照先のエントリに展開する
+	GetEntriesByCollectionId(collectionId int, projectId int, populate string) ([]models.Entry, error)
+	GetEntriesByCollectionIdForSDK(collectionId int, projectId int, collectionIds []int, populate string) ([]models.Entry, error)
+	// CreateEntryForSDK, UpdateEntryForSDK, DeleteEntryForSDK APIキーで利用できるコレクション（collectionIds）のエントリだけを操作する
+	CreateEntryForSDK(ctx context.Context, collectionId int, projectId int, collectionIds []int, data map[string]interface{}) (*models.Entry, error)
+	UpdateEntryForSDK(ctx context.Context, entryId int, collectionId int, projectId int, collectionIds []int, data map[string]interface{}) (*models.Entry, error)
+	DeleteEntryForSDK(ctx context.Context, entryId int, collectionId int, projectId int, collectionIds []int) error
+	UpdateEntry(ctx context.Context, entryId int, data map[string]interface{}, projectId int) error
+	// DeleteEntry, DeleteEntryForSDK エントリを参照しているエントリは、リレーションの on_delete に従って削除・参照の解除・削除の拒否をする
+	DeleteEntry(ctx context.Context, entryId int, projectId int) error
 }
 
 type entriesUsecase struct {
 	entriesRepo        repositories.EntriesRepository
+	fieldRepo          repositories.FieldRepository
+	relationRepo       repositories.RelationRepository
 	collectionsUsecase CollectionsUsecase
+	transactionRepo    repositories.TransactionRepository
+	// populateMaxDepth populate で展開できる階層の数
+	populateMaxDepth int
 }
 
-func NewEntriesUsecase(entriesRepo repositories.EntriesRepository, collectionsUsecase CollectionsUsecase) EntriesUsecase {
+func NewEntriesUsecase(entriesRepo repositories.EntriesRepository, fieldRepo repositories.FieldRepository, relationRepo repositories.RelationRepository, collectionsUsecase CollectionsUsecase, transactionRepo repositories.TransactionRepository) EntriesUsecase {
 	return &entriesUsecase{
 		entriesRepo:        entriesRepo,
+		fieldRepo:          fieldRepo,
+		relationRepo:       relationRepo,
 		collectionsUsecase: collectionsUsecase,
+		transactionRepo:    transactionRepo,
+		populateMaxDepth:   populateMaxDepthFromEnv(),
 	}
 }
 
-func (e *entriesUsecase) CreateEntry(newEntry *models.Entry, projectId int) error {
+func (e *entriesUsecase) CreateEntry(ctx context.Context, newEntry *models.Entry, projectId int) error {
 	// Check if collection belongs to project
-	_, err := e.collectionsUsecase.GetCollectionsByCollectionId(newEntry.CollectionID, projectId)
+	collection, err := e.collectionsUsecase.GetCollectionsByCollectionId(newEntry.CollectionID, projectId)
 	if err != nil {
 		return myerrors.WrapDomainError("entriesUsecase.CreateEntry", err)
 	}
 
-	err = e.entriesRepo.CreateEntry(newEntry)
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(newEntry.Data), &data); err != nil {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "エントリのデータは JSON オブジェクトで指定してください")
+	}
+	err = e.saveEntry(ctx, collection, newEntry, data)
 	if err != nil {
 		return myerrors.WrapDomainError("entriesUsecase.CreateEntry", err)
 	}
 	return nil
 }
 
-func (e *entriesUsecase) GetEntriesByCollectionId(collectionId int, projectId int) ([]models.Entry, error) {
+func (e *entriesUsecase) GetEntriesByCollectionId(collectionId int, projectId int, populate string) ([]models.Entry, error) {
 	// Check if collection belongs to project
 	_, err := e.collectionsUsecase.GetCollectionsByCollectionId(collectionId, projectId)
 	if err != nil {
@@ -53,67 +76,290 @@ func (e *entriesUsecase) GetEntriesByCollectionId(collectionId int, projectId in
 	if err != nil {
 		return nil, myerrors.WrapDomainError("entriesUsecase.GetEntriesByCollectionId", err)
 	}
+	if err := e.populateEntries(entries, collectionId, projectId, nil, populate); err != nil {
+		return nil, myerrors.WrapDomainError("entriesUsecase.GetEntriesByCollectionId", err)
+	}
 	return entries, nil
 }
 
-func (e *entriesUsecase) UpdateEntry(entryId int, data map[string]interface{}, projectId int) error {
+func (e *entriesUsecase) UpdateEntry(ctx context.Context, entryId int, data map[string]interface{}, projectId int) error {
 	// Check if entry exists and belongs to project
 	entry, err := e.entriesRepo.GetEntryByIdAndProjectId(entryId, projectId)
 	if err != nil {
 		return myerrors.WrapDomainError("entriesUsecase.UpdateEntry", err)
 	}
 
-	// Update entry data
-	dataBytes, err := json.Marshal(data)
+	collection, err := e.collectionsUsecase.GetCollectionsByCollectionId(entry.CollectionID, projectId)
 	if err != nil {
-		return myerrors.NewDomainError(myerrors.QueryError, err)
+		return myerrors.WrapDomainError("entriesUsecase.UpdateEntry", err)
 	}
 
-	entry.Data = string(dataBytes)
-
-	err = e.entriesRepo.UpdateEntry(entry)
+	// Update entry data
+	err = e.saveEntry(ctx, collection, entry, data)
 	if err != nil {
 		return myerrors.WrapDomainError("entriesUsecase.UpdateEntry", err)
 	}
 	return nil
 }
 
-func (e *entriesUsecase) DeleteEntry(entryId int, projectId int) error {
+func (e *entriesUsecase) DeleteEntry(ctx context.Context, entryId int, projectId int) error {
 	// Check if entry exists and belongs to project
-	_, err := e.entriesRepo.GetEntryByIdAndProjectId(entryId, projectId)
+	entry, err := e.entriesRepo.GetEntryByIdAndProjectId(entryId, projectId)
 	if err != nil {
 		return myerrors.WrapDomainError("entriesUsecase.DeleteEntry", err)
 	}
 
-	err = e.entriesRepo.DeleteEntry(entryId, projectId)
+	// ゴミ箱にあるコレクションのエントリは削除しない（復元したときに残っているように）
+	if _, err := e.collectionsUsecase.GetCollectionsByCollectionId(entry.CollectionID, projectId); err != nil {
+		return myerrors.WrapDomainError("entriesUsecase.DeleteEntry", err)
+	}
+
+	err = e.deleteEntryWithReferences(ctx, entry)
 	if err != nil {
 		return myerrors.WrapDomainError("entriesUsecase.DeleteEntry", err)
 	}
 	return nil
 }
 
-func (e *entriesUsecase) GetEntriesByCollectionIdForSDK(collectionId int, projectId int, collectionIds []int) ([]models.Entry, error) {
-	// Check if collectionId is in allowed collectionIds
-	allowed := false
-	for _, id := range collectionIds {
-		if id == collectionId {
-			allowed = true
-			break
-		}
+func (e *entriesUsecase) GetEntriesByCollectionIdForSDK(collectionId int, projectId int, collectionIds []int, populate string) ([]models.Entry, error) {
+	if _, err := e.checkCollectionForSDK(collectionId, projectId, collectionIds); err != nil {
+		return nil, myerrors.WrapDomainError("entriesUsecase.GetEntriesByCollectionIdForSDK", err)
+	}
+
+	entries, err := e.entriesRepo.GetEntriesByCollectionIdAndProjectId(collectionId, projectId)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("entriesUsecase.GetEntriesByCollectionIdForSDK", err)
+	}
+	if err := e.populateEntries(entries, collectionId, projectId, collectionIds, populate); err != nil {
+		return nil, myerrors.WrapDomainError("entriesUsecase.GetEntriesByCollectionIdForSDK", err)
+	}
+	return entries, nil
+}
+
+func (e *entriesUsecase) CreateEntryForSDK(ctx context.Context, collectionId int, projectId int, collectionIds []int, data map[string]interface{}) (*models.Entry, error) {
+	collection, err := e.checkCollectionForSDK(collectionId, projectId, collectionIds)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("entriesUsecase.CreateEntryForSDK", err)
 	}
-	if !allowed {
+
+	entry := &models.Entry{ProjectID: projectId, CollectionID: collectionId}
+	if err := e.saveEntry(ctx, collection, entry, data); err != nil {
+		return nil, myerrors.WrapDomainError("entriesUsecase.CreateEntryForSDK", err)
+	}
+	return entry, nil
+}
+
+func (e *entriesUsecase) UpdateEntryForSDK(ctx context.Context, entryId int, collectionId int, projectId int, collectionIds []int, data map[string]interface{}) (*models.Entry, error) {
+	entry, collection, err := e.entryForSDK(entryId, collectionId, projectId, collectionIds)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("entriesUsecase.UpdateEntryForSDK", err)
+	}
+
+	if err := e.saveEntry(ctx, collection, entry, data); err != nil {
+		return nil, myerrors.WrapDomainError("entriesUsecase.UpdateEntryForSDK", err)
+	}
+	return entry, nil
+}
+
+func (e *entriesUsecase) DeleteEntryForSDK(ctx context.Context, entryId int, collectionId int, projectId int, collectionIds []int) error {
+	entry, _, err := e.entryForSDK(entryId, collectionId, projectId, collectionIds)
+	if err != nil {
+		return myerrors.WrapDomainError("entriesUsecase.DeleteEntryForSDK", err)
+	}
+
+	if err := e.deleteEntryWithReferences(ctx, entry); err != nil {
+		return myerrors.WrapDomainError("entriesUsecase.DeleteEntryForSDK", err)
+	}
+	return nil
+}
+
+// checkCollectionForSDK コレクションがAPIキーで利用でき、プロジェクトに属しているか確認する
+func (e *entriesUsecase) checkCollectionForSDK(collectionId int, projectId int, collectionIds []int) (*models.ApiCollection, error) {
+	if !slices.Contains(collectionIds, collectionId) {
 		return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "Collection not accessible with this API key")
 	}
 
 	// Check if collection belongs to project
-	_, err := e.collectionsUsecase.GetCollectionsByCollectionId(collectionId, projectId)
+	return e.collectionsUsecase.GetCollectionsByCollectionId(collectionId, projectId)
+}
+
+// entryForSDK パスのコレクションに属するエントリを返す。別のコレクションのエントリは見つからないものとして扱う
+func (e *entriesUsecase) entryForSDK(entryId int, collectionId int, projectId int, collectionIds []int) (*models.Entry, *models.ApiCollection, error) {
+	collection, err := e.checkCollectionForSDK(collectionId, projectId, collectionIds)
 	if err != nil {
-		return nil, myerrors.WrapDomainError("entriesUsecase.GetEntriesByCollectionIdForSDK", err)
+		return nil, nil, err
 	}
 
-	entries, err := e.entriesRepo.GetEntriesByCollectionIdAndProjectId(collectionId, projectId)
+	entry, err := e.entriesRepo.GetEntryByIdAndProjectId(entryId, projectId)
 	if err != nil {
-		return nil, myerrors.WrapDomainError("entriesUsecase.GetEntriesByCollectionIdForSDK", err)
+		return nil, nil, err
 	}
-	return entries, nil
+	if entry.CollectionID != collectionId {
+		return nil, nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "Entry not found in this collection")
+	}
+	return entry, collection, nil
+}
+
+// saveEntry コレクションのフィールド定義でデータを検証し、デフォルト値を補ったデータでエントリを作成・更新する（ID が 0 なら作成）
+func (e *entriesUsecase) saveEntry(ctx context.Context, collection *models.ApiCollection, entry *models.Entry, data map[string]interface{}) error {
+	fields, err := e.fieldRepo.GetFieldsByCollectionId(collection.ID, entry.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	validated, err := validateEntryData(fields, data, collection.RejectUnknownFields)
+	if err != nil {
+		return err
+	}
+	dataBytes, err := json.Marshal(validated)
+	if err != nil {
+		return myerrors.NewDomainError(myerrors.InvalidParameter, err)
+	}
+	entry.Data = string(dataBytes)
+
+	return e.transactionRepo.Do(ctx, func(ctx context.Context) error {
+		if err := e.checkUniqueFieldValues(ctx, fields, entry, validated); err != nil {
+			return err
+		}
+		if err := e.checkRelationReferences(ctx, fields, validated); err != nil {
+			return err
+		}
+		if entry.ID == 0 {
+			return e.entriesRepo.CreateEntry(ctx, entry)
+		}
+		return e.entriesRepo.UpdateEntry(ctx, entry)
+	})
+}
+
+// checkUniqueFieldValues unique のフィールドの値がコレクション内の他のエントリと重複していないか確認する。
+// 同時に同じ値で保存されないよう、保存するまでフィールドごとにロックする
+func (e *entriesUsecase) checkUniqueFieldValues(ctx context.Context, fields []models.FieldData, entry *models.Entry, data map[string]interface{}) error {
+	uniqueFields := make([]models.FieldData, 0)
+	for _, field := range fields {
+		if field.Validations.Unique && data[field.FieldID] != nil {
+			uniqueFields = append(uniqueFields, field)
+		}
+	}
+	// デッドロックしないように、ロックは常に同じ順番で取る
+	sort.Slice(uniqueFields, func(i, j int) bool { return uniqueFields[i].FieldID < uniqueFields[j].FieldID })
+
+	var fieldErrors []myerrors.FieldError
+	for _, field := range uniqueFields {
+		if err := e.entriesRepo.LockFieldValues(ctx, entry.CollectionID, field.FieldID); err != nil {
+			return err
+		}
+		value, err := json.Marshal(data[field.FieldID])
+		if err != nil {
+			return myerrors.NewDomainError(myerrors.InvalidParameter, err)
+		}
+		exists, err := e.entriesRepo.ExistsFieldValue(ctx, entry.CollectionID, field.FieldID, string(value), entry.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			fieldErrors = append(fieldErrors, myerrors.FieldError{Path: field.FieldID, Code: fieldErrNotUnique, Message: "同じ値のエントリがすでにあります"})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return myerrors.NewValidationError(fieldErrors)
+	}
+	return nil
+}
+
+// checkRelationReferences リレーションのあるフィールドの値が、参照先のコレクションのエントリのIDか確認する。
+// 参照先のエントリは保存するまで削除されないようにロックする
+func (e *entriesUsecase) checkRelationReferences(ctx context.Context, fields []models.FieldData, data map[string]interface{}) error {
+	var fieldErrors []myerrors.FieldError
+	for _, field := range fields {
+		value := data[field.FieldID]
+		if field.FieldType != models.FieldTypeRelation || field.Relation == nil || isEmptyFieldValue(value) {
+			continue
+		}
+		// 値の形は validateEntryData で確認済み
+		items, isArray := value.([]interface{})
+		if !isArray {
+			items = []interface{}{value}
+		}
+		ids := make([]int, 0, len(items))
+		for _, item := range items {
+			n, _ := numberValue(item)
+			ids = append(ids, int(n))
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		existing, err := e.entriesRepo.LockReferencedEntries(ctx, field.Relation.RelatedCollectionID, ids)
+		if err != nil {
+			return err
+		}
+		for i, id := range ids {
+			if slices.Contains(existing, id) {
+				continue
+			}
+			path := field.FieldID
+			if isArray {
+				path = fmt.Sprintf("%s[%d]", field.FieldID, i)
+			}
+			fieldErrors = append(fieldErrors, myerrors.FieldError{Path: path, Code: fieldErrInvalidReference, Message: "参照先のエントリが見つかりません"})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return myerrors.NewValidationError(fieldErrors)
+	}
+	return nil
+}
+
+// deleteEntryWithReferences エントリをロックして、参照しているエントリと同じトランザクションで削除する
+func (e *entriesUsecase) deleteEntryWithReferences(ctx context.Context, entry *models.Entry) error {
+	return e.transactionRepo.Do(ctx, func(ctx context.Context) error {
+		locked, err := e.entriesRepo.LockEntry(ctx, entry.ID, entry.ProjectID)
+		if err != nil {
+			return err
+		}
+		return e.deleteEntry(ctx, locked, map[int]bool{})
+	})
+}
+
+// deleteEntry エントリを削除する。エントリを参照しているエントリはリレーションの on_delete に従って扱い、
+// cascade で削除するエントリも同じように扱う。deleted はこの削除で消すエントリ（循環する参照で同じエントリを2回削除しない）
+func (e *entriesUsecase) deleteEntry(ctx context.Context, entry *models.Entry, deleted map[int]bool) error {
+	deleted[entry.ID] = true
+
+	relations, err := e.relationRepo.GetRelationsByRelatedCollectionId(ctx, entry.CollectionID)
+	if err != nil {
+		return err
+	}
+	for _, relation := range relations {
+		ids, err := e.entriesRepo.LockReferencingEntryIds(ctx, relation.CollectionID, relation.FieldKey, entry.ID)
+		if err != nil {
+			return err
+		}
+		// この削除で消すエントリからの参照は数えない
+		ids = slices.DeleteFunc(ids, func(id int) bool { return deleted[id] })
+		if len(ids) == 0 {
+			continue
+		}
+
+		switch relation.OnDelete {
+		case models.RelationOnDeleteCascade:
+			for _, id := range ids {
+				if deleted[id] {
+					continue
+				}
+				referencing := &models.Entry{ID: id, ProjectID: entry.ProjectID, CollectionID: relation.CollectionID}
+				if err := e.deleteEntry(ctx, referencing, deleted); err != nil {
+					return err
+				}
+			}
+		case models.RelationOnDeleteSetNull:
+			if _, err := e.entriesRepo.RemoveReference(ctx, relation.CollectionID, relation.FieldKey, entry.ID); err != nil {
+				return err
+			}
+		default:
+			return myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, fmt.Sprintf("エントリ %d はコレクション %d の %d 件のエントリ（%s）から参照されているため削除できません", entry.ID, relation.CollectionID, len(ids), relation.FieldKey))
+		}
+	}
+
+	return e.entriesRepo.DeleteEntry(ctx, entry.ID, entry.ProjectID)
 }
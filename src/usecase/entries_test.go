@@ -0,0 +1,592 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	mockRepositories "w3st/mock/repositories"
+	"w3st/usecase"
+)
+
+func TestEntriesUsecase_CreateEntryForSDK(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockEntriesRepo, mockFieldRepo, mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockFieldRepo.EXPECT().GetFieldsByCollectionId(3, 1).Return(nil, nil)
+	mockEntriesRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, entry *models.Entry) error {
+		entry.ID = 10
+		return nil
+	})
+
+	entry, err := uc.CreateEntryForSDK(context.Background(), 3, 1, []int{3}, map[string]interface{}{"title": "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, 10, entry.ID)
+	assert.Equal(t, 3, entry.CollectionID)
+	assert.JSONEq(t, `{"title": "hello"}`, entry.Data)
+}
+
+func TestEntriesUsecase_CreateEntryForSDK_CollectionNotAllowed(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockRepositories.NewMockEntriesRepository(ctrl), mockRepositories.NewMockFieldRepository(ctrl), mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockRepositories.NewMockCollectionsRepository(ctrl), mockTransactionRepo), mockTransactionRepo)
+
+	// APIキーで利用できないコレクションはDBを確認せずに拒否する
+	_, err := uc.CreateEntryForSDK(context.Background(), 4, 1, []int{3}, map[string]interface{}{"title": "hello"})
+	assertErrType(t, err, myerrors.QueryDataNotFoundError)
+}
+
+func TestEntriesUsecase_UpdateEntryForSDK_OtherCollection(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockEntriesRepo, mockRepositories.NewMockFieldRepository(ctrl), mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	// パスのコレクションに属さないエントリは変更しない
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockEntriesRepo.EXPECT().GetEntryByIdAndProjectId(10, 1).Return(&models.Entry{ID: 10, ProjectID: 1, CollectionID: 4}, nil)
+
+	_, err := uc.UpdateEntryForSDK(context.Background(), 10, 3, 1, []int{3, 4}, map[string]interface{}{"title": "changed"})
+	assertErrType(t, err, myerrors.QueryDataNotFoundError)
+}
+
+func TestEntriesUsecase_DeleteEntryForSDK(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockRelationRepo := mockRepositories.NewMockRelationRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockEntriesRepo, mockRepositories.NewMockFieldRepository(ctrl), mockRelationRepo, usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockEntriesRepo.EXPECT().GetEntryByIdAndProjectId(10, 1).Return(&models.Entry{ID: 10, ProjectID: 1, CollectionID: 3}, nil)
+	mockEntriesRepo.EXPECT().LockEntry(gomock.Any(), 10, 1).Return(&models.Entry{ID: 10, ProjectID: 1, CollectionID: 3}, nil)
+	mockRelationRepo.EXPECT().GetRelationsByRelatedCollectionId(gomock.Any(), 3).Return(nil, nil)
+	mockEntriesRepo.EXPECT().DeleteEntry(gomock.Any(), 10, 1).Return(nil)
+
+	require.NoError(t, uc.DeleteEntryForSDK(context.Background(), 10, 3, 1, []int{3}))
+}
+
+func TestEntriesUsecase_CreateEntryForSDK_ValidationFailed(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockRepositories.NewMockEntriesRepository(ctrl), mockFieldRepo, mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3, RejectUnknownFields: true}, nil)
+	mockFieldRepo.EXPECT().GetFieldsByCollectionId(3, 1).Return([]models.FieldData{
+		{FieldID: "title", ViewName: "タイトル", FieldType: models.FieldTypeText, IsRequired: true},
+		{FieldID: "price", ViewName: "価格", FieldType: models.FieldTypeInteger},
+		{FieldID: "contact", ViewName: "連絡先", FieldType: models.FieldTypeEmail},
+		{FieldID: "authors", ViewName: "著者", FieldType: models.FieldTypeRelation},
+		{FieldID: "tags", ViewName: "タグ", FieldType: models.FieldTypeMultiSelect, IsRequired: true},
+	}, nil)
+
+	// エラーはすべての項目についてまとめて返し、エントリは作成しない。必須の配列の項目に空の配列は指定できない
+	_, err := uc.CreateEntryForSDK(context.Background(), 3, 1, []int{3}, map[string]interface{}{
+		"price":   1.5,
+		"contact": "not-an-email",
+		"authors": []interface{}{float64(1), "2"},
+		"tags":    []interface{}{},
+		"extra":   true,
+	})
+	assertErrType(t, err, myerrors.ValidationFailed)
+
+	var validationErr *myerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []myerrors.FieldError{
+		{Path: "title", Code: "required", Message: "タイトル は必須です"},
+		{Path: "price", Code: "invalid_type", Message: "整数を指定してください"},
+		{Path: "contact", Code: "invalid_format", Message: "メールアドレスの形式が正しくありません"},
+		{Path: "authors[1]", Code: "invalid_type", Message: "エントリのIDを指定してください"},
+		{Path: "tags", Code: "required", Message: "タグ は必須です"},
+		{Path: "extra", Code: "unknown_field", Message: "フィールドとして定義されていない項目です"},
+	}, validationErr.Errors)
+}
+
+func TestEntriesUsecase_UpdateEntryForSDK_AppliesDefaults(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockEntriesRepo, mockFieldRepo, mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockEntriesRepo.EXPECT().GetEntryByIdAndProjectId(10, 1).Return(&models.Entry{ID: 10, ProjectID: 1, CollectionID: 3}, nil)
+	mockFieldRepo.EXPECT().GetFieldsByCollectionId(3, 1).Return([]models.FieldData{
+		{FieldID: "title", FieldType: models.FieldTypeText, IsRequired: true},
+		{FieldID: "published", FieldType: models.FieldTypeBoolean, IsRequired: true, DefaultValue: "false"},
+		{FieldID: "published_on", FieldType: models.FieldTypeDate},
+	}, nil)
+	mockEntriesRepo.EXPECT().UpdateEntry(gomock.Any(), gomock.Any()).Return(nil)
+
+	// 値のない項目にはデフォルト値を入れ、定義されていないキーはそのまま残す
+	entry, err := uc.UpdateEntryForSDK(context.Background(), 10, 3, 1, []int{3}, map[string]interface{}{
+		"title":        "changed",
+		"published_on": "2024-02-29",
+		"note":         "memo",
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title": "changed", "published": false, "published_on": "2024-02-29", "note": "memo"}`, entry.Data)
+}
+
+func TestEntriesUsecase_CreateEntryForSDK_FieldRules(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockRepositories.NewMockEntriesRepository(ctrl), mockFieldRepo, mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	minLength, maxItems, maxPrice := 3, 2, 1000.0
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockFieldRepo.EXPECT().GetFieldsByCollectionId(3, 1).Return([]models.FieldData{
+		{FieldID: "title", FieldType: models.FieldTypeText, Validations: models.FieldValidations{MinLength: &minLength}},
+		{FieldID: "code", FieldType: models.FieldTypeText, Validations: models.FieldValidations{Pattern: `^[A-Z]{3}$`, PatternMessage: "英大文字3文字で入力してください"}},
+		{FieldID: "price", FieldType: models.FieldTypeNumber, Validations: models.FieldValidations{Max: &maxPrice}},
+		{FieldID: "status", FieldType: models.FieldTypeText, Validations: models.FieldValidations{Enum: []interface{}{"draft", "published"}}},
+		{FieldID: "tags", FieldType: models.FieldTypeJSON, Validations: models.FieldValidations{MaxItems: &maxItems, Enum: []interface{}{"go", "sql"}}},
+	}, nil)
+
+	_, err := uc.CreateEntryForSDK(context.Background(), 3, 1, []int{3}, map[string]interface{}{
+		"title":  "ab",
+		"code":   "abc",
+		"price":  1000.5,
+		"status": "archived",
+		"tags":   []interface{}{"go", "rust", "sql"},
+	})
+
+	var validationErr *myerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []myerrors.FieldError{
+		{Path: "title", Code: "too_short", Message: "3文字以上で入力してください"},
+		{Path: "code", Code: "pattern_mismatch", Message: "英大文字3文字で入力してください"},
+		{Path: "price", Code: "too_large", Message: "1000以下の値を指定してください"},
+		{Path: "status", Code: "not_allowed", Message: "使えない値です"},
+		{Path: "tags", Code: "too_many_items", Message: "2個以内で指定してください"},
+		{Path: "tags[1]", Code: "not_allowed", Message: "使えない値です"},
+	}, validationErr.Errors)
+}
+
+func TestEntriesUsecase_CreateEntryForSDK_InvalidPattern(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockRepositories.NewMockEntriesRepository(ctrl), mockFieldRepo, mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	// 保存時の確認より前に作られた、読めない pattern のフィールド
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockFieldRepo.EXPECT().GetFieldsByCollectionId(3, 1).Return([]models.FieldData{
+		{FieldID: "code", FieldType: models.FieldTypeText, Validations: models.FieldValidations{Pattern: `^[A-Z`}},
+	}, nil)
+
+	_, err := uc.CreateEntryForSDK(context.Background(), 3, 1, []int{3}, map[string]interface{}{"code": "ABC"})
+
+	var validationErr *myerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []myerrors.FieldError{
+		{Path: "code", Code: "pattern_mismatch", Message: "フィールドの pattern が正しくないため確認できません"},
+	}, validationErr.Errors)
+}
+
+func TestEntriesUsecase_UpdateEntryForSDK_UniqueValue(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockEntriesRepo, mockFieldRepo, mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockEntriesRepo.EXPECT().GetEntryByIdAndProjectId(10, 1).Return(&models.Entry{ID: 10, ProjectID: 1, CollectionID: 3}, nil)
+	mockFieldRepo.EXPECT().GetFieldsByCollectionId(3, 1).Return([]models.FieldData{
+		{FieldID: "sku", FieldType: models.FieldTypeText, Validations: models.FieldValidations{Unique: true}},
+	}, nil)
+
+	// 自分以外のエントリに同じ値があれば保存しない
+	gomock.InOrder(
+		mockEntriesRepo.EXPECT().LockFieldValues(gomock.Any(), 3, "sku").Return(nil),
+		mockEntriesRepo.EXPECT().ExistsFieldValue(gomock.Any(), 3, "sku", `"A-001"`, 10).Return(true, nil),
+	)
+
+	_, err := uc.UpdateEntryForSDK(context.Background(), 10, 3, 1, []int{3}, map[string]interface{}{"sku": "A-001"})
+	assertErrType(t, err, myerrors.ValidationFailed)
+
+	var validationErr *myerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "not_unique", validationErr.Errors[0].Code)
+}
+
+func TestEntriesUsecase_CreateEntryForSDK_SelectOptions(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockRepositories.NewMockEntriesRepository(ctrl), mockFieldRepo, mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	options := []models.ListOption{{ID: 1, Value: "small", Label: "S"}, {ID: 2, Value: "large", Label: "L"}}
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockFieldRepo.EXPECT().GetFieldsByCollectionId(3, 1).Return([]models.FieldData{
+		{FieldID: "size", FieldType: models.FieldTypeSelect, Options: options},
+		{FieldID: "sizes", FieldType: models.FieldTypeMultiSelect, Options: options},
+		{FieldID: "colors", FieldType: models.FieldTypeMultiSelect},
+	}, nil)
+
+	// 表示名ではなく値で指定する。選択肢がまだないフィールドは値を確認しない
+	_, err := uc.CreateEntryForSDK(context.Background(), 3, 1, []int{3}, map[string]interface{}{
+		"size":   "S",
+		"sizes":  []interface{}{"small", "medium", 1},
+		"colors": []interface{}{"red"},
+	})
+
+	var validationErr *myerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []myerrors.FieldError{
+		{Path: "size", Code: "not_allowed", Message: "選択肢にない値です"},
+		{Path: "sizes[2]", Code: "invalid_type", Message: "文字列を指定してください"},
+	}, validationErr.Errors)
+}
+
+func TestEntriesUsecase_CreateEntryForSDK_RelationReferences(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockEntriesRepo, mockFieldRepo, mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockFieldRepo.EXPECT().GetFieldsByCollectionId(3, 1).Return([]models.FieldData{
+		{FieldID: "author", FieldType: models.FieldTypeRelation, Relation: &models.ApiKindRelation{RelatedCollectionID: 4, RelationType: models.RelationTypeOneToMany}},
+		{FieldID: "tags", FieldType: models.FieldTypeRelation, Relation: &models.ApiKindRelation{RelatedCollectionID: 5, RelationType: models.RelationTypeManyToMany}},
+	}, nil)
+	// 参照先のコレクションにないIDはエラーにし、エントリは保存しない
+	mockEntriesRepo.EXPECT().LockReferencedEntries(gomock.Any(), 4, []int{7}).Return([]int{7}, nil)
+	mockEntriesRepo.EXPECT().LockReferencedEntries(gomock.Any(), 5, []int{1, 2, 3}).Return([]int{1, 3}, nil)
+
+	_, err := uc.CreateEntryForSDK(context.Background(), 3, 1, []int{3}, map[string]interface{}{
+		"author": 7,
+		"tags":   []interface{}{1, 2, 3},
+	})
+
+	var validationErr *myerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []myerrors.FieldError{
+		{Path: "tags[1]", Code: "invalid_reference", Message: "参照先のエントリが見つかりません"},
+	}, validationErr.Errors)
+}
+
+func TestEntriesUsecase_CreateEntryForSDK_RelationShape(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockRepositories.NewMockEntriesRepository(ctrl), mockFieldRepo, mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockFieldRepo.EXPECT().GetFieldsByCollectionId(3, 1).Return([]models.FieldData{
+		{FieldID: "author", FieldType: models.FieldTypeRelation, Relation: &models.ApiKindRelation{RelatedCollectionID: 4, RelationType: models.RelationTypeOneToMany}},
+		{FieldID: "tags", FieldType: models.FieldTypeRelation, Relation: &models.ApiKindRelation{RelatedCollectionID: 5, RelationType: models.RelationTypeManyToMany}},
+	}, nil)
+
+	_, err := uc.CreateEntryForSDK(context.Background(), 3, 1, []int{3}, map[string]interface{}{
+		"author": []interface{}{7},
+		"tags":   1,
+	})
+
+	var validationErr *myerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []myerrors.FieldError{
+		{Path: "author", Code: "invalid_type", Message: "エントリのIDを1つ指定してください"},
+		{Path: "tags", Code: "invalid_type", Message: "エントリのIDの配列を指定してください"},
+	}, validationErr.Errors)
+}
+
+func TestEntriesUsecase_DeleteEntry_OnDelete(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockRelationRepo := mockRepositories.NewMockRelationRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockEntriesRepo, mockRepositories.NewMockFieldRepository(ctrl), mockRelationRepo, usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	// 著者(4) を削除すると、記事(3) は cascade で削除し、コメント(5) は参照を外す。
+	// 記事を参照しているブックマーク(6) も cascade で削除する
+	mockEntriesRepo.EXPECT().GetEntryByIdAndProjectId(10, 1).Return(&models.Entry{ID: 10, ProjectID: 1, CollectionID: 4}, nil)
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(4, 1).Return(&models.ApiCollection{ID: 4}, nil)
+	mockEntriesRepo.EXPECT().LockEntry(gomock.Any(), 10, 1).Return(&models.Entry{ID: 10, ProjectID: 1, CollectionID: 4}, nil)
+	mockRelationRepo.EXPECT().GetRelationsByRelatedCollectionId(gomock.Any(), 4).Return([]models.ApiKindRelation{
+		{CollectionID: 3, FieldKey: "author", OnDelete: models.RelationOnDeleteCascade},
+		{CollectionID: 5, FieldKey: "mentions", OnDelete: models.RelationOnDeleteSetNull},
+	}, nil)
+	mockRelationRepo.EXPECT().GetRelationsByRelatedCollectionId(gomock.Any(), 3).Return([]models.ApiKindRelation{
+		{CollectionID: 6, FieldKey: "post", OnDelete: models.RelationOnDeleteCascade},
+	}, nil)
+	mockRelationRepo.EXPECT().GetRelationsByRelatedCollectionId(gomock.Any(), 6).Return(nil, nil)
+
+	gomock.InOrder(
+		mockEntriesRepo.EXPECT().LockReferencingEntryIds(gomock.Any(), 3, "author", 10).Return([]int{20}, nil),
+		mockEntriesRepo.EXPECT().LockReferencingEntryIds(gomock.Any(), 6, "post", 20).Return([]int{30}, nil),
+		mockEntriesRepo.EXPECT().DeleteEntry(gomock.Any(), 30, 1).Return(nil),
+		mockEntriesRepo.EXPECT().DeleteEntry(gomock.Any(), 20, 1).Return(nil),
+		mockEntriesRepo.EXPECT().LockReferencingEntryIds(gomock.Any(), 5, "mentions", 10).Return([]int{40, 41}, nil),
+		mockEntriesRepo.EXPECT().RemoveReference(gomock.Any(), 5, "mentions", 10).Return(int64(2), nil),
+		mockEntriesRepo.EXPECT().DeleteEntry(gomock.Any(), 10, 1).Return(nil),
+	)
+
+	require.NoError(t, uc.DeleteEntry(context.Background(), 10, 1))
+}
+
+func TestEntriesUsecase_DeleteEntry_Restrict(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockRelationRepo := mockRepositories.NewMockRelationRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockEntriesRepo, mockRepositories.NewMockFieldRepository(ctrl), mockRelationRepo, usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	mockEntriesRepo.EXPECT().GetEntryByIdAndProjectId(10, 1).Return(&models.Entry{ID: 10, ProjectID: 1, CollectionID: 4}, nil)
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(4, 1).Return(&models.ApiCollection{ID: 4}, nil)
+	mockEntriesRepo.EXPECT().LockEntry(gomock.Any(), 10, 1).Return(&models.Entry{ID: 10, ProjectID: 1, CollectionID: 4}, nil)
+	mockRelationRepo.EXPECT().GetRelationsByRelatedCollectionId(gomock.Any(), 4).Return([]models.ApiKindRelation{
+		{CollectionID: 4, FieldKey: "parent", OnDelete: models.RelationOnDeleteRestrict},
+	}, nil)
+	// 自分自身への参照は数えず、他のエントリから参照されていれば削除しない
+	mockEntriesRepo.EXPECT().LockReferencingEntryIds(gomock.Any(), 4, "parent", 10).Return([]int{10, 11}, nil)
+	mockEntriesRepo.EXPECT().DeleteEntry(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := uc.DeleteEntry(context.Background(), 10, 1)
+	assertErrType(t, err, myerrors.AlreadyExist)
+}
+
+func TestEntriesUsecase_DeleteEntry_TrashedCollection(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewEntriesUsecase(mockEntriesRepo, mockRepositories.NewMockFieldRepository(ctrl), mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	// ゴミ箱にあるコレクションのエントリは削除しない
+	mockEntriesRepo.EXPECT().GetEntryByIdAndProjectId(10, 1).Return(&models.Entry{ID: 10, ProjectID: 1, CollectionID: 4}, nil)
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(4, 1).
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "コレクションが見つかりません"))
+	mockEntriesRepo.EXPECT().LockEntry(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockEntriesRepo.EXPECT().DeleteEntry(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := uc.DeleteEntry(context.Background(), 10, 1)
+	assertErrType(t, err, myerrors.QueryDataNotFoundError)
+}
+
+func TestEntriesUsecase_GetEntriesByCollectionIdForSDK_Populate(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	uc := usecase.NewEntriesUsecase(mockEntriesRepo, mockFieldRepo, mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	// 記事(3) の author は著者(4)、tags はタグ(5)、タグの category はカテゴリ(6)。APIキーではカテゴリを利用できない
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(4, 1).Return(&models.ApiCollection{ID: 4}, nil)
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(5, 1).Return(&models.ApiCollection{ID: 5}, nil)
+	mockEntriesRepo.EXPECT().GetEntriesByCollectionIdAndProjectId(3, 1).Return([]models.Entry{
+		{ID: 1, CollectionID: 3, Data: `{"title":"a","author":7,"tags":[20,21]}`},
+		{ID: 2, CollectionID: 3, Data: `{"title":"b","author":7,"tags":[21,99]}`},
+	}, nil)
+	mockFieldRepo.EXPECT().GetFieldsByCollectionId(3, 1).Return([]models.FieldData{
+		{FieldID: "author", FieldType: models.FieldTypeRelation, Relation: &models.ApiKindRelation{RelatedCollectionID: 4, RelationType: models.RelationTypeOneToMany}},
+		{FieldID: "tags", FieldType: models.FieldTypeRelation, Relation: &models.ApiKindRelation{RelatedCollectionID: 5, RelationType: models.RelationTypeManyToMany}},
+	}, nil)
+	mockFieldRepo.EXPECT().GetFieldsByCollectionId(5, 1).Return([]models.FieldData{
+		{FieldID: "category", FieldType: models.FieldTypeRelation, Relation: &models.ApiKindRelation{RelatedCollectionID: 6, RelationType: models.RelationTypeOneToMany}},
+	}, nil)
+	// 同じ階層の参照先はフィールドごとに1回で読み込む
+	mockEntriesRepo.EXPECT().GetEntriesByIds(4, 1, []int{7}).Return([]models.Entry{
+		{ID: 7, CollectionID: 4, Data: `{"name":"alice"}`},
+	}, nil)
+	mockEntriesRepo.EXPECT().GetEntriesByIds(5, 1, []int{20, 21, 99}).Return([]models.Entry{
+		{ID: 20, CollectionID: 5, Data: `{"name":"go","category":30}`},
+		{ID: 21, CollectionID: 5, Data: `{"name":"sql","category":31}`},
+	}, nil)
+
+	entries, err := uc.GetEntriesByCollectionIdForSDK(3, 1, []int{3, 4, 5}, "author, tags.category")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	// 見つからないエントリ(99)と、利用できないコレクションへの参照（category）は ID のまま
+	assert.JSONEq(t, `{"title":"a",
+		"author":{"id":7,"collection_id":4,"data":{"name":"alice"}},
+		"tags":[{"id":20,"collection_id":5,"data":{"name":"go","category":30}},{"id":21,"collection_id":5,"data":{"name":"sql","category":31}}]}`, entries[0].Data)
+	assert.JSONEq(t, `{"title":"b",
+		"author":{"id":7,"collection_id":4,"data":{"name":"alice"}},
+		"tags":[{"id":21,"collection_id":5,"data":{"name":"sql","category":31}},99]}`, entries[1].Data)
+}
+
+func TestEntriesUsecase_GetEntriesByCollectionId_PopulateCycle(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	uc := usecase.NewEntriesUsecase(mockEntriesRepo, mockFieldRepo, mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+	// 10 と 11 が互いに parent で参照している
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(4, 1).Return(&models.ApiCollection{ID: 4}, nil).Times(3)
+	mockEntriesRepo.EXPECT().GetEntriesByCollectionIdAndProjectId(4, 1).Return([]models.Entry{
+		{ID: 10, CollectionID: 4, Data: `{"parent":11}`},
+	}, nil)
+	mockFieldRepo.EXPECT().GetFieldsByCollectionId(4, 1).Return([]models.FieldData{
+		{FieldID: "parent", FieldType: models.FieldTypeRelation, Relation: &models.ApiKindRelation{RelatedCollectionID: 4, RelationType: models.RelationTypeOneToMany}},
+	}, nil)
+	mockEntriesRepo.EXPECT().GetEntriesByIds(4, 1, []int{11}).Return([]models.Entry{{ID: 11, CollectionID: 4, Data: `{"parent":10}`}}, nil)
+	mockEntriesRepo.EXPECT().GetEntriesByIds(4, 1, []int{10}).Return([]models.Entry{{ID: 10, CollectionID: 4, Data: `{"parent":11}`}}, nil)
+
+	entries, err := uc.GetEntriesByCollectionId(4, 1, "parent.parent")
+	require.NoError(t, err)
+
+	// 展開元のエントリへの参照は展開しない
+	assert.JSONEq(t, `{"parent":{"id":11,"collection_id":4,"data":{"parent":10}}}`, entries[0].Data)
+}
+
+func TestEntriesUsecase_GetEntriesByCollectionId_PopulateInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		populate string
+	}{
+		{name: "階層が深すぎる", populate: "author.tags.category.parent"},
+		{name: "空のキー", populate: "author..name"},
+		{name: "relation のフィールドではない", populate: "title"},
+		{name: "存在しないフィールド", populate: "editor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+			mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+			mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+			mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+			uc := usecase.NewEntriesUsecase(mockEntriesRepo, mockFieldRepo, mockRepositories.NewMockRelationRepository(ctrl), usecase.NewCollectionsUsecase(mockCollectionsRepo, mockTransactionRepo), mockTransactionRepo)
+
+			mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+			mockEntriesRepo.EXPECT().GetEntriesByCollectionIdAndProjectId(3, 1).Return([]models.Entry{{ID: 1, CollectionID: 3, Data: `{"title":"a"}`}}, nil)
+			mockFieldRepo.EXPECT().GetFieldsByCollectionId(3, 1).Return([]models.FieldData{
+				{FieldID: "title", FieldType: models.FieldTypeText},
+			}, nil).AnyTimes()
+
+			_, err := uc.GetEntriesByCollectionId(3, 1, tt.populate)
+			assertErrType(t, err, myerrors.InvalidParameter)
+		})
+	}
+}
@@ -0,0 +1,448 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+)
+
+// エントリの検証エラーの種類
+const (
+	fieldErrRequired      = "required"
+	fieldErrInvalidType   = "invalid_type"
+	fieldErrInvalidFormat = "invalid_format"
+	fieldErrUnknownField  = "unknown_field"
+	// フィールドの検証ルール（models.FieldValidations）のエラー
+	fieldErrTooShort        = "too_short"
+	fieldErrTooLong         = "too_long"
+	fieldErrTooSmall        = "too_small"
+	fieldErrTooLarge        = "too_large"
+	fieldErrPatternMismatch = "pattern_mismatch"
+	fieldErrNotAllowed      = "not_allowed"
+	fieldErrTooFewItems     = "too_few_items"
+	fieldErrTooManyItems    = "too_many_items"
+	fieldErrNotUnique       = "not_unique"
+	// リレーションの参照先のエントリがない
+	fieldErrInvalidReference = "invalid_reference"
+)
+
+// compiledPatterns フィールドの pattern をコンパイルした正規表現（キーは pattern の文字列）
+var compiledPatterns sync.Map
+
+// compilePattern pattern をコンパイルする。一度コンパイルしたものは compiledPatterns から返す
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := compiledPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	compiledPatterns.Store(pattern, re)
+	return re, nil
+}
+
+// validateEntryData コレクションのフィールド定義でエントリのデータを検証する。
+// 値のない項目にはデフォルト値を入れたデータを返し、誤りがあればすべての項目のエラーをまとめて返す
+func validateEntryData(fields []models.FieldData, data map[string]interface{}, rejectUnknownFields bool) (map[string]interface{}, error) {
+	validated := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		validated[key] = value
+	}
+
+	var fieldErrors []myerrors.FieldError
+	defined := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		defined[field.FieldID] = true
+
+		value := validated[field.FieldID]
+		if value == nil {
+			if defaultValue, ok := fieldDefaultValue(field); ok {
+				value = defaultValue
+				validated[field.FieldID] = defaultValue
+			}
+		}
+
+		if isEmptyFieldValue(value) {
+			if field.IsRequired {
+				fieldErrors = append(fieldErrors, myerrors.FieldError{Path: field.FieldID, Code: fieldErrRequired, Message: fmt.Sprintf("%s は必須です", field.ViewName)})
+			}
+			continue
+		}
+
+		typeErrors := checkFieldValue(field.FieldType, field.FieldID, value)
+		if len(typeErrors) == 0 {
+			typeErrors = checkRelationShape(field, value)
+		}
+		if len(typeErrors) > 0 {
+			fieldErrors = append(fieldErrors, typeErrors...)
+			continue
+		}
+		fieldErrors = append(fieldErrors, checkFieldOptions(field, value)...)
+		fieldErrors = append(fieldErrors, checkFieldRules(field, value)...)
+	}
+
+	if rejectUnknownFields {
+		unknown := make([]string, 0)
+		for key := range data {
+			if !defined[key] {
+				unknown = append(unknown, key)
+			}
+		}
+		sort.Strings(unknown)
+		for _, key := range unknown {
+			fieldErrors = append(fieldErrors, myerrors.FieldError{Path: key, Code: fieldErrUnknownField, Message: "フィールドとして定義されていない項目です"})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, myerrors.NewValidationError(fieldErrors)
+	}
+	return validated, nil
+}
+
+// validateFieldDefinition フィールドの型・検証ルール・デフォルト値が使えるものか確認する
+func validateFieldDefinition(field *models.FieldData) error {
+	if !models.ValidFieldType(field.FieldType) {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, fmt.Sprintf("フィールドの型 %q は使えません", field.FieldType))
+	}
+	if err := validateFieldRules(field.Validations); err != nil {
+		return err
+	}
+	if field.DefaultValue == "" {
+		return nil
+	}
+	var defaultValue interface{}
+	if err := json.Unmarshal([]byte(field.DefaultValue), &defaultValue); err != nil {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "デフォルト値は JSON で指定してください")
+	}
+	if defaultValue == nil {
+		return nil
+	}
+	if len(checkFieldValue(field.FieldType, field.FieldID, defaultValue)) > 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "デフォルト値がフィールドの型と一致しません")
+	}
+	if len(checkFieldRules(*field, defaultValue)) > 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "デフォルト値が検証ルールを満たしません")
+	}
+	return nil
+}
+
+// validateFieldRules 検証ルールの値が正しいか確認する
+func validateFieldRules(rules models.FieldValidations) error {
+	invalid := func(message string) error {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, message)
+	}
+	for _, n := range []*int{rules.MinLength, rules.MaxLength, rules.MinItems, rules.MaxItems} {
+		if n != nil && *n < 0 {
+			return invalid("文字数・要素数には0以上の値を指定してください")
+		}
+	}
+	if rules.MinLength != nil && rules.MaxLength != nil && *rules.MinLength > *rules.MaxLength {
+		return invalid("min_length は max_length 以下にしてください")
+	}
+	if rules.Min != nil && rules.Max != nil && *rules.Min > *rules.Max {
+		return invalid("min は max 以下にしてください")
+	}
+	if rules.MinItems != nil && rules.MaxItems != nil && *rules.MinItems > *rules.MaxItems {
+		return invalid("min_items は max_items 以下にしてください")
+	}
+	if rules.Pattern != "" {
+		if _, err := compilePattern(rules.Pattern); err != nil {
+			return invalid("pattern の正規表現が正しくありません")
+		}
+	}
+	return nil
+}
+
+// fieldDefaultValue フィールドのデフォルト値を返す。未設定や JSON として読めない場合は ok が false
+func fieldDefaultValue(field models.FieldData) (interface{}, bool) {
+	if field.DefaultValue == "" {
+		return nil, false
+	}
+	var defaultValue interface{}
+	if err := json.Unmarshal([]byte(field.DefaultValue), &defaultValue); err != nil || defaultValue == nil {
+		return nil, false
+	}
+	return defaultValue, true
+}
+
+// isEmptyFieldValue 必須の項目で値がないものとして扱うか（null・空文字・空の配列）
+func isEmptyFieldValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// checkFieldValue 値がフィールドの型と一致するか確認する。定義にない型（以前に作られたフィールド）は確認しない
+func checkFieldValue(fieldType string, path string, value interface{}) []myerrors.FieldError {
+	invalidType := func(expected string) []myerrors.FieldError {
+		return []myerrors.FieldError{{Path: path, Code: fieldErrInvalidType, Message: expected + "を指定してください"}}
+	}
+	invalidFormat := func(message string) []myerrors.FieldError {
+		return []myerrors.FieldError{{Path: path, Code: fieldErrInvalidFormat, Message: message}}
+	}
+
+	switch fieldType {
+	case models.FieldTypeText, models.FieldTypeSelect:
+		if _, ok := value.(string); !ok {
+			return invalidType("文字列")
+		}
+	case models.FieldTypeNumber:
+		if _, ok := numberValue(value); !ok {
+			return invalidType("数値")
+		}
+	case models.FieldTypeInteger:
+		n, ok := numberValue(value)
+		if !ok || n != math.Trunc(n) {
+			return invalidType("整数")
+		}
+	case models.FieldTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return invalidType("true か false")
+		}
+	case models.FieldTypeDate:
+		s, ok := value.(string)
+		if !ok {
+			return invalidType("文字列")
+		}
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return invalidFormat("YYYY-MM-DD 形式の日付を指定してください")
+		}
+	case models.FieldTypeDatetime:
+		s, ok := value.(string)
+		if !ok {
+			return invalidType("文字列")
+		}
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return invalidFormat("RFC 3339 形式の日時を指定してください")
+		}
+	case models.FieldTypeEmail:
+		s, ok := value.(string)
+		if !ok {
+			return invalidType("文字列")
+		}
+		if address, err := mail.ParseAddress(s); err != nil || address.Address != s {
+			return invalidFormat("メールアドレスの形式が正しくありません")
+		}
+	case models.FieldTypeURL:
+		s, ok := value.(string)
+		if !ok {
+			return invalidType("文字列")
+		}
+		if u, err := url.ParseRequestURI(s); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return invalidFormat("http または https の URL を指定してください")
+		}
+	case models.FieldTypeMedia:
+		s, ok := value.(string)
+		if !ok {
+			return invalidType("メディアのID")
+		}
+		if _, err := uuid.Parse(s); err != nil {
+			return invalidFormat("メディアのIDの形式が正しくありません")
+		}
+	case models.FieldTypeMultiSelect:
+		items, ok := value.([]interface{})
+		if !ok {
+			return invalidType("文字列の配列")
+		}
+		var fieldErrors []myerrors.FieldError
+		for i, item := range items {
+			if _, ok := item.(string); !ok {
+				fieldErrors = append(fieldErrors, myerrors.FieldError{Path: fmt.Sprintf("%s[%d]", path, i), Code: fieldErrInvalidType, Message: "文字列を指定してください"})
+			}
+		}
+		return fieldErrors
+	case models.FieldTypeRelation:
+		// 1件ならエントリのID、複数なら ID の配列
+		items, isArray := value.([]interface{})
+		if !isArray {
+			if !isEntryId(value) {
+				return invalidType("エントリのID")
+			}
+			return nil
+		}
+		var fieldErrors []myerrors.FieldError
+		for i, item := range items {
+			if !isEntryId(item) {
+				fieldErrors = append(fieldErrors, myerrors.FieldError{Path: fmt.Sprintf("%s[%d]", path, i), Code: fieldErrInvalidType, Message: "エントリのIDを指定してください"})
+			}
+		}
+		return fieldErrors
+	}
+	// json とその他の型はどの値でもよい
+	return nil
+}
+
+// checkRelationShape リレーションのあるフィールドの値が、one-to-many なら ID 1つ、many-to-many なら ID の配列か確認する
+func checkRelationShape(field models.FieldData, value interface{}) []myerrors.FieldError {
+	if field.FieldType != models.FieldTypeRelation || field.Relation == nil {
+		return nil
+	}
+	_, isArray := value.([]interface{})
+	switch {
+	case field.Relation.RelationType == models.RelationTypeOneToMany && isArray:
+		return []myerrors.FieldError{{Path: field.FieldID, Code: fieldErrInvalidType, Message: "エントリのIDを1つ指定してください"}}
+	case field.Relation.RelationType == models.RelationTypeManyToMany && !isArray:
+		return []myerrors.FieldError{{Path: field.FieldID, Code: fieldErrInvalidType, Message: "エントリのIDの配列を指定してください"}}
+	}
+	return nil
+}
+
+// checkFieldOptions select・multiselect の値が選択肢の値か確認する。選択肢がまだない場合は確認しない
+func checkFieldOptions(field models.FieldData, value interface{}) []myerrors.FieldError {
+	if !models.HasOptions(field.FieldType) || len(field.Options) == 0 {
+		return nil
+	}
+	isOption := func(v interface{}) bool {
+		s, _ := v.(string)
+		return slices.ContainsFunc(field.Options, func(option models.ListOption) bool { return option.Value == s })
+	}
+
+	items, isArray := value.([]interface{})
+	if !isArray {
+		if !isOption(value) {
+			return []myerrors.FieldError{{Path: field.FieldID, Code: fieldErrNotAllowed, Message: "選択肢にない値です"}}
+		}
+		return nil
+	}
+	var fieldErrors []myerrors.FieldError
+	for i, item := range items {
+		if !isOption(item) {
+			fieldErrors = append(fieldErrors, myerrors.FieldError{Path: fmt.Sprintf("%s[%d]", field.FieldID, i), Code: fieldErrNotAllowed, Message: "選択肢にない値です"})
+		}
+	}
+	return fieldErrors
+}
+
+// checkFieldRules 型の合っている値がフィールドの検証ルールを満たすか確認する（unique はDBを見るため entriesUsecase で確認する）
+func checkFieldRules(field models.FieldData, value interface{}) []myerrors.FieldError {
+	rules := field.Validations
+	var fieldErrors []myerrors.FieldError
+	add := func(path string, code string, message string) {
+		fieldErrors = append(fieldErrors, myerrors.FieldError{Path: path, Code: code, Message: message})
+	}
+
+	if s, ok := value.(string); ok {
+		length := utf8.RuneCountInString(s)
+		if rules.MinLength != nil && length < *rules.MinLength {
+			add(field.FieldID, fieldErrTooShort, fmt.Sprintf("%d文字以上で入力してください", *rules.MinLength))
+		}
+		if rules.MaxLength != nil && length > *rules.MaxLength {
+			add(field.FieldID, fieldErrTooLong, fmt.Sprintf("%d文字以内で入力してください", *rules.MaxLength))
+		}
+		if rules.Pattern != "" {
+			// pattern はフィールドの保存時に確認しているが、読めない pattern が残っていた場合は値を通さない
+			re, err := compilePattern(rules.Pattern)
+			switch {
+			case err != nil:
+				add(field.FieldID, fieldErrPatternMismatch, "フィールドの pattern が正しくないため確認できません")
+			case !re.MatchString(s):
+				message := rules.PatternMessage
+				if message == "" {
+					message = "形式が正しくありません"
+				}
+				add(field.FieldID, fieldErrPatternMismatch, message)
+			}
+		}
+	}
+
+	if n, ok := numberValue(value); ok {
+		if rules.Min != nil && n < *rules.Min {
+			add(field.FieldID, fieldErrTooSmall, formatNumber(*rules.Min)+"以上の値を指定してください")
+		}
+		if rules.Max != nil && n > *rules.Max {
+			add(field.FieldID, fieldErrTooLarge, formatNumber(*rules.Max)+"以下の値を指定してください")
+		}
+	}
+
+	items, isArray := value.([]interface{})
+	if isArray {
+		if rules.MinItems != nil && len(items) < *rules.MinItems {
+			add(field.FieldID, fieldErrTooFewItems, fmt.Sprintf("%d個以上指定してください", *rules.MinItems))
+		}
+		if rules.MaxItems != nil && len(items) > *rules.MaxItems {
+			add(field.FieldID, fieldErrTooManyItems, fmt.Sprintf("%d個以内で指定してください", *rules.MaxItems))
+		}
+	}
+
+	if len(rules.Enum) > 0 {
+		if !isArray {
+			if !containsJSONValue(rules.Enum, value) {
+				add(field.FieldID, fieldErrNotAllowed, "使えない値です")
+			}
+		} else {
+			for i, item := range items {
+				if !containsJSONValue(rules.Enum, item) {
+					add(fmt.Sprintf("%s[%d]", field.FieldID, i), fieldErrNotAllowed, "使えない値です")
+				}
+			}
+		}
+	}
+	return fieldErrors
+}
+
+// containsJSONValue values に value があるか。数値は型（int・float64 など）によらず値で比べる
+func containsJSONValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if x, ok := numberValue(v); ok {
+			if y, ok := numberValue(value); ok && x == y {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// numberValue JSON の数値を float64 として返す
+func numberValue(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// isEntryId 正の整数かどうか
+func isEntryId(value interface{}) bool {
+	n, ok := numberValue(value)
+	return ok && n > 0 && n == math.Trunc(n)
+}
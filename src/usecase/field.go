@@ -28,6 +28,9 @@ func NewFieldUsecase(fieldRepo repositories.FieldRepository, collectionRepo repo
 }
 
 func (f *fieldUsecase) Create(projectId int, newField *models.FieldData) error {
+	if err := validateFieldDefinition(newField); err != nil {
+		return myerrors.WrapDomainError("fieldUsecase.Create", err)
+	}
 	// collectionが存在するか確認
 	if _, err := f.collectionRepo.GetCollectionsByCollectionId(newField.CollectionID, projectId); err != nil {
 		//	collectionが存在しない場合
@@ -55,10 +58,18 @@ func (f *fieldUsecase) GetByCollectionId(collectionId int, projectId int) ([]mod
 }
 
 func (f *fieldUsecase) Update(projectId int, newField *models.FieldData) error {
+	if err := validateFieldDefinition(newField); err != nil {
+		return myerrors.WrapDomainError("fieldUsecase.Update", err)
+	}
 	// collectionが存在するか確認
 	if _, err := f.collectionRepo.GetCollectionsByCollectionId(newField.CollectionID, projectId); err != nil {
 		return myerrors.WrapDomainError("fieldUsecase.Update", err)
 	}
+	if newField.IsRequired {
+		if err := f.checkRequiredRelation(newField); err != nil {
+			return myerrors.WrapDomainError("fieldUsecase.Update", err)
+		}
+	}
 	if err := f.fieldRepo.UpdateField(newField); err != nil {
 		// フィールドの更新に失敗した場合
 		return myerrors.WrapDomainError("fieldUsecase.Update", err)
@@ -79,3 +90,17 @@ func (f *fieldUsecase) Delete(projectId int, fieldId string) error {
 	}
 	return nil
 }
+
+// checkRequiredRelation リレーションの on_delete が set-null のフィールドは、参照先を削除すると値がなくなるため必須にできない
+func (f *fieldUsecase) checkRequiredRelation(newField *models.FieldData) error {
+	fields, err := f.fieldRepo.GetFieldsByCollectionId(newField.CollectionID, newField.ProjectID)
+	if err != nil {
+		return err
+	}
+	for i := range fields {
+		if fields[i].FieldID == newField.FieldID && fields[i].Relation != nil {
+			return checkRelationOnDelete(newField, fields[i].Relation.OnDelete)
+		}
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"w3st/domain/models"
+	myerrors "w3st/errors"
 	mockRepositories "w3st/mock/repositories"
 	"w3st/usecase"
 )
@@ -26,6 +27,7 @@ func TestFieldUsecase_Create_Success(t *testing.T) {
 	newField := &models.FieldData{
 		CollectionID: 1,
 		ViewName:     "Test Field",
+		FieldType:    models.FieldTypeText,
 	}
 
 	mockCollectionsRepo.EXPECT().
@@ -54,6 +56,7 @@ func TestFieldUsecase_Create_CollectionNotFound(t *testing.T) {
 	newField := &models.FieldData{
 		CollectionID: 1,
 		ViewName:     "Test Field",
+		FieldType:    models.FieldTypeText,
 	}
 
 	mockCollectionsRepo.EXPECT().
@@ -65,6 +68,29 @@ func TestFieldUsecase_Create_CollectionNotFound(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestFieldUsecase_Create_InvalidDefinition(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	uc := usecase.NewFieldUsecase(mockRepositories.NewMockFieldRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl))
+
+	// 使えない型や型に合わないデフォルト値はDBを確認せずに拒否する
+	err := uc.Create(1, &models.FieldData{CollectionID: 1, FieldID: "price", FieldType: "money"})
+	assertErrType(t, err, myerrors.InvalidParameter)
+
+	err = uc.Create(1, &models.FieldData{CollectionID: 1, FieldID: "price", FieldType: models.FieldTypeNumber, DefaultValue: `"free"`})
+	assertErrType(t, err, myerrors.InvalidParameter)
+
+	// 検証ルールの値が正しくない、またはデフォルト値がルールを満たさない場合も拒否する
+	err = uc.Create(1, &models.FieldData{CollectionID: 1, FieldID: "code", FieldType: models.FieldTypeText, Validations: models.FieldValidations{Pattern: "[a-"}})
+	assertErrType(t, err, myerrors.InvalidParameter)
+
+	maxLength := 3
+	err = uc.Create(1, &models.FieldData{CollectionID: 1, FieldID: "code", FieldType: models.FieldTypeText, DefaultValue: `"ABCD"`, Validations: models.FieldValidations{MaxLength: &maxLength}})
+	assertErrType(t, err, myerrors.InvalidParameter)
+}
+
 func TestFieldUsecase_Update_Success(t *testing.T) {
 	t.Parallel()
 	ctrl := gomock.NewController(t)
@@ -78,6 +104,7 @@ func TestFieldUsecase_Update_Success(t *testing.T) {
 	newField := &models.FieldData{
 		CollectionID: 1,
 		ViewName:     "Updated Field",
+		FieldType:    models.FieldTypeText,
 	}
 
 	mockCollectionsRepo.EXPECT().
@@ -93,6 +120,43 @@ func TestFieldUsecase_Update_Success(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestFieldUsecase_Update_RequiredWithSetNullRelation(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	uc := usecase.NewFieldUsecase(mockFieldRepo, mockCollectionsRepo)
+
+	projectID := 1
+	newField := &models.FieldData{
+		ProjectID:    projectID,
+		CollectionID: 3,
+		FieldID:      "author",
+		ViewName:     "著者",
+		FieldType:    models.FieldTypeRelation,
+		IsRequired:   true,
+	}
+
+	mockCollectionsRepo.EXPECT().
+		GetCollectionsByCollectionId(3, projectID).
+		Return(&models.ApiCollection{ID: 3}, nil)
+
+	// 参照先を削除すると参照を外すリレーションがあるフィールドは必須にできない
+	mockFieldRepo.EXPECT().
+		GetFieldsByCollectionId(3, projectID).
+		Return([]models.FieldData{
+			{ID: 7, CollectionID: 3, FieldID: "author", FieldType: models.FieldTypeRelation,
+				Relation: &models.ApiKindRelation{FieldID: 7, RelatedCollectionID: 4, OnDelete: models.RelationOnDeleteSetNull}},
+		}, nil)
+	mockFieldRepo.EXPECT().UpdateField(gomock.Any()).Times(0)
+
+	err := uc.Update(projectID, newField)
+
+	assertErrType(t, err, myerrors.InvalidParameter)
+}
+
 func TestFieldUsecase_Delete_Success(t *testing.T) {
 	t.Parallel()
 	ctrl := gomock.NewController(t)
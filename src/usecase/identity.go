@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+	"w3st/infra/logger"
+
+	"github.com/google/uuid"
+)
+
+// IdentityUsecase 外部の認証基盤のユーザーをローカルユーザーに対応付ける
+type IdentityUsecase interface {
+	// Resolve 連携済みであればそのユーザーを返す。
+	// 未連携の場合はメールアドレスが一致する既存ユーザーに連携し、いなければユーザーを作成する
+	Resolve(ctx context.Context, identity models.ExternalIdentity) (*models.Users, error)
+}
+
+type identityUsecase struct {
+	identityRepo      repositories.UserIdentityRepository
+	userRepo          repositories.UserRepository
+	credentialUsecase CredentialUsecase
+}
+
+func NewIdentityUsecase(identityRepo repositories.UserIdentityRepository, userRepo repositories.UserRepository, credentialUsecase CredentialUsecase) IdentityUsecase {
+	return &identityUsecase{
+		identityRepo:      identityRepo,
+		userRepo:          userRepo,
+		credentialUsecase: credentialUsecase,
+	}
+}
+
+func (i *identityUsecase) Resolve(ctx context.Context, external models.ExternalIdentity) (*models.Users, error) {
+	if external.Provider == "" || external.Subject == "" {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.Unauthenticated, "ユーザーを識別できません")
+	}
+
+	user, err := i.findLinkedUser(ctx, external)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("identityUsecase.Resolve", err)
+	}
+	if user != nil {
+		return user, nil
+	}
+
+	user, err = i.link(ctx, external)
+	if err != nil {
+		// 同じユーザーの初回リクエストが同時に届いた場合は、先に作成された連携を使う
+		if errors.Is(err, &myerrors.DomainError{ErrType: myerrors.AlreadyExist}) {
+			linked, findErr := i.findLinkedUser(ctx, external)
+			if findErr != nil {
+				return nil, myerrors.WrapDomainError("identityUsecase.Resolve", findErr)
+			}
+			if linked != nil {
+				return linked, nil
+			}
+		}
+		return nil, myerrors.WrapDomainError("identityUsecase.Resolve", err)
+	}
+	return user, nil
+}
+
+// findLinkedUser 連携済みでなければ nil を返す
+func (i *identityUsecase) findLinkedUser(ctx context.Context, external models.ExternalIdentity) (*models.Users, error) {
+	identity, domainErr := i.identityRepo.FindBySubject(ctx, external.Provider, external.Subject)
+	if domainErr != nil {
+		if errors.Is(domainErr, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return nil, nil
+		}
+		return nil, domainErr
+	}
+
+	user, domainErr := i.userRepo.FindByID(ctx, identity.UserID.String())
+	if domainErr != nil {
+		return nil, domainErr
+	}
+
+	if err := i.identityRepo.UpdateLastLogin(ctx, identity.ID); err != nil {
+		// 最終ログイン日時の更新に失敗しても認証は継続する
+		logger.Error("failed to update identity last login", "identity_id", identity.ID.String(), "error", err.Error())
+	}
+	return user, nil
+}
+
+// link 既存ユーザーへの連携、またはユーザーの新規作成を行う
+func (i *identityUsecase) link(ctx context.Context, external models.ExternalIdentity) (*models.Users, error) {
+	email := strings.TrimSpace(external.Email)
+	if email == "" {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.Unauthenticated, "メールアドレスを取得できないためユーザーを作成できません")
+	}
+
+	now := time.Now()
+	identity := &models.UserIdentity{
+		ID:          uuid.New(),
+		Provider:    external.Provider,
+		Subject:     external.Subject,
+		Email:       email,
+		LastLoginAt: now,
+		CreatedAt:   now,
+	}
+
+	existing, domainErr := i.userRepo.FindByEmail(ctx, email)
+	if domainErr == nil {
+		// 確認されていないメールアドレスで連携すると他人のアカウントを乗っ取れてしまう
+		if !external.EmailVerified {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.Unauthenticated, "メールアドレスが確認されていないため既存のアカウントと連携できません")
+		}
+		identity.UserID = existing.ID
+		if err := i.identityRepo.Create(ctx, identity); err != nil {
+			return nil, err
+		}
+		logger.Info("linked external identity to existing user",
+			"user_id", existing.ID.String(),
+			"provider", external.Provider,
+		)
+		return existing, nil
+	}
+	if !errors.Is(domainErr, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+		return nil, domainErr
+	}
+
+	// 外部の認証基盤でログインするユーザーにはパスワードがないため、推測できない値を保存しておく
+	randomPassword, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := i.credentialUsecase.HashPassword(randomPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.Users{
+		ID:            uuid.New(),
+		Name:          displayName(external.Name, email),
+		Email:         email,
+		Password:      hashed,
+		EmailVerified: external.EmailVerified,
+	}
+	if err := i.identityRepo.CreateWithUser(ctx, user, identity); err != nil {
+		return nil, err
+	}
+	logger.Info("provisioned user from external identity",
+		"user_id", user.ID.String(),
+		"provider", external.Provider,
+	)
+	return user, nil
+}
+
+// displayName 名前がない場合はメールアドレスの@より前を使う
+func displayName(name string, email string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	if r := []rune(name); len(r) > 100 {
+		name = string(r[:100])
+	}
+	return name
+}
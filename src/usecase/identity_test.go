@@ -0,0 +1,182 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	mockRepositories "w3st/mock/repositories"
+	"w3st/usecase"
+)
+
+func auth0Identity(emailVerified bool) models.ExternalIdentity {
+	return models.ExternalIdentity{
+		Provider:      models.IdentityProviderAuth0,
+		Subject:       "auth0|abc",
+		Email:         "alice@example.com",
+		EmailVerified: emailVerified,
+		Name:          "Alice",
+	}
+}
+
+func identityNotFound() *myerrors.DomainError {
+	return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "連携されたユーザーが見つかりません")
+}
+
+func TestIdentityUsecase_Resolve_AlreadyLinked(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIdentityRepo := mockRepositories.NewMockUserIdentityRepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewIdentityUsecase(mockIdentityRepo, mockUserRepo, newTestCredentialUsecase())
+
+	user := &models.Users{ID: uuid.New(), Email: "alice@example.com"}
+	identity := &models.UserIdentity{ID: uuid.New(), UserID: user.ID}
+
+	mockIdentityRepo.EXPECT().FindBySubject(gomock.Any(), models.IdentityProviderAuth0, "auth0|abc").Return(identity, nil)
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), user.ID.String()).Return(user, nil)
+	mockIdentityRepo.EXPECT().UpdateLastLogin(gomock.Any(), identity.ID).Return(nil)
+
+	got, err := uc.Resolve(context.Background(), auth0Identity(true))
+
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+}
+
+func TestIdentityUsecase_Resolve_LinksExistingUserByVerifiedEmail(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIdentityRepo := mockRepositories.NewMockUserIdentityRepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewIdentityUsecase(mockIdentityRepo, mockUserRepo, newTestCredentialUsecase())
+
+	existing := &models.Users{ID: uuid.New(), Email: "alice@example.com"}
+
+	mockIdentityRepo.EXPECT().FindBySubject(gomock.Any(), models.IdentityProviderAuth0, "auth0|abc").Return(nil, identityNotFound())
+	mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "alice@example.com").Return(existing, nil)
+	mockIdentityRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, identity *models.UserIdentity) *myerrors.DomainError {
+			assert.Equal(t, existing.ID, identity.UserID)
+			assert.Equal(t, "auth0|abc", identity.Subject)
+			return nil
+		})
+
+	got, err := uc.Resolve(context.Background(), auth0Identity(true))
+
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, got.ID)
+}
+
+func TestIdentityUsecase_Resolve_UnverifiedEmailIsNotLinked(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIdentityRepo := mockRepositories.NewMockUserIdentityRepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewIdentityUsecase(mockIdentityRepo, mockUserRepo, newTestCredentialUsecase())
+
+	// 確認されていないメールアドレスでは既存ユーザーに連携しない
+	mockIdentityRepo.EXPECT().FindBySubject(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, identityNotFound())
+	mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "alice@example.com").Return(&models.Users{ID: uuid.New()}, nil)
+
+	_, err := uc.Resolve(context.Background(), auth0Identity(false))
+
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.Unauthenticated, domainErr.ErrType)
+}
+
+func TestIdentityUsecase_Resolve_ProvisionsNewUser(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIdentityRepo := mockRepositories.NewMockUserIdentityRepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewIdentityUsecase(mockIdentityRepo, mockUserRepo, newTestCredentialUsecase())
+
+	mockIdentityRepo.EXPECT().FindBySubject(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, identityNotFound())
+	mockUserRepo.EXPECT().
+		FindByEmail(gomock.Any(), "alice@example.com").
+		Return(&models.Users{}, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "ユーザーが見つかりません"))
+	mockIdentityRepo.EXPECT().
+		CreateWithUser(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, user *models.Users, identity *models.UserIdentity) *myerrors.DomainError {
+			assert.Equal(t, "Alice", user.Name)
+			assert.True(t, user.EmailVerified)
+			// パスワードは推測できない値のハッシュで、空ではない
+			assert.NotEmpty(t, user.Password)
+			assert.Equal(t, models.IdentityProviderAuth0, identity.Provider)
+			return nil
+		})
+
+	got, err := uc.Resolve(context.Background(), auth0Identity(true))
+
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, got.ID)
+	assert.Equal(t, "alice@example.com", got.Email)
+}
+
+func TestIdentityUsecase_Resolve_ConcurrentProvisioning(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIdentityRepo := mockRepositories.NewMockUserIdentityRepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewIdentityUsecase(mockIdentityRepo, mockUserRepo, newTestCredentialUsecase())
+
+	winner := &models.Users{ID: uuid.New(), Email: "alice@example.com"}
+	identity := &models.UserIdentity{ID: uuid.New(), UserID: winner.ID}
+
+	// 先に別のリクエストが連携を作成した場合はそのユーザーを使う
+	gomock.InOrder(
+		mockIdentityRepo.EXPECT().FindBySubject(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, identityNotFound()),
+		mockIdentityRepo.EXPECT().FindBySubject(gomock.Any(), gomock.Any(), gomock.Any()).Return(identity, nil),
+	)
+	mockUserRepo.EXPECT().
+		FindByEmail(gomock.Any(), "alice@example.com").
+		Return(&models.Users{}, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "ユーザーが見つかりません"))
+	mockIdentityRepo.EXPECT().
+		CreateWithUser(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "このアカウントはすでに連携されています"))
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), winner.ID.String()).Return(winner, nil)
+	mockIdentityRepo.EXPECT().UpdateLastLogin(gomock.Any(), identity.ID).Return(nil)
+
+	got, err := uc.Resolve(context.Background(), auth0Identity(true))
+
+	require.NoError(t, err)
+	assert.Equal(t, winner.ID, got.ID)
+}
+
+func TestIdentityUsecase_Resolve_MissingEmail(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIdentityRepo := mockRepositories.NewMockUserIdentityRepository(ctrl)
+	uc := usecase.NewIdentityUsecase(mockIdentityRepo, mockRepositories.NewMockUserRepository(ctrl), newTestCredentialUsecase())
+
+	external := auth0Identity(true)
+	external.Email = ""
+
+	mockIdentityRepo.EXPECT().FindBySubject(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, identityNotFound())
+
+	_, err := uc.Resolve(context.Background(), external)
+
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.Unauthenticated, domainErr.ErrType)
+}
@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+)
+
+type ListOptionsUsecase interface {
+	GetOptions(ctx context.Context, projectId int, collectionId int, fieldId int) ([]models.ListOption, error)
+	// CreateOption 選択肢を最後に追加する。Label を省略した場合は Value を使う
+	CreateOption(ctx context.Context, projectId int, collectionId int, option *models.ListOption) error
+	// UpdateOption 値を変更した場合は、エントリに保存されている値も書き換え、書き換えたエントリの数を返す
+	UpdateOption(ctx context.Context, projectId int, collectionId int, fieldId int, optionId int, update models.ListOptionUpdate) (*models.ListOption, int64, error)
+	// DeleteOption エントリで使われている選択肢は削除しない
+	DeleteOption(ctx context.Context, projectId int, collectionId int, fieldId int, optionId int) error
+	// ReorderOptions optionIds の順に並べ替える。フィールドのすべての選択肢を指定する
+	ReorderOptions(ctx context.Context, projectId int, collectionId int, fieldId int, optionIds []int) ([]models.ListOption, error)
+}
+
+type listOptionsUsecase struct {
+	optionsRepo     repositories.ListOptionRepository
+	fieldRepo       repositories.FieldRepository
+	collectionsRepo repositories.CollectionsRepository
+	entriesRepo     repositories.EntriesRepository
+	transactionRepo repositories.TransactionRepository
+}
+
+func NewListOptionsUsecase(optionsRepo repositories.ListOptionRepository, fieldRepo repositories.FieldRepository, collectionsRepo repositories.CollectionsRepository, entriesRepo repositories.EntriesRepository, transactionRepo repositories.TransactionRepository) ListOptionsUsecase {
+	return &listOptionsUsecase{
+		optionsRepo:     optionsRepo,
+		fieldRepo:       fieldRepo,
+		collectionsRepo: collectionsRepo,
+		entriesRepo:     entriesRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+func (l *listOptionsUsecase) GetOptions(ctx context.Context, projectId int, collectionId int, fieldId int) ([]models.ListOption, error) {
+	field, err := l.optionField(projectId, collectionId, fieldId)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("listOptionsUsecase.GetOptions", err)
+	}
+	if field.Options == nil {
+		return []models.ListOption{}, nil
+	}
+	return field.Options, nil
+}
+
+func (l *listOptionsUsecase) CreateOption(ctx context.Context, projectId int, collectionId int, option *models.ListOption) error {
+	option.Value = strings.TrimSpace(option.Value)
+	option.Label = strings.TrimSpace(option.Label)
+	if option.Label == "" {
+		option.Label = option.Value
+	}
+	if err := validateListOption(option.Value, option.Label); err != nil {
+		return myerrors.WrapDomainError("listOptionsUsecase.CreateOption", err)
+	}
+
+	field, err := l.optionField(projectId, collectionId, option.FieldID)
+	if err != nil {
+		return myerrors.WrapDomainError("listOptionsUsecase.CreateOption", err)
+	}
+	// 最後の選択肢の後に追加する
+	option.Position = 0
+	if len(field.Options) > 0 {
+		option.Position = field.Options[len(field.Options)-1].Position + 1
+	}
+
+	if err := l.optionsRepo.CreateOption(ctx, option); err != nil {
+		return myerrors.WrapDomainError("listOptionsUsecase.CreateOption", err)
+	}
+	return nil
+}
+
+func (l *listOptionsUsecase) UpdateOption(ctx context.Context, projectId int, collectionId int, fieldId int, optionId int, update models.ListOptionUpdate) (*models.ListOption, int64, error) {
+	if update.Value == nil && update.Label == nil {
+		return nil, 0, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "変更する項目を指定してください")
+	}
+
+	field, err := l.optionField(projectId, collectionId, fieldId)
+	if err != nil {
+		return nil, 0, myerrors.WrapDomainError("listOptionsUsecase.UpdateOption", err)
+	}
+
+	var option *models.ListOption
+	var entriesUpdated int64
+	err = l.transactionRepo.Do(ctx, func(ctx context.Context) error {
+		var err error
+		option, err = l.optionsRepo.LockOption(ctx, fieldId, optionId)
+		if err != nil {
+			return err
+		}
+
+		oldValue := option.Value
+		if update.Value != nil {
+			option.Value = strings.TrimSpace(*update.Value)
+		}
+		if update.Label != nil {
+			option.Label = strings.TrimSpace(*update.Label)
+		}
+		if err := validateListOption(option.Value, option.Label); err != nil {
+			return err
+		}
+		if err := l.optionsRepo.UpdateOption(ctx, option); err != nil {
+			return err
+		}
+
+		// エントリに保存されている値を新しい値に書き換える（選択肢の変更と同じトランザクションで行う）
+		if option.Value != oldValue {
+			entriesUpdated, err = l.entriesRepo.ReplaceOptionValue(ctx, collectionId, field.FieldID, oldValue, option.Value)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, myerrors.WrapDomainError("listOptionsUsecase.UpdateOption", err)
+	}
+	return option, entriesUpdated, nil
+}
+
+func (l *listOptionsUsecase) DeleteOption(ctx context.Context, projectId int, collectionId int, fieldId int, optionId int) error {
+	field, err := l.optionField(projectId, collectionId, fieldId)
+	if err != nil {
+		return myerrors.WrapDomainError("listOptionsUsecase.DeleteOption", err)
+	}
+
+	err = l.transactionRepo.Do(ctx, func(ctx context.Context) error {
+		option, err := l.optionsRepo.LockOption(ctx, fieldId, optionId)
+		if err != nil {
+			return err
+		}
+		count, err := l.entriesRepo.CountOptionValue(ctx, collectionId, field.FieldID, option.Value)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, fmt.Sprintf("この選択肢は %d 件のエントリで使われています。エントリの値を変更してから削除してください", count))
+		}
+		return l.optionsRepo.DeleteOption(ctx, fieldId, optionId)
+	})
+	if err != nil {
+		return myerrors.WrapDomainError("listOptionsUsecase.DeleteOption", err)
+	}
+	return nil
+}
+
+func (l *listOptionsUsecase) ReorderOptions(ctx context.Context, projectId int, collectionId int, fieldId int, optionIds []int) ([]models.ListOption, error) {
+	field, err := l.optionField(projectId, collectionId, fieldId)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("listOptionsUsecase.ReorderOptions", err)
+	}
+
+	// すべての選択肢を1回ずつ指定しているか
+	remaining := make(map[int]bool, len(field.Options))
+	for _, option := range field.Options {
+		remaining[option.ID] = true
+	}
+	for _, optionId := range optionIds {
+		if !remaining[optionId] {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "フィールドの選択肢のIDを重複なく指定してください")
+		}
+		delete(remaining, optionId)
+	}
+	if len(remaining) > 0 {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "フィールドのすべての選択肢のIDを指定してください")
+	}
+
+	var options []models.ListOption
+	err = l.transactionRepo.Do(ctx, func(ctx context.Context) error {
+		if err := l.optionsRepo.UpdateOptionPositions(ctx, fieldId, optionIds); err != nil {
+			return err
+		}
+		options, err = l.optionsRepo.GetOptionsByFieldId(ctx, fieldId)
+		return err
+	})
+	if err != nil {
+		return nil, myerrors.WrapDomainError("listOptionsUsecase.ReorderOptions", err)
+	}
+	return options, nil
+}
+
+// optionField プロジェクトのコレクションにある、選択肢を持つ型のフィールドを返す
+func (l *listOptionsUsecase) optionField(projectId int, collectionId int, fieldId int) (*models.FieldData, error) {
+	if _, err := l.collectionsRepo.GetCollectionsByCollectionId(collectionId, projectId); err != nil {
+		return nil, err
+	}
+	field, err := l.fieldRepo.GetField(collectionId, projectId, fieldId)
+	if err != nil {
+		return nil, err
+	}
+	if !models.HasOptions(field.FieldType) {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "選択肢は select・multiselect のフィールドにだけ追加できます")
+	}
+	return field, nil
+}
+
+// validateListOption 値と表示名が空でなく、長すぎないか確認する
+func validateListOption(value string, label string) error {
+	if value == "" || label == "" {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "選択肢の値と表示名を指定してください")
+	}
+	if utf8.RuneCountInString(value) > models.ListOptionValueMaxLength || utf8.RuneCountInString(label) > models.ListOptionValueMaxLength {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, fmt.Sprintf("選択肢の値と表示名は%d文字以内で指定してください", models.ListOptionValueMaxLength))
+	}
+	return nil
+}
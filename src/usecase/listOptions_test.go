@@ -0,0 +1,193 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	mockRepositories "w3st/mock/repositories"
+	"w3st/usecase"
+)
+
+// expectSelectField コレクション3にある select フィールド7（size）を返すようにする
+func expectSelectField(mockCollectionsRepo *mockRepositories.MockCollectionsRepository, mockFieldRepo *mockRepositories.MockFieldRepository, options ...models.ListOption) {
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockFieldRepo.EXPECT().GetField(3, 1, 7).Return(&models.FieldData{ID: 7, FieldID: "size", FieldType: models.FieldTypeSelect, Options: options}, nil)
+}
+
+func TestListOptionsUsecase_CreateOption(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOptionsRepo := mockRepositories.NewMockListOptionRepository(ctrl)
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewListOptionsUsecase(mockOptionsRepo, mockFieldRepo, mockCollectionsRepo, mockRepositories.NewMockEntriesRepository(ctrl), mockTransactionRepo)
+
+	expectSelectField(mockCollectionsRepo, mockFieldRepo, models.ListOption{ID: 1, FieldID: 7, Value: "small", Label: "S", Position: 4})
+	mockOptionsRepo.EXPECT().CreateOption(gomock.Any(), gomock.Any()).Return(nil)
+
+	option := &models.ListOption{FieldID: 7, Value: " large "}
+	require.NoError(t, uc.CreateOption(context.Background(), 1, 3, option))
+
+	// 表示名を省略すると値を使い、最後の選択肢の後に追加する
+	assert.Equal(t, "large", option.Value)
+	assert.Equal(t, "large", option.Label)
+	assert.Equal(t, 5, option.Position)
+}
+
+func TestListOptionsUsecase_CreateOption_NotSelectField(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewListOptionsUsecase(mockRepositories.NewMockListOptionRepository(ctrl), mockFieldRepo, mockCollectionsRepo, mockRepositories.NewMockEntriesRepository(ctrl), mockTransactionRepo)
+
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockFieldRepo.EXPECT().GetField(3, 1, 7).Return(&models.FieldData{ID: 7, FieldID: "title", FieldType: models.FieldTypeText}, nil)
+
+	err := uc.CreateOption(context.Background(), 1, 3, &models.ListOption{FieldID: 7, Value: "small"})
+	assertErrType(t, err, myerrors.InvalidParameter)
+}
+
+func TestListOptionsUsecase_UpdateOption_RenameRewritesEntries(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOptionsRepo := mockRepositories.NewMockListOptionRepository(ctrl)
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewListOptionsUsecase(mockOptionsRepo, mockFieldRepo, mockCollectionsRepo, mockEntriesRepo, mockTransactionRepo)
+
+	expectSelectField(mockCollectionsRepo, mockFieldRepo)
+	gomock.InOrder(
+		mockOptionsRepo.EXPECT().LockOption(gomock.Any(), 7, 1).Return(&models.ListOption{ID: 1, FieldID: 7, Value: "small", Label: "S"}, nil),
+		mockOptionsRepo.EXPECT().UpdateOption(gomock.Any(), gomock.Any()).Return(nil),
+		mockEntriesRepo.EXPECT().ReplaceOptionValue(gomock.Any(), 3, "size", "small", "s").Return(int64(12), nil),
+	)
+
+	value := "s"
+	option, entriesUpdated, err := uc.UpdateOption(context.Background(), 1, 3, 7, 1, models.ListOptionUpdate{Value: &value})
+	require.NoError(t, err)
+	assert.Equal(t, "s", option.Value)
+	assert.Equal(t, "S", option.Label)
+	assert.Equal(t, int64(12), entriesUpdated)
+}
+
+func TestListOptionsUsecase_UpdateOption_LabelOnly(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOptionsRepo := mockRepositories.NewMockListOptionRepository(ctrl)
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewListOptionsUsecase(mockOptionsRepo, mockFieldRepo, mockCollectionsRepo, mockEntriesRepo, mockTransactionRepo)
+
+	expectSelectField(mockCollectionsRepo, mockFieldRepo)
+	mockOptionsRepo.EXPECT().LockOption(gomock.Any(), 7, 1).Return(&models.ListOption{ID: 1, FieldID: 7, Value: "small", Label: "S"}, nil)
+	mockOptionsRepo.EXPECT().UpdateOption(gomock.Any(), gomock.Any()).Return(nil)
+	// 値が変わらなければエントリは書き換えない
+	mockEntriesRepo.EXPECT().ReplaceOptionValue(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	label := "Sサイズ"
+	option, entriesUpdated, err := uc.UpdateOption(context.Background(), 1, 3, 7, 1, models.ListOptionUpdate{Label: &label})
+	require.NoError(t, err)
+	assert.Equal(t, "Sサイズ", option.Label)
+	assert.Zero(t, entriesUpdated)
+}
+
+func TestListOptionsUsecase_DeleteOption_InUse(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOptionsRepo := mockRepositories.NewMockListOptionRepository(ctrl)
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+	mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+		AnyTimes()
+	uc := usecase.NewListOptionsUsecase(mockOptionsRepo, mockFieldRepo, mockCollectionsRepo, mockEntriesRepo, mockTransactionRepo)
+
+	expectSelectField(mockCollectionsRepo, mockFieldRepo)
+	mockOptionsRepo.EXPECT().LockOption(gomock.Any(), 7, 1).Return(&models.ListOption{ID: 1, FieldID: 7, Value: "small"}, nil)
+	mockEntriesRepo.EXPECT().CountOptionValue(gomock.Any(), 3, "size", "small").Return(int64(2), nil)
+	mockOptionsRepo.EXPECT().DeleteOption(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := uc.DeleteOption(context.Background(), 1, 3, 7, 1)
+	assertErrType(t, err, myerrors.AlreadyExist)
+}
+
+func TestListOptionsUsecase_ReorderOptions(t *testing.T) {
+	options := []models.ListOption{{ID: 1, Value: "small"}, {ID: 2, Value: "medium"}, {ID: 3, Value: "large"}}
+
+	tests := []struct {
+		name      string
+		optionIds []int
+		wantErr   bool
+	}{
+		{name: "すべての選択肢を指定", optionIds: []int{3, 1, 2}},
+		{name: "足りない", optionIds: []int{3, 1}, wantErr: true},
+		{name: "重複", optionIds: []int{3, 1, 1}, wantErr: true},
+		{name: "ほかのフィールドの選択肢", optionIds: []int{3, 1, 2, 9}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOptionsRepo := mockRepositories.NewMockListOptionRepository(ctrl)
+			mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+			mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+			mockTransactionRepo := mockRepositories.NewMockTransactionRepository(ctrl)
+			mockTransactionRepo.EXPECT().Do(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, f func(context.Context) error) error { return f(ctx) }).
+				AnyTimes()
+			uc := usecase.NewListOptionsUsecase(mockOptionsRepo, mockFieldRepo, mockCollectionsRepo, mockRepositories.NewMockEntriesRepository(ctrl), mockTransactionRepo)
+
+			expectSelectField(mockCollectionsRepo, mockFieldRepo, options...)
+			if !tt.wantErr {
+				mockOptionsRepo.EXPECT().UpdateOptionPositions(gomock.Any(), 7, tt.optionIds).Return(nil)
+				mockOptionsRepo.EXPECT().GetOptionsByFieldId(gomock.Any(), 7).Return(options, nil)
+			}
+
+			_, err := uc.ReorderOptions(context.Background(), 1, 3, 7, tt.optionIds)
+			if tt.wantErr {
+				assertErrType(t, err, myerrors.InvalidParameter)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package usecase
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MFARecoveryCodeCount 発行するリカバリーコードの数
+	MFARecoveryCodeCount = 10
+	// MFAMaxFailedAttempts 連続で失敗できる回数
+	MFAMaxFailedAttempts = 5
+	// MFALockDuration 失敗回数の上限に達した場合にロックする期間
+	MFALockDuration = 15 * time.Minute
+	// DefaultMFAIssuer MFA_ISSUER が未設定の場合に認証アプリに表示する発行者名
+	DefaultMFAIssuer = "w3st"
+)
+
+// リカバリーコードに使う文字（読み間違えやすい 0/o, 1/l/i を除く）
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+type MFAUsecase interface {
+	// Enroll シークレットを発行して登録を開始する。Confirm するまでは有効にならない
+	Enroll(ctx context.Context, userID uuid.UUID) (*models.MFAEnrollment, error)
+	// Confirm 認証アプリのコードで登録を完了し、リカバリーコードを返す
+	Confirm(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error)
+	// Verify TOTPコードまたはリカバリーコードを検証する
+	Verify(ctx context.Context, userID uuid.UUID, code string) error
+	// StartChallenge パスワード認証に成功したユーザーに入力待ちトークンを発行する
+	StartChallenge(userID uuid.UUID) (models.Token, error)
+	// CompleteChallenge 入力待ちトークンとコードを検証し、ログインを完了するユーザーIDを返す
+	CompleteChallenge(ctx context.Context, challengeToken string, code string) (uuid.UUID, error)
+	// Disable 本人がコードを入力して二要素認証を無効化する
+	Disable(ctx context.Context, userID uuid.UUID, code string) error
+	// Reset 管理者が二要素認証を解除する
+	Reset(ctx context.Context, userID uuid.UUID) error
+}
+
+type mfaUsecase struct {
+	mfaRepo    repositories.MFARepository
+	userRepo   repositories.UserRepository
+	jwtUsecase JwtUsecase
+	aead       cipher.AEAD
+	issuer     string
+}
+
+func NewMFAUsecase(mfaRepo repositories.MFARepository, userRepo repositories.UserRepository, jwtUsecase JwtUsecase) MFAUsecase {
+	// シークレットの暗号鍵は SECRET_KEY から用途別に導出する
+	key := sha256.Sum256([]byte("mfa-secret:" + os.Getenv("SECRET_KEY")))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = DefaultMFAIssuer
+	}
+
+	return &mfaUsecase{
+		mfaRepo:    mfaRepo,
+		userRepo:   userRepo,
+		jwtUsecase: jwtUsecase,
+		aead:       aead,
+		issuer:     issuer,
+	}
+}
+
+func (m *mfaUsecase) Enroll(ctx context.Context, userID uuid.UUID) (*models.MFAEnrollment, error) {
+	user, domainErr := m.userRepo.FindByID(ctx, userID.String())
+	if domainErr != nil {
+		return nil, myerrors.WrapDomainError("mfaUsecase.Enroll", domainErr)
+	}
+
+	existing, domainErr := m.mfaRepo.FindByUserID(ctx, userID)
+	if domainErr != nil && !errors.Is(domainErr, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+		return nil, myerrors.WrapDomainError("mfaUsecase.Enroll", domainErr)
+	}
+	if existing != nil && existing.Enabled {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "二要素認証はすでに有効です")
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.ErrorUnknown, "シークレットの生成に失敗しました")
+	}
+	encrypted, err := m.encryptSecret(secret)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("mfaUsecase.Enroll", err)
+	}
+
+	// 確認前の登録はやり直せるよう上書きする
+	now := time.Now()
+	mfa := &models.UserMFA{
+		UserID:          userID,
+		SecretEncrypted: encrypted,
+		Enabled:         false,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := m.mfaRepo.Save(ctx, mfa); err != nil {
+		return nil, myerrors.WrapDomainError("mfaUsecase.Enroll", err)
+	}
+
+	return &models.MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURI: m.otpAuthURI(user.Email, secret),
+	}, nil
+}
+
+func (m *mfaUsecase) Confirm(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	mfa, domainErr := m.mfaRepo.FindByUserID(ctx, userID)
+	if domainErr != nil {
+		if errors.Is(domainErr, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "先に二要素認証の登録を開始してください")
+		}
+		return nil, myerrors.WrapDomainError("mfaUsecase.Confirm", domainErr)
+	}
+	if mfa.Enabled {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "二要素認証はすでに有効です")
+	}
+
+	secret, err := m.decryptSecret(mfa.SecretEncrypted)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("mfaUsecase.Confirm", err)
+	}
+	now := time.Now()
+	step, ok := verifyTOTP(secret, normalizeMFACode(code), now)
+	if !ok {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "認証コードが正しくありません")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, myerrors.WrapDomainError("mfaUsecase.Confirm", err)
+	}
+	if err := m.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, myerrors.WrapDomainError("mfaUsecase.Confirm", err)
+	}
+
+	mfa.Enabled = true
+	mfa.ConfirmedAt = &now
+	mfa.LastUsedStep = step
+	mfa.UpdatedAt = now
+	if err := m.mfaRepo.Save(ctx, mfa); err != nil {
+		return nil, myerrors.WrapDomainError("mfaUsecase.Confirm", err)
+	}
+
+	return codes, nil
+}
+
+func (m *mfaUsecase) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	mfa, err := m.mfaRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return false, nil
+		}
+		return false, myerrors.WrapDomainError("mfaUsecase.IsEnabled", err)
+	}
+	return mfa.Enabled, nil
+}
+
+func (m *mfaUsecase) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	mfa, domainErr := m.mfaRepo.FindByUserID(ctx, userID)
+	if domainErr != nil {
+		if errors.Is(domainErr, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "二要素認証が有効になっていません")
+		}
+		return myerrors.WrapDomainError("mfaUsecase.Verify", domainErr)
+	}
+	if !mfa.Enabled {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "二要素認証が有効になっていません")
+	}
+
+	now := time.Now()
+	if mfa.LockedUntil != nil && mfa.LockedUntil.After(now) {
+		return myerrors.NewDomainErrorWithMessage(myerrors.Unauthenticated, "認証コードの試行回数が上限に達しました。しばらくしてから再度お試しください")
+	}
+
+	ok, err := m.checkCode(ctx, mfa, code, now)
+	if err != nil {
+		return myerrors.WrapDomainError("mfaUsecase.Verify", err)
+	}
+
+	if !ok {
+		failed := mfa.FailedAttempts + 1
+		var lockedUntil *time.Time
+		if failed >= MFAMaxFailedAttempts {
+			until := now.Add(MFALockDuration)
+			lockedUntil = &until
+			failed = 0
+		}
+		if err := m.mfaRepo.UpdateLockout(ctx, userID, failed, lockedUntil); err != nil {
+			return myerrors.WrapDomainError("mfaUsecase.Verify", err)
+		}
+		return myerrors.NewDomainErrorWithMessage(myerrors.Unauthenticated, "認証コードが正しくありません")
+	}
+
+	if mfa.FailedAttempts > 0 || mfa.LockedUntil != nil {
+		if err := m.mfaRepo.UpdateLockout(ctx, userID, 0, nil); err != nil {
+			return myerrors.WrapDomainError("mfaUsecase.Verify", err)
+		}
+	}
+	return nil
+}
+
+func (m *mfaUsecase) StartChallenge(userID uuid.UUID) (models.Token, error) {
+	token, err := m.jwtUsecase.GenerateMFAChallengeToken(userID)
+	if err != nil {
+		return "", myerrors.WrapDomainError("mfaUsecase.StartChallenge", err)
+	}
+	return token, nil
+}
+
+func (m *mfaUsecase) CompleteChallenge(ctx context.Context, challengeToken string, code string) (uuid.UUID, error) {
+	userIDStr, err := m.jwtUsecase.ValidateMFAChallengeToken(challengeToken)
+	if err != nil {
+		return uuid.Nil, myerrors.WrapDomainError("mfaUsecase.CompleteChallenge", err)
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, myerrors.NewDomainErrorWithMessage(myerrors.Unauthenticated, "UUIDのパースに失敗しました")
+	}
+
+	if err := m.Verify(ctx, userID, code); err != nil {
+		return uuid.Nil, myerrors.WrapDomainError("mfaUsecase.CompleteChallenge", err)
+	}
+	return userID, nil
+}
+
+func (m *mfaUsecase) Disable(ctx context.Context, userID uuid.UUID, code string) error {
+	if err := m.Verify(ctx, userID, code); err != nil {
+		return myerrors.WrapDomainError("mfaUsecase.Disable", err)
+	}
+	if err := m.mfaRepo.Delete(ctx, userID); err != nil {
+		return myerrors.WrapDomainError("mfaUsecase.Disable", err)
+	}
+	return nil
+}
+
+func (m *mfaUsecase) Reset(ctx context.Context, userID uuid.UUID) error {
+	if _, err := m.userRepo.FindByID(ctx, userID.String()); err != nil {
+		return myerrors.WrapDomainError("mfaUsecase.Reset", err)
+	}
+	if err := m.mfaRepo.Delete(ctx, userID); err != nil {
+		return myerrors.WrapDomainError("mfaUsecase.Reset", err)
+	}
+	return nil
+}
+
+// checkCode 6桁の数字はTOTP、それ以外はリカバリーコードとして照合する
+func (m *mfaUsecase) checkCode(ctx context.Context, mfa *models.UserMFA, code string, now time.Time) (bool, error) {
+	normalized := normalizeMFACode(code)
+	if normalized == "" {
+		return false, nil
+	}
+
+	if isDigits(normalized) && len(normalized) == TOTPDigits {
+		secret, err := m.decryptSecret(mfa.SecretEncrypted)
+		if err != nil {
+			return false, err
+		}
+		step, ok := verifyTOTP(secret, normalized, now)
+		if !ok {
+			return false, nil
+		}
+		// 同じコードの使い回し（リプレイ）を防ぐ
+		updated, domainErr := m.mfaRepo.UpdateLastUsedStep(ctx, mfa.UserID, step)
+		if domainErr != nil {
+			return false, domainErr
+		}
+		return updated, nil
+	}
+
+	used, domainErr := m.mfaRepo.UseRecoveryCode(ctx, mfa.UserID, hashRecoveryCode(normalized))
+	if domainErr != nil {
+		return false, domainErr
+	}
+	return used, nil
+}
+
+func (m *mfaUsecase) otpAuthURI(account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", m.issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", "6")
+	query.Set("period", "30")
+
+	label := url.PathEscape(m.issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+func (m *mfaUsecase) encryptSecret(secret string) (string, error) {
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", myerrors.NewDomainErrorWithMessage(myerrors.ErrorUnknown, "シークレットの暗号化に失敗しました")
+	}
+	sealed := m.aead.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (m *mfaUsecase) decryptSecret(encrypted string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < m.aead.NonceSize() {
+		return "", myerrors.NewDomainErrorWithMessage(myerrors.ErrorUnknown, "シークレットの復号に失敗しました")
+	}
+	nonce, ciphertext := sealed[:m.aead.NonceSize()], sealed[m.aead.NonceSize():]
+	plain, err := m.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", myerrors.NewDomainErrorWithMessage(myerrors.ErrorUnknown, "シークレットの復号に失敗しました")
+	}
+	return string(plain), nil
+}
+
+// generateRecoveryCodes 表示用のコードと保存用のハッシュを生成する
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, MFARecoveryCodeCount)
+	hashes := make([]string, MFARecoveryCodeCount)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, myerrors.NewDomainErrorWithMessage(myerrors.ErrorUnknown, "リカバリーコードの生成に失敗しました")
+		}
+		var sb strings.Builder
+		for j, b := range buf {
+			if j == 5 {
+				sb.WriteByte('-')
+			}
+			sb.WriteByte(recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+		}
+		codes[i] = sb.String()
+		hashes[i] = hashRecoveryCode(normalizeMFACode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeMFACode 空白とハイフンを除き小文字にそろえる
+func normalizeMFACode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,322 @@
+package usecase_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	mockRepositories "w3st/mock/repositories"
+	"w3st/usecase"
+)
+
+// enrollMFA 登録を開始し、保存された設定と平文のシークレットを返す
+func enrollMFA(t *testing.T, uc usecase.MFAUsecase, mockMFARepo *mockRepositories.MockMFARepository, mockUserRepo *mockRepositories.MockUserRepository, userID uuid.UUID) (*models.UserMFA, string) {
+	t.Helper()
+	var saved *models.UserMFA
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), userID.String()).Return(&models.Users{ID: userID, Email: "alice@example.com"}, nil)
+	mockMFARepo.EXPECT().
+		FindByUserID(gomock.Any(), userID).
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "二要素認証が設定されていません"))
+	mockMFARepo.EXPECT().
+		Save(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, mfa *models.UserMFA) *myerrors.DomainError {
+			saved = mfa
+			return nil
+		})
+
+	enrollment, err := uc.Enroll(context.Background(), userID)
+	require.NoError(t, err)
+	return saved, enrollment.Secret
+}
+
+func totpCodeAt(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := usecase.GenerateTOTPCode(secret, at)
+	require.NoError(t, err)
+	return code
+}
+
+func TestMFAUsecase_Enroll_ReturnsOTPAuthURI(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mockRepositories.NewMockMFARepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewMFAUsecase(mockMFARepo, mockUserRepo, usecase.NewjwtAuthUsecase())
+
+	userID := uuid.New()
+
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), userID.String()).Return(&models.Users{ID: userID, Email: "alice@example.com"}, nil)
+	mockMFARepo.EXPECT().
+		FindByUserID(gomock.Any(), userID).
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "二要素認証が設定されていません"))
+	var saved *models.UserMFA
+	mockMFARepo.EXPECT().
+		Save(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, mfa *models.UserMFA) *myerrors.DomainError {
+			saved = mfa
+			return nil
+		})
+
+	enrollment, err := uc.Enroll(context.Background(), userID)
+
+	require.NoError(t, err)
+	uri, err := url.Parse(enrollment.OTPAuthURI)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/w3st:alice@example.com", uri.Path)
+	assert.Equal(t, enrollment.Secret, uri.Query().Get("secret"))
+	assert.Equal(t, "w3st", uri.Query().Get("issuer"))
+	// 登録を確認するまでは無効で、シークレットは平文で保存されない
+	assert.False(t, saved.Enabled)
+	assert.NotContains(t, saved.SecretEncrypted, enrollment.Secret)
+}
+
+func TestMFAUsecase_Enroll_AlreadyEnabled(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mockRepositories.NewMockMFARepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewMFAUsecase(mockMFARepo, mockUserRepo, usecase.NewjwtAuthUsecase())
+
+	userID := uuid.New()
+
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), userID.String()).Return(&models.Users{ID: userID}, nil)
+	mockMFARepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&models.UserMFA{UserID: userID, Enabled: true}, nil)
+
+	_, err := uc.Enroll(context.Background(), userID)
+
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.AlreadyExist, domainErr.ErrType)
+}
+
+func TestMFAUsecase_Confirm_IssuesRecoveryCodes(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mockRepositories.NewMockMFARepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewMFAUsecase(mockMFARepo, mockUserRepo, usecase.NewjwtAuthUsecase())
+
+	userID := uuid.New()
+	pending, secret := enrollMFA(t, uc, mockMFARepo, mockUserRepo, userID)
+
+	var hashes []string
+	mockMFARepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(pending, nil)
+	mockMFARepo.EXPECT().
+		ReplaceRecoveryCodes(gomock.Any(), userID, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, codeHashes []string) *myerrors.DomainError {
+			hashes = codeHashes
+			return nil
+		})
+	mockMFARepo.EXPECT().Save(gomock.Any(), pending).Return(nil)
+
+	codes, err := uc.Confirm(context.Background(), userID, totpCodeAt(t, secret, time.Now()))
+
+	require.NoError(t, err)
+	assert.True(t, pending.Enabled)
+	assert.NotNil(t, pending.ConfirmedAt)
+	require.Len(t, codes, usecase.MFARecoveryCodeCount)
+	require.Len(t, hashes, usecase.MFARecoveryCodeCount)
+	// DBにはハイフンを除いたコードのハッシュだけが保存される
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(codes[0], "-", "")))
+	assert.Equal(t, hex.EncodeToString(sum[:]), hashes[0])
+}
+
+func TestMFAUsecase_Confirm_WrongCode(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mockRepositories.NewMockMFARepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewMFAUsecase(mockMFARepo, mockUserRepo, usecase.NewjwtAuthUsecase())
+
+	userID := uuid.New()
+	pending, secret := enrollMFA(t, uc, mockMFARepo, mockUserRepo, userID)
+
+	mockMFARepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(pending, nil)
+
+	_, err := uc.Confirm(context.Background(), userID, totpCodeAt(t, secret, time.Now().Add(-time.Hour)))
+
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.InvalidParameter, domainErr.ErrType)
+	assert.False(t, pending.Enabled)
+}
+
+func TestMFAUsecase_Verify_TOTPReplayRejected(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mockRepositories.NewMockMFARepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewMFAUsecase(mockMFARepo, mockUserRepo, usecase.NewjwtAuthUsecase())
+
+	userID := uuid.New()
+	mfa, secret := enrollMFA(t, uc, mockMFARepo, mockUserRepo, userID)
+	mfa.Enabled = true
+	code := totpCodeAt(t, secret, time.Now())
+
+	// 1回目は成功し、同じステップのコードを再度使うとリポジトリが更新を拒否する
+	mockMFARepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(mfa, nil).Times(2)
+	gomock.InOrder(
+		mockMFARepo.EXPECT().UpdateLastUsedStep(gomock.Any(), userID, gomock.Any()).Return(true, nil),
+		mockMFARepo.EXPECT().UpdateLastUsedStep(gomock.Any(), userID, gomock.Any()).Return(false, nil),
+	)
+	mockMFARepo.EXPECT().UpdateLockout(gomock.Any(), userID, 1, nil).Return(nil)
+
+	require.NoError(t, uc.Verify(context.Background(), userID, code))
+
+	err := uc.Verify(context.Background(), userID, code)
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.Unauthenticated, domainErr.ErrType)
+}
+
+func TestMFAUsecase_Verify_RecoveryCode(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mockRepositories.NewMockMFARepository(ctrl)
+	uc := usecase.NewMFAUsecase(mockMFARepo, mockRepositories.NewMockUserRepository(ctrl), usecase.NewjwtAuthUsecase())
+
+	userID := uuid.New()
+	mfa := &models.UserMFA{UserID: userID, Enabled: true}
+
+	sum := sha256.Sum256([]byte("abcdefghjk"))
+	mockMFARepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(mfa, nil)
+	mockMFARepo.EXPECT().UseRecoveryCode(gomock.Any(), userID, hex.EncodeToString(sum[:])).Return(true, nil)
+
+	// 大文字や前後の空白が混ざっていても受け付ける
+	err := uc.Verify(context.Background(), userID, " ABCDE-FGHJK ")
+
+	require.NoError(t, err)
+}
+
+func TestMFAUsecase_Verify_LocksAfterMaxFailures(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mockRepositories.NewMockMFARepository(ctrl)
+	uc := usecase.NewMFAUsecase(mockMFARepo, mockRepositories.NewMockUserRepository(ctrl), usecase.NewjwtAuthUsecase())
+
+	userID := uuid.New()
+	mfa := &models.UserMFA{UserID: userID, Enabled: true, FailedAttempts: usecase.MFAMaxFailedAttempts - 1}
+
+	var lockedUntil *time.Time
+	mockMFARepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(mfa, nil)
+	mockMFARepo.EXPECT().UseRecoveryCode(gomock.Any(), userID, gomock.Any()).Return(false, nil)
+	mockMFARepo.EXPECT().
+		UpdateLockout(gomock.Any(), userID, 0, gomock.Not(gomock.Nil())).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, _ int, until *time.Time) *myerrors.DomainError {
+			lockedUntil = until
+			return nil
+		})
+
+	err := uc.Verify(context.Background(), userID, "wrong-code")
+
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.Unauthenticated, domainErr.ErrType)
+	require.NotNil(t, lockedUntil)
+	assert.WithinDuration(t, time.Now().Add(usecase.MFALockDuration), *lockedUntil, time.Minute)
+}
+
+func TestMFAUsecase_Verify_Locked(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mockRepositories.NewMockMFARepository(ctrl)
+	uc := usecase.NewMFAUsecase(mockMFARepo, mockRepositories.NewMockUserRepository(ctrl), usecase.NewjwtAuthUsecase())
+
+	userID := uuid.New()
+	until := time.Now().Add(time.Minute)
+	mfa := &models.UserMFA{UserID: userID, Enabled: true, LockedUntil: &until}
+
+	// ロック中は正しいコードであっても照合しない
+	mockMFARepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(mfa, nil)
+
+	err := uc.Verify(context.Background(), userID, "123456")
+
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.Unauthenticated, domainErr.ErrType)
+}
+
+func TestMFAUsecase_CompleteChallenge_Success(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mockRepositories.NewMockMFARepository(ctrl)
+	uc := usecase.NewMFAUsecase(mockMFARepo, mockRepositories.NewMockUserRepository(ctrl), usecase.NewjwtAuthUsecase())
+
+	userID := uuid.New()
+	mfa := &models.UserMFA{UserID: userID, Enabled: true}
+
+	mockMFARepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(mfa, nil)
+	mockMFARepo.EXPECT().UseRecoveryCode(gomock.Any(), userID, gomock.Any()).Return(true, nil)
+
+	challenge, err := uc.StartChallenge(userID)
+	require.NoError(t, err)
+
+	got, err := uc.CompleteChallenge(context.Background(), string(challenge), "abcde-fghjk")
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, got)
+}
+
+func TestMFAUsecase_CompleteChallenge_InvalidToken(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	uc := usecase.NewMFAUsecase(mockRepositories.NewMockMFARepository(ctrl), mockRepositories.NewMockUserRepository(ctrl), usecase.NewjwtAuthUsecase())
+
+	_, err := uc.CompleteChallenge(context.Background(), "invalid", "123456")
+
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.Unauthenticated, domainErr.ErrType)
+}
+
+func TestMFAUsecase_Reset(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := mockRepositories.NewMockMFARepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewMFAUsecase(mockMFARepo, mockUserRepo, usecase.NewjwtAuthUsecase())
+
+	userID := uuid.New()
+
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), userID.String()).Return(&models.Users{ID: userID}, nil)
+	mockMFARepo.EXPECT().Delete(gomock.Any(), userID).Return(nil)
+
+	err := uc.Reset(context.Background(), userID)
+
+	require.NoError(t, err)
+}
@@ -2,103 +2,374 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
 
 	"w3st/domain/models"
 	"w3st/domain/repositories"
 	myerrors "w3st/errors"
+	"w3st/infra/logger"
 
 	"github.com/google/uuid"
 )
 
+const (
+	// PermissionSweepInterval 期限切れの権限を削除する間隔
+	PermissionSweepInterval = 10 * time.Minute
+
+	// まとめて操作できるユーザー数と権限数の上限
+	maxPermissionBatchUsers       = 100
+	maxPermissionBatchPermissions = 50
+)
+
+// PermissionUsecase プロジェクトごとにユーザーへ個別に付与（または禁止）した権限を管理する。
+// リソースはプロジェクト内のものに限られ、"collection:5" のように省略した場合は "project:N/collection:5" として扱う
 type PermissionUsecase interface {
-	CheckPermission(ctx context.Context, userID uuid.UUID, permission, resource string) (bool, error)
-	GrantPermission(ctx context.Context, userID uuid.UUID, permission, resource string) error
-	RevokePermission(ctx context.Context, userID uuid.UUID, permission, resource string) error
-	GetPermissionsByUser(ctx context.Context, userID uuid.UUID) ([]*models.UserPermission, error)
+	CheckPermission(ctx context.Context, projectID int, userID uuid.UUID, permission, resource string) (bool, error)
+	// ExplainPermission CheckPermission の結果と、その根拠になった権限を返す
+	ExplainPermission(ctx context.Context, projectID int, userID uuid.UUID, permission, resource string) (*models.PermissionExplanation, error)
+	// GrantPermissions grant.UserIDs のそれぞれに grant.Permissions を登録する。grant.ActorRole が持っていない権限は登録できない。
+	// 登録済みの組み合わせはスキップし、すべて登録済みの場合は AlreadyExist を返す
+	GrantPermissions(ctx context.Context, grant models.PermissionGrant) (*models.PermissionBatchResult, error)
+	// RevokePermissions resource と完全に一致する権限（allow, deny の両方）を削除する。1件も削除しなかった場合は NotFound を返す
+	RevokePermissions(ctx context.Context, projectID int, userIDs []uuid.UUID, permissions []string, resource string) (*models.PermissionBatchResult, error)
+	// CopyPermissions userID の権限を fromProjectID から toProjectID にコピーする。actorID は fromProjectID で permissions:read を持っている必要があり、
+	// コピーする権限はすべて toProjectID での actorRole が持っている必要がある。
+	// プロジェクト全体、またはワイルドカードのリソースの権限だけをコピーし、個別のIDを含むリソースの権限はスキップする
+	CopyPermissions(ctx context.Context, actorID uuid.UUID, actorRole string, userID uuid.UUID, fromProjectID, toProjectID int) (*models.PermissionBatchResult, error)
+	GetPermissionsByUser(ctx context.Context, projectID int, userID uuid.UUID) ([]*models.UserPermission, error)
+	// DeleteExpiredPermissions 期限切れの権限を削除し、削除した件数を返す
+	DeleteExpiredPermissions(ctx context.Context) (int, error)
 }
 
 type permissionUsecase struct {
 	permissionRepo repositories.PermissionRepository
+	memberRepo     repositories.ProjectMemberRepository
+	roleUsecase    RoleUsecase
 }
 
-func NewPermissionUsecase(permissionRepo repositories.PermissionRepository) PermissionUsecase {
+func NewPermissionUsecase(permissionRepo repositories.PermissionRepository, memberRepo repositories.ProjectMemberRepository, roleUsecase RoleUsecase) PermissionUsecase {
 	return &permissionUsecase{
 		permissionRepo: permissionRepo,
+		memberRepo:     memberRepo,
+		roleUsecase:    roleUsecase,
 	}
 }
 
-func (p *permissionUsecase) CheckPermission(ctx context.Context, userID uuid.UUID, permission, resource string) (bool, error) {
-	permissions, err := p.permissionRepo.FindByUserIDAndResource(ctx, userID.String(), resource)
+func (p *permissionUsecase) CheckPermission(ctx context.Context, projectID int, userID uuid.UUID, permission, resource string) (bool, error) {
+	explanation, err := p.ExplainPermission(ctx, projectID, userID, permission, resource)
 	if err != nil {
 		return false, myerrors.WrapDomainError("permissionUsecase.CheckPermission", err)
 	}
+	return explanation.Allowed, nil
+}
+
+// ExplainPermission resource を含む権限をすべて集め、禁止ルールが1つでもあれば拒否、
+// なければ許可する。どちらの場合も最も限定的なリソースの権限を根拠とする
+func (p *permissionUsecase) ExplainPermission(ctx context.Context, projectID int, userID uuid.UUID, permission, resource string) (*models.PermissionExplanation, error) {
+	explanation := &models.PermissionExplanation{
+		Permission: permission,
+		Resource:   resource,
+		Matches:    []*models.UserPermission{},
+	}
+	// プロジェクトに属さない操作には個別の権限は存在しない
+	if projectID == 0 {
+		return explanation, nil
+	}
+
+	target, err := scopeResource(projectID, resource)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("permissionUsecase.ExplainPermission", err)
+	}
+	explanation.Resource = target.String()
+
+	permissions, domainErr := p.permissionRepo.FindByUserIDAndPermission(ctx, projectID, userID.String(), permission)
+	if domainErr != nil {
+		return nil, myerrors.WrapDomainError("permissionUsecase.ExplainPermission", domainErr)
+	}
 
+	bestScore := -1
 	for _, perm := range permissions {
-		if perm.Permission == permission {
-			return true, nil
+		granted, err := perm.ParsedResource()
+		if err != nil || !granted.Covers(target) {
+			continue
+		}
+		explanation.Matches = append(explanation.Matches, perm)
+
+		// 禁止ルールは許可よりも優先し、同じ effect の中では限定的なリソースを優先する
+		score := granted.Specificity()
+		if perm.IsDeny() {
+			score += 1 << 16
+		}
+		if score > bestScore {
+			bestScore = score
+			explanation.Grant = perm
 		}
 	}
 
-	return false, nil
+	if explanation.Grant != nil {
+		explanation.Effect = explanation.Grant.Effect
+		explanation.Allowed = !explanation.Grant.IsDeny()
+	}
+	return explanation, nil
 }
 
-func (p *permissionUsecase) GrantPermission(ctx context.Context, userID uuid.UUID, permission, resource string) error {
-	// すでに権限があるかチェック
-	hasPermission, err := p.CheckPermission(ctx, userID, permission, resource)
+func (p *permissionUsecase) GrantPermissions(ctx context.Context, grant models.PermissionGrant) (*models.PermissionBatchResult, error) {
+	if grant.Effect == "" {
+		grant.Effect = models.PermissionEffectAllow
+	}
+	if grant.Effect != models.PermissionEffectAllow && grant.Effect != models.PermissionEffectDeny {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "effect は allow または deny を指定してください")
+	}
+	if grant.ExpiresAt != nil && !grant.ExpiresAt.After(time.Now()) {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "有効期限には未来の日時を指定してください")
+	}
+	permissions, err := validatePermissions(grant.Permissions)
 	if err != nil {
-		return myerrors.WrapDomainError("permissionUsecase.GrantPermission", err)
+		return nil, myerrors.WrapDomainError("permissionUsecase.GrantPermissions", err)
 	}
-	if hasPermission {
-		return myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "すでに権限が付与されています")
+	if err := p.roleUsecase.RequireHeldPermissions(ctx, grant.ProjectID, grant.ActorRole, permissions); err != nil {
+		return nil, myerrors.WrapDomainError("permissionUsecase.GrantPermissions", err)
 	}
-
-	// UserPermission を作成
-	userPerm := &models.UserPermission{
-		UserID:     userID,
-		Permission: permission,
-		Resource:   resource,
+	target, domainErr := scopeResource(grant.ProjectID, grant.Resource)
+	if domainErr != nil {
+		return nil, myerrors.WrapDomainError("permissionUsecase.GrantPermissions", domainErr)
+	}
+	userIDs, err := p.validateUsers(ctx, grant.ProjectID, grant.UserIDs)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("permissionUsecase.GrantPermissions", err)
 	}
 
-	// リポジトリで作成
-	if err := p.permissionRepo.Create(ctx, userPerm); err != nil {
-		return myerrors.WrapDomainError("permissionUsecase.GrantPermission", err)
+	rows := make([]*models.UserPermission, 0, len(userIDs)*len(permissions))
+	for _, userID := range userIDs {
+		for _, permission := range permissions {
+			row := &models.UserPermission{
+				UserID:     userID,
+				ProjectID:  grant.ProjectID,
+				Permission: permission,
+				Effect:     grant.Effect,
+				ExpiresAt:  grant.ExpiresAt,
+			}
+			row.SetResource(target)
+			rows = append(rows, row)
+		}
 	}
 
-	return nil
+	// 同じリソースに登録済みの権限（effect が異なるものを含む）はスキップされる
+	created, createErr := p.permissionRepo.CreateBatch(ctx, rows)
+	if createErr != nil {
+		return nil, myerrors.WrapDomainError("permissionUsecase.GrantPermissions", createErr)
+	}
+	if created == 0 {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.AlreadyExist, "すでに権限が登録されています。effect を変更する場合は先に削除してください")
+	}
+	return &models.PermissionBatchResult{Affected: created, Skipped: len(rows) - created}, nil
 }
 
-func (p *permissionUsecase) RevokePermission(ctx context.Context, userID uuid.UUID, permission, resource string) error {
-	// 権限を取得
-	permissions, err := p.permissionRepo.FindByUserIDAndResource(ctx, userID.String(), resource)
+func (p *permissionUsecase) RevokePermissions(ctx context.Context, projectID int, userIDs []uuid.UUID, permissions []string, resource string) (*models.PermissionBatchResult, error) {
+	if len(userIDs) == 0 || len(permissions) == 0 {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "ユーザーと権限を1つ以上指定してください")
+	}
+	target, err := scopeResource(projectID, resource)
 	if err != nil {
-		return myerrors.WrapDomainError("permissionUsecase.RevokePermission", err)
+		return nil, myerrors.WrapDomainError("permissionUsecase.RevokePermissions", err)
 	}
 
-	var permID string
-	for _, perm := range permissions {
-		if perm.Permission == permission {
-			permID = perm.ID.String()
-			break
+	ids := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		ids[i] = userID.String()
+	}
+	deleted, domainErr := p.permissionRepo.DeleteByResource(ctx, projectID, ids, permissions, target)
+	if domainErr != nil {
+		return nil, myerrors.WrapDomainError("permissionUsecase.RevokePermissions", domainErr)
+	}
+	if deleted == 0 {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "権限が見つかりません")
+	}
+	return &models.PermissionBatchResult{Affected: deleted}, nil
+}
+
+func (p *permissionUsecase) CopyPermissions(ctx context.Context, actorID uuid.UUID, actorRole string, userID uuid.UUID, fromProjectID, toProjectID int) (*models.PermissionBatchResult, error) {
+	if fromProjectID == toProjectID {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "コピー元とコピー先に同じプロジェクトは指定できません")
+	}
+
+	// コピー元のプロジェクトで権限を閲覧できるか確認する（コピー先の権限はルートで確認済み）
+	actor, domainErr := p.memberRepo.FindByProjectAndUser(ctx, fromProjectID, actorID)
+	if domainErr != nil {
+		if errors.Is(domainErr, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.UnPermittedOperation, "コピー元のプロジェクトのメンバーではありません")
 		}
+		return nil, myerrors.WrapDomainError("permissionUsecase.CopyPermissions", domainErr)
+	}
+	allowed, err := p.roleUsecase.HasPermission(ctx, fromProjectID, actor.Role, models.PermissionPermissionsRead)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("permissionUsecase.CopyPermissions", err)
+	}
+	if !allowed {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.UnPermittedOperation, "コピー元のプロジェクトの権限を閲覧できません")
+	}
+
+	if _, err := p.validateUsers(ctx, toProjectID, []uuid.UUID{userID}); err != nil {
+		return nil, myerrors.WrapDomainError("permissionUsecase.CopyPermissions", err)
 	}
 
-	if permID == "" {
-		return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "権限が見つかりません")
+	source, domainErr := p.permissionRepo.FindByUserID(ctx, fromProjectID, userID.String())
+	if domainErr != nil {
+		return nil, myerrors.WrapDomainError("permissionUsecase.CopyPermissions", domainErr)
 	}
 
-	// 削除
-	if err := p.permissionRepo.Delete(ctx, permID); err != nil {
-		return myerrors.WrapDomainError("permissionUsecase.RevokePermission", err)
+	result := &models.PermissionBatchResult{}
+	rows := make([]*models.UserPermission, 0, len(source))
+	for _, perm := range source {
+		resource, err := perm.ParsedResource()
+		// コレクションなどのIDはプロジェクトごとに異なるため、別のリソースを指してしまわないようにスキップする
+		if err != nil || !projectWide(resource) {
+			result.Skipped++
+			continue
+		}
+		resource[0].ID = strconv.Itoa(toProjectID)
+
+		row := &models.UserPermission{
+			UserID:     userID,
+			ProjectID:  toProjectID,
+			Permission: perm.Permission,
+			Effect:     perm.Effect,
+			ExpiresAt:  perm.ExpiresAt,
+		}
+		row.SetResource(resource)
+		rows = append(rows, row)
+	}
+
+	permissions := make([]string, len(rows))
+	for i, row := range rows {
+		permissions[i] = row.Permission
+	}
+	if err := p.roleUsecase.RequireHeldPermissions(ctx, toProjectID, actorRole, uniqueValues(permissions)); err != nil {
+		return nil, myerrors.WrapDomainError("permissionUsecase.CopyPermissions", err)
 	}
 
-	return nil
+	created, domainErr := p.permissionRepo.CreateBatch(ctx, rows)
+	if domainErr != nil {
+		return nil, myerrors.WrapDomainError("permissionUsecase.CopyPermissions", domainErr)
+	}
+	result.Affected = created
+	result.Skipped += len(rows) - created
+	return result, nil
 }
 
-func (p *permissionUsecase) GetPermissionsByUser(ctx context.Context, userID uuid.UUID) ([]*models.UserPermission, error) {
-	permissions, err := p.permissionRepo.FindByUserID(ctx, userID.String())
+func (p *permissionUsecase) GetPermissionsByUser(ctx context.Context, projectID int, userID uuid.UUID) ([]*models.UserPermission, error) {
+	permissions, err := p.permissionRepo.FindByUserID(ctx, projectID, userID.String())
 	if err != nil {
 		return nil, myerrors.WrapDomainError("permissionUsecase.GetPermissionsByUser", err)
 	}
 
 	return permissions, nil
 }
+
+func (p *permissionUsecase) DeleteExpiredPermissions(ctx context.Context) (int, error) {
+	deleted, err := p.permissionRepo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return 0, myerrors.WrapDomainError("permissionUsecase.DeleteExpiredPermissions", err)
+	}
+	return deleted, nil
+}
+
+// validateUsers 重複を取り除いたユーザーを返す。ユーザーはすべてプロジェクトのメンバーである必要がある
+func (p *permissionUsecase) validateUsers(ctx context.Context, projectID int, userIDs []uuid.UUID) ([]uuid.UUID, error) {
+	userIDs = uniqueValues(userIDs)
+	if len(userIDs) == 0 || len(userIDs) > maxPermissionBatchUsers {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "ユーザーは1人以上100人以下で指定してください")
+	}
+
+	members, err := p.memberRepo.FindByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, userID := range userIDs {
+		if !slices.ContainsFunc(members, func(m models.ProjectMemberDetail) bool { return m.UserID == userID }) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "プロジェクトのメンバーではないユーザーが含まれています")
+		}
+	}
+	return userIDs, nil
+}
+
+// validatePermissions 重複を取り除いた権限を返す。プロジェクトで使える権限だけを受け付ける
+func validatePermissions(permissions []string) ([]string, error) {
+	permissions = uniqueValues(permissions)
+	if len(permissions) == 0 || len(permissions) > maxPermissionBatchPermissions {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "権限は1個以上50個以下で指定してください")
+	}
+	for _, permission := range permissions {
+		if !models.IsProjectPermission(permission) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "存在しない権限です: "+permission)
+		}
+	}
+	return permissions, nil
+}
+
+func uniqueValues[T comparable](values []T) []T {
+	unique := make([]T, 0, len(values))
+	for _, v := range values {
+		if !slices.Contains(unique, v) {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// scopeResource resource を projectID のプロジェクト内のリソースとして読み取る。
+// 先頭の project を省略した場合は補い、別のプロジェクトのリソースは受け付けない
+func scopeResource(projectID int, resource string) (models.Resource, *myerrors.DomainError) {
+	parsed, err := models.ParseResource(resource)
+	if errors.Is(err, models.ErrInvalidResource) {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "リソースは \"project:1/collection:5/entry:*\" の形式で指定してください")
+	}
+
+	project := models.ResourceSegment{Type: "project", ID: strconv.Itoa(projectID)}
+	if len(parsed) == 0 || parsed[0].Type != project.Type {
+		return append(models.Resource{project}, parsed...), nil
+	}
+	if parsed[0].ID != project.ID {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "他のプロジェクトのリソースは指定できません")
+	}
+	return parsed, nil
+}
+
+// projectWide プロジェクトの後にワイルドカード以外のIDを含まないリソースかどうか
+func projectWide(resource models.Resource) bool {
+	if len(resource) == 0 || resource[0].Type != "project" {
+		return false
+	}
+	for _, segment := range resource[1:] {
+		if segment.ID != models.ResourceWildcard {
+			return false
+		}
+	}
+	return true
+}
+
+// StartExpiredPermissionSweep ctx がキャンセルされるまで interval ごとに期限切れの権限を削除する
+func StartExpiredPermissionSweep(ctx context.Context, permissionUsecase PermissionUsecase, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				deleted, err := permissionUsecase.DeleteExpiredPermissions(ctx)
+				if err != nil {
+					logger.Error("failed to delete expired permissions", "error", err.Error())
+					continue
+				}
+				if deleted > 0 {
+					logger.Info("deleted expired permissions", "count", deleted)
+				}
+			}
+		}
+	}()
+}
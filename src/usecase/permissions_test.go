@@ -3,6 +3,7 @@ package usecase_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
@@ -10,41 +11,56 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"w3st/domain/models"
+	myerrors "w3st/errors"
 	mockRepositories "w3st/mock/repositories"
 	"w3st/usecase"
 )
 
 const (
-	testPermissionRead   = "read"
-	testResourceDocument = "document"
+	testPermissionRead   = "entries:read"
+	testResourceDocument = "project:1/collection:5/entry:9"
 )
 
+// projectMembers プロジェクトのメンバー
+func projectMembers(userIDs ...uuid.UUID) []models.ProjectMemberDetail {
+	members := make([]models.ProjectMemberDetail, len(userIDs))
+	for i, userID := range userIDs {
+		members[i] = models.ProjectMemberDetail{UserID: userID, Role: models.ProjectRoleEditor}
+	}
+	return members
+}
+
+// newUserPermission リポジトリから読み込んだ状態の権限を作る
+func newUserPermission(t *testing.T, id int, userID uuid.UUID, permission, resource, effect string) *models.UserPermission {
+	t.Helper()
+	parsed, err := models.ParseResource(resource)
+	require.NoError(t, err)
+	perm := &models.UserPermission{ID: id, UserID: userID, ProjectID: 1, Permission: permission, Effect: effect}
+	perm.SetResource(parsed)
+	return perm
+}
+
 func TestPermissionUsecase_CheckPermission_HasPermission(t *testing.T) {
 	t.Parallel()
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
-	uc := usecase.NewPermissionUsecase(mockPermissionRepo)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
 
 	ctx := context.Background()
 	userID := uuid.New()
-	permission := testPermissionRead
-	resource := testResourceDocument
 
 	permissions := []*models.UserPermission{
-		{
-			UserID:     userID,
-			Permission: permission,
-			Resource:   resource,
-		},
+		newUserPermission(t, 1, userID, testPermissionRead, testResourceDocument, models.PermissionEffectAllow),
 	}
 
 	mockPermissionRepo.EXPECT().
-		FindByUserIDAndResource(ctx, userID.String(), resource).
+		FindByUserIDAndPermission(ctx, 1, userID.String(), testPermissionRead).
 		Return(permissions, nil)
 
-	hasPermission, err := uc.CheckPermission(ctx, userID, permission, resource)
+	hasPermission, err := uc.CheckPermission(ctx, 1, userID, testPermissionRead, testResourceDocument)
 
 	require.NoError(t, err)
 	assert.True(t, hasPermission)
@@ -56,121 +72,422 @@ func TestPermissionUsecase_CheckPermission_NoPermission(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
-	uc := usecase.NewPermissionUsecase(mockPermissionRepo)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
 
 	ctx := context.Background()
 	userID := uuid.New()
-	permission := "write"
-	resource := testResourceDocument
 
+	// 別のコレクションへの権限は一致しない
 	permissions := []*models.UserPermission{
-		{
-			UserID:     userID,
-			Permission: testPermissionRead,
-			Resource:   resource,
-		},
+		newUserPermission(t, 1, userID, testPermissionRead, "project:1/collection:6", models.PermissionEffectAllow),
 	}
 
 	mockPermissionRepo.EXPECT().
-		FindByUserIDAndResource(ctx, userID.String(), resource).
+		FindByUserIDAndPermission(ctx, 1, userID.String(), testPermissionRead).
 		Return(permissions, nil)
 
-	hasPermission, err := uc.CheckPermission(ctx, userID, permission, resource)
+	hasPermission, err := uc.CheckPermission(ctx, 1, userID, testPermissionRead, testResourceDocument)
 
 	require.NoError(t, err)
 	assert.False(t, hasPermission)
 }
 
-func TestPermissionUsecase_GrantPermission_Success(t *testing.T) {
+func TestPermissionUsecase_CheckPermission_WildcardAndPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		granted string
+		want    bool
+	}{
+		{name: "entry wildcard", granted: "project:1/collection:5/entry:*", want: true},
+		{name: "parent collection", granted: "project:1/collection:5", want: true},
+		{name: "trailing wildcard", granted: "project:1/*", want: true},
+		{name: "collection wildcard", granted: "project:1/collection:*/entry:9", want: true},
+		{name: "everything", granted: "*", want: true},
+		{name: "other project", granted: "project:2/collection:5", want: false},
+		{name: "deeper than target", granted: "project:1/collection:5/entry:9/field:title", want: false},
+		{name: "different type", granted: "project:1/media:5", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
+			mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+			uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
+
+			ctx := context.Background()
+			userID := uuid.New()
+
+			mockPermissionRepo.EXPECT().
+				FindByUserIDAndPermission(ctx, 1, userID.String(), testPermissionRead).
+				Return([]*models.UserPermission{
+					newUserPermission(t, 1, userID, testPermissionRead, tt.granted, models.PermissionEffectAllow),
+				}, nil)
+
+			hasPermission, err := uc.CheckPermission(ctx, 1, userID, testPermissionRead, testResourceDocument)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, hasPermission)
+		})
+	}
+}
+
+func TestPermissionUsecase_ExplainPermission_DenyOverridesAllow(t *testing.T) {
 	t.Parallel()
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
-	uc := usecase.NewPermissionUsecase(mockPermissionRepo)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
 
 	ctx := context.Background()
 	userID := uuid.New()
-	permission := testPermissionRead
-	resource := testResourceDocument
 
-	// CheckPermission が false を返す
+	// より限定的な allow があっても、上位のリソースの deny が優先される
+	deny := newUserPermission(t, 2, userID, testPermissionRead, "project:1/collection:5", models.PermissionEffectDeny)
 	mockPermissionRepo.EXPECT().
-		FindByUserIDAndResource(ctx, userID.String(), resource).
-		Return([]*models.UserPermission{}, nil)
+		FindByUserIDAndPermission(ctx, 1, userID.String(), testPermissionRead).
+		Return([]*models.UserPermission{
+			newUserPermission(t, 1, userID, testPermissionRead, testResourceDocument, models.PermissionEffectAllow),
+			deny,
+			newUserPermission(t, 3, userID, testPermissionRead, "project:2", models.PermissionEffectDeny),
+		}, nil)
+
+	explanation, err := uc.ExplainPermission(ctx, 1, userID, testPermissionRead, testResourceDocument)
 
+	require.NoError(t, err)
+	assert.False(t, explanation.Allowed)
+	assert.Equal(t, models.PermissionEffectDeny, explanation.Effect)
+	assert.Equal(t, deny, explanation.Grant)
+	assert.Len(t, explanation.Matches, 2)
+}
+
+func TestPermissionUsecase_ExplainPermission_MostSpecificAllow(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
+
+	ctx := context.Background()
+	userID := uuid.New()
+
+	specific := newUserPermission(t, 2, userID, testPermissionRead, "project:1/collection:5/entry:*", models.PermissionEffectAllow)
 	mockPermissionRepo.EXPECT().
-		Create(ctx, gomock.Any()).
-		Return(nil)
+		FindByUserIDAndPermission(ctx, 1, userID.String(), testPermissionRead).
+		Return([]*models.UserPermission{
+			newUserPermission(t, 1, userID, testPermissionRead, "project:1", models.PermissionEffectAllow),
+			specific,
+		}, nil)
 
-	err := uc.GrantPermission(ctx, userID, permission, resource)
+	explanation, err := uc.ExplainPermission(ctx, 1, userID, testPermissionRead, testResourceDocument)
 
 	require.NoError(t, err)
+	assert.True(t, explanation.Allowed)
+	assert.Equal(t, specific, explanation.Grant)
+}
+
+func TestPermissionUsecase_ExplainPermission_InvalidResource(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockRepositories.NewMockPermissionRepository(ctrl), mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
+
+	_, err := uc.ExplainPermission(context.Background(), 1, uuid.New(), testPermissionRead, "collection5")
+	assertErrType(t, err, myerrors.InvalidParameter)
+
+	// 別のプロジェクトのリソースは指定できない
+	_, err = uc.ExplainPermission(context.Background(), 1, uuid.New(), testPermissionRead, "project:2/collection:5")
+	assertErrType(t, err, myerrors.InvalidParameter)
 }
 
-func TestPermissionUsecase_GrantPermission_AlreadyExists(t *testing.T) {
+func TestPermissionUsecase_ExplainPermission_ResourceWithoutProject(t *testing.T) {
 	t.Parallel()
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
-	uc := usecase.NewPermissionUsecase(mockPermissionRepo)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
 
 	ctx := context.Background()
 	userID := uuid.New()
-	permission := testPermissionRead
-	resource := testResourceDocument
-
-	permissions := []*models.UserPermission{
-		{
-			UserID:     userID,
-			Permission: permission,
-			Resource:   resource,
-		},
-	}
 
+	// "collection:5" は操作中のプロジェクトのコレクションとして扱う
 	mockPermissionRepo.EXPECT().
-		FindByUserIDAndResource(ctx, userID.String(), resource).
-		Return(permissions, nil)
+		FindByUserIDAndPermission(ctx, 1, userID.String(), testPermissionRead).
+		Return([]*models.UserPermission{
+			newUserPermission(t, 1, userID, testPermissionRead, "project:1/collection:5", models.PermissionEffectAllow),
+		}, nil)
+
+	explanation, err := uc.ExplainPermission(ctx, 1, userID, testPermissionRead, "collection:5/entry:9")
+
+	require.NoError(t, err)
+	assert.True(t, explanation.Allowed)
+	assert.Equal(t, testResourceDocument, explanation.Resource)
+}
+
+func TestPermissionUsecase_GrantPermissions_Success(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
+
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+	expiresAt := time.Now().Add(24 * time.Hour)
 
-	err := uc.GrantPermission(ctx, userID, permission, resource)
+	mockMemberRepo.EXPECT().FindByProjectID(ctx, 1).Return(projectMembers(alice, bob), nil)
+	mockPermissionRepo.EXPECT().
+		CreateBatch(ctx, gomock.Any()).
+		DoAndReturn(func(_ context.Context, perms []*models.UserPermission) (int, *myerrors.DomainError) {
+			// 2人 × 2つの権限（重複は取り除く）
+			require.Len(t, perms, 4)
+			for _, perm := range perms {
+				assert.Equal(t, 1, perm.ProjectID)
+				assert.Equal(t, models.PermissionEffectAllow, perm.Effect)
+				assert.Equal(t, &expiresAt, perm.ExpiresAt)
+				// リソースは resource_type, resource_id に分けて保存される
+				require.NotNil(t, perm.ResourceType)
+				require.NotNil(t, perm.ResourceID)
+				assert.Equal(t, "project/collection/entry", *perm.ResourceType)
+				assert.Equal(t, "1/5/*", *perm.ResourceID)
+			}
+			// 1件は登録済み
+			return 3, nil
+		})
+
+	result, err := uc.GrantPermissions(ctx, models.PermissionGrant{
+		ProjectID:   1,
+		UserIDs:     []uuid.UUID{alice, bob, alice},
+		Permissions: []string{models.PermissionEntriesRead, models.PermissionEntriesWrite},
+		Resource:    "collection:5/entry:*",
+		ExpiresAt:   &expiresAt,
+		ActorRole:   models.ProjectRoleAdmin,
+	})
 
-	require.Error(t, err)
+	require.NoError(t, err)
+	assert.Equal(t, &models.PermissionBatchResult{Affected: 3, Skipped: 1}, result)
 }
 
-func TestPermissionUsecase_RevokePermission_Success(t *testing.T) {
+func TestPermissionUsecase_GrantPermissions_AlreadyExists(t *testing.T) {
 	t.Parallel()
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
-	uc := usecase.NewPermissionUsecase(mockPermissionRepo)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
 
 	ctx := context.Background()
 	userID := uuid.New()
-	permission := testPermissionRead
-	resource := testResourceDocument
 
-	permissions := []*models.UserPermission{
-		{
-			ID:         uuid.New(),
-			UserID:     userID,
-			Permission: permission,
-			Resource:   resource,
-		},
+	mockMemberRepo.EXPECT().FindByProjectID(ctx, 1).Return(projectMembers(userID), nil)
+	mockPermissionRepo.EXPECT().CreateBatch(ctx, gomock.Any()).Return(0, nil)
+
+	_, err := uc.GrantPermissions(ctx, models.PermissionGrant{
+		ProjectID:   1,
+		UserIDs:     []uuid.UUID{userID},
+		Permissions: []string{testPermissionRead},
+		Resource:    testResourceDocument,
+		Effect:      models.PermissionEffectDeny,
+		ActorRole:   models.ProjectRoleAdmin,
+	})
+
+	assertErrType(t, err, myerrors.AlreadyExist)
+}
+
+func TestPermissionUsecase_GrantPermissions_Invalid(t *testing.T) {
+	t.Parallel()
+
+	member := uuid.New()
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name  string
+		grant models.PermissionGrant
+	}{
+		{name: "not a member", grant: models.PermissionGrant{UserIDs: []uuid.UUID{uuid.New()}, Permissions: []string{testPermissionRead}, Resource: "*"}},
+		{name: "unknown permission", grant: models.PermissionGrant{UserIDs: []uuid.UUID{member}, Permissions: []string{"read"}, Resource: "*"}},
+		{name: "system permission", grant: models.PermissionGrant{UserIDs: []uuid.UUID{member}, Permissions: []string{models.PermissionUsersManage}, Resource: "*"}},
+		{name: "expired", grant: models.PermissionGrant{UserIDs: []uuid.UUID{member}, Permissions: []string{testPermissionRead}, Resource: "*", ExpiresAt: &past}},
+		{name: "other project", grant: models.PermissionGrant{UserIDs: []uuid.UUID{member}, Permissions: []string{testPermissionRead}, Resource: "project:2"}},
+		{name: "no users", grant: models.PermissionGrant{Permissions: []string{testPermissionRead}, Resource: "*"}},
 	}
 
-	mockPermissionRepo.EXPECT().
-		FindByUserIDAndResource(ctx, userID.String(), resource).
-		Return(permissions, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+			uc := usecase.NewPermissionUsecase(mockRepositories.NewMockPermissionRepository(ctrl), mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
+
+			mockMemberRepo.EXPECT().FindByProjectID(gomock.Any(), 1).Return(projectMembers(member), nil).AnyTimes()
+
+			tt.grant.ProjectID = 1
+			tt.grant.ActorRole = models.ProjectRoleOwner
+			_, err := uc.GrantPermissions(context.Background(), tt.grant)
+
+			assertErrType(t, err, myerrors.InvalidParameter)
+		})
+	}
+}
+
+func TestPermissionUsecase_GrantPermissions_PermissionNotHeld(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
+
+	// admin は permissions:write を持っているが、自分にない projects:write は付与できない
+	mockMemberRepo.EXPECT().FindByProjectID(gomock.Any(), 1).Return(projectMembers(uuid.New()), nil).AnyTimes()
+	mockPermissionRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := uc.GrantPermissions(context.Background(), models.PermissionGrant{
+		ProjectID:   1,
+		UserIDs:     []uuid.UUID{uuid.New()},
+		Permissions: []string{testPermissionRead, models.PermissionProjectsWrite},
+		Resource:    "*",
+		ActorRole:   models.ProjectRoleAdmin,
+	})
+
+	assertErrType(t, err, myerrors.UnPermittedOperation)
+}
+
+func TestPermissionUsecase_RevokePermissions_Success(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
+
+	ctx := context.Background()
+	userID := uuid.New()
+	resource, err := models.ParseResource(testResourceDocument)
+	require.NoError(t, err)
 
 	mockPermissionRepo.EXPECT().
-		Delete(ctx, permissions[0].ID.String()).
-		Return(nil)
+		DeleteByResource(ctx, 1, []string{userID.String()}, []string{testPermissionRead}, resource).
+		Return(1, nil)
 
-	err := uc.RevokePermission(ctx, userID, permission, resource)
+	result, err := uc.RevokePermissions(ctx, 1, []uuid.UUID{userID}, []string{testPermissionRead}, testResourceDocument)
 
 	require.NoError(t, err)
+	assert.Equal(t, 1, result.Affected)
+}
+
+func TestPermissionUsecase_RevokePermissions_NotFound(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
+
+	mockPermissionRepo.EXPECT().DeleteByResource(gomock.Any(), 1, gomock.Any(), gomock.Any(), gomock.Any()).Return(0, nil)
+
+	_, err := uc.RevokePermissions(context.Background(), 1, []uuid.UUID{uuid.New()}, []string{testPermissionRead}, testResourceDocument)
+
+	assertErrType(t, err, myerrors.QueryDataNotFoundError)
+}
+
+func TestPermissionUsecase_CopyPermissions_Success(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
+
+	ctx := context.Background()
+	actorID, userID := uuid.New(), uuid.New()
+
+	mockMemberRepo.EXPECT().FindByProjectAndUser(ctx, 1, actorID).Return(&models.ProjectMember{ProjectID: 1, UserID: actorID, Role: models.ProjectRoleAdmin}, nil)
+	mockMemberRepo.EXPECT().FindByProjectID(ctx, 2).Return(projectMembers(userID), nil)
+	mockPermissionRepo.EXPECT().FindByUserID(ctx, 1, userID.String()).Return([]*models.UserPermission{
+		newUserPermission(t, 1, userID, testPermissionRead, "project:1", models.PermissionEffectAllow),
+		newUserPermission(t, 2, userID, models.PermissionEntriesWrite, "project:1/collection:*/entry:*", models.PermissionEffectDeny),
+		// コレクションのIDはプロジェクトごとに異なるためコピーしない
+		newUserPermission(t, 3, userID, testPermissionRead, "project:1/collection:5", models.PermissionEffectAllow),
+	}, nil)
+	mockPermissionRepo.EXPECT().
+		CreateBatch(ctx, gomock.Any()).
+		DoAndReturn(func(_ context.Context, perms []*models.UserPermission) (int, *myerrors.DomainError) {
+			require.Len(t, perms, 2)
+			assert.Equal(t, "project:2", perms[0].Resource)
+			assert.Equal(t, "project:2/collection:*/entry:*", perms[1].Resource)
+			assert.Equal(t, models.PermissionEffectDeny, perms[1].Effect)
+			for _, perm := range perms {
+				assert.Equal(t, 2, perm.ProjectID)
+			}
+			return 2, nil
+		})
+
+	result, err := uc.CopyPermissions(ctx, actorID, models.ProjectRoleAdmin, userID, 1, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, &models.PermissionBatchResult{Affected: 2, Skipped: 1}, result)
+}
+
+func TestPermissionUsecase_CopyPermissions_SourceNotReadable(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockRepositories.NewMockPermissionRepository(ctrl), mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
+
+	ctx := context.Background()
+	actorID := uuid.New()
+
+	mockMemberRepo.EXPECT().FindByProjectAndUser(ctx, 1, actorID).Return(&models.ProjectMember{ProjectID: 1, UserID: actorID, Role: models.ProjectRoleViewer}, nil)
+
+	_, err := uc.CopyPermissions(ctx, actorID, models.ProjectRoleAdmin, uuid.New(), 1, 2)
+
+	assertErrType(t, err, myerrors.UnPermittedOperation)
+}
+
+func TestPermissionUsecase_CopyPermissions_PermissionNotHeld(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
+
+	ctx := context.Background()
+	actorID := uuid.New()
+
+	// コピー元のプロジェクトの owner でも、コピー先で admin なら projects:write はコピーできない
+	mockMemberRepo.EXPECT().FindByProjectAndUser(ctx, 1, actorID).Return(&models.ProjectMember{ProjectID: 1, UserID: actorID, Role: models.ProjectRoleOwner}, nil)
+	mockMemberRepo.EXPECT().FindByProjectID(ctx, 2).Return(projectMembers(actorID), nil)
+	mockPermissionRepo.EXPECT().FindByUserID(ctx, 1, actorID.String()).Return([]*models.UserPermission{
+		newUserPermission(t, 1, actorID, models.PermissionProjectsWrite, "project:1", models.PermissionEffectAllow),
+	}, nil)
+	mockPermissionRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := uc.CopyPermissions(ctx, actorID, models.ProjectRoleAdmin, actorID, 1, 2)
+
+	assertErrType(t, err, myerrors.UnPermittedOperation)
 }
 
 func TestPermissionUsecase_GetPermissionsByUser_Success(t *testing.T) {
@@ -179,25 +496,39 @@ func TestPermissionUsecase_GetPermissionsByUser_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
-	uc := usecase.NewPermissionUsecase(mockPermissionRepo)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
 
 	ctx := context.Background()
 	userID := uuid.New()
 
 	expectedPermissions := []*models.UserPermission{
-		{
-			UserID:     userID,
-			Permission: testPermissionRead,
-			Resource:   testResourceDocument,
-		},
+		newUserPermission(t, 1, userID, testPermissionRead, testResourceDocument, models.PermissionEffectAllow),
 	}
 
 	mockPermissionRepo.EXPECT().
-		FindByUserID(ctx, userID.String()).
+		FindByUserID(ctx, 1, userID.String()).
 		Return(expectedPermissions, nil)
 
-	permissions, err := uc.GetPermissionsByUser(ctx, userID)
+	permissions, err := uc.GetPermissionsByUser(ctx, 1, userID)
 
 	require.NoError(t, err)
 	assert.Equal(t, expectedPermissions, permissions)
 }
+
+func TestPermissionUsecase_DeleteExpiredPermissions(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPermissionRepo := mockRepositories.NewMockPermissionRepository(ctrl)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewPermissionUsecase(mockPermissionRepo, mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo))
+
+	mockPermissionRepo.EXPECT().DeleteExpired(gomock.Any(), gomock.Any()).Return(2, nil)
+
+	deleted, err := uc.DeleteExpiredPermissions(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+}
@@ -0,0 +1,244 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+)
+
+// DefaultPopulateMaxDepth populate で展開できる階層の数（POPULATE_MAX_DEPTH で変更できる）
+const DefaultPopulateMaxDepth = 3
+
+// populateMaxDepthFromEnv POPULATE_MAX_DEPTH から展開できる階層の数を読み込む。未設定・1未満の場合は既定値
+func populateMaxDepthFromEnv() int {
+	if v := os.Getenv("POPULATE_MAX_DEPTH"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return DefaultPopulateMaxDepth
+}
+
+// populateTree 展開するフィールドのキーと、その参照先でさらに展開するフィールド
+type populateTree map[string]populateTree
+
+// parsePopulate "author,tags.category" のような指定を populateTree にする
+func parsePopulate(populate string, maxDepth int) (populateTree, error) {
+	tree := populateTree{}
+	for _, path := range strings.Split(populate, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		keys := strings.Split(path, ".")
+		if len(keys) > maxDepth {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, fmt.Sprintf("populate は %d 階層まで指定できます（%s）", maxDepth, path))
+		}
+		node := tree
+		for _, key := range keys {
+			if key == "" {
+				return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, fmt.Sprintf("populate の指定が正しくありません（%s）", path))
+			}
+			if node[key] == nil {
+				node[key] = populateTree{}
+			}
+			node = node[key]
+		}
+	}
+	return tree, nil
+}
+
+// populateNode 展開中のエントリのデータ。ancestors は展開元をたどったエントリ（循環する参照を展開しない）
+type populateNode struct {
+	data      map[string]interface{}
+	ancestors map[int]bool
+}
+
+// populator 1回のリクエストで参照先のエントリを展開する
+type populator struct {
+	e         *entriesUsecase
+	projectId int
+	// collectionIds 展開できるコレクション（APIキーで利用できるコレクション）。nil の場合はプロジェクトのすべてのコレクション
+	collectionIds []int
+	fields        map[int][]models.FieldData
+}
+
+// populateEntries エントリの relation のフィールドの値（エントリのID）を、参照先のエントリ {"id", "collection_id", "data"} に置き換える。
+// 同じ階層の参照先はフィールドごとにまとめて読み込む。展開できない参照（利用できないコレクション・見つからないエントリ・循環する参照）は ID のまま残す
+func (e *entriesUsecase) populateEntries(entries []models.Entry, collectionId int, projectId int, collectionIds []int, populate string) error {
+	tree, err := parsePopulate(populate, e.populateMaxDepth)
+	if err != nil || len(tree) == 0 || len(entries) == 0 {
+		return err
+	}
+
+	nodes := make([]*populateNode, len(entries))
+	for i, entry := range entries {
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(entry.Data), &data); err != nil {
+			return myerrors.NewDomainError(myerrors.QueryError, err)
+		}
+		nodes[i] = &populateNode{data: data, ancestors: map[int]bool{entry.ID: true}}
+	}
+
+	p := &populator{e: e, projectId: projectId, collectionIds: collectionIds, fields: map[int][]models.FieldData{}}
+	if err := p.populateLevel(nodes, collectionId, tree, ""); err != nil {
+		return err
+	}
+
+	for i, node := range nodes {
+		dataBytes, err := json.Marshal(node.data)
+		if err != nil {
+			return myerrors.NewDomainError(myerrors.QueryError, err)
+		}
+		entries[i].Data = string(dataBytes)
+	}
+	return nil
+}
+
+// populateLevel collectionId のコレクションのエントリ（nodes）で、tree のフィールドを展開する。prefix はエラーメッセージ用のパス
+func (p *populator) populateLevel(nodes []*populateNode, collectionId int, tree populateTree, prefix string) error {
+	fields, err := p.collectionFields(collectionId)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(tree))
+	for key := range tree {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		idx := slices.IndexFunc(fields, func(field models.FieldData) bool { return field.FieldID == key })
+		if idx < 0 || fields[idx].FieldType != models.FieldTypeRelation || fields[idx].Relation == nil {
+			return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, fmt.Sprintf("populate の %s はリレーションのあるフィールドではありません", prefix+key))
+		}
+		relatedId := fields[idx].Relation.RelatedCollectionID
+
+		available, err := p.collectionAvailable(relatedId)
+		if err != nil {
+			return err
+		}
+		if !available {
+			continue
+		}
+
+		related, err := p.loadReferencedEntries(nodes, key, relatedId)
+		if err != nil {
+			return err
+		}
+
+		children := make([]*populateNode, 0)
+		for _, node := range nodes {
+			expand := func(value interface{}) interface{} {
+				if !isEntryId(value) {
+					return value
+				}
+				n, _ := numberValue(value)
+				entry, found := related[int(n)]
+				if !found || node.ancestors[entry.ID] {
+					return value
+				}
+				var data map[string]interface{}
+				if err := json.Unmarshal([]byte(entry.Data), &data); err != nil {
+					return value
+				}
+				ancestors := make(map[int]bool, len(node.ancestors)+1)
+				for id := range node.ancestors {
+					ancestors[id] = true
+				}
+				ancestors[entry.ID] = true
+				children = append(children, &populateNode{data: data, ancestors: ancestors})
+				return map[string]interface{}{"id": entry.ID, "collection_id": entry.CollectionID, "data": data}
+			}
+
+			switch value := node.data[key].(type) {
+			case []interface{}:
+				expanded := make([]interface{}, len(value))
+				for i, item := range value {
+					expanded[i] = expand(item)
+				}
+				node.data[key] = expanded
+			case nil:
+			default:
+				node.data[key] = expand(value)
+			}
+		}
+
+		if len(tree[key]) > 0 && len(children) > 0 {
+			if err := p.populateLevel(children, relatedId, tree[key], prefix+key+"."); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadReferencedEntries nodes の key のフィールドが参照しているエントリを1回のクエリで読み込む
+func (p *populator) loadReferencedEntries(nodes []*populateNode, key string, collectionId int) (map[int]models.Entry, error) {
+	seen := map[int]bool{}
+	ids := make([]int, 0)
+	collect := func(value interface{}) {
+		if n, _ := numberValue(value); isEntryId(value) && !seen[int(n)] {
+			seen[int(n)] = true
+			ids = append(ids, int(n))
+		}
+	}
+	for _, node := range nodes {
+		if items, ok := node.data[key].([]interface{}); ok {
+			for _, item := range items {
+				collect(item)
+			}
+		} else {
+			collect(node.data[key])
+		}
+	}
+
+	related := make(map[int]models.Entry, len(ids))
+	if len(ids) == 0 {
+		return related, nil
+	}
+	entries, err := p.e.entriesRepo.GetEntriesByIds(collectionId, p.projectId, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		related[entry.ID] = entry
+	}
+	return related, nil
+}
+
+// collectionFields コレクションのフィールド定義（同じリクエストでは1回だけ読み込む）
+func (p *populator) collectionFields(collectionId int) ([]models.FieldData, error) {
+	if fields, ok := p.fields[collectionId]; ok {
+		return fields, nil
+	}
+	fields, err := p.e.fieldRepo.GetFieldsByCollectionId(collectionId, p.projectId)
+	if err != nil {
+		return nil, err
+	}
+	p.fields[collectionId] = fields
+	return fields, nil
+}
+
+// collectionAvailable 参照先のコレクションを展開できるか（APIキーで利用でき、ゴミ箱にないか）
+func (p *populator) collectionAvailable(collectionId int) (bool, error) {
+	if p.collectionIds != nil && !slices.Contains(p.collectionIds, collectionId) {
+		return false, nil
+	}
+	if _, err := p.e.collectionsUsecase.GetCollectionsByCollectionId(collectionId, p.projectId); err != nil {
+		if errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
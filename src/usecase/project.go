@@ -2,27 +2,47 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"w3st/domain/models"
 	"w3st/domain/repositories"
 	myerrors "w3st/errors"
+
+	"github.com/google/uuid"
 )
 
 type ProjectUsecase interface {
 	GetProjectByID(ctx context.Context, id int) (*models.Project, error)
-	GetAllProjects(ctx context.Context) ([]models.Project, error)
-	CreateProject(ctx context.Context, name, description string, rateLimit int) (*models.Project, error)
+	// GetProjectsByUser ユーザーが参加しているプロジェクトを返す
+	GetProjectsByUser(ctx context.Context, userID uuid.UUID) ([]models.Project, error)
+	// CreateProject 作成したユーザーはプロジェクトの owner になる
+	CreateProject(ctx context.Context, ownerID uuid.UUID, name, description string, rateLimit int) (*models.Project, error)
 	UpdateProject(ctx context.Context, project *models.Project) error
 	GetRateLimitByProjectID(ctx context.Context, projectID int) (int, error)
+	// GetMembership メンバーでない場合は UnPermittedOperation を返す
+	GetMembership(ctx context.Context, projectID int, userID uuid.UUID) (*models.ProjectMember, error)
+	ListMembers(ctx context.Context, projectID int) ([]models.ProjectMemberDetail, error)
+	// InviteMember 登録済みのユーザーをメールアドレスで招待する。招待には members:write 権限が必要。
+	// システム管理者はメンバーでなくても owner として操作できる（RemoveMember も同じ）
+	InviteMember(ctx context.Context, projectID int, actorID uuid.UUID, email string, role string) (*models.ProjectMember, error)
+	// RemoveMember members:write 権限があれば他のメンバーを、なくても自分自身を削除できる
+	RemoveMember(ctx context.Context, projectID int, actorID uuid.UUID, userID uuid.UUID) error
 }
 
 type projectUsecase struct {
 	projectRepo repositories.ProjectRepository
+	memberRepo  repositories.ProjectMemberRepository
+	roleUsecase RoleUsecase
+	userRepo    repositories.UserRepository
 }
 
-func NewProjectUsecase(projectRepo repositories.ProjectRepository) ProjectUsecase {
+func NewProjectUsecase(projectRepo repositories.ProjectRepository, memberRepo repositories.ProjectMemberRepository, roleUsecase RoleUsecase, userRepo repositories.UserRepository) ProjectUsecase {
 	return &projectUsecase{
 		projectRepo: projectRepo,
+		memberRepo:  memberRepo,
+		roleUsecase: roleUsecase,
+		userRepo:    userRepo,
 	}
 }
 
@@ -35,28 +55,32 @@ func (u *projectUsecase) GetProjectByID(ctx context.Context, id int) (*models.Pr
 	return project, nil
 }
 
-func (u *projectUsecase) GetAllProjects(ctx context.Context) ([]models.Project, error) {
-	projects, err := u.projectRepo.FindAll(ctx)
+func (u *projectUsecase) GetProjectsByUser(ctx context.Context, userID uuid.UUID) ([]models.Project, error) {
+	projects, err := u.memberRepo.FindProjectsByUserID(ctx, userID)
 	if err != nil {
-		return nil, myerrors.WrapDomainError("projectUsecase.GetAllProjects", err)
+		return nil, myerrors.WrapDomainError("projectUsecase.GetProjectsByUser", err)
 	}
 
 	return projects, nil
 }
 
-func (u *projectUsecase) CreateProject(ctx context.Context, name, description string, rateLimit int) (*models.Project, error) {
+func (u *projectUsecase) CreateProject(ctx context.Context, ownerID uuid.UUID, name, description string, rateLimit int) (*models.Project, error) {
 	project := &models.Project{
 		Name:             name,
 		Description:      description,
 		RateLimitPerHour: rateLimit,
 	}
+	owner := &models.ProjectMember{
+		UserID:    ownerID,
+		Role:      models.ProjectRoleOwner,
+		InvitedBy: ownerID,
+	}
 
-	createdProject, err := u.projectRepo.Create(ctx, project)
-	if err != nil {
+	if err := u.memberRepo.CreateProjectWithOwner(ctx, project, owner); err != nil {
 		return nil, myerrors.WrapDomainError("projectUsecase.CreateProject", err)
 	}
 
-	return createdProject, nil
+	return project, nil
 }
 
 func (u *projectUsecase) UpdateProject(ctx context.Context, project *models.Project) error {
@@ -76,3 +100,123 @@ func (u *projectUsecase) GetRateLimitByProjectID(ctx context.Context, projectID
 
 	return project.RateLimitPerHour, nil
 }
+
+func (u *projectUsecase) GetMembership(ctx context.Context, projectID int, userID uuid.UUID) (*models.ProjectMember, error) {
+	member, err := u.memberRepo.FindByProjectAndUser(ctx, projectID, userID)
+	if err != nil {
+		// プロジェクトの存在を知られないよう、存在しない場合もメンバーでない場合と同じエラーにする
+		if errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.UnPermittedOperation, "このプロジェクトのメンバーではありません")
+		}
+		return nil, myerrors.WrapDomainError("projectUsecase.GetMembership", err)
+	}
+
+	return member, nil
+}
+
+func (u *projectUsecase) ListMembers(ctx context.Context, projectID int) ([]models.ProjectMemberDetail, error) {
+	members, err := u.memberRepo.FindByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("projectUsecase.ListMembers", err)
+	}
+
+	return members, nil
+}
+
+func (u *projectUsecase) InviteMember(ctx context.Context, projectID int, actorID uuid.UUID, email string, role string) (*models.ProjectMember, error) {
+	actor, err := u.actorMembership(ctx, projectID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.requireMembersWrite(ctx, actor, "メンバーを招待する権限がありません"); err != nil {
+		return nil, err
+	}
+	// owner を増やせるのは owner のみ
+	if role == models.ProjectRoleOwner && actor.Role != models.ProjectRoleOwner {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.UnPermittedOperation, "owner として招待できるのは owner のみです")
+	}
+	if err := u.roleUsecase.ValidateRole(ctx, projectID, role); err != nil {
+		return nil, myerrors.WrapDomainError("projectUsecase.InviteMember", err)
+	}
+
+	user, domainErr := u.userRepo.FindByEmail(ctx, strings.TrimSpace(email))
+	if domainErr != nil {
+		return nil, myerrors.WrapDomainError("projectUsecase.InviteMember", domainErr)
+	}
+
+	member := &models.ProjectMember{
+		ProjectID: projectID,
+		UserID:    user.ID,
+		Role:      role,
+		InvitedBy: actorID,
+	}
+	if domainErr := u.memberRepo.Create(ctx, member); domainErr != nil {
+		return nil, myerrors.WrapDomainError("projectUsecase.InviteMember", domainErr)
+	}
+
+	return member, nil
+}
+
+func (u *projectUsecase) RemoveMember(ctx context.Context, projectID int, actorID uuid.UUID, userID uuid.UUID) error {
+	actor, err := u.actorMembership(ctx, projectID, actorID)
+	if err != nil {
+		return err
+	}
+
+	target := actor
+	if userID != actorID {
+		if err := u.requireMembersWrite(ctx, actor, "メンバーを削除する権限がありません"); err != nil {
+			return err
+		}
+		member, domainErr := u.memberRepo.FindByProjectAndUser(ctx, projectID, userID)
+		if domainErr != nil {
+			return myerrors.WrapDomainError("projectUsecase.RemoveMember", domainErr)
+		}
+		target = member
+	}
+
+	if target.Role == models.ProjectRoleOwner {
+		if actor.Role != models.ProjectRoleOwner {
+			return myerrors.NewDomainErrorWithMessage(myerrors.UnPermittedOperation, "owner を削除できるのは owner のみです")
+		}
+		owners, domainErr := u.memberRepo.CountByRole(ctx, projectID, models.ProjectRoleOwner)
+		if domainErr != nil {
+			return myerrors.WrapDomainError("projectUsecase.RemoveMember", domainErr)
+		}
+		if owners <= 1 {
+			return myerrors.NewDomainErrorWithMessage(myerrors.UnPermittedOperation, "最後の owner は削除できません")
+		}
+	}
+
+	if domainErr := u.memberRepo.Delete(ctx, projectID, target.UserID); domainErr != nil {
+		return myerrors.WrapDomainError("projectUsecase.RemoveMember", domainErr)
+	}
+
+	return nil
+}
+
+// actorMembership 操作するユーザーのメンバー情報。
+// ProjectContextMiddleware と同じく、システム管理者はメンバーかどうかにかかわらず owner として扱う
+func (u *projectUsecase) actorMembership(ctx context.Context, projectID int, actorID uuid.UUID) (*models.ProjectMember, error) {
+	user, domainErr := u.userRepo.FindByID(ctx, actorID.String())
+	if domainErr != nil {
+		return nil, myerrors.WrapDomainError("projectUsecase.actorMembership", domainErr)
+	}
+	if user.Role == models.UserRoleAdmin {
+		return &models.ProjectMember{ProjectID: projectID, UserID: actorID, Role: models.ProjectRoleOwner}, nil
+	}
+
+	return u.GetMembership(ctx, projectID, actorID)
+}
+
+// requireMembersWrite actor のロールにメンバーを管理する権限があるか確認する
+func (u *projectUsecase) requireMembersWrite(ctx context.Context, actor *models.ProjectMember, message string) error {
+	allowed, err := u.roleUsecase.HasPermission(ctx, actor.ProjectID, actor.Role, models.PermissionMembersWrite)
+	if err != nil {
+		return myerrors.WrapDomainError("projectUsecase.requireMembersWrite", err)
+	}
+	if !allowed {
+		return myerrors.NewDomainErrorWithMessage(myerrors.UnPermittedOperation, message)
+	}
+	return nil
+}
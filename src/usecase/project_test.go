@@ -0,0 +1,239 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	mockRepositories "w3st/mock/repositories"
+	"w3st/usecase"
+)
+
+func memberNotFound() *myerrors.DomainError {
+	return myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "プロジェクトのメンバーが見つかりません")
+}
+
+func assertErrType(t *testing.T, err error, errType myerrors.ErrorType) {
+	t.Helper()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, &myerrors.DomainError{ErrType: errType})
+}
+
+func TestProjectUsecase_CreateProject_CreatorBecomesOwner(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewProjectUsecase(mockRepositories.NewMockProjectRepository(ctrl), mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo), mockRepositories.NewMockUserRepository(ctrl))
+
+	ownerID := uuid.New()
+
+	mockMemberRepo.EXPECT().CreateProjectWithOwner(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, project *models.Project, owner *models.ProjectMember) *myerrors.DomainError {
+			assert.Equal(t, "Blog", project.Name)
+			assert.Equal(t, ownerID, owner.UserID)
+			assert.Equal(t, models.ProjectRoleOwner, owner.Role)
+			project.ID = 7
+			return nil
+		})
+
+	project, err := uc.CreateProject(context.Background(), ownerID, "Blog", "", 1000)
+
+	require.NoError(t, err)
+	assert.Equal(t, 7, project.ID)
+}
+
+func TestProjectUsecase_GetMembership_NotMember(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewProjectUsecase(mockRepositories.NewMockProjectRepository(ctrl), mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo), mockRepositories.NewMockUserRepository(ctrl))
+
+	userID := uuid.New()
+	mockMemberRepo.EXPECT().FindByProjectAndUser(gomock.Any(), 1, userID).Return(nil, memberNotFound())
+
+	_, err := uc.GetMembership(context.Background(), 1, userID)
+
+	// メンバーでない場合は 404 ではなく 403 にする
+	assertErrType(t, err, myerrors.UnPermittedOperation)
+}
+
+func TestProjectUsecase_InviteMember(t *testing.T) {
+	t.Parallel()
+
+	actorID := uuid.New()
+	invitee := &models.Users{ID: uuid.New(), Email: "bob@example.com"}
+
+	tests := []struct {
+		name        string
+		actorRole   string
+		systemAdmin bool
+		role        string
+		wantErr     myerrors.ErrorType
+		invited     bool
+	}{
+		{name: "owner can invite editor", actorRole: models.ProjectRoleOwner, role: models.ProjectRoleEditor, invited: true},
+		{name: "admin can invite viewer", actorRole: models.ProjectRoleAdmin, role: models.ProjectRoleViewer, invited: true},
+		{name: "owner can invite owner", actorRole: models.ProjectRoleOwner, role: models.ProjectRoleOwner, invited: true},
+		{name: "admin cannot invite owner", actorRole: models.ProjectRoleAdmin, role: models.ProjectRoleOwner, wantErr: myerrors.UnPermittedOperation},
+		{name: "editor cannot invite", actorRole: models.ProjectRoleEditor, role: models.ProjectRoleViewer, wantErr: myerrors.UnPermittedOperation},
+		// システム管理者はメンバーでなくても owner として招待できる
+		{name: "system admin can invite owner", systemAdmin: true, role: models.ProjectRoleOwner, invited: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+			mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+			uc := usecase.NewProjectUsecase(mockRepositories.NewMockProjectRepository(ctrl), mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo), mockUserRepo)
+
+			if tt.systemAdmin {
+				mockUserRepo.EXPECT().FindByID(gomock.Any(), actorID.String()).Return(&models.Users{ID: actorID, Role: models.UserRoleAdmin}, nil)
+			} else {
+				mockUserRepo.EXPECT().FindByID(gomock.Any(), actorID.String()).Return(&models.Users{ID: actorID, Role: models.UserRoleUser}, nil)
+				mockMemberRepo.EXPECT().FindByProjectAndUser(gomock.Any(), 1, actorID).
+					Return(&models.ProjectMember{ProjectID: 1, UserID: actorID, Role: tt.actorRole}, nil)
+			}
+			if tt.invited {
+				mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "bob@example.com").Return(invitee, nil)
+				mockMemberRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			member, err := uc.InviteMember(context.Background(), 1, actorID, " bob@example.com ", tt.role)
+
+			if !tt.invited {
+				assertErrType(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, invitee.ID, member.UserID)
+			assert.Equal(t, tt.role, member.Role)
+			assert.Equal(t, actorID, member.InvitedBy)
+		})
+	}
+}
+
+func TestProjectUsecase_InviteMember_CustomRole(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	mockRoleRepo := mockRepositories.NewMockRoleRepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewProjectUsecase(mockRepositories.NewMockProjectRepository(ctrl), mockMemberRepo, usecase.NewRoleUsecase(mockRoleRepo, mockMemberRepo), mockUserRepo)
+
+	actorID := uuid.New()
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), actorID.String()).Return(&models.Users{ID: actorID, Role: models.UserRoleUser}, nil)
+	mockMemberRepo.EXPECT().FindByProjectAndUser(gomock.Any(), 1, actorID).
+		Return(&models.ProjectMember{ProjectID: 1, UserID: actorID, Role: models.ProjectRoleAdmin}, nil)
+	mockRoleRepo.EXPECT().FindByName(gomock.Any(), 1, "translator").
+		Return(&models.ProjectRole{ID: 3, ProjectID: 1, Name: "translator"}, nil)
+	mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "bob@example.com").Return(&models.Users{ID: uuid.New()}, nil)
+	mockMemberRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	member, err := uc.InviteMember(context.Background(), 1, actorID, "bob@example.com", "translator")
+
+	require.NoError(t, err)
+	assert.Equal(t, "translator", member.Role)
+}
+
+func TestProjectUsecase_InviteMember_UnknownRole(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	mockRoleRepo := mockRepositories.NewMockRoleRepository(ctrl)
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewProjectUsecase(mockRepositories.NewMockProjectRepository(ctrl), mockMemberRepo, usecase.NewRoleUsecase(mockRoleRepo, mockMemberRepo), mockUserRepo)
+
+	actorID := uuid.New()
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), actorID.String()).Return(&models.Users{ID: actorID, Role: models.UserRoleUser}, nil)
+	mockMemberRepo.EXPECT().FindByProjectAndUser(gomock.Any(), 1, actorID).
+		Return(&models.ProjectMember{ProjectID: 1, UserID: actorID, Role: models.ProjectRoleOwner}, nil)
+	mockRoleRepo.EXPECT().FindByName(gomock.Any(), 1, "superuser").
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "ロールが見つかりません"))
+
+	_, err := uc.InviteMember(context.Background(), 1, actorID, "bob@example.com", "superuser")
+
+	assertErrType(t, err, myerrors.InvalidParameter)
+}
+
+func TestProjectUsecase_RemoveMember(t *testing.T) {
+	t.Parallel()
+
+	actorID := uuid.New()
+	targetID := uuid.New()
+
+	tests := []struct {
+		name        string
+		actorRole   string
+		systemAdmin bool
+		target      uuid.UUID
+		targetRole  string
+		owners      int64
+		wantErr     myerrors.ErrorType
+		removed     bool
+	}{
+		{name: "admin removes editor", actorRole: models.ProjectRoleAdmin, target: targetID, targetRole: models.ProjectRoleEditor, removed: true},
+		{name: "viewer leaves project", actorRole: models.ProjectRoleViewer, target: actorID, removed: true},
+		{name: "editor cannot remove others", actorRole: models.ProjectRoleEditor, target: targetID, wantErr: myerrors.UnPermittedOperation},
+		{name: "admin cannot remove owner", actorRole: models.ProjectRoleAdmin, target: targetID, targetRole: models.ProjectRoleOwner, wantErr: myerrors.UnPermittedOperation},
+		{name: "owner removes another owner", actorRole: models.ProjectRoleOwner, target: targetID, targetRole: models.ProjectRoleOwner, owners: 2, removed: true},
+		{name: "last owner cannot leave", actorRole: models.ProjectRoleOwner, target: actorID, owners: 1, wantErr: myerrors.UnPermittedOperation},
+		// システム管理者はメンバーでなくても owner として削除できる
+		{name: "system admin removes owner", systemAdmin: true, target: targetID, targetRole: models.ProjectRoleOwner, owners: 2, removed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+			mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+			uc := usecase.NewProjectUsecase(mockRepositories.NewMockProjectRepository(ctrl), mockMemberRepo, usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockMemberRepo), mockUserRepo)
+
+			if tt.systemAdmin {
+				mockUserRepo.EXPECT().FindByID(gomock.Any(), actorID.String()).Return(&models.Users{ID: actorID, Role: models.UserRoleAdmin}, nil)
+			} else {
+				mockUserRepo.EXPECT().FindByID(gomock.Any(), actorID.String()).Return(&models.Users{ID: actorID, Role: models.UserRoleUser}, nil)
+				mockMemberRepo.EXPECT().FindByProjectAndUser(gomock.Any(), 1, actorID).
+					Return(&models.ProjectMember{ProjectID: 1, UserID: actorID, Role: tt.actorRole}, nil)
+			}
+			if tt.target != actorID && tt.targetRole != "" {
+				mockMemberRepo.EXPECT().FindByProjectAndUser(gomock.Any(), 1, tt.target).
+					Return(&models.ProjectMember{ProjectID: 1, UserID: tt.target, Role: tt.targetRole}, nil)
+			}
+			if tt.owners > 0 {
+				mockMemberRepo.EXPECT().CountByRole(gomock.Any(), 1, models.ProjectRoleOwner).Return(tt.owners, nil)
+			}
+			if tt.removed {
+				mockMemberRepo.EXPECT().Delete(gomock.Any(), 1, tt.target).Return(nil)
+			}
+
+			err := uc.RemoveMember(context.Background(), 1, actorID, tt.target)
+
+			if !tt.removed {
+				assertErrType(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+)
+
+type RelationsUsecase interface {
+	GetRelations(ctx context.Context, projectId int, collectionId int) ([]models.ApiKindRelation, error)
+	// CreateRelation relation 型のフィールドに参照先のコレクションを設定する。OnDelete を省略した場合は restrict
+	CreateRelation(ctx context.Context, projectId int, relation *models.ApiKindRelation) error
+	// UpdateRelationOnDelete 参照先のエントリを削除したときの扱いだけを変更できる
+	UpdateRelationOnDelete(ctx context.Context, projectId int, collectionId int, relationId int, onDelete string) (*models.ApiKindRelation, error)
+	DeleteRelation(ctx context.Context, projectId int, collectionId int, relationId int) error
+	// GetReferences エントリを参照しているエントリをリレーションごとに返す
+	GetReferences(ctx context.Context, projectId int, collectionId int, entryId int) ([]models.EntryReferences, error)
+}
+
+type relationsUsecase struct {
+	relationRepo    repositories.RelationRepository
+	fieldRepo       repositories.FieldRepository
+	collectionsRepo repositories.CollectionsRepository
+	entriesRepo     repositories.EntriesRepository
+}
+
+func NewRelationsUsecase(relationRepo repositories.RelationRepository, fieldRepo repositories.FieldRepository, collectionsRepo repositories.CollectionsRepository, entriesRepo repositories.EntriesRepository) RelationsUsecase {
+	return &relationsUsecase{
+		relationRepo:    relationRepo,
+		fieldRepo:       fieldRepo,
+		collectionsRepo: collectionsRepo,
+		entriesRepo:     entriesRepo,
+	}
+}
+
+func (r *relationsUsecase) GetRelations(ctx context.Context, projectId int, collectionId int) ([]models.ApiKindRelation, error) {
+	if _, err := r.collectionsRepo.GetCollectionsByCollectionId(collectionId, projectId); err != nil {
+		return nil, myerrors.WrapDomainError("relationsUsecase.GetRelations", err)
+	}
+	relations, err := r.relationRepo.GetRelationsByCollectionId(ctx, collectionId)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("relationsUsecase.GetRelations", err)
+	}
+	return relations, nil
+}
+
+func (r *relationsUsecase) CreateRelation(ctx context.Context, projectId int, relation *models.ApiKindRelation) error {
+	if relation.OnDelete == "" {
+		relation.OnDelete = models.RelationOnDeleteRestrict
+	}
+	if !models.ValidRelationType(relation.RelationType) {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "relation_type は one-to-many か many-to-many を指定してください")
+	}
+	if !models.ValidRelationOnDelete(relation.OnDelete) {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "on_delete は restrict, cascade, set-null のいずれかを指定してください")
+	}
+
+	if _, err := r.collectionsRepo.GetCollectionsByCollectionId(relation.CollectionID, projectId); err != nil {
+		return myerrors.WrapDomainError("relationsUsecase.CreateRelation", err)
+	}
+	field, err := r.fieldRepo.GetField(relation.CollectionID, projectId, relation.FieldID)
+	if err != nil {
+		return myerrors.WrapDomainError("relationsUsecase.CreateRelation", err)
+	}
+	if field.FieldType != models.FieldTypeRelation {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "リレーションは relation 型のフィールドにだけ設定できます")
+	}
+	if err := checkRelationOnDelete(field, relation.OnDelete); err != nil {
+		return err
+	}
+	// 参照先は同じプロジェクトのコレクションだけ
+	if _, err := r.collectionsRepo.GetCollectionsByCollectionId(relation.RelatedCollectionID, projectId); err != nil {
+		return myerrors.WrapDomainError("relationsUsecase.CreateRelation", err)
+	}
+
+	if err := r.relationRepo.CreateRelation(ctx, relation); err != nil {
+		return myerrors.WrapDomainError("relationsUsecase.CreateRelation", err)
+	}
+	relation.FieldKey = field.FieldID
+	return nil
+}
+
+func (r *relationsUsecase) UpdateRelationOnDelete(ctx context.Context, projectId int, collectionId int, relationId int, onDelete string) (*models.ApiKindRelation, error) {
+	if !models.ValidRelationOnDelete(onDelete) {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "on_delete は restrict, cascade, set-null のいずれかを指定してください")
+	}
+	relation, field, err := r.relationField(ctx, projectId, collectionId, relationId)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("relationsUsecase.UpdateRelationOnDelete", err)
+	}
+	if err := checkRelationOnDelete(field, onDelete); err != nil {
+		return nil, err
+	}
+
+	relation.OnDelete = onDelete
+	if err := r.relationRepo.UpdateRelationOnDelete(ctx, relation); err != nil {
+		return nil, myerrors.WrapDomainError("relationsUsecase.UpdateRelationOnDelete", err)
+	}
+	return relation, nil
+}
+
+func (r *relationsUsecase) DeleteRelation(ctx context.Context, projectId int, collectionId int, relationId int) error {
+	if _, _, err := r.relationField(ctx, projectId, collectionId, relationId); err != nil {
+		return myerrors.WrapDomainError("relationsUsecase.DeleteRelation", err)
+	}
+	if err := r.relationRepo.DeleteRelation(ctx, collectionId, relationId); err != nil {
+		return myerrors.WrapDomainError("relationsUsecase.DeleteRelation", err)
+	}
+	return nil
+}
+
+func (r *relationsUsecase) GetReferences(ctx context.Context, projectId int, collectionId int, entryId int) ([]models.EntryReferences, error) {
+	if _, err := r.collectionsRepo.GetCollectionsByCollectionId(collectionId, projectId); err != nil {
+		return nil, myerrors.WrapDomainError("relationsUsecase.GetReferences", err)
+	}
+	entry, err := r.entriesRepo.GetEntryByIdAndProjectId(entryId, projectId)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("relationsUsecase.GetReferences", err)
+	}
+	if entry.CollectionID != collectionId {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "Entry not found in this collection")
+	}
+
+	relations, err := r.relationRepo.GetRelationsByRelatedCollectionId(ctx, collectionId)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("relationsUsecase.GetReferences", err)
+	}
+	references := make([]models.EntryReferences, 0, len(relations))
+	for _, relation := range relations {
+		entries, err := r.entriesRepo.GetReferencingEntries(ctx, relation.CollectionID, relation.FieldKey, entryId)
+		if err != nil {
+			return nil, myerrors.WrapDomainError("relationsUsecase.GetReferences", err)
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		references = append(references, models.EntryReferences{Relation: relation, Entries: entries})
+	}
+	return references, nil
+}
+
+// relationField プロジェクトのコレクションのリレーションと、リレーションを設定したフィールドを返す
+func (r *relationsUsecase) relationField(ctx context.Context, projectId int, collectionId int, relationId int) (*models.ApiKindRelation, *models.FieldData, error) {
+	if _, err := r.collectionsRepo.GetCollectionsByCollectionId(collectionId, projectId); err != nil {
+		return nil, nil, err
+	}
+	relation, err := r.relationRepo.GetRelation(ctx, collectionId, relationId)
+	if err != nil {
+		return nil, nil, err
+	}
+	field, err := r.fieldRepo.GetField(collectionId, projectId, relation.FieldID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return relation, field, nil
+}
+
+// checkRelationOnDelete 必須のフィールドは参照を外すと必須の値がなくなるため、set-null を使えない
+func checkRelationOnDelete(field *models.FieldData, onDelete string) error {
+	if onDelete == models.RelationOnDeleteSetNull && field.IsRequired {
+		return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "必須のフィールドのリレーションには set-null を使えません")
+	}
+	return nil
+}
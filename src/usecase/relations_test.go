@@ -0,0 +1,143 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	mockRepositories "w3st/mock/repositories"
+	"w3st/usecase"
+)
+
+func TestRelationsUsecase_CreateRelation(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRelationRepo := mockRepositories.NewMockRelationRepository(ctrl)
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	uc := usecase.NewRelationsUsecase(mockRelationRepo, mockFieldRepo, mockCollectionsRepo, mockRepositories.NewMockEntriesRepository(ctrl))
+
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(4, 1).Return(&models.ApiCollection{ID: 4}, nil)
+	mockFieldRepo.EXPECT().GetField(3, 1, 7).Return(&models.FieldData{ID: 7, FieldID: "author", FieldType: models.FieldTypeRelation}, nil)
+	mockRelationRepo.EXPECT().CreateRelation(gomock.Any(), gomock.Any()).Return(nil)
+
+	relation := &models.ApiKindRelation{CollectionID: 3, FieldID: 7, RelatedCollectionID: 4, RelationType: models.RelationTypeOneToMany}
+	require.NoError(t, uc.CreateRelation(context.Background(), 1, relation))
+
+	// on_delete を省略すると restrict
+	assert.Equal(t, models.RelationOnDeleteRestrict, relation.OnDelete)
+	assert.Equal(t, "author", relation.FieldKey)
+}
+
+func TestRelationsUsecase_CreateRelation_InvalidField(t *testing.T) {
+	tests := []struct {
+		name  string
+		field models.FieldData
+	}{
+		{name: "relation 型でない", field: models.FieldData{ID: 7, FieldID: "title", FieldType: models.FieldTypeText}},
+		{name: "必須のフィールドに set-null", field: models.FieldData{ID: 7, FieldID: "author", FieldType: models.FieldTypeRelation, IsRequired: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRelationRepo := mockRepositories.NewMockRelationRepository(ctrl)
+			mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+			mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+			uc := usecase.NewRelationsUsecase(mockRelationRepo, mockFieldRepo, mockCollectionsRepo, mockRepositories.NewMockEntriesRepository(ctrl))
+
+			mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+			mockFieldRepo.EXPECT().GetField(3, 1, 7).Return(&tt.field, nil)
+			mockRelationRepo.EXPECT().CreateRelation(gomock.Any(), gomock.Any()).Times(0)
+
+			relation := &models.ApiKindRelation{CollectionID: 3, FieldID: 7, RelatedCollectionID: 4, RelationType: models.RelationTypeOneToMany, OnDelete: models.RelationOnDeleteSetNull}
+			err := uc.CreateRelation(context.Background(), 1, relation)
+			assertErrType(t, err, myerrors.InvalidParameter)
+		})
+	}
+}
+
+func TestRelationsUsecase_CreateRelation_InvalidType(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	uc := usecase.NewRelationsUsecase(mockRepositories.NewMockRelationRepository(ctrl), mockRepositories.NewMockFieldRepository(ctrl), mockRepositories.NewMockCollectionsRepository(ctrl), mockRepositories.NewMockEntriesRepository(ctrl))
+
+	err := uc.CreateRelation(context.Background(), 1, &models.ApiKindRelation{CollectionID: 3, FieldID: 7, RelatedCollectionID: 4, RelationType: "oneToOne"})
+	assertErrType(t, err, myerrors.InvalidParameter)
+}
+
+func TestRelationsUsecase_UpdateRelationOnDelete_RequiredField(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRelationRepo := mockRepositories.NewMockRelationRepository(ctrl)
+	mockFieldRepo := mockRepositories.NewMockFieldRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	uc := usecase.NewRelationsUsecase(mockRelationRepo, mockFieldRepo, mockCollectionsRepo, mockRepositories.NewMockEntriesRepository(ctrl))
+
+	// 必須のフィールドのリレーションは、あとから set-null に変えることもできない
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(3, 1).Return(&models.ApiCollection{ID: 3}, nil)
+	mockRelationRepo.EXPECT().GetRelation(gomock.Any(), 3, 5).Return(&models.ApiKindRelation{ID: 5, CollectionID: 3, FieldID: 7, OnDelete: models.RelationOnDeleteRestrict}, nil)
+	mockFieldRepo.EXPECT().GetField(3, 1, 7).Return(&models.FieldData{ID: 7, FieldID: "author", FieldType: models.FieldTypeRelation, IsRequired: true}, nil)
+	mockRelationRepo.EXPECT().UpdateRelationOnDelete(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := uc.UpdateRelationOnDelete(context.Background(), 1, 3, 5, models.RelationOnDeleteSetNull)
+	assertErrType(t, err, myerrors.InvalidParameter)
+}
+
+func TestRelationsUsecase_GetReferences(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRelationRepo := mockRepositories.NewMockRelationRepository(ctrl)
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	uc := usecase.NewRelationsUsecase(mockRelationRepo, mockRepositories.NewMockFieldRepository(ctrl), mockCollectionsRepo, mockEntriesRepo)
+
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(4, 1).Return(&models.ApiCollection{ID: 4}, nil)
+	mockEntriesRepo.EXPECT().GetEntryByIdAndProjectId(10, 1).Return(&models.Entry{ID: 10, ProjectID: 1, CollectionID: 4}, nil)
+	mockRelationRepo.EXPECT().GetRelationsByRelatedCollectionId(gomock.Any(), 4).Return([]models.ApiKindRelation{
+		{ID: 1, CollectionID: 3, FieldKey: "author"},
+		{ID: 2, CollectionID: 5, FieldKey: "reviewer"},
+	}, nil)
+	mockEntriesRepo.EXPECT().GetReferencingEntries(gomock.Any(), 3, "author", 10).Return([]models.Entry{{ID: 20}, {ID: 21}}, nil)
+	mockEntriesRepo.EXPECT().GetReferencingEntries(gomock.Any(), 5, "reviewer", 10).Return(nil, nil)
+
+	references, err := uc.GetReferences(context.Background(), 1, 4, 10)
+	require.NoError(t, err)
+
+	// 参照しているエントリのないリレーションは返さない
+	require.Len(t, references, 1)
+	assert.Equal(t, 1, references[0].Relation.ID)
+	assert.Len(t, references[0].Entries, 2)
+}
+
+func TestRelationsUsecase_GetReferences_OtherCollection(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCollectionsRepo := mockRepositories.NewMockCollectionsRepository(ctrl)
+	mockEntriesRepo := mockRepositories.NewMockEntriesRepository(ctrl)
+	uc := usecase.NewRelationsUsecase(mockRepositories.NewMockRelationRepository(ctrl), mockRepositories.NewMockFieldRepository(ctrl), mockCollectionsRepo, mockEntriesRepo)
+
+	mockCollectionsRepo.EXPECT().GetCollectionsByCollectionId(4, 1).Return(&models.ApiCollection{ID: 4}, nil)
+	mockEntriesRepo.EXPECT().GetEntryByIdAndProjectId(10, 1).Return(&models.Entry{ID: 10, ProjectID: 1, CollectionID: 3}, nil)
+
+	_, err := uc.GetReferences(context.Background(), 1, 4, 10)
+	assertErrType(t, err, myerrors.QueryDataNotFoundError)
+}
@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+)
+
+// roleNameMaxLength project_roles.name の最大長
+const roleNameMaxLength = 50
+
+// RoleUsecase 組み込みロールとプロジェクトのカスタムロールを扱う
+type RoleUsecase interface {
+	// ListRoles 組み込みロールとプロジェクトのカスタムロールを返す
+	ListRoles(ctx context.Context, projectID int) ([]models.ProjectRole, error)
+	// CreateRole actorRole（操作するユーザーのロール）が持っていない権限を含むロールは作成できない
+	CreateRole(ctx context.Context, projectID int, actorRole string, name, description string, permissions []string) (*models.ProjectRole, error)
+	// DeleteRole メンバーに割り当てられているロールは削除できない
+	DeleteRole(ctx context.Context, projectID int, roleID int) error
+	// HasPermission ロールに permission が含まれるか確認する。存在しないロールは権限なしとして扱う
+	HasPermission(ctx context.Context, projectID int, role string, permission string) (bool, error)
+	// ValidateRole 組み込みロールか、プロジェクトに定義されたカスタムロールであることを確認する
+	ValidateRole(ctx context.Context, projectID int, role string) error
+	// RequireHeldPermissions actorRole が permissions をすべて持っているか確認する。
+	// 自分が持っていない権限をロールや個別の権限で与えられないようにする（owner は確認しない）
+	RequireHeldPermissions(ctx context.Context, projectID int, actorRole string, permissions []string) error
+}
+
+type roleUsecase struct {
+	roleRepo   repositories.RoleRepository
+	memberRepo repositories.ProjectMemberRepository
+}
+
+func NewRoleUsecase(roleRepo repositories.RoleRepository, memberRepo repositories.ProjectMemberRepository) RoleUsecase {
+	return &roleUsecase{
+		roleRepo:   roleRepo,
+		memberRepo: memberRepo,
+	}
+}
+
+func (u *roleUsecase) ListRoles(ctx context.Context, projectID int) ([]models.ProjectRole, error) {
+	roles := make([]models.ProjectRole, 0, len(models.BuiltinRoleNames))
+	for _, name := range models.BuiltinRoleNames {
+		permissions, _ := models.BuiltinRolePermissions(name)
+		roles = append(roles, models.ProjectRole{
+			ProjectID:   projectID,
+			Name:        name,
+			Permissions: permissions,
+			BuiltIn:     true,
+		})
+	}
+
+	custom, err := u.roleRepo.FindByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("roleUsecase.ListRoles", err)
+	}
+
+	return append(roles, custom...), nil
+}
+
+func (u *roleUsecase) CreateRole(ctx context.Context, projectID int, actorRole string, name, description string, permissions []string) (*models.ProjectRole, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > roleNameMaxLength {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "ロール名は1〜50文字で指定してください")
+	}
+	if models.IsBuiltinRole(name) {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "組み込みロールと同じ名前は使えません")
+	}
+	if len(permissions) == 0 {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "権限を1つ以上指定してください")
+	}
+
+	unique := make([]string, 0, len(permissions))
+	seen := make(map[string]struct{}, len(permissions))
+	for _, permission := range permissions {
+		if !models.IsProjectPermission(permission) {
+			return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "存在しない権限です: "+permission)
+		}
+		if _, ok := seen[permission]; ok {
+			continue
+		}
+		seen[permission] = struct{}{}
+		unique = append(unique, permission)
+	}
+	if err := u.RequireHeldPermissions(ctx, projectID, actorRole, unique); err != nil {
+		return nil, err
+	}
+
+	role := &models.ProjectRole{
+		ProjectID:   projectID,
+		Name:        name,
+		Description: description,
+		Permissions: unique,
+	}
+	if err := u.roleRepo.Create(ctx, role); err != nil {
+		return nil, myerrors.WrapDomainError("roleUsecase.CreateRole", err)
+	}
+
+	return role, nil
+}
+
+func (u *roleUsecase) DeleteRole(ctx context.Context, projectID int, roleID int) error {
+	role, err := u.roleRepo.FindByID(ctx, projectID, roleID)
+	if err != nil {
+		return myerrors.WrapDomainError("roleUsecase.DeleteRole", err)
+	}
+
+	members, err := u.memberRepo.CountByRole(ctx, projectID, role.Name)
+	if err != nil {
+		return myerrors.WrapDomainError("roleUsecase.DeleteRole", err)
+	}
+	if members > 0 {
+		return myerrors.NewDomainErrorWithMessage(myerrors.UnPermittedOperation, "このロールが割り当てられたメンバーがいるため削除できません")
+	}
+
+	if err := u.roleRepo.Delete(ctx, projectID, roleID); err != nil {
+		return myerrors.WrapDomainError("roleUsecase.DeleteRole", err)
+	}
+
+	return nil
+}
+
+func (u *roleUsecase) HasPermission(ctx context.Context, projectID int, role string, permission string) (bool, error) {
+	if permissions, ok := models.BuiltinRolePermissions(role); ok {
+		return slices.Contains(permissions, permission), nil
+	}
+
+	custom, err := u.roleRepo.FindByName(ctx, projectID, role)
+	if err != nil {
+		if errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return false, nil
+		}
+		return false, myerrors.WrapDomainError("roleUsecase.HasPermission", err)
+	}
+
+	return custom.HasPermission(permission), nil
+}
+
+func (u *roleUsecase) ValidateRole(ctx context.Context, projectID int, role string) error {
+	if models.IsBuiltinRole(role) {
+		return nil
+	}
+	if _, err := u.roleRepo.FindByName(ctx, projectID, role); err != nil {
+		if errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "ロールが存在しません")
+		}
+		return myerrors.WrapDomainError("roleUsecase.ValidateRole", err)
+	}
+	return nil
+}
+
+func (u *roleUsecase) RequireHeldPermissions(ctx context.Context, projectID int, actorRole string, permissions []string) error {
+	if actorRole == models.ProjectRoleOwner {
+		return nil
+	}
+
+	held, ok := models.BuiltinRolePermissions(actorRole)
+	if !ok {
+		custom, err := u.roleRepo.FindByName(ctx, projectID, actorRole)
+		if err != nil && !errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return myerrors.WrapDomainError("roleUsecase.RequireHeldPermissions", err)
+		}
+		if custom != nil {
+			held = custom.Permissions
+		}
+	}
+
+	for _, permission := range permissions {
+		if !slices.Contains(held, permission) {
+			return myerrors.NewDomainErrorWithMessage(myerrors.UnPermittedOperation, "自分のロールにない権限は与えられません: "+permission)
+		}
+	}
+	return nil
+}
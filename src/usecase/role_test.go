@@ -0,0 +1,191 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	mockRepositories "w3st/mock/repositories"
+	"w3st/usecase"
+)
+
+func TestRoleUsecase_HasPermission_Builtin(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	uc := usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockRepositories.NewMockProjectMemberRepository(ctrl))
+
+	tests := []struct {
+		role       string
+		permission string
+		want       bool
+	}{
+		{models.ProjectRoleOwner, models.PermissionProjectsWrite, true},
+		{models.ProjectRoleAdmin, models.PermissionProjectsWrite, false},
+		{models.ProjectRoleAdmin, models.PermissionMembersWrite, true},
+		{models.ProjectRoleEditor, models.PermissionEntriesWrite, true},
+		{models.ProjectRoleEditor, models.PermissionCollectionsWrite, false},
+		{models.ProjectRoleAuthor, models.PermissionEntriesCreate, true},
+		{models.ProjectRoleAuthor, models.PermissionEntriesWrite, false},
+		{models.ProjectRoleViewer, models.PermissionEntriesRead, true},
+		{models.ProjectRoleViewer, models.PermissionMediaWrite, false},
+		// システム管理者のみの権限はどのロールにも含まれない
+		{models.ProjectRoleOwner, models.PermissionUsersManage, false},
+	}
+
+	for _, tt := range tests {
+		got, err := uc.HasPermission(context.Background(), 1, tt.role, tt.permission)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%s %s", tt.role, tt.permission)
+	}
+}
+
+func TestRoleUsecase_HasPermission_CustomRole(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRoleRepo := mockRepositories.NewMockRoleRepository(ctrl)
+	uc := usecase.NewRoleUsecase(mockRoleRepo, mockRepositories.NewMockProjectMemberRepository(ctrl))
+
+	mockRoleRepo.EXPECT().FindByName(gomock.Any(), 1, "translator").
+		Return(&models.ProjectRole{Name: "translator", Permissions: []string{models.PermissionEntriesWrite}}, nil).Times(2)
+	mockRoleRepo.EXPECT().FindByName(gomock.Any(), 1, "deleted").
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "ロールが見つかりません"))
+
+	allowed, err := uc.HasPermission(context.Background(), 1, "translator", models.PermissionEntriesWrite)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = uc.HasPermission(context.Background(), 1, "translator", models.PermissionMediaWrite)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	// 削除されたロールは権限なしとして扱う
+	allowed, err = uc.HasPermission(context.Background(), 1, "deleted", models.PermissionEntriesRead)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestRoleUsecase_CreateRole(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRoleRepo := mockRepositories.NewMockRoleRepository(ctrl)
+	uc := usecase.NewRoleUsecase(mockRoleRepo, mockRepositories.NewMockProjectMemberRepository(ctrl))
+
+	mockRoleRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	role, err := uc.CreateRole(context.Background(), 1, models.ProjectRoleAdmin, " translator ", "", []string{
+		models.PermissionEntriesRead, models.PermissionEntriesWrite, models.PermissionEntriesRead,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "translator", role.Name)
+	assert.Equal(t, []string{models.PermissionEntriesRead, models.PermissionEntriesWrite}, []string(role.Permissions))
+}
+
+func TestRoleUsecase_CreateRole_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		roleName    string
+		permissions []string
+	}{
+		{name: "builtin name", roleName: models.ProjectRoleEditor, permissions: []string{models.PermissionEntriesRead}},
+		{name: "empty name", roleName: " ", permissions: []string{models.PermissionEntriesRead}},
+		{name: "no permissions", roleName: "translator"},
+		{name: "unknown permission", roleName: "translator", permissions: []string{"entries:publish"}},
+		{name: "system permission", roleName: "translator", permissions: []string{models.PermissionUsersManage}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			uc := usecase.NewRoleUsecase(mockRepositories.NewMockRoleRepository(ctrl), mockRepositories.NewMockProjectMemberRepository(ctrl))
+
+			_, err := uc.CreateRole(context.Background(), 1, models.ProjectRoleOwner, tt.roleName, "", tt.permissions)
+
+			assertErrType(t, err, myerrors.InvalidParameter)
+		})
+	}
+}
+
+func TestRoleUsecase_CreateRole_PermissionNotHeld(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		actorRole string
+	}{
+		// admin は roles:write を持っているが projects:write は持っていない
+		{name: "admin", actorRole: models.ProjectRoleAdmin},
+		{name: "custom role", actorRole: "manager"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRoleRepo := mockRepositories.NewMockRoleRepository(ctrl)
+			uc := usecase.NewRoleUsecase(mockRoleRepo, mockRepositories.NewMockProjectMemberRepository(ctrl))
+
+			mockRoleRepo.EXPECT().FindByName(gomock.Any(), 1, "manager").
+				Return(&models.ProjectRole{ProjectID: 1, Name: "manager", Permissions: []string{models.PermissionRolesWrite, models.PermissionEntriesRead}}, nil).
+				AnyTimes()
+			mockRoleRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := uc.CreateRole(context.Background(), 1, tt.actorRole, "maintainer", "", []string{
+				models.PermissionEntriesRead, models.PermissionProjectsWrite,
+			})
+
+			assertErrType(t, err, myerrors.UnPermittedOperation)
+		})
+	}
+}
+
+func TestRoleUsecase_CreateRole_OwnerGrantsAnyPermission(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRoleRepo := mockRepositories.NewMockRoleRepository(ctrl)
+	uc := usecase.NewRoleUsecase(mockRoleRepo, mockRepositories.NewMockProjectMemberRepository(ctrl))
+
+	mockRoleRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	role, err := uc.CreateRole(context.Background(), 1, models.ProjectRoleOwner, "maintainer", "", []string{models.PermissionProjectsWrite})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.PermissionProjectsWrite}, []string(role.Permissions))
+}
+
+func TestRoleUsecase_DeleteRole_InUse(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRoleRepo := mockRepositories.NewMockRoleRepository(ctrl)
+	mockMemberRepo := mockRepositories.NewMockProjectMemberRepository(ctrl)
+	uc := usecase.NewRoleUsecase(mockRoleRepo, mockMemberRepo)
+
+	mockRoleRepo.EXPECT().FindByID(gomock.Any(), 1, 3).Return(&models.ProjectRole{ID: 3, ProjectID: 1, Name: "translator"}, nil)
+	mockMemberRepo.EXPECT().CountByRole(gomock.Any(), 1, "translator").Return(int64(2), nil)
+
+	err := uc.DeleteRole(context.Background(), 1, 3)
+
+	assertErrType(t, err, myerrors.UnPermittedOperation)
+}
@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+	"w3st/infra/logger"
+
+	"github.com/google/uuid"
+)
+
+// RefreshTokenTTL リフレッシュトークンの有効期間
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+type SessionUsecase interface {
+	// Start ログイン成功時にセッションを開始し、トークンの組を発行する
+	Start(ctx context.Context, userID uuid.UUID, meta models.SessionMetadata) (*models.TokenPair, error)
+	// Refresh リフレッシュトークンをローテーションし、新しいトークンの組を発行する
+	Refresh(ctx context.Context, refreshToken string, meta models.SessionMetadata) (*models.TokenPair, error)
+	// ValidateSession アクセストークンに紐づくセッションが有効か確認する
+	ValidateSession(ctx context.Context, sessionID string) error
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error
+}
+
+type sessionUsecase struct {
+	sessionRepo repositories.SessionRepository
+	jwtUsecase  JwtUsecase
+}
+
+func NewSessionUsecase(sessionRepo repositories.SessionRepository, jwtUsecase JwtUsecase) SessionUsecase {
+	return &sessionUsecase{
+		sessionRepo: sessionRepo,
+		jwtUsecase:  jwtUsecase,
+	}
+}
+
+func (s *sessionUsecase) Start(ctx context.Context, userID uuid.UUID, meta models.SessionMetadata) (*models.TokenPair, error) {
+	pair, err := s.issue(ctx, uuid.New(), userID, meta)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("sessionUsecase.Start", err)
+	}
+	return pair, nil
+}
+
+func (s *sessionUsecase) Refresh(ctx context.Context, refreshToken string, meta models.SessionMetadata) (*models.TokenPair, error) {
+	current, err := s.sessionRepo.FindByTokenHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			return nil, errInvalidRefreshToken()
+		}
+		return nil, myerrors.WrapDomainError("sessionUsecase.Refresh", err)
+	}
+
+	if current.RevokedAt != nil || !current.ExpiresAt.After(time.Now()) {
+		return nil, errInvalidRefreshToken()
+	}
+
+	// 使用済みトークンが再度使われた場合は漏洩とみなしてセッションごと無効化する
+	if current.RotatedAt != nil {
+		return nil, s.revokeOnReuse(ctx, current)
+	}
+
+	rotated, err := s.sessionRepo.MarkRotated(ctx, current.ID)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("sessionUsecase.Refresh", err)
+	}
+	if !rotated {
+		// 同じトークンで同時にリフレッシュされた場合も再利用として扱う
+		return nil, s.revokeOnReuse(ctx, current)
+	}
+
+	// 端末名はリフレッシュ時に送られてこなければ引き継ぐ
+	if meta.DeviceName == "" {
+		meta.DeviceName = current.DeviceName
+	}
+
+	pair, issueErr := s.issue(ctx, current.FamilyID, current.UserID, meta)
+	if issueErr != nil {
+		return nil, myerrors.WrapDomainError("sessionUsecase.Refresh", issueErr)
+	}
+	return pair, nil
+}
+
+func (s *sessionUsecase) ValidateSession(ctx context.Context, sessionID string) error {
+	familyID, err := uuid.Parse(sessionID)
+	if err != nil {
+		return myerrors.NewDomainErrorWithMessage(myerrors.Unauthenticated, "セッションIDが不正です")
+	}
+
+	active, domainErr := s.sessionRepo.IsFamilyActive(ctx, familyID)
+	if domainErr != nil {
+		return myerrors.WrapDomainError("sessionUsecase.ValidateSession", domainErr)
+	}
+	if !active {
+		return myerrors.NewDomainErrorWithMessage(myerrors.Unauthenticated, "セッションは無効化されています")
+	}
+	return nil
+}
+
+func (s *sessionUsecase) ListSessions(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.FindActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("sessionUsecase.ListSessions", err)
+	}
+	return sessions, nil
+}
+
+func (s *sessionUsecase) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	if err := s.sessionRepo.RevokeFamilyByUser(ctx, userID, sessionID); err != nil {
+		return myerrors.WrapDomainError("sessionUsecase.RevokeSession", err)
+	}
+	return nil
+}
+
+// issue 新しいリフレッシュトークンを保存し、同じセッションIDでアクセストークンを発行する
+func (s *sessionUsecase) issue(ctx context.Context, familyID, userID uuid.UUID, meta models.SessionMetadata) (*models.TokenPair, error) {
+	refreshToken, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:               uuid.New(),
+		FamilyID:         familyID,
+		UserID:           userID,
+		RefreshTokenHash: hashToken(refreshToken),
+		DeviceName:       meta.DeviceName,
+		IPAddress:        meta.IPAddress,
+		UserAgent:        meta.UserAgent,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(RefreshTokenTTL),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.jwtUsecase.GenerateToken(userID, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(AccessTokenTTL.Seconds()),
+		SessionID:    familyID,
+	}, nil
+}
+
+func (s *sessionUsecase) revokeOnReuse(ctx context.Context, session *models.Session) error {
+	logger.Error("refresh token reuse detected",
+		"user_id", session.UserID.String(),
+		"session_id", session.FamilyID.String(),
+	)
+	if err := s.sessionRepo.RevokeFamily(ctx, session.FamilyID); err != nil {
+		return myerrors.WrapDomainError("sessionUsecase.Refresh", err)
+	}
+	return myerrors.NewDomainErrorWithMessage(myerrors.Unauthenticated, "リフレッシュトークンが再利用されたためセッションを無効化しました")
+}
+
+// generateOpaqueToken クライアントに渡すランダムなトークンを生成する
+func generateOpaqueToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", myerrors.NewDomainErrorWithMessage(myerrors.ErrorUnknown, "トークンの生成に失敗しました")
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// hashToken DBにはトークンのハッシュのみを保存する
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func errInvalidRefreshToken() *myerrors.DomainError {
+	return myerrors.NewDomainErrorWithMessage(myerrors.Unauthenticated, "リフレッシュトークンが無効です")
+}
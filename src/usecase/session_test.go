@@ -0,0 +1,231 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	mockRepositories "w3st/mock/repositories"
+	"w3st/usecase"
+)
+
+func activeSession(userID uuid.UUID) *models.Session {
+	now := time.Now()
+	return &models.Session{
+		ID:         uuid.New(),
+		FamilyID:   uuid.New(),
+		UserID:     userID,
+		DeviceName: "iPhone",
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+}
+
+func requireUnauthenticated(t *testing.T, err error) {
+	t.Helper()
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.Unauthenticated, domainErr.ErrType)
+}
+
+func TestSessionUsecase_Start_Success(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockRepositories.NewMockSessionRepository(ctrl)
+	jwtUsecase := usecase.NewjwtAuthUsecase()
+	uc := usecase.NewSessionUsecase(mockSessionRepo, jwtUsecase)
+
+	userID := uuid.New()
+	meta := models.SessionMetadata{DeviceName: "MacBook", IPAddress: "192.0.2.1", UserAgent: "test-agent"}
+
+	var saved *models.Session
+	mockSessionRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, s *models.Session) *myerrors.DomainError {
+			saved = s
+			return nil
+		})
+
+	pair, err := uc.Start(context.Background(), userID, meta)
+
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, userID, saved.UserID)
+	assert.Equal(t, pair.SessionID, saved.FamilyID)
+	assert.Equal(t, "MacBook", saved.DeviceName)
+	assert.NotEqual(t, pair.RefreshToken, saved.RefreshTokenHash)
+	assert.Equal(t, int64(usecase.AccessTokenTTL.Seconds()), pair.ExpiresIn)
+
+	claims, err := jwtUsecase.ValidateToken(string(pair.AccessToken))
+	require.NoError(t, err)
+	assert.Equal(t, userID.String(), claims.UserID)
+	assert.Equal(t, saved.FamilyID.String(), claims.SessionID)
+}
+
+func TestSessionUsecase_Refresh_RotatesToken(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockRepositories.NewMockSessionRepository(ctrl)
+	uc := usecase.NewSessionUsecase(mockSessionRepo, usecase.NewjwtAuthUsecase())
+
+	current := activeSession(uuid.New())
+
+	mockSessionRepo.EXPECT().FindByTokenHash(gomock.Any(), gomock.Any()).Return(current, nil)
+	mockSessionRepo.EXPECT().MarkRotated(gomock.Any(), current.ID).Return(true, nil)
+	mockSessionRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, s *models.Session) *myerrors.DomainError {
+			assert.Equal(t, current.FamilyID, s.FamilyID)
+			assert.Equal(t, current.UserID, s.UserID)
+			assert.NotEqual(t, current.ID, s.ID)
+			// 端末名は引き継がれる
+			assert.Equal(t, "iPhone", s.DeviceName)
+			return nil
+		})
+
+	pair, err := uc.Refresh(context.Background(), "refresh-token", models.SessionMetadata{})
+
+	require.NoError(t, err)
+	assert.Equal(t, current.FamilyID, pair.SessionID)
+	assert.NotEmpty(t, pair.RefreshToken)
+}
+
+func TestSessionUsecase_Refresh_ReuseRevokesFamily(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockRepositories.NewMockSessionRepository(ctrl)
+	uc := usecase.NewSessionUsecase(mockSessionRepo, usecase.NewjwtAuthUsecase())
+
+	current := activeSession(uuid.New())
+	rotatedAt := time.Now().Add(-time.Minute)
+	current.RotatedAt = &rotatedAt
+
+	mockSessionRepo.EXPECT().FindByTokenHash(gomock.Any(), gomock.Any()).Return(current, nil)
+	mockSessionRepo.EXPECT().RevokeFamily(gomock.Any(), current.FamilyID).Return(nil)
+
+	pair, err := uc.Refresh(context.Background(), "stolen-token", models.SessionMetadata{})
+
+	require.Error(t, err)
+	assert.Nil(t, pair)
+	requireUnauthenticated(t, err)
+}
+
+func TestSessionUsecase_Refresh_ConcurrentRotationRevokesFamily(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockRepositories.NewMockSessionRepository(ctrl)
+	uc := usecase.NewSessionUsecase(mockSessionRepo, usecase.NewjwtAuthUsecase())
+
+	current := activeSession(uuid.New())
+
+	mockSessionRepo.EXPECT().FindByTokenHash(gomock.Any(), gomock.Any()).Return(current, nil)
+	mockSessionRepo.EXPECT().MarkRotated(gomock.Any(), current.ID).Return(false, nil)
+	mockSessionRepo.EXPECT().RevokeFamily(gomock.Any(), current.FamilyID).Return(nil)
+
+	_, err := uc.Refresh(context.Background(), "refresh-token", models.SessionMetadata{})
+
+	requireUnauthenticated(t, err)
+}
+
+func TestSessionUsecase_Refresh_UnknownToken(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockRepositories.NewMockSessionRepository(ctrl)
+	uc := usecase.NewSessionUsecase(mockSessionRepo, usecase.NewjwtAuthUsecase())
+
+	mockSessionRepo.EXPECT().
+		FindByTokenHash(gomock.Any(), gomock.Any()).
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "セッションが見つかりません"))
+
+	_, err := uc.Refresh(context.Background(), "unknown", models.SessionMetadata{})
+
+	requireUnauthenticated(t, err)
+}
+
+func TestSessionUsecase_Refresh_Expired(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockRepositories.NewMockSessionRepository(ctrl)
+	uc := usecase.NewSessionUsecase(mockSessionRepo, usecase.NewjwtAuthUsecase())
+
+	current := activeSession(uuid.New())
+	current.ExpiresAt = time.Now().Add(-time.Minute)
+
+	mockSessionRepo.EXPECT().FindByTokenHash(gomock.Any(), gomock.Any()).Return(current, nil)
+
+	_, err := uc.Refresh(context.Background(), "expired", models.SessionMetadata{})
+
+	requireUnauthenticated(t, err)
+}
+
+func TestSessionUsecase_ValidateSession_Revoked(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockRepositories.NewMockSessionRepository(ctrl)
+	uc := usecase.NewSessionUsecase(mockSessionRepo, usecase.NewjwtAuthUsecase())
+
+	sessionID := uuid.New()
+	mockSessionRepo.EXPECT().IsFamilyActive(gomock.Any(), sessionID).Return(false, nil)
+
+	err := uc.ValidateSession(context.Background(), sessionID.String())
+
+	requireUnauthenticated(t, err)
+}
+
+func TestSessionUsecase_ValidateSession_Active(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockRepositories.NewMockSessionRepository(ctrl)
+	uc := usecase.NewSessionUsecase(mockSessionRepo, usecase.NewjwtAuthUsecase())
+
+	sessionID := uuid.New()
+	mockSessionRepo.EXPECT().IsFamilyActive(gomock.Any(), sessionID).Return(true, nil)
+
+	err := uc.ValidateSession(context.Background(), sessionID.String())
+
+	require.NoError(t, err)
+}
+
+func TestSessionUsecase_RevokeSession_NotFound(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockRepositories.NewMockSessionRepository(ctrl)
+	uc := usecase.NewSessionUsecase(mockSessionRepo, usecase.NewjwtAuthUsecase())
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+	mockSessionRepo.EXPECT().
+		RevokeFamilyByUser(gomock.Any(), userID, sessionID).
+		Return(myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "セッションが見つかりません"))
+
+	err := uc.RevokeSession(context.Background(), userID, sessionID)
+
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.QueryDataNotFoundError, domainErr.ErrType)
+}
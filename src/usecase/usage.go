@@ -0,0 +1,428 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"w3st/domain/models"
+	"w3st/domain/repositories"
+	myerrors "w3st/errors"
+	"w3st/infra/logger"
+)
+
+// UsageFlushInterval 利用量をDBに書き込む間隔
+const UsageFlushInterval = 10 * time.Second
+
+// UsageQuotaCacheTTL 月間上限と今月のリクエスト数をDBから読み直す間隔（このサーバーが利用量を書き込んだプロジェクトは書き込んだときに読み直す）。
+// このサーバーの利用量は、まだ書き込んでいないもの・書き込み中のものもメモリで数えるため遅れない。
+// 複数のサーバーで動かしている場合、ほかのサーバーの利用量は最大でこの間隔と UsageFlushInterval だけ遅れて反映される
+const UsageQuotaCacheTTL = time.Minute
+
+// usageQuotaLoadAttempts CheckQuota がDBを読んでいる間に Flush が終わった場合に読み直す回数
+const usageQuotaLoadAttempts = 3
+
+// usageMaxHourlyRange granularity=hour で集計できる期間
+const usageMaxHourlyRange = 31 * 24 * time.Hour
+
+// usageAlertThresholds 上限に対する割合。超えるたびに CheckAndCreateApiLimitAlert でアラートを作成する
+var usageAlertThresholds = []float64{75, 85, 95}
+
+type UsageUsecase interface {
+	// Record SDKのリクエスト1件分の利用量をメモリに加える。DBには Flush でまとめて書き込む
+	Record(projectID, apiKeyID, collectionID int, bytesServed int64, entriesCreated int64)
+	// Flush メモリの利用量をDBに書き込み、1時間・今月のリクエスト数が上限に近づいたプロジェクトのアラートを作成する。
+	// 書き込みに失敗した場合は利用量をメモリに戻し、次の Flush で書き込む
+	Flush(ctx context.Context) error
+	// GetUsage 期間内の利用量を集計する。まだ書き込んでいない利用量は含まない
+	GetUsage(ctx context.Context, query models.UsageQuery) (*models.UsageReport, error)
+	// CheckQuota プロジェクトの今月のリクエスト数（まだ書き込んでいない利用量を含む）と月間上限を返す
+	CheckQuota(ctx context.Context, projectID int) (models.MonthlyQuota, error)
+	// SetMonthlyQuota 月間上限を変更する。quota が nil の場合は上限をなくす
+	SetMonthlyQuota(ctx context.Context, projectID int, quota *int) (*models.Project, error)
+}
+
+// usageKey メモリで利用量を数える単位（UsageBucket の主キー）
+type usageKey struct {
+	projectID    int
+	apiKeyID     int
+	collectionID int
+	bucketStart  time.Time
+}
+
+// usageAlertKey アラートを作成した期間。period は "hour" または "month"
+type usageAlertKey struct {
+	projectID   int
+	period      string
+	periodStart time.Time
+}
+
+// projectQuota キャッシュした月間上限と、DBに書き込み済みの今月のリクエスト数
+type projectQuota struct {
+	month     time.Time
+	limit     *int
+	used      int64
+	expiresAt time.Time
+}
+
+type usageUsecase struct {
+	usageRepo          repositories.UsageRepository
+	projectRepo        repositories.ProjectRepository
+	systemAlertUsecase SystemAlertUsecase
+	now                func() time.Time
+
+	mu sync.Mutex
+	// pending まだ書き込んでいない利用量
+	pending map[usageKey]*models.UsageBucket
+	// unflushed プロジェクトごとのまだ書き込んでいない今月のリクエスト数
+	unflushed map[int]int64
+	// flushing Flush で書き込み中のリクエスト数。書き込みが終わり、キャッシュを消すまで CheckQuota で数える
+	flushing map[int]int64
+	// flushGen 書き込みが終わった Flush の回数。CheckQuota が書き込み前のDBの値をキャッシュしないようにする
+	flushGen uint64
+	quotas   map[int]*projectQuota
+	// alerted 期間ごとにアラートを作成した割合（同じ割合のアラートを繰り返し作成しない）
+	alerted map[usageAlertKey]float64
+
+	// flushMu Flush を同時に実行しない
+	flushMu sync.Mutex
+}
+
+type UsageUsecaseOption func(*usageUsecase)
+
+// WithUsageClock テスト用に現在時刻を差し替える
+func WithUsageClock(now func() time.Time) UsageUsecaseOption {
+	return func(u *usageUsecase) {
+		u.now = now
+	}
+}
+
+func NewUsageUsecase(usageRepo repositories.UsageRepository, projectRepo repositories.ProjectRepository, systemAlertUsecase SystemAlertUsecase, opts ...UsageUsecaseOption) UsageUsecase {
+	u := &usageUsecase{
+		usageRepo:          usageRepo,
+		projectRepo:        projectRepo,
+		systemAlertUsecase: systemAlertUsecase,
+		now:                time.Now,
+		pending:            make(map[usageKey]*models.UsageBucket),
+		unflushed:          make(map[int]int64),
+		flushing:           make(map[int]int64),
+		quotas:             make(map[int]*projectQuota),
+		alerted:            make(map[usageAlertKey]float64),
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+// StartUsageFlush ctx がキャンセルされるまで interval ごとに利用量をDBに書き込む。キャンセルされたときに残りを書き込む
+func StartUsageFlush(ctx context.Context, usageUsecase UsageUsecase, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if err := usageUsecase.Flush(context.WithoutCancel(ctx)); err != nil {
+					logger.Error("failed to flush usage", "error", err.Error())
+				}
+				return
+			case <-ticker.C:
+				if err := usageUsecase.Flush(ctx); err != nil {
+					logger.Error("failed to flush usage", "error", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+func (u *usageUsecase) Record(projectID, apiKeyID, collectionID int, bytesServed int64, entriesCreated int64) {
+	key := usageKey{
+		projectID:    projectID,
+		apiKeyID:     apiKeyID,
+		collectionID: collectionID,
+		bucketStart:  u.now().UTC().Truncate(time.Hour),
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	bucket, ok := u.pending[key]
+	if !ok {
+		bucket = &models.UsageBucket{ProjectID: projectID, ApiKeyID: apiKeyID, CollectionID: collectionID, BucketStart: key.bucketStart}
+		u.pending[key] = bucket
+	}
+	bucket.Requests++
+	bucket.BytesServed += bytesServed
+	bucket.EntriesCreated += entriesCreated
+	u.unflushed[projectID]++
+}
+
+func (u *usageUsecase) Flush(ctx context.Context) error {
+	u.flushMu.Lock()
+	defer u.flushMu.Unlock()
+
+	u.mu.Lock()
+	pending := u.pending
+	unflushed := u.unflushed
+	u.pending = make(map[usageKey]*models.UsageBucket)
+	u.unflushed = make(map[int]int64)
+	u.flushing = unflushed
+	u.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	buckets := make([]models.UsageBucket, 0, len(pending))
+	for _, bucket := range pending {
+		buckets = append(buckets, *bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		a, b := buckets[i], buckets[j]
+		if !a.BucketStart.Equal(b.BucketStart) {
+			return a.BucketStart.Before(b.BucketStart)
+		}
+		if a.ProjectID != b.ProjectID {
+			return a.ProjectID < b.ProjectID
+		}
+		if a.ApiKeyID != b.ApiKeyID {
+			return a.ApiKeyID < b.ApiKeyID
+		}
+		return a.CollectionID < b.CollectionID
+	})
+
+	if err := u.usageRepo.AddBuckets(ctx, buckets); err != nil {
+		u.restore(pending, unflushed)
+		return myerrors.WrapDomainError("usageUsecase.Flush", err)
+	}
+
+	// 書き込んだプロジェクトの今月のリクエスト数は次の CheckQuota でDBから読み直す
+	projectIDs := make([]int, 0, len(unflushed))
+	u.mu.Lock()
+	for projectID := range unflushed {
+		delete(u.quotas, projectID)
+		projectIDs = append(projectIDs, projectID)
+	}
+	u.flushing = make(map[int]int64)
+	u.flushGen++
+	u.mu.Unlock()
+	sort.Ints(projectIDs)
+
+	return u.checkAlerts(ctx, projectIDs)
+}
+
+// restore 書き込めなかった利用量をメモリに戻す
+func (u *usageUsecase) restore(pending map[usageKey]*models.UsageBucket, unflushed map[int]int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.flushing = make(map[int]int64)
+	for key, bucket := range pending {
+		if current, ok := u.pending[key]; ok {
+			current.Requests += bucket.Requests
+			current.BytesServed += bucket.BytesServed
+			current.EntriesCreated += bucket.EntriesCreated
+			continue
+		}
+		u.pending[key] = bucket
+	}
+	for projectID, requests := range unflushed {
+		u.unflushed[projectID] += requests
+	}
+}
+
+// checkAlerts 集計した1時間・今月のリクエスト数をプロジェクトのレート制限・月間上限と比べてアラートを作成する
+func (u *usageUsecase) checkAlerts(ctx context.Context, projectIDs []int) error {
+	now := u.now().UTC()
+	hour := now.Truncate(time.Hour)
+	month := monthStart(now)
+
+	hourly, err := u.usageRepo.SumRequests(ctx, projectIDs, hour)
+	if err != nil {
+		return myerrors.WrapDomainError("usageUsecase.checkAlerts", err)
+	}
+	monthly, err := u.usageRepo.SumRequests(ctx, projectIDs, month)
+	if err != nil {
+		return myerrors.WrapDomainError("usageUsecase.checkAlerts", err)
+	}
+
+	u.mu.Lock()
+	for key := range u.alerted {
+		if (key.period == "hour" && key.periodStart.Before(hour)) || (key.period == "month" && key.periodStart.Before(month)) {
+			delete(u.alerted, key)
+		}
+	}
+	u.mu.Unlock()
+
+	for _, projectID := range projectIDs {
+		project, err := u.projectRepo.FindByID(ctx, projectID)
+		if err != nil {
+			logger.Error("failed to find project for usage alert", "project_id", projectID, "error", err.Error())
+			continue
+		}
+		u.alert(ctx, usageAlertKey{projectID: projectID, period: "hour", periodStart: hour}, hourly[projectID], project.RateLimitPerHour)
+		if project.MonthlyRequestQuota != nil {
+			u.alert(ctx, usageAlertKey{projectID: projectID, period: "month", periodStart: month}, monthly[projectID], *project.MonthlyRequestQuota)
+		}
+	}
+	return nil
+}
+
+// alert requests が limit に対して前回より上のしきい値を超えた場合だけアラートを作成する
+func (u *usageUsecase) alert(ctx context.Context, key usageAlertKey, requests int64, limit int) {
+	if limit <= 0 {
+		return
+	}
+	percent := float64(requests) / float64(limit) * 100
+	var threshold float64
+	for _, t := range usageAlertThresholds {
+		if percent >= t {
+			threshold = t
+		}
+	}
+
+	u.mu.Lock()
+	if threshold <= u.alerted[key] {
+		u.mu.Unlock()
+		return
+	}
+	u.alerted[key] = threshold
+	u.mu.Unlock()
+
+	if err := u.systemAlertUsecase.CheckAndCreateApiLimitAlert(ctx, key.projectID, int(requests), limit); err != nil {
+		// アラート作成失敗はログ出力のみ
+		logger.Error("failed to create API limit alert", "project_id", key.projectID, "error", err.Error())
+	}
+}
+
+func (u *usageUsecase) GetUsage(ctx context.Context, query models.UsageQuery) (*models.UsageReport, error) {
+	now := u.now().UTC()
+	if query.Granularity == "" {
+		query.Granularity = models.UsageGranularityDay
+	}
+	if query.To.IsZero() {
+		query.To = now
+	}
+	if query.From.IsZero() {
+		query.From = monthStart(query.To)
+	}
+	query.From, query.To = query.From.UTC(), query.To.UTC()
+
+	switch query.Granularity {
+	case models.UsageGranularityHour, models.UsageGranularityDay, models.UsageGranularityMonth:
+	default:
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "granularity は hour, day, month のいずれかで指定してください")
+	}
+	if !query.From.Before(query.To) {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "from は to より前の日時を指定してください")
+	}
+	if query.Granularity == models.UsageGranularityHour && query.To.Sub(query.From) > usageMaxHourlyRange {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, "granularity=hour の期間は31日以内で指定してください")
+	}
+
+	points, err := u.usageRepo.Aggregate(ctx, query)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("usageUsecase.GetUsage", err)
+	}
+	var totals models.UsageTotals
+	for _, p := range points {
+		totals.Requests += p.Requests
+		totals.BytesServed += p.BytesServed
+		totals.EntriesCreated += p.EntriesCreated
+	}
+
+	quota, quotaErr := u.CheckQuota(ctx, query.ProjectID)
+	if quotaErr != nil {
+		return nil, quotaErr
+	}
+
+	return &models.UsageReport{
+		From:        query.From,
+		To:          query.To,
+		Granularity: query.Granularity,
+		Usage:       points,
+		Totals:      totals,
+		Quota:       quota,
+	}, nil
+}
+
+func (u *usageUsecase) CheckQuota(ctx context.Context, projectID int) (models.MonthlyQuota, error) {
+	now := u.now()
+	month := monthStart(now)
+	reset := month.AddDate(0, 1, 0)
+
+	u.mu.Lock()
+	q, ok := u.quotas[projectID]
+	if ok && q.month.Equal(month) && now.Before(q.expiresAt) {
+		quota := models.MonthlyQuota{Limit: q.limit, Used: q.used + u.inMemoryRequests(projectID), Reset: reset}
+		u.mu.Unlock()
+		return quota, nil
+	}
+	u.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		u.mu.Lock()
+		gen := u.flushGen
+		u.mu.Unlock()
+
+		project, err := u.projectRepo.FindByID(ctx, projectID)
+		if err != nil {
+			return models.MonthlyQuota{}, myerrors.WrapDomainError("usageUsecase.CheckQuota", err)
+		}
+		sums, domainErr := u.usageRepo.SumRequests(ctx, []int{projectID}, month)
+		if domainErr != nil {
+			return models.MonthlyQuota{}, myerrors.WrapDomainError("usageUsecase.CheckQuota", domainErr)
+		}
+
+		u.mu.Lock()
+		// 読んでいる間に Flush が終わった場合、書き込み中だった利用量がDBの値に含まれているかわからないので読み直す。
+		// 書き込みが終わってからキャッシュを消すまでの間は、書き込み中の利用量を二重に数えることがある（多く数える）
+		if u.flushGen != gen && attempt < usageQuotaLoadAttempts {
+			u.mu.Unlock()
+			continue
+		}
+		if u.flushGen == gen {
+			u.quotas[projectID] = &projectQuota{month: month, limit: project.MonthlyRequestQuota, used: sums[projectID], expiresAt: now.Add(UsageQuotaCacheTTL)}
+		}
+		quota := models.MonthlyQuota{Limit: project.MonthlyRequestQuota, Used: sums[projectID] + u.inMemoryRequests(projectID), Reset: reset}
+		u.mu.Unlock()
+		return quota, nil
+	}
+}
+
+// inMemoryRequests まだDBに書き込んでいない（書き込み中を含む）今月のリクエスト数。u.mu をロックして呼ぶ
+func (u *usageUsecase) inMemoryRequests(projectID int) int64 {
+	return u.unflushed[projectID] + u.flushing[projectID]
+}
+
+func (u *usageUsecase) SetMonthlyQuota(ctx context.Context, projectID int, quota *int) (*models.Project, error) {
+	if quota != nil && *quota <= 0 {
+		return nil, myerrors.NewDomainErrorWithMessage(myerrors.InvalidParameter, fmt.Sprintf("monthly_request_quota は1以上で指定してください（%d）", *quota))
+	}
+
+	project, err := u.projectRepo.FindByID(ctx, projectID)
+	if err != nil {
+		return nil, myerrors.WrapDomainError("usageUsecase.SetMonthlyQuota", err)
+	}
+	project.MonthlyRequestQuota = quota
+	if err := u.projectRepo.Update(ctx, project); err != nil {
+		return nil, myerrors.WrapDomainError("usageUsecase.SetMonthlyQuota", err)
+	}
+
+	// 変更した上限をすぐに使う。今月のアラートは新しい上限で改めて作成する
+	u.mu.Lock()
+	delete(u.quotas, projectID)
+	for key := range u.alerted {
+		if key.projectID == projectID && key.period == "month" {
+			delete(u.alerted, key)
+		}
+	}
+	u.mu.Unlock()
+	return project, nil
+}
+
+// monthStart t を含む月（UTC）の始まり
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
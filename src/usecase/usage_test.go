@@ -0,0 +1,410 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"w3st/domain/models"
+	myerrors "w3st/errors"
+	mockRepositories "w3st/mock/repositories"
+	"w3st/usecase"
+)
+
+// stubSystemAlertUsecase CheckAndCreateApiLimitAlert の呼び出しを記録する
+type stubSystemAlertUsecase struct {
+	usecase.SystemAlertUsecase
+	mu     sync.Mutex
+	alerts []apiLimitAlert
+}
+
+type apiLimitAlert struct {
+	projectID    int
+	requestCount int
+	limit        int
+}
+
+func (s *stubSystemAlertUsecase) CheckAndCreateApiLimitAlert(_ context.Context, projectID int, requestCount int, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts = append(s.alerts, apiLimitAlert{projectID, requestCount, limit})
+	return nil
+}
+
+// usageNow 2026年3月15日 10:30（UTC）
+var usageNow = time.Date(2026, 3, 15, 10, 30, 0, 0, time.UTC)
+
+func quota(n int) *int {
+	return &n
+}
+
+func TestUsageUsecase_Flush_AggregatesHourlyBuckets(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsageRepo := mockRepositories.NewMockUsageRepository(ctrl)
+	mockProjectRepo := mockRepositories.NewMockProjectRepository(ctrl)
+	alerts := &stubSystemAlertUsecase{}
+	now := usageNow
+	uc := usecase.NewUsageUsecase(mockUsageRepo, mockProjectRepo, alerts, usecase.WithUsageClock(func() time.Time { return now }))
+
+	uc.Record(1, 10, 100, 200, 0)
+	uc.Record(1, 10, 100, 300, 1)
+	uc.Record(1, 10, 0, 50, 0)
+	uc.Record(2, 20, 200, 10, 0)
+	now = usageNow.Add(time.Hour)
+	uc.Record(1, 10, 100, 100, 0)
+
+	hour := usageNow.Truncate(time.Hour)
+	mockUsageRepo.EXPECT().AddBuckets(gomock.Any(), []models.UsageBucket{
+		{ProjectID: 1, ApiKeyID: 10, CollectionID: 0, BucketStart: hour, Requests: 1, BytesServed: 50},
+		{ProjectID: 1, ApiKeyID: 10, CollectionID: 100, BucketStart: hour, Requests: 2, BytesServed: 500, EntriesCreated: 1},
+		{ProjectID: 2, ApiKeyID: 20, CollectionID: 200, BucketStart: hour, Requests: 1, BytesServed: 10},
+		{ProjectID: 1, ApiKeyID: 10, CollectionID: 100, BucketStart: hour.Add(time.Hour), Requests: 1, BytesServed: 100},
+	}).Return(nil)
+	mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1, 2}, gomock.Any()).Return(map[int]int64{1: 1, 2: 1}, nil).Times(2)
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 1).Return(&models.Project{ID: 1, RateLimitPerHour: 1000}, nil)
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 2).Return(&models.Project{ID: 2, RateLimitPerHour: 1000}, nil)
+
+	require.NoError(t, uc.Flush(context.Background()))
+	// 書き込んだ利用量は残らない
+	require.NoError(t, uc.Flush(context.Background()))
+	assert.Empty(t, alerts.alerts)
+}
+
+func TestUsageUsecase_Flush_KeepsUsageOnError(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsageRepo := mockRepositories.NewMockUsageRepository(ctrl)
+	mockProjectRepo := mockRepositories.NewMockProjectRepository(ctrl)
+	uc := usecase.NewUsageUsecase(mockUsageRepo, mockProjectRepo, &stubSystemAlertUsecase{}, usecase.WithUsageClock(func() time.Time { return usageNow }))
+
+	uc.Record(1, 10, 100, 200, 0)
+
+	mockUsageRepo.EXPECT().AddBuckets(gomock.Any(), gomock.Any()).Return(myerrors.NewDomainError(myerrors.QueryError, errors.New("connection refused")))
+	err := uc.Flush(context.Background())
+	assertErrType(t, err, myerrors.QueryError)
+
+	// 書き込めなかった利用量は次の Flush で、その間の利用量とまとめて書き込む
+	uc.Record(1, 10, 100, 300, 0)
+	mockUsageRepo.EXPECT().AddBuckets(gomock.Any(), []models.UsageBucket{
+		{ProjectID: 1, ApiKeyID: 10, CollectionID: 100, BucketStart: usageNow.Truncate(time.Hour), Requests: 2, BytesServed: 500},
+	}).Return(nil)
+	mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, gomock.Any()).Return(map[int]int64{1: 2}, nil).Times(2)
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 1).Return(&models.Project{ID: 1, RateLimitPerHour: 1000}, nil)
+	require.NoError(t, uc.Flush(context.Background()))
+}
+
+func TestUsageUsecase_Flush_CreatesAlertsFromAggregates(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsageRepo := mockRepositories.NewMockUsageRepository(ctrl)
+	mockProjectRepo := mockRepositories.NewMockProjectRepository(ctrl)
+	alerts := &stubSystemAlertUsecase{}
+	uc := usecase.NewUsageUsecase(mockUsageRepo, mockProjectRepo, alerts, usecase.WithUsageClock(func() time.Time { return usageNow }))
+
+	hour := usageNow.Truncate(time.Hour)
+	month := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mockUsageRepo.EXPECT().AddBuckets(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 1).Return(&models.Project{ID: 1, RateLimitPerHour: 100, MonthlyRequestQuota: quota(10000)}, nil).Times(3)
+
+	flush := func(hourly, monthly int64) {
+		t.Helper()
+		uc.Record(1, 10, 100, 0, 0)
+		mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, hour).Return(map[int]int64{1: hourly}, nil)
+		mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, month).Return(map[int]int64{1: monthly}, nil)
+		require.NoError(t, uc.Flush(context.Background()))
+	}
+
+	// 1時間のリクエスト数がレート制限の75%を超えた
+	flush(80, 9000)
+	// 同じしきい値のアラートは繰り返し作成しない
+	flush(84, 9100)
+	// 85%・95%（月間上限）を超えた
+	flush(90, 9600)
+
+	assert.Equal(t, []apiLimitAlert{
+		{1, 80, 100},
+		{1, 9000, 10000},
+		{1, 90, 100},
+		{1, 9600, 10000},
+	}, alerts.alerts)
+}
+
+func TestUsageUsecase_CheckQuota(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsageRepo := mockRepositories.NewMockUsageRepository(ctrl)
+	mockProjectRepo := mockRepositories.NewMockProjectRepository(ctrl)
+	uc := usecase.NewUsageUsecase(mockUsageRepo, mockProjectRepo, &stubSystemAlertUsecase{}, usecase.WithUsageClock(func() time.Time { return usageNow }))
+
+	month := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 1).Return(&models.Project{ID: 1, MonthlyRequestQuota: quota(100)}, nil)
+	mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, month).Return(map[int]int64{1: 98}, nil)
+
+	q, err := uc.CheckQuota(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(98), q.Used)
+	assert.Equal(t, int64(2), q.Remaining())
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), q.Reset)
+	assert.False(t, q.Exceeded())
+
+	// まだ書き込んでいない利用量を含める（DBは UsageQuotaCacheTTL の間読み直さない）
+	uc.Record(1, 10, 100, 0, 0)
+	uc.Record(1, 10, 100, 0, 0)
+	q, err = uc.CheckQuota(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), q.Used)
+	assert.True(t, q.Exceeded())
+
+	// 上限がない場合
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 2).Return(&models.Project{ID: 2}, nil)
+	mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{2}, month).Return(map[int]int64{}, nil)
+	q, err = uc.CheckQuota(context.Background(), 2)
+	require.NoError(t, err)
+	assert.False(t, q.Exceeded())
+	assert.Equal(t, int64(-1), q.Remaining())
+}
+
+func TestUsageUsecase_CheckQuota_ReloadsAfterFlush(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsageRepo := mockRepositories.NewMockUsageRepository(ctrl)
+	mockProjectRepo := mockRepositories.NewMockProjectRepository(ctrl)
+	uc := usecase.NewUsageUsecase(mockUsageRepo, mockProjectRepo, &stubSystemAlertUsecase{}, usecase.WithUsageClock(func() time.Time { return usageNow }))
+
+	month := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 1).Return(&models.Project{ID: 1, RateLimitPerHour: 1000, MonthlyRequestQuota: quota(100)}, nil).AnyTimes()
+	mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, month).Return(map[int]int64{1: 10}, nil)
+	_, err := uc.CheckQuota(context.Background(), 1)
+	require.NoError(t, err)
+
+	uc.Record(1, 10, 100, 0, 0)
+	mockUsageRepo.EXPECT().AddBuckets(gomock.Any(), gomock.Any()).Return(nil)
+	mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, gomock.Any()).Return(map[int]int64{1: 11}, nil).Times(2)
+	require.NoError(t, uc.Flush(context.Background()))
+
+	// 書き込んだ利用量を二重に数えないよう、書き込んだ後はDBから読み直す
+	mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, month).Return(map[int]int64{1: 11}, nil)
+	q, err := uc.CheckQuota(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), q.Used)
+}
+
+func TestUsageUsecase_CheckQuota_CountsUsageBeingFlushed(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsageRepo := mockRepositories.NewMockUsageRepository(ctrl)
+	mockProjectRepo := mockRepositories.NewMockProjectRepository(ctrl)
+	uc := usecase.NewUsageUsecase(mockUsageRepo, mockProjectRepo, &stubSystemAlertUsecase{}, usecase.WithUsageClock(func() time.Time { return usageNow }))
+
+	month := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 1).Return(&models.Project{ID: 1, RateLimitPerHour: 1000, MonthlyRequestQuota: quota(100)}, nil).AnyTimes()
+	mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, month).Return(map[int]int64{1: 95}, nil)
+	_, err := uc.CheckQuota(context.Background(), 1)
+	require.NoError(t, err)
+
+	for range 3 {
+		uc.Record(1, 10, 100, 0, 0)
+	}
+
+	// 書き込み中の利用量も、その間の利用量も数える
+	mockUsageRepo.EXPECT().AddBuckets(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ []models.UsageBucket) error {
+		uc.Record(1, 10, 100, 0, 0)
+		q, err := uc.CheckQuota(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(99), q.Used)
+		return myerrors.NewDomainError(myerrors.QueryError, errors.New("connection refused"))
+	})
+	err = uc.Flush(context.Background())
+	assertErrType(t, err, myerrors.QueryError)
+
+	// 書き込めなかった利用量はまだ書き込んでいない利用量に戻す
+	q, err := uc.CheckQuota(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(99), q.Used)
+}
+
+func TestUsageUsecase_CheckQuota_ReloadsWhenFlushedDuringLoad(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsageRepo := mockRepositories.NewMockUsageRepository(ctrl)
+	mockProjectRepo := mockRepositories.NewMockProjectRepository(ctrl)
+	uc := usecase.NewUsageUsecase(mockUsageRepo, mockProjectRepo, &stubSystemAlertUsecase{}, usecase.WithUsageClock(func() time.Time { return usageNow }))
+
+	month := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 1).Return(&models.Project{ID: 1, RateLimitPerHour: 1000, MonthlyRequestQuota: quota(100)}, nil).AnyTimes()
+	uc.Record(1, 10, 100, 0, 0)
+
+	// DBを読んでいる間に Flush が書き込みを終えた場合、読んだ値（書き込み前の 10）は使わずに読み直す
+	gomock.InOrder(
+		mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, month).DoAndReturn(func(ctx context.Context, _ []int, _ time.Time) (map[int]int64, *myerrors.DomainError) {
+			mockUsageRepo.EXPECT().AddBuckets(gomock.Any(), gomock.Any()).Return(nil)
+			mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, gomock.Any()).Return(map[int]int64{1: 11}, nil).Times(2)
+			require.NoError(t, uc.Flush(ctx))
+			return map[int]int64{1: 10}, nil
+		}),
+		mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, month).Return(map[int]int64{1: 11}, nil),
+	)
+
+	q, err := uc.CheckQuota(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), q.Used)
+
+	// 読み直した値はキャッシュする
+	q, err = uc.CheckQuota(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), q.Used)
+}
+
+func TestUsageUsecase_GetUsage(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsageRepo := mockRepositories.NewMockUsageRepository(ctrl)
+	mockProjectRepo := mockRepositories.NewMockProjectRepository(ctrl)
+	uc := usecase.NewUsageUsecase(mockUsageRepo, mockProjectRepo, &stubSystemAlertUsecase{}, usecase.WithUsageClock(func() time.Time { return usageNow }))
+
+	day := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	mockUsageRepo.EXPECT().Aggregate(gomock.Any(), models.UsageQuery{
+		ProjectID:   1,
+		From:        time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:          usageNow,
+		Granularity: models.UsageGranularityDay,
+		ApiKeyID:    10,
+	}).Return([]models.UsagePoint{
+		{PeriodStart: day, UsageTotals: models.UsageTotals{Requests: 10, BytesServed: 1000, EntriesCreated: 1}},
+		{PeriodStart: day.AddDate(0, 0, 1), UsageTotals: models.UsageTotals{Requests: 5, BytesServed: 500}},
+	}, nil)
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 1).Return(&models.Project{ID: 1}, nil)
+	mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, gomock.Any()).Return(map[int]int64{1: 15}, nil)
+
+	// from・to・granularity を省略した場合は今月の始まりから現在までを日ごとに集計する
+	report, err := uc.GetUsage(context.Background(), models.UsageQuery{ProjectID: 1, ApiKeyID: 10})
+	require.NoError(t, err)
+	assert.Equal(t, models.UsageTotals{Requests: 15, BytesServed: 1500, EntriesCreated: 1}, report.Totals)
+	assert.Len(t, report.Usage, 2)
+	assert.Equal(t, int64(15), report.Quota.Used)
+}
+
+func TestUsageUsecase_GetUsage_InvalidQuery(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	uc := usecase.NewUsageUsecase(mockRepositories.NewMockUsageRepository(ctrl), mockRepositories.NewMockProjectRepository(ctrl), &stubSystemAlertUsecase{}, usecase.WithUsageClock(func() time.Time { return usageNow }))
+
+	tests := map[string]models.UsageQuery{
+		"granularity": {ProjectID: 1, Granularity: "week"},
+		"from after to": {
+			ProjectID: 1,
+			From:      usageNow,
+			To:        usageNow.Add(-time.Hour),
+		},
+		"hourly range": {
+			ProjectID:   1,
+			Granularity: models.UsageGranularityHour,
+			From:        usageNow.AddDate(0, -2, 0),
+			To:          usageNow,
+		},
+	}
+	for name, query := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := uc.GetUsage(context.Background(), query)
+			assertErrType(t, err, myerrors.InvalidParameter)
+		})
+	}
+}
+
+func TestUsageUsecase_SetMonthlyQuota(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsageRepo := mockRepositories.NewMockUsageRepository(ctrl)
+	mockProjectRepo := mockRepositories.NewMockProjectRepository(ctrl)
+	uc := usecase.NewUsageUsecase(mockUsageRepo, mockProjectRepo, &stubSystemAlertUsecase{}, usecase.WithUsageClock(func() time.Time { return usageNow }))
+
+	_, err := uc.SetMonthlyQuota(context.Background(), 1, quota(0))
+	assertErrType(t, err, myerrors.InvalidParameter)
+
+	// キャッシュした上限を使わずに、変更した上限をすぐに使う
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 1).Return(&models.Project{ID: 1}, nil).Times(2)
+	mockUsageRepo.EXPECT().SumRequests(gomock.Any(), []int{1}, gomock.Any()).Return(map[int]int64{1: 50}, nil).Times(2)
+	q, err := uc.CheckQuota(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Nil(t, q.Limit)
+
+	mockProjectRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, project *models.Project) error {
+		assert.Equal(t, 50, *project.MonthlyRequestQuota)
+		return nil
+	})
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 1).Return(&models.Project{ID: 1, MonthlyRequestQuota: quota(50)}, nil)
+	project, err := uc.SetMonthlyQuota(context.Background(), 1, quota(50))
+	require.NoError(t, err)
+	assert.Equal(t, 50, *project.MonthlyRequestQuota)
+
+	q, err = uc.CheckQuota(context.Background(), 1)
+	require.NoError(t, err)
+	assert.True(t, q.Exceeded())
+}
+
+func TestUsageUsecase_Record_Concurrent(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsageRepo := mockRepositories.NewMockUsageRepository(ctrl)
+	mockProjectRepo := mockRepositories.NewMockProjectRepository(ctrl)
+	uc := usecase.NewUsageUsecase(mockUsageRepo, mockProjectRepo, &stubSystemAlertUsecase{}, usecase.WithUsageClock(func() time.Time { return usageNow }))
+
+	var written int64
+	mockUsageRepo.EXPECT().AddBuckets(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, buckets []models.UsageBucket) *myerrors.DomainError {
+		for _, b := range buckets {
+			written += b.Requests
+		}
+		return nil
+	}).AnyTimes()
+	mockUsageRepo.EXPECT().SumRequests(gomock.Any(), gomock.Any(), gomock.Any()).Return(map[int]int64{}, nil).AnyTimes()
+	mockProjectRepo.EXPECT().FindByID(gomock.Any(), 1).Return(&models.Project{ID: 1, RateLimitPerHour: 1000}, nil).AnyTimes()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				uc.Record(1, 10, 100, 10, 0)
+				if i%25 == 0 {
+					assert.NoError(t, uc.Flush(context.Background()))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, uc.Flush(context.Background()))
+
+	// 同時に数えて書き込んでも取りこぼさない
+	assert.Equal(t, int64(800), written)
+}
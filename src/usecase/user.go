@@ -7,11 +7,13 @@ import (
 	"w3st/domain/models"
 	"w3st/domain/repositories"
 	myerrors "w3st/errors"
+	"w3st/infra/logger"
 )
 
 type UserUsecase interface {
 	Create(newUser *models.Users, ctx context.Context) (*models.Users, error)
 	FindByEmail(email string) (*models.Users, error)
+	Authenticate(ctx context.Context, email, password string) (*models.Users, error)
 	FindByID(userID string) (*models.Users, error)
 	Update(user *models.Users, ctx context.Context) error
 	GetAllUsers() ([]models.Users, error)
@@ -19,21 +21,34 @@ type UserUsecase interface {
 }
 
 type userUsecase struct {
-	userRepo repositories.UserRepository
+	userRepo          repositories.UserRepository
+	credentialUsecase CredentialUsecase
 }
 
-func NewUserUsecase(userRepo repositories.UserRepository) UserUsecase {
+func NewUserUsecase(userRepo repositories.UserRepository, credentialUsecase CredentialUsecase) UserUsecase {
 	return &userUsecase{
-		userRepo: userRepo,
+		userRepo:          userRepo,
+		credentialUsecase: credentialUsecase,
 	}
 }
 
 func (u *userUsecase) Create(newUser *models.Users, ctx context.Context) (*models.Users, error) {
+	// パスワードポリシーの確認
+	if err := u.credentialUsecase.ValidatePolicy(newUser.Password); err != nil {
+		return nil, myerrors.WrapDomainError("usecase.Create", err)
+	}
+
 	// すでに存在するか確認
 	_, err := u.userRepo.FindByEmail(ctx, newUser.Email)
 	if err != nil {
 		// ユーザーが存在しない場合
 		if errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			hashed, err := u.credentialUsecase.HashPassword(newUser.Password)
+			if err != nil {
+				return nil, myerrors.WrapDomainError("usecase.Create", err)
+			}
+			newUser.Password = hashed
+
 			if err := u.userRepo.Create(ctx, newUser); err != nil {
 				return nil, myerrors.WrapDomainError("usecase.Create", err)
 			}
@@ -55,6 +70,41 @@ func (u *userUsecase) FindByEmail(email string) (*models.Users, error) {
 	return user, nil
 }
 
+// Authenticate メールアドレスとパスワードでユーザーを認証する
+func (u *userUsecase) Authenticate(ctx context.Context, email, password string) (*models.Users, error) {
+	user, err := u.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, &myerrors.DomainError{ErrType: myerrors.QueryDataNotFoundError}) {
+			// ユーザーの存在有無が応答時間から推測されないよう照合処理は必ず行う
+			u.credentialUsecase.VerifyPassword("", password)
+			return nil, errInvalidCredentials()
+		}
+		return nil, myerrors.WrapDomainError("usecase.Authenticate", err)
+	}
+
+	ok, needsRehash := u.credentialUsecase.VerifyPassword(user.Password, password)
+	if !ok {
+		return nil, errInvalidCredentials()
+	}
+
+	// 平文や古いパラメータで保存されている場合はログイン成功時に再ハッシュする
+	if needsRehash {
+		hashed, err := u.credentialUsecase.HashPassword(password)
+		if err == nil {
+			user.Password = hashed
+			if err := u.userRepo.Update(ctx, user); err != nil {
+				logger.Error("failed to rehash password", "user_id", user.ID.String(), "error", err.Error())
+			}
+		}
+	}
+
+	return user, nil
+}
+
+func errInvalidCredentials() *myerrors.DomainError {
+	return myerrors.NewDomainErrorWithMessage(myerrors.Unauthenticated, "メールアドレスまたはパスワードが正しくありません")
+}
+
 func (u *userUsecase) FindByID(userID string) (*models.Users, error) {
 	user, err := u.userRepo.FindByID(context.Background(), userID)
 	if err != nil {
@@ -24,7 +24,7 @@ func TestUserUsecase_Create_AlreadyExists(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mockRepositories.NewMockUserRepository(ctrl)
-	uc := usecase.NewUserUsecase(mockRepo)
+	uc := usecase.NewUserUsecase(mockRepo, newTestCredentialUsecase())
 
 	ctx := context.Background()
 	newUser := &models.Users{
@@ -52,13 +52,13 @@ func TestUserUsecase_Create_FindByEmailFails(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mockRepositories.NewMockUserRepository(ctrl)
-	uc := usecase.NewUserUsecase(mockRepo)
+	uc := usecase.NewUserUsecase(mockRepo, newTestCredentialUsecase())
 
 	ctx := context.Background()
 	newUser := &models.Users{
 		Name:     "Bob",
 		Email:    "bob@example.com",
-		Password: "pass123",
+		Password: "pass123word",
 	}
 
 	mockRepo.EXPECT().FindByEmail(ctx, "bob@example.com").
@@ -80,13 +80,13 @@ func TestUserUsecase_Create_CreateFails(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mockRepositories.NewMockUserRepository(ctrl)
-	uc := usecase.NewUserUsecase(mockRepo)
+	uc := usecase.NewUserUsecase(mockRepo, newTestCredentialUsecase())
 
 	ctx := context.Background()
 	newUser := &models.Users{
 		Name:     "Charlie",
 		Email:    "charlie@example.com",
-		Password: "pass456",
+		Password: "pass456word",
 	}
 
 	mockRepo.EXPECT().FindByEmail(ctx, "charlie@example.com").
@@ -111,7 +111,7 @@ func TestUserUsecase_FindByEmail_DBError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mockRepositories.NewMockUserRepository(ctrl)
-	uc := usecase.NewUserUsecase(mockRepo)
+	uc := usecase.NewUserUsecase(mockRepo, newTestCredentialUsecase())
 
 	email := "notfound@example.com"
 
@@ -130,7 +130,7 @@ func TestUserUsecase_FindByID_DBError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mockRepositories.NewMockUserRepository(ctrl)
-	uc := usecase.NewUserUsecase(mockRepo)
+	uc := usecase.NewUserUsecase(mockRepo, newTestCredentialUsecase())
 
 	userID := testInvalidUUID
 
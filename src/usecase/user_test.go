@@ -21,7 +21,7 @@ func TestUserUsecase_Create_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
-	uc := usecase.NewUserUsecase(mockUserRepo)
+	uc := usecase.NewUserUsecase(mockUserRepo, newTestCredentialUsecase())
 
 	ctx := context.Background()
 	newUser := &models.Users{
@@ -52,7 +52,7 @@ func TestUserUsecase_FindByEmail_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
-	uc := usecase.NewUserUsecase(mockUserRepo)
+	uc := usecase.NewUserUsecase(mockUserRepo, newTestCredentialUsecase())
 
 	email := "success@example.com"
 	expectedUser := &models.Users{
@@ -77,7 +77,7 @@ func TestUserUsecase_FindById_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
-	uc := usecase.NewUserUsecase(mockUserRepo)
+	uc := usecase.NewUserUsecase(mockUserRepo, newTestCredentialUsecase())
 
 	userID := uuid.New()
 	expectedUser := &models.Users{
@@ -96,3 +96,157 @@ func TestUserUsecase_FindById_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, expectedUser, result)
 }
+
+func TestUserUsecase_Create_HashesPassword(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	credential := newTestCredentialUsecase()
+	uc := usecase.NewUserUsecase(mockUserRepo, credential)
+
+	ctx := context.Background()
+	newUser := &models.Users{
+		Name:     "Test User",
+		Email:    "hash@example.com",
+		Password: "password123",
+	}
+
+	mockUserRepo.EXPECT().
+		FindByEmail(ctx, "hash@example.com").
+		Return(nil, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "not found"))
+	mockUserRepo.EXPECT().
+		Create(ctx, gomock.Any()).
+		DoAndReturn(func(_ context.Context, u *models.Users) *myerrors.DomainError {
+			assert.NotEqual(t, "password123", u.Password)
+			ok, _ := credential.VerifyPassword(u.Password, "password123")
+			assert.True(t, ok)
+			return nil
+		})
+
+	_, err := uc.Create(newUser, ctx)
+
+	require.NoError(t, err)
+}
+
+func TestUserUsecase_Create_PolicyViolation(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewUserUsecase(mockUserRepo, newTestCredentialUsecase())
+
+	newUser := &models.Users{
+		Name:     "Weak",
+		Email:    "weak@example.com",
+		Password: "short",
+	}
+
+	result, err := uc.Create(newUser, context.Background())
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.InvalidParameter, domainErr.ErrType)
+}
+
+func TestUserUsecase_Authenticate_Success(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	credential := newTestCredentialUsecase()
+	uc := usecase.NewUserUsecase(mockUserRepo, credential)
+
+	hashed, err := credential.HashPassword("password123")
+	require.NoError(t, err)
+	user := &models.Users{ID: uuid.New(), Email: "login@example.com", Password: hashed}
+
+	mockUserRepo.EXPECT().
+		FindByEmail(gomock.Any(), "login@example.com").
+		Return(user, nil)
+
+	result, err := uc.Authenticate(context.Background(), "login@example.com", "password123")
+
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, result.ID)
+}
+
+func TestUserUsecase_Authenticate_WrongPassword(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	credential := newTestCredentialUsecase()
+	uc := usecase.NewUserUsecase(mockUserRepo, credential)
+
+	hashed, err := credential.HashPassword("password123")
+	require.NoError(t, err)
+
+	mockUserRepo.EXPECT().
+		FindByEmail(gomock.Any(), "login@example.com").
+		Return(&models.Users{ID: uuid.New(), Password: hashed}, nil)
+
+	result, err := uc.Authenticate(context.Background(), "login@example.com", "wrong-password1")
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.Unauthenticated, domainErr.ErrType)
+}
+
+func TestUserUsecase_Authenticate_UserNotFound(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	uc := usecase.NewUserUsecase(mockUserRepo, newTestCredentialUsecase())
+
+	mockUserRepo.EXPECT().
+		FindByEmail(gomock.Any(), "nobody@example.com").
+		Return(&models.Users{}, myerrors.NewDomainErrorWithMessage(myerrors.QueryDataNotFoundError, "not found"))
+
+	result, err := uc.Authenticate(context.Background(), "nobody@example.com", "password123")
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	var domainErr *myerrors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, myerrors.Unauthenticated, domainErr.ErrType)
+}
+
+func TestUserUsecase_Authenticate_RehashesLegacyPassword(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mockRepositories.NewMockUserRepository(ctrl)
+	credential := newTestCredentialUsecase()
+	uc := usecase.NewUserUsecase(mockUserRepo, credential)
+
+	user := &models.Users{ID: uuid.New(), Email: "legacy@example.com", Password: "password"}
+
+	mockUserRepo.EXPECT().
+		FindByEmail(gomock.Any(), "legacy@example.com").
+		Return(user, nil)
+	mockUserRepo.EXPECT().
+		Update(gomock.Any(), user).
+		DoAndReturn(func(_ context.Context, u *models.Users) *myerrors.DomainError {
+			ok, needsRehash := credential.VerifyPassword(u.Password, "password")
+			assert.True(t, ok)
+			assert.False(t, needsRehash)
+			return nil
+		})
+
+	result, err := uc.Authenticate(context.Background(), "legacy@example.com", "password")
+
+	require.NoError(t, err)
+	assert.NotEqual(t, "password", result.Password)
+}